@@ -0,0 +1,603 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	kinddefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/crossplane"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/oci"
+	"github.com/kanzi/kindplane/internal/registry"
+	"github.com/kanzi/kindplane/internal/state"
+	"github.com/kanzi/kindplane/internal/xpkg"
+)
+
+const (
+	// FormatVersion is the bundle layout version written by Create
+	FormatVersion = 1
+
+	// ManifestFile is the first entry of a bundle and lists its contents
+	ManifestFile = "manifest.json"
+
+	// hostImagesFile holds the images the container runtime needs: the
+	// node image and the registry image
+	hostImagesFile = "host-images.tar"
+	// chartsDir holds the chart archives
+	chartsDir = "charts"
+	// imagesDir is the OCI image layout holding container images and
+	// Crossplane packages
+	imagesDir = "images"
+	// gitDir holds a checkout of each git composition source
+	gitDir = "git"
+
+	// verifiedFile marks an extracted bundle whose checksums were verified
+	verifiedFile = ".verified"
+)
+
+// Manifest describes the contents of a bundle
+type Manifest struct {
+	Version   int       `json:"version"`
+	CreatedAt time.Time `json:"createdAt"`
+	// Platform is the node platform images were collected for, e.g. linux/amd64
+	Platform string `json:"platform"`
+	// NodeImage is the Kind node image, without a digest
+	NodeImage string `json:"nodeImage"`
+	// HostImages are the images in host-images.tar
+	HostImages []string `json:"hostImages"`
+	// Charts are the chart archives under charts/
+	Charts []Chart `json:"charts,omitempty"`
+	// Images are the container images in the images/ OCI layout
+	Images []string `json:"images,omitempty"`
+	// Packages are the fully qualified Crossplane packages in the images/
+	// OCI layout
+	Packages []string `json:"packages,omitempty"`
+	// GitSources are the git composition sources under git/
+	GitSources []GitSource `json:"gitSources,omitempty"`
+	// Files maps every other file of the bundle to its sha256 checksum
+	Files map[string]string `json:"files"`
+}
+
+// Chart is a chart archive in a bundle
+type Chart struct {
+	Repo  string `json:"repo"`
+	Chart string `json:"chart"`
+	// Version is the version as configured, empty for the latest
+	Version string `json:"version,omitempty"`
+	// File is the archive path within the bundle
+	File string `json:"file"`
+
+	// auth and tls hold the configured repository credentials and TLS
+	// settings when fetching the chart
+	auth *config.ChartAuth
+	tls  *config.ChartRepoTLS
+}
+
+// GitSource is a checkout of a git composition source in a bundle
+type GitSource struct {
+	Repo   string `json:"repo"`
+	Branch string `json:"branch,omitempty"`
+	// Dir is the checkout path within the bundle
+	Dir string `json:"dir"`
+}
+
+// Bundle is an extracted and verified bundle
+type Bundle struct {
+	// Dir is the directory the bundle was extracted to
+	Dir      string
+	Manifest *Manifest
+}
+
+// Open extracts a bundle into the kindplane cache directory and verifies
+// its checksums. A bundle extracted and verified before is reused.
+func Open(bundlePath string) (*Bundle, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	tr, data, err := readManifest(f, bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	manifest, err := parseManifest(data)
+	if err != nil {
+		return nil, err
+	}
+
+	cacheDir, err := state.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	sum := sha256.Sum256(data)
+	b := &Bundle{
+		Dir:      filepath.Join(cacheDir, "bundles", hex.EncodeToString(sum[:8])),
+		Manifest: manifest,
+	}
+	if _, err := os.Stat(filepath.Join(b.Dir, verifiedFile)); err == nil {
+		return b, nil
+	}
+
+	if err := os.RemoveAll(b.Dir); err != nil {
+		return nil, err
+	}
+	if err := extract(tr, b.Dir); err != nil {
+		_ = os.RemoveAll(b.Dir)
+		return nil, err
+	}
+	if err := verify(b.Dir, manifest.Files); err != nil {
+		_ = os.RemoveAll(b.Dir)
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(b.Dir, verifiedFile), nil, 0644); err != nil {
+		return nil, err
+	}
+	return b, nil
+}
+
+// ReadManifest returns the manifest of a bundle without extracting it
+func ReadManifest(bundlePath string) (*Manifest, error) {
+	f, err := os.Open(bundlePath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open bundle: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	_, data, err := readManifest(f, bundlePath)
+	if err != nil {
+		return nil, err
+	}
+	return parseManifest(data)
+}
+
+// readManifest reads the manifest entry at the start of a bundle and
+// returns the reader positioned after it
+func readManifest(r io.Reader, bundlePath string) (*tar.Reader, []byte, error) {
+	tr, err := newTarReader(r)
+	if err != nil {
+		return nil, nil, err
+	}
+	hdr, err := tr.Next()
+	if err != nil || hdr.Name != ManifestFile {
+		return nil, nil, fmt.Errorf("%s is not a kindplane bundle: %s must come first", bundlePath, ManifestFile)
+	}
+	data, err := io.ReadAll(tr)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read bundle manifest: %w", err)
+	}
+	return tr, data, nil
+}
+
+// parseManifest decodes a bundle manifest and checks its format version
+func parseManifest(data []byte) (*Manifest, error) {
+	var m Manifest
+	if err := json.Unmarshal(data, &m); err != nil {
+		return nil, fmt.Errorf("invalid bundle manifest: %w", err)
+	}
+	if m.Version < 1 || m.Version > FormatVersion {
+		return nil, fmt.Errorf("unsupported bundle version %d; this kindplane reads version %d", m.Version, FormatVersion)
+	}
+	return &m, nil
+}
+
+// newTarReader reads a tar archive, gzip-compressed or not
+func newTarReader(r io.Reader) (*tar.Reader, error) {
+	br := bufio.NewReader(r)
+	magic, err := br.Peek(2)
+	if err == nil && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read bundle: %w", err)
+		}
+		return tar.NewReader(gz), nil
+	}
+	return tar.NewReader(br), nil
+}
+
+// extract writes the remaining entries of a tar archive under dir
+func extract(tr *tar.Reader, dir string) error {
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to read bundle: %w", err)
+		}
+		name := path.Clean(hdr.Name)
+		if !fs.ValidPath(name) {
+			return fmt.Errorf("invalid path in bundle: %s", hdr.Name)
+		}
+		target := filepath.Join(dir, filepath.FromSlash(name))
+
+		switch hdr.Typeflag {
+		case tar.TypeDir:
+			if err := os.MkdirAll(target, 0755); err != nil {
+				return err
+			}
+		case tar.TypeReg:
+			if err := os.MkdirAll(filepath.Dir(target), 0755); err != nil {
+				return err
+			}
+			out, err := os.OpenFile(target, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0644)
+			if err != nil {
+				return err
+			}
+			if _, err := io.Copy(out, tr); err != nil {
+				_ = out.Close()
+				return fmt.Errorf("failed to extract %s: %w", name, err)
+			}
+			if err := out.Close(); err != nil {
+				return err
+			}
+		default:
+			return fmt.Errorf("unsupported entry in bundle: %s", hdr.Name)
+		}
+	}
+}
+
+// verify checks the files under dir against their recorded checksums
+func verify(dir string, files map[string]string) error {
+	var bad []string
+	for name, want := range files {
+		got, err := fileChecksum(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil || got != want {
+			bad = append(bad, name)
+		}
+	}
+	if len(bad) > 0 {
+		sort.Strings(bad)
+		return fmt.Errorf("bundle is corrupt: checksum mismatch for %s", strings.Join(bad, ", "))
+	}
+	return nil
+}
+
+// fileChecksum returns the hex sha256 of a file
+func fileChecksum(name string) (string, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = f.Close() }()
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// write archives the files under dir as a bundle at bundlePath, recording
+// their checksums in the manifest, which is written first. The archive is
+// gzip-compressed when the path ends in .gz or .tgz.
+func write(dir string, manifest *Manifest, bundlePath string) error {
+	manifest.Files = map[string]string{}
+	var names []string
+	err := filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		rel, err := filepath.Rel(dir, p)
+		if err != nil {
+			return err
+		}
+		name := filepath.ToSlash(rel)
+		if manifest.Files[name], err = fileChecksum(p); err != nil {
+			return err
+		}
+		names = append(names, name)
+		return nil
+	})
+	if err != nil {
+		return fmt.Errorf("failed to checksum bundle contents: %w", err)
+	}
+	data, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tmp := bundlePath + ".tmp"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return fmt.Errorf("failed to create bundle: %w", err)
+	}
+	defer func() { _ = os.Remove(tmp) }()
+
+	var w io.Writer = f
+	var gz *gzip.Writer
+	if strings.HasSuffix(bundlePath, ".gz") || strings.HasSuffix(bundlePath, ".tgz") {
+		gz = gzip.NewWriter(f)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	writeErr := func() error {
+		hdr := &tar.Header{Name: ManifestFile, Mode: 0644, Size: int64(len(data)), ModTime: manifest.CreatedAt}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(data); err != nil {
+			return err
+		}
+		for _, name := range names {
+			if err := addFile(tw, filepath.Join(dir, filepath.FromSlash(name)), name); err != nil {
+				return err
+			}
+		}
+		if err := tw.Close(); err != nil {
+			return err
+		}
+		if gz != nil {
+			return gz.Close()
+		}
+		return nil
+	}()
+	if err := f.Close(); writeErr == nil {
+		writeErr = err
+	}
+	if writeErr != nil {
+		return fmt.Errorf("failed to write bundle: %w", writeErr)
+	}
+	return os.Rename(tmp, bundlePath)
+}
+
+// addFile writes one file to a tar archive under name
+func addFile(tw *tar.Writer, file, name string) error {
+	f, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+	info, err := f.Stat()
+	if err != nil {
+		return err
+	}
+	hdr := &tar.Header{Name: name, Mode: 0644, Size: info.Size(), ModTime: info.ModTime()}
+	if err := tw.WriteHeader(hdr); err != nil {
+		return err
+	}
+	_, err = io.Copy(tw, f)
+	return err
+}
+
+// Missing lists what the configuration needs that the bundle lacks: the
+// node and registry images, the Crossplane chart and configured charts,
+// provider packages, git composition sources and the images CollectImages
+// finds: Crossplane's, those of the charts and additional images
+func (b *Bundle) Missing(ctx context.Context, cfg *config.Config) []string {
+	return b.missing(cfg, func(cfg *config.Config) []kind.ImageSource {
+		return kind.CollectImages(ctx, cfg, func(string) {})
+	})
+}
+
+// missing implements Missing with an injectable image collector
+func (b *Bundle) missing(cfg *config.Config, collectImages func(*config.Config) []kind.ImageSource) []string {
+	m := b.Manifest
+	var missing []string
+
+	if image := nodeImage(cfg); !slices.Contains(m.HostImages, image) {
+		missing = append(missing, "node image "+image)
+	}
+	if !slices.Contains(m.HostImages, registry.DefaultRegistryImage) {
+		missing = append(missing, "registry image "+registry.DefaultRegistryImage)
+	}
+	for _, c := range configuredCharts(cfg) {
+		if b.chart(c.Repo, c.Chart, c.Version) == nil {
+			missing = append(missing, fmt.Sprintf("chart %s from %s (version %s)", c.Chart, c.Repo, versionOrLatest(c.Version)))
+		}
+	}
+	for _, p := range cfg.Crossplane.Providers {
+		if !slices.Contains(m.Packages, xpkg.Qualify(p.Package)) {
+			missing = append(missing, fmt.Sprintf("package %s of provider %s", p.Package, p.Name))
+		}
+	}
+	for _, s := range cfg.Compositions.Sources {
+		if s.Type == "git" && b.gitSource(s.Repo, s.Branch) == nil {
+			missing = append(missing, "git repository "+s.Repo)
+		}
+	}
+
+	// Provider images are left out: their packages are checked above, and
+	// without network access their images could only be guessed. Charts are
+	// rendered from the bundle where it has them.
+	collectCfg := imageCollectConfig(cfg)
+	disabled := false
+	collectCfg.Crossplane.ImageCache.PreloadProviders = &disabled
+	collectCfg.Charts = slices.Clone(cfg.Charts)
+	for i, c := range collectCfg.Charts {
+		if bundled := b.chart(c.Repo, c.Chart, c.Version); c.Path == "" && bundled != nil && b.Dir != "" {
+			collectCfg.Charts[i].Path = filepath.Join(b.Dir, filepath.FromSlash(bundled.File))
+		}
+	}
+	for _, s := range collectImages(&collectCfg) {
+		if !slices.Contains(m.Images, s.Image) {
+			missing = append(missing, fmt.Sprintf("image %s (%s)", s.Image, strings.Join(s.Sources, ", ")))
+		}
+	}
+	return missing
+}
+
+// CheckPlatform fails when the bundle was collected for another
+// architecture than this machine's
+func (b *Bundle) CheckPlatform() error {
+	want := "linux/" + runtime.GOARCH
+	if b.Manifest.Platform != want {
+		return fmt.Errorf("bundle was created for %s, but this machine runs %s nodes", b.Manifest.Platform, want)
+	}
+	return nil
+}
+
+// LoadHostImages loads the node and registry images into the container runtime
+func (b *Bundle) LoadHostImages(ctx context.Context, rt container.Runtime) error {
+	if err := rt.LoadImages(ctx, filepath.Join(b.Dir, hostImagesFile)); err != nil {
+		return fmt.Errorf("failed to load bundled images: %w", err)
+	}
+	return nil
+}
+
+// Configure points the configuration at the bundle, in memory: the node
+// image is the bundled one, images are preloaded into the nodes only from
+// the bundle and git composition sources use their bundled checkouts.
+// Provider packages are served by the local registry, which Crossplane only
+// pulls from over HTTPS, so the registry is enabled with TLS when it is not
+// configured; a configured registry without TLS is an error.
+func (b *Bundle) Configure(cfg *config.Config) error {
+	reg := &cfg.Cluster.Registry
+	if len(cfg.Crossplane.Providers) > 0 {
+		if reg.Enabled && !reg.TLS {
+			return fmt.Errorf("providers are installed from the local registry when using a bundle; set cluster.registry.tls: true")
+		}
+		if !reg.Enabled {
+			reg.Enabled, reg.TLS = true, true
+		}
+	}
+
+	cfg.Cluster.NodeImage = b.Manifest.NodeImage
+
+	var overrides map[string][]string
+	if cfg.Crossplane.ImageCache != nil {
+		overrides = cfg.Crossplane.ImageCache.ImageOverrides
+	}
+	enabled, disabled := true, false
+	cfg.Crossplane.ImageCache = &config.ImageCacheConfig{
+		Enabled:           &enabled,
+		PreloadProviders:  &disabled,
+		PreloadCrossplane: &disabled,
+		PreloadCharts:     &disabled,
+		AdditionalImages:  b.Manifest.Images,
+		Source:            config.ImageSourceLayout,
+		LayoutDir:         filepath.Join(b.Dir, imagesDir),
+		ImageOverrides:    overrides,
+	}
+
+	for i, s := range cfg.Compositions.Sources {
+		if s.Type != "git" {
+			continue
+		}
+		src := b.gitSource(s.Repo, s.Branch)
+		if src == nil {
+			return fmt.Errorf("git repository %s is not in the bundle", s.Repo)
+		}
+		cfg.Compositions.Sources[i] = config.CompositionSource{
+			Type: "local",
+			Path: filepath.Join(b.Dir, filepath.FromSlash(src.Dir), s.Path),
+		}
+	}
+	return nil
+}
+
+// ChartPaths returns the bundled chart archives keyed by helm.LocalChartKey,
+// for helm.Installer.SetLocalCharts
+func (b *Bundle) ChartPaths() map[string]string {
+	paths := make(map[string]string, len(b.Manifest.Charts))
+	for _, c := range b.Manifest.Charts {
+		paths[helm.LocalChartKey(c.Repo, c.Chart, c.Version)] = filepath.Join(b.Dir, filepath.FromSlash(c.File))
+	}
+	return paths
+}
+
+// PushPackages copies the bundled Crossplane packages to the local registry
+// at registryHost and rewrites the configured provider packages to pull
+// from internalHost, the registry's address inside the cluster
+func (b *Bundle) PushPackages(ctx context.Context, cfg *config.Config, registryHost, internalHost string) error {
+	puller, err := oci.NewPuller(ctx, oci.Options{
+		LayoutDir:  filepath.Join(b.Dir, imagesDir),
+		LayoutOnly: true,
+		TrustedCAs: cfg.Cluster.TrustedCAs.Registries,
+	})
+	if err != nil {
+		return err
+	}
+	for _, pkg := range b.Manifest.Packages {
+		img, err := puller.Resolve(ctx, pkg)
+		if err != nil {
+			return err
+		}
+		if err := puller.Push(ctx, img, kind.RetagForRegistry(pkg, registryHost)); err != nil {
+			return err
+		}
+	}
+	for i, p := range cfg.Crossplane.Providers {
+		cfg.Crossplane.Providers[i].Package = kind.RetagForRegistry(xpkg.Qualify(p.Package), internalHost)
+	}
+	return nil
+}
+
+// chart returns the bundled chart for a configured chart version
+func (b *Bundle) chart(repo, chartName, version string) *Chart {
+	key := helm.LocalChartKey(repo, chartName, version)
+	for i, c := range b.Manifest.Charts {
+		if helm.LocalChartKey(c.Repo, c.Chart, c.Version) == key {
+			return &b.Manifest.Charts[i]
+		}
+	}
+	return nil
+}
+
+// gitSource returns the bundled checkout of a git repository branch
+func (b *Bundle) gitSource(repo, branch string) *GitSource {
+	for i, s := range b.Manifest.GitSources {
+		if s.Repo == repo && s.Branch == branch {
+			return &b.Manifest.GitSources[i]
+		}
+	}
+	return nil
+}
+
+// configuredCharts returns the Crossplane chart and the configured charts
+// as bundle chart entries without files. Charts with a local path are left
+// out, as they are read from disk like values files.
+func configuredCharts(cfg *config.Config) []Chart {
+	repo := cfg.Crossplane.Repo
+	if repo == "" {
+		repo = crossplane.CrossplaneRepoURL
+	}
+	charts := []Chart{{Repo: repo, Chart: crossplane.CrossplaneChartName, Version: cfg.Crossplane.Version, auth: cfg.Crossplane.RepoAuth, tls: cfg.Crossplane.RepoTLS}}
+	for _, c := range cfg.Charts {
+		if c.Path != "" {
+			continue
+		}
+		charts = append(charts, Chart{Repo: c.Repo, Chart: c.Chart, Version: c.Version, auth: c.Auth, tls: c.TLS})
+	}
+	return charts
+}
+
+// nodeImage returns the Kind node image of the configuration without its
+// digest: images loaded into the runtime from an archive lose their
+// registry digests, and the bundle checksums stand in for them
+func nodeImage(cfg *config.Config) string {
+	image, _ := kind.GetNodeImage(cfg)
+	if image == "" {
+		image = kinddefaults.Image
+	}
+	name, _, _ := strings.Cut(image, "@")
+	return name
+}
+
+// versionOrLatest returns a chart version for messages
+func versionOrLatest(version string) string {
+	if version == "" {
+		return "latest"
+	}
+	return version
+}
@@ -0,0 +1,341 @@
+package bundle
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/registry"
+)
+
+// testManifest describes a bundle for a config with one chart, one
+// provider and one git composition source
+func testManifest() *Manifest {
+	return &Manifest{
+		Version:    FormatVersion,
+		Platform:   "linux/" + runtime.GOARCH,
+		NodeImage:  "kindest/node:v1.31.0",
+		HostImages: []string{"kindest/node:v1.31.0", registry.DefaultRegistryImage},
+		Charts: []Chart{
+			{Repo: "https://charts.crossplane.io/stable", Chart: "crossplane", Version: "1.18.0", File: "charts/crossplane-1.18.0.tgz"},
+			{Repo: "https://charts.jetstack.io", Chart: "cert-manager", Version: "v1.16.0", File: "charts/cert-manager-v1.16.0.tgz"},
+		},
+		Images:     []string{"crossplane/crossplane:v1.18.0", "ghcr.io/example/tool:v1"},
+		Packages:   []string{"xpkg.upbound.io/crossplane-contrib/provider-kubernetes:v0.15.0"},
+		GitSources: []GitSource{{Repo: "https://github.com/example/compositions", Branch: "main", Dir: "git/0"}},
+	}
+}
+
+// testConfig returns the configuration testManifest was created for
+func testConfig() *config.Config {
+	return &config.Config{
+		Cluster: config.ClusterConfig{Name: "test", KubernetesVersion: "1.31.0"},
+		Crossplane: config.CrossplaneConfig{
+			Version:   "1.18.0",
+			Providers: []config.ProviderConfig{{Name: "kubernetes", Package: "crossplane-contrib/provider-kubernetes:v0.15.0"}},
+			ImageCache: &config.ImageCacheConfig{
+				AdditionalImages: []string{"ghcr.io/example/tool:v1"},
+			},
+		},
+		Charts: []config.ChartConfig{
+			{Name: "cert-manager", Repo: "https://charts.jetstack.io", Chart: "cert-manager", Version: "v1.16.0"},
+		},
+		Compositions: config.CompositionsConfig{
+			Sources: []config.CompositionSource{
+				{Type: "git", Repo: "https://github.com/example/compositions", Branch: "main", Path: "apis"},
+				{Type: "local", Path: "./local"},
+			},
+		},
+	}
+}
+
+// writeFile creates a file under dir
+func writeFile(t *testing.T, dir, name, content string) {
+	t.Helper()
+	p := filepath.Join(dir, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(p, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestWriteAndOpen(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	for _, name := range []string{"bundle.tar", "bundle.tar.gz"} {
+		t.Run(name, func(t *testing.T) {
+			staging := t.TempDir()
+			writeFile(t, staging, hostImagesFile, "images")
+			writeFile(t, staging, "charts/cert-manager-v1.16.0.tgz", "chart "+name)
+			writeFile(t, staging, "git/0/apis/xrd.yaml", "kind: CompositeResourceDefinition")
+
+			bundlePath := filepath.Join(t.TempDir(), name)
+			if err := write(staging, testManifest(), bundlePath); err != nil {
+				t.Fatalf("write() error = %v", err)
+			}
+
+			b, err := Open(bundlePath)
+			if err != nil {
+				t.Fatalf("Open() error = %v", err)
+			}
+			if len(b.Manifest.Files) != 3 {
+				t.Errorf("manifest lists %d files, want 3: %v", len(b.Manifest.Files), b.Manifest.Files)
+			}
+			data, err := os.ReadFile(filepath.Join(b.Dir, "git", "0", "apis", "xrd.yaml"))
+			if err != nil || string(data) != "kind: CompositeResourceDefinition" {
+				t.Errorf("extracted file = %q, %v", data, err)
+			}
+
+			// A verified extraction is reused
+			if err := os.WriteFile(filepath.Join(b.Dir, hostImagesFile), []byte("changed"), 0644); err != nil {
+				t.Fatal(err)
+			}
+			again, err := Open(bundlePath)
+			if err != nil || again.Dir != b.Dir {
+				t.Fatalf("Open() again = %v, %v", again, err)
+			}
+			if data, _ := os.ReadFile(filepath.Join(b.Dir, hostImagesFile)); string(data) != "changed" {
+				t.Error("expected the earlier extraction to be reused")
+			}
+		})
+	}
+}
+
+func TestOpen_Invalid(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	dir := t.TempDir()
+
+	notBundle := filepath.Join(dir, "not-a-bundle.tar")
+	writeFile(t, dir, "not-a-bundle.tar", "plain text")
+	if _, err := Open(notBundle); err == nil || !strings.Contains(err.Error(), "not a kindplane bundle") {
+		t.Errorf("Open() of a non-bundle error = %v", err)
+	}
+
+	staging := t.TempDir()
+	writeFile(t, staging, hostImagesFile, "images")
+	future := testManifest()
+	future.Version = FormatVersion + 1
+	futurePath := filepath.Join(dir, "future.tar")
+	if err := write(staging, future, futurePath); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := Open(futurePath); err == nil || !strings.Contains(err.Error(), "unsupported bundle version") {
+		t.Errorf("Open() of a newer bundle error = %v", err)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, dir, "charts/a.tgz", "a")
+	sum, err := fileChecksum(filepath.Join(dir, "charts", "a.tgz"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := verify(dir, map[string]string{"charts/a.tgz": sum}); err != nil {
+		t.Errorf("verify() error = %v", err)
+	}
+	err = verify(dir, map[string]string{"charts/a.tgz": strings.Repeat("0", 64), "charts/missing.tgz": sum})
+	if err == nil || !strings.Contains(err.Error(), "charts/a.tgz, charts/missing.tgz") {
+		t.Errorf("verify() of a corrupt bundle error = %v", err)
+	}
+}
+
+// collectTestImages stands in for kind.CollectImages: the Crossplane image,
+// a cert-manager image of each chart's version and additional images
+func collectTestImages(cfg *config.Config) []kind.ImageSource {
+	sources := []kind.ImageSource{{Image: "crossplane/crossplane:v" + cfg.Crossplane.Version, Sources: []string{"crossplane"}}}
+	for _, c := range cfg.Charts {
+		sources = append(sources, kind.ImageSource{Image: "quay.io/jetstack/cert-manager-controller:" + c.Version, Sources: []string{"chart " + c.Name}})
+	}
+	for _, image := range cfg.Crossplane.ImageCache.AdditionalImages {
+		sources = append(sources, kind.ImageSource{Image: image, Sources: []string{"additionalImages"}})
+	}
+	return sources
+}
+
+func TestMissing(t *testing.T) {
+	b := &Bundle{Manifest: testManifest()}
+	b.Manifest.Images = append(b.Manifest.Images, "quay.io/jetstack/cert-manager-controller:v1.16.0")
+	if missing := b.missing(testConfig(), collectTestImages); len(missing) != 0 {
+		t.Errorf("missing() = %v, want nothing", missing)
+	}
+
+	cfg := testConfig()
+	cfg.Cluster.KubernetesVersion = "1.32.0"
+	cfg.Charts[0].Version = "v1.17.0"
+	cfg.Crossplane.Providers = append(cfg.Crossplane.Providers, config.ProviderConfig{Name: "helm", Package: "xpkg.upbound.io/crossplane-contrib/provider-helm:v0.20.0"})
+	cfg.Compositions.Sources[0].Branch = "dev"
+	cfg.Crossplane.ImageCache.AdditionalImages = append(cfg.Crossplane.ImageCache.AdditionalImages, "busybox:1.36")
+
+	want := []string{
+		"node image kindest/node:v1.32.0",
+		"chart cert-manager from https://charts.jetstack.io (version v1.17.0)",
+		"package xpkg.upbound.io/crossplane-contrib/provider-helm:v0.20.0 of provider helm",
+		"git repository https://github.com/example/compositions",
+		"image quay.io/jetstack/cert-manager-controller:v1.17.0 (chart cert-manager)",
+		"image busybox:1.36 (additionalImages)",
+	}
+	if got := b.missing(cfg, collectTestImages); !reflect.DeepEqual(got, want) {
+		t.Errorf("missing() = %q, want %q", got, want)
+	}
+}
+
+func TestMissing_CollectConfig(t *testing.T) {
+	b := &Bundle{Dir: "/cache/bundles/abc", Manifest: testManifest()}
+	cfg := testConfig()
+	enabled, disabled := true, false
+	cfg.Crossplane.ImageCache.PreloadCharts = &disabled
+	cfg.Crossplane.ImageCache.PreloadProviders = &enabled
+
+	var collected *config.Config
+	b.missing(cfg, func(c *config.Config) []kind.ImageSource {
+		collected = c
+		return nil
+	})
+
+	ic := collected.Crossplane.ImageCache
+	if !ic.ShouldPreloadCharts() || ic.ShouldPreloadProviders() {
+		t.Errorf("collected with charts %v and providers %v, want charts only", ic.ShouldPreloadCharts(), ic.ShouldPreloadProviders())
+	}
+	if want := filepath.Join(b.Dir, "charts", "cert-manager-v1.16.0.tgz"); collected.Charts[0].Path != want {
+		t.Errorf("chart path = %q, want the bundled archive %q", collected.Charts[0].Path, want)
+	}
+	if cfg.Charts[0].Path != "" || !cfg.Crossplane.ImageCache.ShouldPreloadProviders() {
+		t.Error("missing() changed the configuration")
+	}
+}
+
+func TestNodeImage(t *testing.T) {
+	cfg := &config.Config{}
+	cfg.Cluster.NodeImage = "kindest/node:v1.31.0@sha256:abc"
+	if got := nodeImage(cfg); got != "kindest/node:v1.31.0" {
+		t.Errorf("nodeImage() = %s, want the digest stripped", got)
+	}
+	if got := nodeImage(&config.Config{}); !strings.HasPrefix(got, "kindest/node:") || strings.Contains(got, "@") {
+		t.Errorf("nodeImage() of the Kind default = %s", got)
+	}
+}
+
+func TestCheckPlatform(t *testing.T) {
+	b := &Bundle{Manifest: testManifest()}
+	if err := b.CheckPlatform(); err != nil {
+		t.Errorf("CheckPlatform() error = %v", err)
+	}
+	b.Manifest.Platform = "linux/s390x"
+	if runtime.GOARCH != "s390x" {
+		if err := b.CheckPlatform(); err == nil {
+			t.Error("CheckPlatform() of another architecture succeeded")
+		}
+	}
+}
+
+func TestConfigure(t *testing.T) {
+	b := &Bundle{Dir: "/cache/bundles/abc", Manifest: testManifest()}
+
+	cfg := testConfig()
+	cfg.Crossplane.ImageCache.ImageOverrides = map[string][]string{"a": {"b"}}
+	if err := b.Configure(cfg); err != nil {
+		t.Fatalf("Configure() error = %v", err)
+	}
+	if !cfg.Cluster.Registry.Enabled || !cfg.Cluster.Registry.TLS {
+		t.Error("expected the registry to be enabled with TLS for provider packages")
+	}
+	if cfg.Cluster.NodeImage != "kindest/node:v1.31.0" {
+		t.Errorf("NodeImage = %s", cfg.Cluster.NodeImage)
+	}
+	ic := cfg.Crossplane.ImageCache
+	if ic.GetSource() != config.ImageSourceLayout || ic.LayoutDir != filepath.Join(b.Dir, imagesDir) {
+		t.Errorf("image cache source = %s, layout %s", ic.GetSource(), ic.LayoutDir)
+	}
+	if ic.ShouldPreloadProviders() || ic.ShouldPreloadCharts() || ic.ShouldPreloadCrossplane() {
+		t.Error("expected images to be preloaded only from the bundle list")
+	}
+	if !reflect.DeepEqual(ic.AdditionalImages, b.Manifest.Images) || ic.ImageOverrides["a"] == nil {
+		t.Errorf("image cache = %+v", ic)
+	}
+	wantSources := []config.CompositionSource{
+		{Type: "local", Path: filepath.Join(b.Dir, "git", "0", "apis")},
+		{Type: "local", Path: "./local"},
+	}
+	if !reflect.DeepEqual(cfg.Compositions.Sources, wantSources) {
+		t.Errorf("composition sources = %+v, want %+v", cfg.Compositions.Sources, wantSources)
+	}
+
+	insecure := testConfig()
+	insecure.Cluster.Registry.Enabled = true
+	if err := b.Configure(insecure); err == nil || !strings.Contains(err.Error(), "cluster.registry.tls") {
+		t.Errorf("Configure() with a plain HTTP registry error = %v", err)
+	}
+
+	noProviders := testConfig()
+	noProviders.Crossplane.Providers = nil
+	if err := b.Configure(noProviders); err != nil || noProviders.Cluster.Registry.Enabled {
+		t.Errorf("Configure() without providers enabled the registry (err %v)", err)
+	}
+}
+
+func TestChartPaths(t *testing.T) {
+	b := &Bundle{Dir: "/cache/bundles/abc", Manifest: testManifest()}
+	paths := b.ChartPaths()
+	key := helm.LocalChartKey("https://charts.jetstack.io", "cert-manager", "v1.16.0")
+	if got := paths[key]; got != filepath.Join(b.Dir, "charts", "cert-manager-v1.16.0.tgz") {
+		t.Errorf("ChartPaths()[%s] = %q", key, got)
+	}
+	if len(paths) != 2 {
+		t.Errorf("ChartPaths() has %d entries, want 2", len(paths))
+	}
+}
+
+func TestLoadHostImages(t *testing.T) {
+	ctx := context.Background()
+	rt := container.NewFake()
+	rt.Images["kindest/node:v1.31.0@sha256:abc"] = container.Platform{OS: "linux", Architecture: "amd64"}
+	staging := t.TempDir()
+
+	cfg := testConfig()
+	cfg.Cluster.NodeImage = "kindest/node:v1.31.0@sha256:abc"
+	manifest := &Manifest{}
+	if err := saveHostImages(ctx, cfg, staging, manifest, CreateOptions{Platform: "linux/amd64", Log: func(string) {}, Runtime: rt}); err != nil {
+		t.Fatalf("saveHostImages() error = %v", err)
+	}
+	if !rt.Called("PullImage registry:2 linux/amd64") || rt.Called("PullImage kindest") {
+		t.Errorf("expected only the missing registry image to be pulled: %v", rt.Calls)
+	}
+	if !rt.Called("TagImage kindest/node:v1.31.0@sha256:abc kindest/node:v1.31.0") {
+		t.Errorf("expected the pinned node image to be tagged: %v", rt.Calls)
+	}
+
+	loaded := container.NewFake()
+	b := &Bundle{Dir: staging, Manifest: manifest}
+	if err := b.LoadHostImages(ctx, loaded); err != nil {
+		t.Fatalf("LoadHostImages() error = %v", err)
+	}
+	for _, image := range manifest.HostImages {
+		if _, ok := loaded.Images[image]; !ok {
+			t.Errorf("image %s was not loaded", image)
+		}
+	}
+}
+
+func TestParsePlatform(t *testing.T) {
+	p, err := parsePlatform("linux/arm64/v8")
+	if err != nil || p.OS != "linux" || p.Architecture != "arm64" || p.Variant != "v8" {
+		t.Errorf("parsePlatform() = %+v, %v", p, err)
+	}
+	for _, bad := range []string{"linux", "/amd64", "linux/amd64/v8/x"} {
+		if _, err := parsePlatform(bad); err == nil {
+			t.Errorf("parsePlatform(%q) succeeded", bad)
+		}
+	}
+}
@@ -0,0 +1,239 @@
+package bundle
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	kinddefaults "sigs.k8s.io/kind/pkg/apis/config/defaults"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/git"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/oci"
+	"github.com/kanzi/kindplane/internal/registry"
+	"github.com/kanzi/kindplane/internal/xpkg"
+)
+
+// CreateOptions configures Create
+type CreateOptions struct {
+	// Platform is the node platform to collect images for (default
+	// linux/<host architecture>)
+	Platform string
+	// Log receives progress messages
+	Log func(string)
+	// Runtime saves the node and registry images (default container.Default())
+	Runtime container.Runtime
+}
+
+// Create collects everything cfg needs to bootstrap without network access
+// into a bundle at bundlePath: the node and registry images, the Crossplane
+// chart and configured charts, provider packages, the images CollectImages
+// finds and git composition sources. Anything that cannot be collected is
+// an error, since the bundle would be incomplete.
+func Create(ctx context.Context, cfg *config.Config, bundlePath string, opts CreateOptions) (*Manifest, error) {
+	if opts.Platform == "" {
+		opts.Platform = "linux/" + runtime.GOARCH
+	}
+	if opts.Log == nil {
+		opts.Log = func(string) {}
+	}
+	if opts.Runtime == nil {
+		opts.Runtime = container.Default()
+	}
+	platform, err := parsePlatform(opts.Platform)
+	if err != nil {
+		return nil, err
+	}
+
+	staging, err := os.MkdirTemp(filepath.Dir(bundlePath), ".kindplane-bundle-*")
+	if err != nil {
+		return nil, fmt.Errorf("failed to create staging directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(staging) }()
+
+	manifest := &Manifest{
+		Version:   FormatVersion,
+		CreatedAt: time.Now().UTC(),
+		Platform:  opts.Platform,
+	}
+
+	if err := saveHostImages(ctx, cfg, staging, manifest, opts); err != nil {
+		return nil, err
+	}
+	if err := fetchCharts(ctx, cfg, staging, manifest, opts); err != nil {
+		return nil, err
+	}
+	if err := saveImages(ctx, cfg, staging, manifest, platform, opts); err != nil {
+		return nil, err
+	}
+	if err := cloneGitSources(ctx, cfg, staging, manifest, opts); err != nil {
+		return nil, err
+	}
+
+	opts.Log("Writing bundle...")
+	if err := write(staging, manifest, bundlePath); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// saveHostImages pulls the node and registry images when they are missing
+// and saves them to host-images.tar. A node image pinned by digest is saved
+// under its tag, since archives do not keep registry digests.
+func saveHostImages(ctx context.Context, cfg *config.Config, staging string, manifest *Manifest, opts CreateOptions) error {
+	rt := opts.Runtime
+	configured, _ := kind.GetNodeImage(cfg)
+	if configured == "" {
+		configured = kinddefaults.Image
+	}
+	manifest.NodeImage = nodeImage(cfg)
+	manifest.HostImages = []string{manifest.NodeImage, registry.DefaultRegistryImage}
+
+	for _, image := range []string{configured, registry.DefaultRegistryImage} {
+		exists, err := rt.ImageExists(ctx, image)
+		if err != nil {
+			return err
+		}
+		if !exists {
+			opts.Log(fmt.Sprintf("Pulling %s...", image))
+			if err := rt.PullImage(ctx, image, opts.Platform); err != nil {
+				return fmt.Errorf("failed to pull %s: %w", image, err)
+			}
+		}
+	}
+	if configured != manifest.NodeImage {
+		if err := rt.TagImage(ctx, configured, manifest.NodeImage); err != nil {
+			return fmt.Errorf("failed to tag %s: %w", manifest.NodeImage, err)
+		}
+	}
+
+	opts.Log("Saving node and registry images...")
+	if err := rt.SaveImages(ctx, filepath.Join(staging, hostImagesFile), manifest.HostImages...); err != nil {
+		return fmt.Errorf("failed to save images: %w", err)
+	}
+	return nil
+}
+
+// fetchCharts downloads the Crossplane chart and the configured charts
+func fetchCharts(ctx context.Context, cfg *config.Config, staging string, manifest *Manifest, opts CreateOptions) error {
+	dir := filepath.Join(staging, chartsDir)
+	for _, c := range configuredCharts(cfg) {
+		if slices.ContainsFunc(manifest.Charts, func(b Chart) bool {
+			return helm.LocalChartKey(b.Repo, b.Chart, b.Version) == helm.LocalChartKey(c.Repo, c.Chart, c.Version)
+		}) {
+			continue
+		}
+		opts.Log(fmt.Sprintf("Fetching chart %s (%s)...", c.Chart, versionOrLatest(c.Version)))
+		file, err := helm.FetchChart(ctx, config.ChartConfig{Repo: c.Repo, Chart: c.Chart, Version: c.Version, Auth: c.auth, TLS: c.tls}, dir)
+		if err != nil {
+			return err
+		}
+		c.File = path.Join(chartsDir, filepath.Base(file))
+		manifest.Charts = append(manifest.Charts, c)
+	}
+	return nil
+}
+
+// saveImages copies the provider packages and every image the
+// configuration preloads into the images/ OCI layout. Image cache settings
+// that turn preloading off are ignored, as the nodes cannot pull anything.
+func saveImages(ctx context.Context, cfg *config.Config, staging string, manifest *Manifest, platform ocispec.Platform, opts CreateOptions) error {
+	collectCfg := imageCollectConfig(cfg)
+	opts.Log("Collecting images...")
+	for _, s := range kind.CollectImages(ctx, &collectCfg, opts.Log) {
+		manifest.Images = append(manifest.Images, s.Image)
+	}
+	for _, p := range cfg.Crossplane.Providers {
+		if pkg := xpkg.Qualify(p.Package); !slices.Contains(manifest.Packages, pkg) {
+			manifest.Packages = append(manifest.Packages, pkg)
+		}
+	}
+
+	puller, err := oci.NewPuller(ctx, oci.Options{
+		TrustedCAs: cfg.Cluster.TrustedCAs.Registries,
+		Platform:   platform,
+	})
+	if err != nil {
+		return err
+	}
+	layout, err := oci.CreateLayout(filepath.Join(staging, imagesDir))
+	if err != nil {
+		return err
+	}
+	refs := slices.Clone(manifest.Images)
+	for _, pkg := range manifest.Packages {
+		if !slices.Contains(refs, pkg) {
+			refs = append(refs, pkg)
+		}
+	}
+	for i, ref := range refs {
+		opts.Log(fmt.Sprintf("Copying %s (%d/%d)...", ref, i+1, len(refs)))
+		img, err := puller.Resolve(ctx, ref)
+		if err != nil {
+			return err
+		}
+		if err := puller.Save(ctx, img, layout); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// imageCollectConfig returns a copy of cfg for CollectImages that finds
+// every image a bundle holds, whatever the image cache settings
+func imageCollectConfig(cfg *config.Config) config.Config {
+	collectCfg := *cfg
+	collectCfg.Crossplane.ImageCache = &config.ImageCacheConfig{}
+	if ic := cfg.Crossplane.ImageCache; ic != nil {
+		collectCfg.Crossplane.ImageCache.AdditionalImages = ic.AdditionalImages
+		collectCfg.Crossplane.ImageCache.ImageOverrides = ic.ImageOverrides
+	}
+	return collectCfg
+}
+
+// cloneGitSources checks out each git composition source, without its
+// history
+func cloneGitSources(ctx context.Context, cfg *config.Config, staging string, manifest *Manifest, opts CreateOptions) error {
+	for _, s := range cfg.Compositions.Sources {
+		if s.Type != "git" || slices.ContainsFunc(manifest.GitSources, func(g GitSource) bool {
+			return g.Repo == s.Repo && g.Branch == s.Branch
+		}) {
+			continue
+		}
+		dir := path.Join(gitDir, strconv.Itoa(len(manifest.GitSources)))
+		opts.Log(fmt.Sprintf("Cloning %s...", s.Repo))
+		dest := filepath.Join(staging, filepath.FromSlash(dir))
+		if err := git.CloneRepoToPath(ctx, s.Repo, s.Branch, dest); err != nil {
+			return fmt.Errorf("failed to clone %s: %w", s.Repo, err)
+		}
+		if err := os.RemoveAll(filepath.Join(dest, ".git")); err != nil {
+			return err
+		}
+		manifest.GitSources = append(manifest.GitSources, GitSource{Repo: s.Repo, Branch: s.Branch, Dir: dir})
+	}
+	return nil
+}
+
+// parsePlatform parses an os/arch[/variant] platform
+func parsePlatform(s string) (ocispec.Platform, error) {
+	parts := strings.Split(s, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" {
+		return ocispec.Platform{}, fmt.Errorf("invalid platform %q: use os/arch, e.g. linux/amd64", s)
+	}
+	p := ocispec.Platform{OS: parts[0], Architecture: parts[1]}
+	if len(parts) == 3 {
+		p.Variant = parts[2]
+	}
+	return p, nil
+}
@@ -0,0 +1,186 @@
+package cache
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"sync/atomic"
+	"time"
+
+	"github.com/kanzi/kindplane/internal/state"
+)
+
+// Kinds of cached data, each in its own directory under the kindplane cache
+// directory
+const (
+	// Charts holds Helm chart archives by repository, chart and version
+	Charts = "charts"
+	// Images holds image tarballs saved for loading into Kind nodes
+	Images = "images"
+	// Git holds checkouts of git composition sources
+	Git = "git"
+	// Schemas holds the OpenAPI schemas of Kubernetes versions
+	Schemas = "schemas"
+)
+
+// Kinds lists the kinds of cached data in display order
+var Kinds = []string{Charts, Images, Git, Schemas}
+
+// MetaFile is the file describing a directory entry, holding JSON with at
+// least a "name" field
+const MetaFile = "entry.json"
+
+// offline is set by SetOffline
+var offline atomic.Bool
+
+// SetOffline forbids network access for data kindplane caches: charts, git
+// sources and schemas then come from the cache only
+func SetOffline(v bool) {
+	offline.Store(v)
+}
+
+// Offline reports whether network access for cached data is forbidden
+func Offline() bool {
+	return offline.Load()
+}
+
+// Dir returns the cache directory of a kind of data
+func Dir(kind string) (string, error) {
+	dir, err := state.CacheDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, kind), nil
+}
+
+// Entry is one cached item: a file or a directory in a kind's directory
+type Entry struct {
+	Kind     string    `json:"kind"`
+	Name     string    `json:"name"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	LastUsed time.Time `json:"lastUsed"`
+}
+
+// List returns the entries of the given kinds, or of every kind, most
+// recently used first
+func List(kinds ...string) ([]Entry, error) {
+	if len(kinds) == 0 {
+		kinds = Kinds
+	}
+
+	var entries []Entry
+	for _, kind := range kinds {
+		if !slices.Contains(Kinds, kind) {
+			return nil, fmt.Errorf("unknown cache kind %q (use %v)", kind, Kinds)
+		}
+		dir, err := Dir(kind)
+		if err != nil {
+			return nil, err
+		}
+		items, err := os.ReadDir(dir)
+		if os.IsNotExist(err) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read cache: %w", err)
+		}
+		for _, item := range items {
+			// Entries being written are hidden temporary files
+			if item.Name()[0] == '.' {
+				continue
+			}
+			entry, err := readEntry(kind, filepath.Join(dir, item.Name()))
+			if err != nil {
+				return nil, err
+			}
+			entries = append(entries, entry)
+		}
+	}
+
+	sort.SliceStable(entries, func(a, b int) bool { return entries[a].LastUsed.After(entries[b].LastUsed) })
+	return entries, nil
+}
+
+// readEntry describes the entry at path
+func readEntry(kind, path string) (Entry, error) {
+	info, err := os.Stat(path)
+	if err != nil {
+		return Entry{}, err
+	}
+	entry := Entry{Kind: kind, Name: filepath.Base(path), Path: path, LastUsed: info.ModTime()}
+	if !info.IsDir() {
+		entry.Size = info.Size()
+		return entry, nil
+	}
+
+	var meta struct {
+		Name string `json:"name"`
+	}
+	if err := ReadMeta(path, &meta); err == nil && meta.Name != "" {
+		entry.Name = meta.Name
+	}
+	err = filepath.WalkDir(path, func(_ string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		entry.Size += info.Size()
+		return nil
+	})
+	return entry, err
+}
+
+// Prune removes the entries of the given kinds, or of every kind, that were
+// last used more than olderThan ago; 0 removes them all. It returns the
+// removed entries.
+func Prune(olderThan time.Duration, kinds ...string) ([]Entry, error) {
+	entries, err := List(kinds...)
+	if err != nil {
+		return nil, err
+	}
+
+	var removed []Entry
+	cutoff := time.Now().Add(-olderThan)
+	for _, e := range entries {
+		if olderThan > 0 && e.LastUsed.After(cutoff) {
+			continue
+		}
+		if err := os.RemoveAll(e.Path); err != nil {
+			return removed, fmt.Errorf("failed to remove %s: %w", e.Path, err)
+		}
+		removed = append(removed, e)
+	}
+	return removed, nil
+}
+
+// Touch marks an entry as used now, so pruning by age keeps it
+func Touch(path string) {
+	now := time.Now()
+	_ = os.Chtimes(path, now, now)
+}
+
+// WriteMeta writes the MetaFile of a directory entry
+func WriteMeta(dir string, meta interface{}) error {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, MetaFile), data, 0644)
+}
+
+// ReadMeta reads the MetaFile of a directory entry into meta
+func ReadMeta(dir string, meta interface{}) error {
+	data, err := os.ReadFile(filepath.Join(dir, MetaFile))
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, meta)
+}
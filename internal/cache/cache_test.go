@@ -0,0 +1,97 @@
+package cache
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeEntry creates a cache entry last used age ago
+func writeEntry(t *testing.T, kind, name string, meta interface{}, age time.Duration) string {
+	t.Helper()
+	dir, err := Dir(kind)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, name)
+	if meta != nil {
+		if err := os.MkdirAll(path, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := WriteMeta(path, meta); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(path, "data"), make([]byte, 100), 0644); err != nil {
+			t.Fatal(err)
+		}
+	} else {
+		if err := os.MkdirAll(dir, 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, make([]byte, 50), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	used := time.Now().Add(-age)
+	if err := os.Chtimes(path, used, used); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestList(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	writeEntry(t, Charts, "abc-1.0.0", map[string]string{"name": "demo 1.0.0"}, time.Hour)
+	writeEntry(t, Images, "sha-linux-amd64-ref.tar", nil, time.Minute)
+	writeEntry(t, Git, ".clone-123", map[string]string{"name": "partial"}, 0)
+
+	entries, err := List()
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("List() = %+v, want 2 entries", entries)
+	}
+	if entries[0].Kind != Images || entries[0].Size != 50 {
+		t.Errorf("List()[0] = %+v, want the image archive of 50 bytes first", entries[0])
+	}
+	if entries[1].Kind != Charts || entries[1].Name != "demo 1.0.0" || entries[1].Size <= 100 {
+		t.Errorf("List()[1] = %+v, want the chart named by its metadata", entries[1])
+	}
+
+	charts, err := List(Charts)
+	if err != nil || len(charts) != 1 {
+		t.Errorf("List(charts) = %+v, %v; want 1 entry", charts, err)
+	}
+	if _, err := List("bogus"); err == nil {
+		t.Error("List(bogus) succeeded")
+	}
+}
+
+func TestPrune(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	old := writeEntry(t, Charts, "abc-1.0.0", map[string]string{"name": "demo 1.0.0"}, 48*time.Hour)
+	recent := writeEntry(t, Charts, "abc-2.0.0", map[string]string{"name": "demo 2.0.0"}, time.Hour)
+	image := writeEntry(t, Images, "old.tar", nil, 48*time.Hour)
+
+	removed, err := Prune(24*time.Hour, Charts)
+	if err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	if len(removed) != 1 || removed[0].Path != old {
+		t.Errorf("Prune(24h, charts) removed %+v, want %s", removed, old)
+	}
+	for path, want := range map[string]bool{old: false, recent: true, image: true} {
+		if _, err := os.Stat(path); (err == nil) != want {
+			t.Errorf("%s exists = %v, want %v", path, err == nil, want)
+		}
+	}
+
+	removed, err = Prune(0)
+	if err != nil || len(removed) != 2 {
+		t.Errorf("Prune(0) removed %+v, %v; want the 2 remaining entries", removed, err)
+	}
+}
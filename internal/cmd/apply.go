@@ -63,13 +63,19 @@ func runApply(cmd *cobra.Command, args []string) error {
 	// Require config
 	requireConfig()
 
+	clusterLock, err := lockCluster(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), applyTimeout)
 	defer cancel()
 
 	clusterName := cfg.Cluster.Name
 
 	// Check if cluster exists
-	exists, err := kind.ClusterExists(clusterName)
+	exists, err := kind.ClusterAvailable(clusterName)
 	if err != nil {
 		printError("Failed to check cluster status: %v", err)
 		return err
@@ -87,13 +93,13 @@ func runApply(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kubernetes client
-	kubeClient, err := kind.GetKubeClient(clusterName)
+	conn, err := kind.Connect(clusterName)
 	if err != nil {
 		printError("Failed to connect to cluster: %v", err)
 		return err
 	}
 
-	installer := crossplane.NewInstaller(kubeClient)
+	installer := crossplane.NewInstaller(conn)
 
 	// Print header
 	fmt.Println()
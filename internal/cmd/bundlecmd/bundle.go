@@ -0,0 +1,27 @@
+package bundlecmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// BundleCmd is the parent command for air-gapped bundle subcommands
+var BundleCmd = &cobra.Command{
+	Use:   "bundle",
+	Short: "Create bundles for air-gapped bootstraps",
+	Long: `Create and inspect bundles holding everything kindplane.yaml needs to
+bootstrap a cluster without network access.
+
+A bundle is a tarball with the Kind node and registry images, the
+Crossplane chart and configured charts, provider packages, every image the
+configuration preloads and the git composition sources, listed with their
+checksums in a manifest. Bootstrap from it with 'kindplane up --bundle'.
+
+Available subcommands:
+  create  - Collect everything the configuration needs into a bundle
+  inspect - Show the contents of a bundle`,
+}
+
+func init() {
+	BundleCmd.AddCommand(createCmd)
+	BundleCmd.AddCommand(inspectCmd)
+}
@@ -0,0 +1,82 @@
+package bundlecmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/bundle"
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	createOutput   string
+	createPlatform string
+	createTimeout  time.Duration
+)
+
+var createCmd = &cobra.Command{
+	Use:   "create",
+	Short: "Collect everything the configuration needs into a bundle",
+	Long: `Collect everything kindplane.yaml needs into one bundle, on a machine with
+network access:
+
+  - the Kind node image and the registry image
+  - the Crossplane chart and every chart in the charts list
+  - provider packages
+  - the images 'kindplane images list' shows, for the nodes' platform,
+    whatever crossplane.imageCache turns off
+  - the git composition sources
+
+Every item must be collected; the command fails rather than write an
+incomplete bundle. The output is gzip-compressed when its name ends in .gz
+or .tgz.`,
+	Example: `  # Bundle the configuration in the current directory
+  kindplane bundle create -o lab.tar.gz
+
+  # Bundle for arm64 nodes
+  kindplane bundle create -o lab-arm64.tar.gz --platform linux/arm64`,
+	Args: cobra.NoArgs,
+	RunE: runCreate,
+}
+
+func init() {
+	createCmd.Flags().StringVarP(&createOutput, "output", "o", "kindplane-bundle.tar.gz", "Bundle file to write")
+	createCmd.Flags().StringVar(&createPlatform, "platform", "", "Node platform to collect images for (default linux/<host architecture>)")
+	createCmd.Flags().DurationVar(&createTimeout, "timeout", 30*time.Minute, "Timeout for collecting the bundle")
+}
+
+func runCreate(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), createTimeout)
+	defer cancel()
+
+	manifest, err := bundle.Create(ctx, cfg, createOutput, bundle.CreateOptions{
+		Platform: createPlatform,
+		Log: func(msg string) {
+			fmt.Println(ui.Muted("  %s", msg))
+		},
+	})
+	if err != nil {
+		fmt.Println(ui.Error("Failed to create bundle: %v", err))
+		return err
+	}
+
+	size := ""
+	if info, err := os.Stat(createOutput); err == nil {
+		size = fmt.Sprintf(", %.1f MiB", float64(info.Size())/(1<<20))
+	}
+	fmt.Println(ui.Success("Bundle written to %s (%d charts, %d images, %d packages%s)",
+		createOutput, len(manifest.Charts), len(manifest.Images), len(manifest.Packages), size))
+	fmt.Println(ui.Muted("  Next: kindplane up --bundle %s", createOutput))
+	return nil
+}
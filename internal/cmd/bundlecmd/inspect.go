@@ -0,0 +1,113 @@
+package bundlecmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/bundle"
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var inspectFormat string
+
+var inspectCmd = &cobra.Command{
+	Use:   "inspect <file>",
+	Short: "Show the contents of a bundle",
+	Long: `Show the manifest of a bundle without extracting it.
+
+When a kindplane.yaml is present, anything it needs that the bundle lacks
+is listed too, the same check 'kindplane up --bundle' makes before it
+starts. The configured charts are rendered from their repositories to find
+the images they need.`,
+	Example: `  # Show what a bundle holds
+  kindplane bundle inspect lab.tar.gz
+
+  # Print the manifest
+  kindplane bundle inspect lab.tar.gz -o json`,
+	Args: cobra.ExactArgs(1),
+	RunE: runInspect,
+}
+
+func init() {
+	inspectCmd.Flags().StringVarP(&inspectFormat, "format", "o", "table", "Output format (table, json)")
+}
+
+func runInspect(cmd *cobra.Command, args []string) error {
+	if inspectFormat != "table" && inspectFormat != "json" {
+		fmt.Println(ui.Error("Unknown format: %s. Use 'table' or 'json'.", inspectFormat))
+		return fmt.Errorf("unknown format: %s", inspectFormat)
+	}
+
+	manifest, err := bundle.ReadManifest(args[0])
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	if inspectFormat == "json" {
+		output, err := json.MarshalIndent(manifest, "", "  ")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to marshal output: %v", err))
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconPackage + " Bundle " + args[0]))
+	fmt.Println(ui.Divider())
+	fmt.Println(ui.KeyValue("Created", manifest.CreatedAt.Local().Format("2006-01-02 15:04")))
+	fmt.Println(ui.KeyValue("Platform", manifest.Platform))
+	fmt.Println(ui.KeyValue("Node image", manifest.NodeImage))
+
+	fmt.Println()
+	fmt.Println(ui.Title(fmt.Sprintf("Charts (%d)", len(manifest.Charts))))
+	for _, c := range manifest.Charts {
+		version := c.Version
+		if version == "" {
+			version = "latest"
+		}
+		fmt.Printf("  %s %s %s\n", c.Chart, ui.Muted("%s", version), ui.Muted("from %s", c.Repo))
+	}
+	fmt.Println()
+	fmt.Println(ui.Title(fmt.Sprintf("Packages (%d)", len(manifest.Packages))))
+	for _, p := range manifest.Packages {
+		fmt.Printf("  %s\n", p)
+	}
+	fmt.Println()
+	fmt.Println(ui.Title(fmt.Sprintf("Images (%d)", len(manifest.Images))))
+	for _, image := range manifest.Images {
+		fmt.Printf("  %s\n", image)
+	}
+	if len(manifest.GitSources) > 0 {
+		fmt.Println()
+		fmt.Println(ui.Title(fmt.Sprintf("Git sources (%d)", len(manifest.GitSources))))
+		for _, s := range manifest.GitSources {
+			fmt.Printf("  %s %s\n", s.Repo, ui.Muted("%s", s.Branch))
+		}
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		return nil
+	}
+	fmt.Println()
+	b := &bundle.Bundle{Manifest: manifest}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Minute)
+	defer cancel()
+	if missing := b.Missing(ctx, cfg); len(missing) > 0 {
+		fmt.Println(ui.Error("The bundle lacks %d item(s) kindplane.yaml needs:", len(missing)))
+		for _, m := range missing {
+			fmt.Printf("  - %s\n", m)
+		}
+		return fmt.Errorf("bundle does not match kindplane.yaml")
+	}
+	fmt.Println(ui.Success("The bundle has everything kindplane.yaml needs"))
+	return nil
+}
@@ -0,0 +1,31 @@
+package cachecmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// CacheCmd is the parent command for the local download cache
+var CacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local download cache",
+	Long: `Inspect and clean the cache kindplane keeps under ~/.cache/kindplane
+($XDG_CACHE_HOME/kindplane when set).
+
+The cache holds:
+  charts  - Helm chart archives, by repository, chart and version
+  images  - Image tarballs saved for loading into Kind nodes
+  git     - Checkouts of git composition sources
+  schemas - Kubernetes OpenAPI schemas for 'chart template --validate'
+
+With the global --offline flag, charts, git sources and schemas come from
+the cache only.
+
+Available subcommands:
+  ls    - List cache entries with their sizes
+  prune - Remove cache entries`,
+}
+
+func init() {
+	CacheCmd.AddCommand(lsCmd)
+	CacheCmd.AddCommand(pruneCmd)
+}
@@ -0,0 +1,81 @@
+package cachecmd
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/cache"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var lsFormat string
+
+var lsCmd = &cobra.Command{
+	Use:     "ls [kind...]",
+	Aliases: []string{"list"},
+	Short:   "List cache entries",
+	Long: `List the entries of the cache, most recently used first, with their
+sizes. Kinds are charts, images, git and schemas; without kinds every entry
+is listed.`,
+	Example: `  # List the whole cache
+  kindplane cache ls
+
+  # List cached charts as JSON
+  kindplane cache ls charts -o json`,
+	ValidArgs: cache.Kinds,
+	Args:      cobra.OnlyValidArgs,
+	RunE:      runLs,
+}
+
+func init() {
+	lsCmd.Flags().StringVarP(&lsFormat, "format", "o", "table", "Output format (table, json)")
+}
+
+func runLs(cmd *cobra.Command, args []string) error {
+	if lsFormat != "table" && lsFormat != "json" {
+		fmt.Println(ui.Error("Unknown format: %s. Use 'table' or 'json'.", lsFormat))
+		return fmt.Errorf("unknown format: %s", lsFormat)
+	}
+
+	entries, err := cache.List(args...)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	if lsFormat == "json" {
+		if entries == nil {
+			entries = []cache.Entry{}
+		}
+		output, err := json.MarshalIndent(entries, "", "  ")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to marshal output: %v", err))
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(entries) == 0 {
+		fmt.Println(ui.Muted("The cache is empty"))
+		return nil
+	}
+
+	headers := []string{"KIND", "NAME", "SIZE", "LAST USED"}
+	var rows [][]string
+	var total int64
+	for _, e := range entries {
+		rows = append(rows, []string{e.Kind, e.Name, ui.FormatBytes(e.Size), e.LastUsed.Format("2006-01-02 15:04")})
+		total += e.Size
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconFolder + " Cache"))
+	fmt.Println(ui.Divider())
+	fmt.Println(ui.RenderTable(headers, rows))
+	fmt.Println(ui.Muted("%d entries, %s", len(entries), ui.FormatBytes(total)))
+
+	return nil
+}
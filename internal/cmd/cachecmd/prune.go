@@ -0,0 +1,56 @@
+package cachecmd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/cache"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var pruneOlderThan time.Duration
+
+var pruneCmd = &cobra.Command{
+	Use:   "prune [kind...]",
+	Short: "Remove cache entries",
+	Long: `Remove entries from the cache. Kinds are charts, images, git and
+schemas; without kinds every kind is pruned.
+
+With --older-than, only entries not used for that long are removed;
+otherwise everything is. Removed entries are downloaded again when next
+needed, which --offline does not allow.`,
+	Example: `  # Empty the cache
+  kindplane cache prune
+
+  # Remove image tarballs unused for a week
+  kindplane cache prune images --older-than 168h`,
+	ValidArgs: cache.Kinds,
+	Args:      cobra.OnlyValidArgs,
+	RunE:      runPrune,
+}
+
+func init() {
+	pruneCmd.Flags().DurationVar(&pruneOlderThan, "older-than", 0, "Only remove entries unused for this long")
+}
+
+func runPrune(cmd *cobra.Command, args []string) error {
+	removed, err := cache.Prune(pruneOlderThan, args...)
+	var freed int64
+	for _, e := range removed {
+		fmt.Println(ui.Muted("  removed %s %s", e.Kind, e.Name))
+		freed += e.Size
+	}
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	if len(removed) == 0 {
+		fmt.Println(ui.Muted("Nothing to remove"))
+		return nil
+	}
+	fmt.Println(ui.Success("Removed %d cache entries, freeing %s", len(removed), ui.FormatBytes(freed)))
+	return nil
+}
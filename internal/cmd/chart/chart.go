@@ -1,7 +1,17 @@
 package chart
 
 import (
+	"context"
+	"fmt"
+
 	"github.com/spf13/cobra"
+	"gopkg.in/yaml.v3"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/kube"
+	"github.com/kanzi/kindplane/internal/ui"
 )
 
 // ChartCmd is the parent command for chart subcommands
@@ -14,7 +24,11 @@ Available subcommands:
   install   - Install a Helm chart
   upgrade   - Upgrade a Helm release
   list      - List installed Helm releases
-  uninstall - Uninstall a Helm release`,
+  uninstall - Uninstall a Helm release
+  history   - Show the revisions of a Helm release
+  rollback  - Roll a Helm release back to an earlier revision
+  diff      - Compare configured charts with the deployed releases
+  template  - Render a configured chart locally`,
 }
 
 func init() {
@@ -22,4 +36,67 @@ func init() {
 	ChartCmd.AddCommand(upgradeCmd)
 	ChartCmd.AddCommand(listCmd)
 	ChartCmd.AddCommand(uninstallCmd)
+	ChartCmd.AddCommand(historyCmd)
+	ChartCmd.AddCommand(rollbackCmd)
+	ChartCmd.AddCommand(diffCmd)
+	ChartCmd.AddCommand(templateCmd)
+}
+
+// markSourceFlags makes install and upgrade take a chart either from --repo
+// and --chart or from --path, with credentials and TLS settings only for a
+// repo
+func markSourceFlags(cmd *cobra.Command) {
+	cmd.MarkFlagsOneRequired("repo", "path")
+	cmd.MarkFlagsRequiredTogether("repo", "chart")
+	cmd.MarkFlagsRequiredTogether("username-env", "password-env")
+	cmd.MarkFlagsMutuallyExclusive("ca-file", "insecure-skip-tls-verify")
+	for _, flag := range []string{"repo", "chart", "version", "username-env", "ca-file", "insecure-skip-tls-verify"} {
+		cmd.MarkFlagsMutuallyExclusive("path", flag)
+	}
+}
+
+// chartAuth returns the repository credentials named by --username-env and
+// --password-env, or nil
+func chartAuth(usernameEnv, passwordEnv string) *config.ChartAuth {
+	if usernameEnv == "" {
+		return nil
+	}
+	return &config.ChartAuth{UsernameEnv: usernameEnv, PasswordEnv: passwordEnv}
+}
+
+// chartTLS returns the repository TLS settings of --ca-file and
+// --insecure-skip-tls-verify, or nil
+func chartTLS(caFile string, insecure bool) *config.ChartRepoTLS {
+	if caFile == "" && !insecure {
+		return nil
+	}
+	return &config.ChartRepoTLS{CAFile: caFile, InsecureSkipTLSVerify: insecure}
+}
+
+// chartLabel describes where a chart comes from in messages
+func chartLabel(repo, chart, path string) string {
+	if path != "" {
+		return path
+	}
+	return helm.ChartRef(repo, repo, chart)
+}
+
+// valuesContext returns the values template context of the cluster, with
+// the host ports it was created with
+func valuesContext(ctx context.Context, cfg *config.Config, conn *kube.Connection) *helm.ValuesContext {
+	if pm, err := kind.LoadPortMap(ctx, conn.Client); err == nil && pm != nil {
+		pm.Apply(cfg)
+	}
+	return helm.NewValuesContext(cfg)
+}
+
+// printValues shows the rendered values a release is installed with, for
+// --show-values
+func printValues(releaseName string, values map[string]interface{}) {
+	valuesYAML, err := yaml.Marshal(values)
+	if err != nil {
+		return
+	}
+	fmt.Println(ui.Info("Rendered values for %s:", releaseName))
+	fmt.Println(ui.Code(string(valuesYAML)))
 }
@@ -0,0 +1,266 @@
+package chart
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/crossplane"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	diffFormat  string
+	diffTimeout time.Duration
+)
+
+// Release states reported by chart diff
+const (
+	diffInSync       = "in-sync"
+	diffDrifted      = "drifted"
+	diffNotInstalled = "not-installed"
+	diffUnmanaged    = "unmanaged"
+)
+
+// releaseDiff compares a release with its chart in kindplane.yaml
+type releaseDiff struct {
+	Name            string             `json:"name"`
+	Namespace       string             `json:"namespace"`
+	State           string             `json:"state"`
+	Chart           string             `json:"chart,omitempty"`
+	ConfigVersion   string             `json:"configVersion,omitempty"`
+	DeployedVersion string             `json:"deployedVersion,omitempty"`
+	Values          []helm.ValueChange `json:"values,omitempty"`
+}
+
+var diffCmd = &cobra.Command{
+	Use:   "diff [name...]",
+	Short: "Compare configured charts with the deployed releases",
+	Long: `Compare the charts in kindplane.yaml with the releases in the cluster.
+
+For each configured chart, the pinned chart version and the merged values
+(with templates rendered) are compared with the chart version and the values
+supplied to the deployed release. Without chart names, releases in the cluster
+that kindplane.yaml does not configure are reported as unmanaged; the
+Crossplane release is managed by the crossplane section and not reported.
+
+The command exits with an error when any chart has drifted, is not installed
+or when unmanaged releases exist, so it can guard CI jobs.`,
+	Example: `  # Check every chart
+  kindplane chart diff
+
+  # Check one chart
+  kindplane chart diff ingress-nginx
+
+  # Machine-readable output
+  kindplane chart diff -o json`,
+	RunE: runDiff,
+}
+
+func init() {
+	diffCmd.Flags().StringVarP(&diffFormat, "format", "o", "table", "Output format (table, json)")
+	diffCmd.Flags().DurationVar(&diffTimeout, "timeout", time.Minute, "Timeout for reading releases")
+}
+
+func runDiff(cmd *cobra.Command, args []string) error {
+	if diffFormat != "table" && diffFormat != "json" {
+		fmt.Println(ui.Error("Unknown format: %s. Use 'table' or 'json'.", diffFormat))
+		return fmt.Errorf("unknown format: %s", diffFormat)
+	}
+
+	// Load config to get cluster name
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	charts := cfg.Charts
+	if len(args) > 0 {
+		charts = nil
+		for _, name := range args {
+			i := slices.IndexFunc(cfg.Charts, func(c config.ChartConfig) bool { return c.Name == name })
+			if i < 0 {
+				fmt.Println(ui.Error("Chart '%s' not found in kindplane.yaml", name))
+				return fmt.Errorf("chart not found")
+			}
+			charts = append(charts, cfg.Charts[i])
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), diffTimeout)
+	defer cancel()
+
+	// Check cluster exists
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check cluster: %v", err))
+		return err
+	}
+	if !exists {
+		fmt.Println(ui.Error("Cluster '%s' not found. Run 'kindplane up' first.", cfg.Cluster.Name))
+		return fmt.Errorf("cluster not found")
+	}
+
+	// Get kube client
+	conn, err := kind.Connect(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
+		return err
+	}
+
+	helmInstaller := helm.NewInstaller(conn)
+	vc := valuesContext(ctx, cfg, conn)
+
+	var diffs []releaseDiff
+	for _, chartCfg := range charts {
+		diff, err := diffChart(ctx, helmInstaller, chartCfg, vc)
+		if err != nil {
+			fmt.Println(ui.Error("Failed to compare chart %s: %v", chartCfg.Name, err))
+			return err
+		}
+		diffs = append(diffs, diff)
+	}
+
+	if len(args) == 0 {
+		releases, err := helmInstaller.ListReleases(ctx, "")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to list releases: %v", err))
+			return err
+		}
+		for _, rel := range releases {
+			configured := slices.ContainsFunc(cfg.Charts, func(c config.ChartConfig) bool {
+				return c.Name == rel.Name && c.Namespace == rel.Namespace
+			})
+			if configured || (rel.Name == crossplane.CrossplaneChartName && rel.Namespace == crossplane.CrossplaneNamespace) {
+				continue
+			}
+			diffs = append(diffs, releaseDiff{Name: rel.Name, Namespace: rel.Namespace, State: diffUnmanaged, Chart: rel.Chart})
+		}
+	}
+
+	drifted := 0
+	for _, d := range diffs {
+		if d.State != diffInSync {
+			drifted++
+		}
+	}
+
+	if diffFormat == "json" {
+		output, err := json.MarshalIndent(diffs, "", "  ")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to marshal output: %v", err))
+			return err
+		}
+		fmt.Println(string(output))
+	} else {
+		printDiffs(diffs)
+	}
+
+	if drifted > 0 {
+		return fmt.Errorf("%d of %d releases differ from kindplane.yaml", drifted, len(diffs))
+	}
+	return nil
+}
+
+// diffChart compares a configured chart with its release
+func diffChart(ctx context.Context, helmInstaller *helm.Installer, chartCfg config.ChartConfig, vc *helm.ValuesContext) (releaseDiff, error) {
+	diff := releaseDiff{Name: chartCfg.Name, Namespace: chartCfg.Namespace}
+
+	version, err := helm.ConfiguredVersion(chartCfg)
+	if err != nil {
+		return diff, err
+	}
+	diff.ConfigVersion = version
+
+	rel, err := helmInstaller.GetRelease(ctx, chartCfg.Name, chartCfg.Namespace)
+	if err != nil {
+		return diff, err
+	}
+	if rel == nil {
+		diff.State = diffNotInstalled
+		return diff, nil
+	}
+	diff.Chart = rel.Chart
+	diff.DeployedVersion = rel.Version
+
+	values, err := helmInstaller.GetMergedValuesFromConfig(chartCfg, vc)
+	if err != nil {
+		return diff, fmt.Errorf("failed to merge values: %w", err)
+	}
+	diff.Values, err = helm.DiffValues(values, rel.Values)
+	if err != nil {
+		return diff, err
+	}
+
+	diff.State = diffInSync
+	if len(diff.Values) > 0 || (version != "" && version != rel.Version) {
+		diff.State = diffDrifted
+	}
+	return diff, nil
+}
+
+// printDiffs shows the comparison of every release
+func printDiffs(diffs []releaseDiff) {
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconGear + " Chart Drift"))
+	fmt.Println(ui.Divider())
+
+	if len(diffs) == 0 {
+		fmt.Println(ui.Muted("No charts configured and no releases installed"))
+		return
+	}
+
+	drifted := 0
+	for _, d := range diffs {
+		release := fmt.Sprintf("%s (%s)", d.Name, d.Namespace)
+		switch d.State {
+		case diffInSync:
+			fmt.Println(ui.Success("%s in sync", release))
+			continue
+		case diffNotInstalled:
+			fmt.Println(ui.Error("%s is not installed", release))
+		case diffUnmanaged:
+			fmt.Println(ui.Warning("%s is not in kindplane.yaml (chart %s)", release, d.Chart))
+		case diffDrifted:
+			fmt.Println(ui.Error("%s has drifted", release))
+			if d.ConfigVersion != "" && d.ConfigVersion != d.DeployedVersion {
+				fmt.Println(ui.Muted("    version: %s in kindplane.yaml, %s deployed", d.ConfigVersion, d.DeployedVersion))
+			}
+			for _, c := range d.Values {
+				switch c.Kind {
+				case helm.ValueChanged:
+					fmt.Printf("    ~ %s: %s → %s\n", c.Path, formatValue(c.Config), formatValue(c.Deployed))
+				case helm.ValueNotDeployed:
+					fmt.Printf("    - %s: %s (not deployed)\n", c.Path, formatValue(c.Config))
+				case helm.ValueNotConfigured:
+					fmt.Printf("    + %s: %s (not in kindplane.yaml)\n", c.Path, formatValue(c.Deployed))
+				}
+			}
+		}
+		drifted++
+	}
+
+	fmt.Println()
+	if drifted == 0 {
+		fmt.Println(ui.Success("All %d releases match kindplane.yaml", len(diffs)))
+	} else {
+		fmt.Println(ui.Warning("%d of %d releases differ from kindplane.yaml", drifted, len(diffs)))
+	}
+}
+
+// formatValue shows a value of a diff on one line, as JSON
+func formatValue(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
@@ -0,0 +1,121 @@
+package chart
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	historyNamespace string
+	historyMax       int
+	historyTimeout   time.Duration
+)
+
+var historyCmd = &cobra.Command{
+	Use:   "history <release-name>",
+	Short: "Show the revisions of a Helm release",
+	Long: `Show the revisions of a Helm release in the cluster, oldest first.
+
+Each install, upgrade and rollback records a revision. Use
+'kindplane chart rollback' to return to one of them.`,
+	Example: `  # Show the revisions of a release
+  kindplane chart history nginx-ingress --namespace ingress-nginx
+
+  # Show only the last 3 revisions
+  kindplane chart history prometheus --namespace monitoring --max 3`,
+	Args: cobra.ExactArgs(1),
+	RunE: runHistory,
+}
+
+func init() {
+	historyCmd.Flags().StringVarP(&historyNamespace, "namespace", "n", "", "Release namespace (required)")
+	historyCmd.Flags().IntVar(&historyMax, "max", 10, "Maximum number of revisions to show (0 for all)")
+	historyCmd.Flags().DurationVar(&historyTimeout, "timeout", 30*time.Second, "Timeout for reading the history")
+
+	_ = historyCmd.MarkFlagRequired("namespace")
+}
+
+func runHistory(cmd *cobra.Command, args []string) error {
+	releaseName := args[0]
+
+	// Load config to get cluster name
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), historyTimeout)
+	defer cancel()
+
+	// Check cluster exists
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check cluster: %v", err))
+		return err
+	}
+	if !exists {
+		fmt.Println(ui.Error("Cluster '%s' not found. Run 'kindplane up' first.", cfg.Cluster.Name))
+		return fmt.Errorf("cluster not found")
+	}
+
+	// Get kube client
+	conn, err := kind.Connect(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
+		return err
+	}
+
+	helmInstaller := helm.NewInstaller(conn)
+	revisions, err := helmInstaller.History(ctx, releaseName, historyNamespace, historyMax)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to read history of release %s: %v", releaseName, err))
+		return err
+	}
+
+	// Build table data
+	headers := []string{"REVISION", "STATUS", "CHART", "APP VERSION", "DESCRIPTION", "UPDATED"}
+	var rows [][]string
+
+	for _, rev := range revisions {
+		updated := ""
+		if !rev.Updated.IsZero() {
+			updated = rev.Updated.Format("2006-01-02 15:04:05")
+		}
+
+		var status string
+		switch rev.Status {
+		case "deployed":
+			status = ui.IconSuccess + " deployed"
+		case "superseded":
+			status = rev.Status
+		default:
+			status = ui.IconWarning + " " + rev.Status
+		}
+
+		rows = append(rows, []string{
+			strconv.Itoa(rev.Revision),
+			status,
+			ui.TruncateWithEllipsis(rev.Chart, 30),
+			ui.TruncateWithEllipsis(rev.AppVersion, 15),
+			ui.TruncateWithEllipsis(rev.Description, 40),
+			updated,
+		})
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconGear + " History of " + releaseName))
+	fmt.Println(ui.Divider())
+	fmt.Println(ui.RenderTable(headers, rows))
+
+	return nil
+}
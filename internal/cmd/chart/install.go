@@ -10,16 +10,24 @@ import (
 	"github.com/kanzi/kindplane/internal/config"
 	"github.com/kanzi/kindplane/internal/helm"
 	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
 	"github.com/kanzi/kindplane/internal/ui"
 )
 
 var (
 	installRepo        string
 	installChart       string
+	installPath        string
+	installUserEnv     string
+	installPassEnv     string
+	installCAFile      string
+	installInsecure    bool
 	installVersion     string
 	installNamespace   string
 	installValuesFiles []string
 	installSetValues   []string
+	installTemplate    bool
+	installShowValues  bool
 	installWait        bool
 	installTimeout     time.Duration
 	installCreate      bool
@@ -37,6 +45,25 @@ The release name is used as the Kubernetes resource name.`,
     --chart ingress-nginx \
     --namespace ingress-nginx
 
+  # Install from an OCI registry
+  kindplane chart install podinfo \
+    --repo oci://ghcr.io/stefanprodan/charts \
+    --chart podinfo \
+    --namespace podinfo
+
+  # Install from a chart museum behind basic auth with an internal CA
+  kindplane chart install platform \
+    --repo https://charts.internal.example.com \
+    --chart platform \
+    --namespace platform \
+    --username-env MUSEUM_USER --password-env MUSEUM_PASSWORD \
+    --ca-file ./certs/internal-ca.pem
+
+  # Install a chart vendored in the repository
+  kindplane chart install platform \
+    --path ./charts/platform \
+    --namespace platform
+
   # Install with custom values file
   kindplane chart install prometheus \
     --repo https://prometheus-community.github.io/helm-charts \
@@ -55,19 +82,25 @@ The release name is used as the Kubernetes resource name.`,
 }
 
 func init() {
-	installCmd.Flags().StringVar(&installRepo, "repo", "", "Helm repository URL (required)")
-	installCmd.Flags().StringVar(&installChart, "chart", "", "Chart name in the repository (required)")
+	installCmd.Flags().StringVar(&installRepo, "repo", "", "Helm repository URL, or oci:// registry URL")
+	installCmd.Flags().StringVar(&installChart, "chart", "", "Chart name in the repository (required with --repo)")
+	installCmd.Flags().StringVar(&installPath, "path", "", "Local chart directory or .tgz archive, instead of --repo and --chart")
+	installCmd.Flags().StringVar(&installUserEnv, "username-env", "", "Environment variable holding the repository username")
+	installCmd.Flags().StringVar(&installPassEnv, "password-env", "", "Environment variable holding the repository password or token")
+	installCmd.Flags().StringVar(&installCAFile, "ca-file", "", "CA certificate to verify the repository with")
+	installCmd.Flags().BoolVar(&installInsecure, "insecure-skip-tls-verify", false, "Skip verification of the repository's certificate")
 	installCmd.Flags().StringVar(&installVersion, "version", "", "Chart version (optional, latest if not specified)")
 	installCmd.Flags().StringVarP(&installNamespace, "namespace", "n", "", "Target namespace (required)")
 	installCmd.Flags().StringArrayVarP(&installValuesFiles, "values", "f", nil, "Path to values file (can be specified multiple times)")
 	installCmd.Flags().StringArrayVar(&installSetValues, "set", nil, "Set values on command line (key=value, can be specified multiple times)")
+	installCmd.Flags().BoolVar(&installTemplate, "template-values", false, "Render values files and --set values as Go templates with cluster facts")
+	installCmd.Flags().BoolVar(&installShowValues, "show-values", false, "Display the rendered values before the installation")
 	installCmd.Flags().BoolVar(&installWait, "wait", true, "Wait for resources to be ready")
 	installCmd.Flags().DurationVar(&installTimeout, "timeout", 5*time.Minute, "Timeout for installation")
 	installCmd.Flags().BoolVar(&installCreate, "create-namespace", true, "Create namespace if it doesn't exist")
 
-	_ = installCmd.MarkFlagRequired("repo")
-	_ = installCmd.MarkFlagRequired("chart")
 	_ = installCmd.MarkFlagRequired("namespace")
+	markSourceFlags(installCmd)
 }
 
 func runInstall(cmd *cobra.Command, args []string) error {
@@ -80,11 +113,18 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), installTimeout)
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -95,7 +135,7 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
@@ -108,12 +148,20 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Merge values: files first, then --set values (highest priority)
-	mergedValues, err := helm.MergeValues(installValuesFiles, setValues)
+	// Merge values: files first, then --set values (highest priority),
+	// rendering templates with the cluster's facts for --template-values
+	var vc *helm.ValuesContext
+	if installTemplate {
+		vc = valuesContext(ctx, cfg, conn)
+	}
+	mergedValues, err := helm.MergeValues(installValuesFiles, setValues, vc)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to merge values: %v", err))
 		return err
 	}
+	if installShowValues {
+		printValues(releaseName, mergedValues)
+	}
 
 	// Build chart config
 	wait := installWait
@@ -122,6 +170,9 @@ func runInstall(cmd *cobra.Command, args []string) error {
 		Name:            releaseName,
 		Repo:            installRepo,
 		Chart:           installChart,
+		Path:            installPath,
+		Auth:            chartAuth(installUserEnv, installPassEnv),
+		TLS:             chartTLS(installCAFile, installInsecure),
 		Version:         installVersion,
 		Namespace:       installNamespace,
 		CreateNamespace: &create,
@@ -131,8 +182,8 @@ func runInstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Install chart
-	fmt.Println(ui.Info("Installing chart %s (%s/%s)...", releaseName, installRepo, installChart))
-	helmInstaller := helm.NewInstaller(kubeClient)
+	fmt.Println(ui.Info("Installing chart %s (%s)...", releaseName, chartLabel(installRepo, installChart, installPath)))
+	helmInstaller := helm.NewInstaller(conn)
 	if err := helmInstaller.InstallChartFromConfig(ctx, chartCfg); err != nil {
 		fmt.Println(ui.Error("Failed to install chart: %v", err))
 		return err
@@ -49,7 +49,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -60,14 +60,14 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
 	}
 
 	// List releases
-	helmInstaller := helm.NewInstaller(kubeClient)
+	helmInstaller := helm.NewInstaller(conn)
 	releases, err := helmInstaller.ListReleases(ctx, listNamespace)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to list releases: %v", err))
@@ -0,0 +1,123 @@
+package chart
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	rollbackNamespace string
+	rollbackWait      bool
+	rollbackTimeout   time.Duration
+)
+
+var rollbackCmd = &cobra.Command{
+	Use:   "rollback <release-name> [revision]",
+	Short: "Roll a Helm release back to an earlier revision",
+	Long: `Roll a Helm release back to an earlier revision.
+
+Without a revision the release is rolled back to the previous one. The
+rollback is recorded as a new revision; 'kindplane chart history' lists them.`,
+	Example: `  # Undo the last upgrade
+  kindplane chart rollback nginx-ingress --namespace ingress-nginx
+
+  # Roll back to revision 2
+  kindplane chart rollback nginx-ingress 2 --namespace ingress-nginx`,
+	Args: cobra.RangeArgs(1, 2),
+	RunE: runRollback,
+}
+
+func init() {
+	rollbackCmd.Flags().StringVarP(&rollbackNamespace, "namespace", "n", "", "Release namespace (required)")
+	rollbackCmd.Flags().BoolVar(&rollbackWait, "wait", true, "Wait for resources to be ready")
+	rollbackCmd.Flags().DurationVar(&rollbackTimeout, "timeout", 5*time.Minute, "Timeout for rollback")
+
+	_ = rollbackCmd.MarkFlagRequired("namespace")
+}
+
+func runRollback(cmd *cobra.Command, args []string) error {
+	releaseName := args[0]
+
+	revision := 0
+	if len(args) == 2 {
+		r, err := strconv.Atoi(args[1])
+		if err != nil || r < 1 {
+			fmt.Println(ui.Error("Invalid revision '%s': must be a positive number", args[1]))
+			return fmt.Errorf("invalid revision: %s", args[1])
+		}
+		revision = r
+	}
+
+	// Load config to get cluster name
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), rollbackTimeout)
+	defer cancel()
+
+	// Check cluster exists
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check cluster: %v", err))
+		return err
+	}
+	if !exists {
+		fmt.Println(ui.Error("Cluster '%s' not found. Run 'kindplane up' first.", cfg.Cluster.Name))
+		return fmt.Errorf("cluster not found")
+	}
+
+	// Get kube client
+	conn, err := kind.Connect(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
+		return err
+	}
+
+	helmInstaller := helm.NewInstaller(conn)
+
+	// Check if release exists
+	installed, err := helmInstaller.IsInstalled(ctx, releaseName, rollbackNamespace)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check release: %v", err))
+		return err
+	}
+	if !installed {
+		fmt.Println(ui.Error("Release '%s' not found in namespace '%s'", releaseName, rollbackNamespace))
+		return fmt.Errorf("release not found")
+	}
+
+	target := "the previous revision"
+	if revision > 0 {
+		target = fmt.Sprintf("revision %d", revision)
+	}
+
+	fmt.Println(ui.Info("Rolling back release %s to %s...", releaseName, target))
+	opts := helm.RollbackOptions{Revision: revision, Wait: rollbackWait, Timeout: rollbackTimeout}
+	if err := helmInstaller.Rollback(ctx, releaseName, rollbackNamespace, opts); err != nil {
+		fmt.Println(ui.Error("Failed to roll back release: %v", err))
+		return err
+	}
+
+	fmt.Println(ui.Success("Release %s rolled back to %s", releaseName, target))
+	return nil
+}
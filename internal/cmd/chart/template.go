@@ -0,0 +1,295 @@
+package chart
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/bundle"
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/crossplane"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	templateOutput      string
+	templateOutputDir   string
+	templatePhase       string
+	templateValidate    bool
+	templateSchema      string
+	templateKubeVersion string
+	templateBundle      string
+	templateTimeout     time.Duration
+)
+
+// crossplanePhase is the phase of the Crossplane chart, between the
+// pre-crossplane and post-crossplane charts
+const crossplanePhase = "crossplane"
+
+// templatePhases lists the phases charts are rendered in, in bootstrap order
+var templatePhases = []string{
+	config.ChartPhasePrecrossplane,
+	crossplanePhase,
+	config.ChartPhasePostCrossplane,
+	config.ChartPhasePostProviders,
+	config.ChartPhaseFinal,
+}
+
+// templateChart is a chart to render with its phase
+type templateChart struct {
+	phase string
+	chart config.ChartConfig
+}
+
+var templateCmd = &cobra.Command{
+	Use:   "template [name...]",
+	Short: "Render configured charts locally",
+	Long: `Render charts from kindplane.yaml, like 'helm template'.
+
+Without names, the Crossplane chart and every chart in the charts section are
+rendered in the order 'kindplane up' installs them; 'crossplane' names the
+Crossplane chart. --phase limits them to one phase: pre-crossplane,
+crossplane, post-crossplane, post-providers or final.
+
+Charts are rendered with their merged values and postRender patches applied,
+exactly as 'kindplane up' would install them, without contacting the cluster.
+Hook resources are printed after a chart's resources; Helm does not
+post-render them. With --bundle, charts are rendered from the bundle's
+archives and with the settings 'kindplane up --bundle' applies, such as the
+registry it enables.
+
+--validate checks the rendered resources against the OpenAPI schema of the
+cluster's Kubernetes version, downloaded once from the Kubernetes repository
+and cached, or against the schema given with --schema. Field names, types and
+required fields are checked; resources of kinds the schema does not define,
+such as custom resources, are listed as not checked.`,
+	Example: `  # Show the manifests of the ingress-nginx chart
+  kindplane chart template ingress-nginx
+
+  # Save every chart to its own file
+  kindplane chart template --output-dir manifests/
+
+  # Render and validate the charts installed before Crossplane
+  kindplane chart template --phase pre-crossplane --validate
+
+  # Validate against a newer Kubernetes version
+  kindplane chart template --validate --kube-version 1.32.0 -o all.yaml`,
+	RunE: runTemplate,
+}
+
+func init() {
+	templateCmd.Flags().StringVarP(&templateOutput, "output", "o", "", "Write output to file instead of stdout")
+	templateCmd.Flags().StringVar(&templateOutputDir, "output-dir", "", "Write each chart to <name>.yaml in this directory")
+	templateCmd.Flags().StringVar(&templatePhase, "phase", "", "Only render charts of this phase")
+	templateCmd.Flags().BoolVar(&templateValidate, "validate", false, "Validate the manifests against the Kubernetes OpenAPI schema")
+	templateCmd.Flags().StringVar(&templateSchema, "schema", "", "OpenAPI (v2) schema file or URL to validate against (default: the schema of the Kubernetes version)")
+	templateCmd.Flags().StringVar(&templateKubeVersion, "kube-version", "", "Kubernetes version to render and validate for (default: cluster.kubernetesVersion)")
+	templateCmd.Flags().StringVar(&templateBundle, "bundle", "", "Render the charts from a bundle created by 'kindplane bundle create'")
+	templateCmd.Flags().DurationVar(&templateTimeout, "timeout", 5*time.Minute, "Timeout for fetching and rendering the charts")
+
+	templateCmd.MarkFlagsMutuallyExclusive("output", "output-dir")
+}
+
+func runTemplate(cmd *cobra.Command, args []string) error {
+	if templatePhase != "" && !slices.Contains(templatePhases, templatePhase) {
+		fmt.Println(ui.Error("Unknown phase: %s. Use one of: %s", templatePhase, strings.Join(templatePhases, ", ")))
+		return fmt.Errorf("unknown phase: %s", templatePhase)
+	}
+
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	// Messages go to stderr while the manifests are printed to stdout
+	status := io.Writer(os.Stdout)
+	if templateOutput == "" && templateOutputDir == "" {
+		status = os.Stderr
+	}
+
+	var chartPaths map[string]string
+	if templateBundle != "" {
+		b, err := bundle.Open(templateBundle)
+		if err == nil {
+			err = b.Configure(cfg)
+		}
+		if err != nil {
+			fmt.Fprintln(status, ui.Error("%v", err))
+			return err
+		}
+		chartPaths = b.ChartPaths()
+	}
+
+	if slices.ContainsFunc(cfg.Charts, shadowsCrossplane) {
+		fmt.Fprintln(status, ui.Warning("Chart '%s' in kindplane.yaml shadows the Crossplane chart, which is not rendered", crossplane.CrossplaneChartName))
+	}
+
+	charts, err := templateCharts(cfg, args, templatePhase)
+	if err != nil {
+		fmt.Fprintln(status, ui.Error("%v", err))
+		return err
+	}
+	if len(charts) == 0 {
+		fmt.Fprintln(status, ui.Warning("No charts to render"))
+		return nil
+	}
+	for i, c := range charts {
+		if path := chartPaths[helm.LocalChartKey(c.chart.Repo, c.chart.Chart, c.chart.Version)]; c.chart.Path == "" && path != "" {
+			charts[i].chart.Path = path
+		}
+	}
+
+	kubeVersion := templateKubeVersion
+	if kubeVersion == "" {
+		kubeVersion = cfg.Cluster.KubernetesVersion
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), templateTimeout)
+	defer cancel()
+
+	var schema *helm.KubeSchema
+	if templateValidate {
+		if templateSchema == "" && kubeVersion == "" {
+			fmt.Fprintln(status, ui.Error("Set cluster.kubernetesVersion, --kube-version or --schema to validate"))
+			return fmt.Errorf("no kubernetes version to validate against")
+		}
+		if schema, err = helm.LoadKubeSchema(ctx, kubeVersion, templateSchema); err != nil {
+			fmt.Fprintln(status, ui.Error("Failed to load schema: %v", err))
+			return err
+		}
+	}
+
+	if templateOutputDir != "" {
+		if err := os.MkdirAll(templateOutputDir, 0755); err != nil {
+			fmt.Println(ui.Error("Failed to create directory: %v", err))
+			return err
+		}
+	}
+
+	vc := helm.NewValuesContext(cfg)
+	var all strings.Builder
+	invalid := 0
+	for _, c := range charts {
+		manifest, err := helm.RenderChart(ctx, c.chart, kubeVersion, vc)
+		if err != nil {
+			fmt.Fprintln(status, ui.Error("Failed to render chart %s: %v", c.chart.Name, err))
+			return err
+		}
+
+		switch {
+		case templateOutputDir != "":
+			path := filepath.Join(templateOutputDir, c.chart.Name+".yaml")
+			if err := os.WriteFile(path, []byte(manifest), 0644); err != nil {
+				fmt.Println(ui.Error("Failed to write file: %v", err))
+				return err
+			}
+			fmt.Println(ui.Success("Manifests of %s written to %s", c.chart.Name, path))
+		case len(charts) > 1:
+			fmt.Fprintf(&all, "# Chart: %s (%s)\n%s", c.chart.Name, c.phase, manifest)
+		default:
+			all.WriteString(manifest)
+		}
+
+		if schema != nil {
+			result, err := schema.Validate(manifest)
+			if err != nil {
+				fmt.Fprintln(status, ui.Error("Failed to validate chart %s: %v", c.chart.Name, err))
+				return err
+			}
+			printValidation(status, c.chart.Name, result)
+			invalid += len(result.Invalid)
+		}
+	}
+
+	switch {
+	case templateOutputDir != "":
+	case templateOutput != "":
+		if err := os.WriteFile(templateOutput, []byte(all.String()), 0644); err != nil {
+			fmt.Println(ui.Error("Failed to write file: %v", err))
+			return err
+		}
+		fmt.Println(ui.Success("Manifests of %s written to %s", chartNames(charts), templateOutput))
+	default:
+		fmt.Print(all.String())
+	}
+
+	if invalid > 0 {
+		return fmt.Errorf("%d resources failed validation", invalid)
+	}
+	return nil
+}
+
+// templateCharts returns the charts to render, in bootstrap order: the named
+// ones, or all of a phase, or all. The Crossplane chart is named crossplane
+// unless a configured chart has that name.
+func templateCharts(cfg *config.Config, names []string, phase string) ([]templateChart, error) {
+	var all []templateChart
+	if !slices.ContainsFunc(cfg.Charts, shadowsCrossplane) {
+		all = append(all, templateChart{phase: crossplanePhase, chart: crossplane.ChartConfig(cfg.Crossplane)})
+	}
+	for _, c := range cfg.Charts {
+		all = append(all, templateChart{phase: c.GetPhase(), chart: c})
+	}
+	slices.SortStableFunc(all, func(a, b templateChart) int {
+		return slices.Index(templatePhases, a.phase) - slices.Index(templatePhases, b.phase)
+	})
+
+	var charts []templateChart
+	for _, c := range all {
+		if len(names) > 0 && !slices.Contains(names, c.chart.Name) {
+			continue
+		}
+		if phase != "" && c.phase != phase {
+			continue
+		}
+		charts = append(charts, c)
+	}
+
+	for _, name := range names {
+		if !slices.ContainsFunc(all, func(c templateChart) bool { return c.chart.Name == name }) {
+			return nil, fmt.Errorf("chart '%s' not found in kindplane.yaml", name)
+		}
+	}
+	return charts, nil
+}
+
+// shadowsCrossplane reports whether a configured chart takes the name
+// templateCharts gives the Crossplane chart
+func shadowsCrossplane(c config.ChartConfig) bool {
+	return c.Name == crossplane.CrossplaneChartName
+}
+
+// chartNames joins the names of charts for messages
+func chartNames(charts []templateChart) string {
+	names := make([]string, len(charts))
+	for i, c := range charts {
+		names[i] = c.chart.Name
+	}
+	return strings.Join(names, ", ")
+}
+
+// printValidation reports the validation of a chart's manifests
+func printValidation(w io.Writer, name string, result helm.ValidationResult) {
+	if len(result.Invalid) == 0 {
+		fmt.Fprintln(w, ui.Success("%s: %d resources valid", name, result.Validated))
+	} else {
+		fmt.Fprintln(w, ui.Error("%s: %d resources invalid", name, len(result.Invalid)))
+		for _, r := range result.Invalid {
+			for _, e := range r.Errors {
+				fmt.Fprintf(w, "    %s: %s\n", r.Resource, e)
+			}
+		}
+	}
+	if len(result.Unknown) > 0 {
+		fmt.Fprintln(w, ui.Muted("    not checked (no schema): %s", strings.Join(result.Unknown, ", ")))
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/kanzi/kindplane/internal/config"
 	"github.com/kanzi/kindplane/internal/helm"
 	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
 	"github.com/kanzi/kindplane/internal/ui"
 )
 
@@ -51,11 +52,18 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), uninstallTimeout)
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -66,13 +74,13 @@ func runUninstall(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
 	}
 
-	helmInstaller := helm.NewInstaller(kubeClient)
+	helmInstaller := helm.NewInstaller(conn)
 
 	// Check if release exists
 	installed, err := helmInstaller.IsInstalled(ctx, releaseName, uninstallNamespace)
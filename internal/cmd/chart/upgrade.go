@@ -10,19 +10,28 @@ import (
 	"github.com/kanzi/kindplane/internal/config"
 	"github.com/kanzi/kindplane/internal/helm"
 	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
 	"github.com/kanzi/kindplane/internal/ui"
 )
 
 var (
 	upgradeRepo        string
 	upgradeChart       string
+	upgradePath        string
+	upgradeUserEnv     string
+	upgradePassEnv     string
+	upgradeCAFile      string
+	upgradeInsecure    bool
 	upgradeVersion     string
 	upgradeNamespace   string
 	upgradeValuesFiles []string
 	upgradeSetValues   []string
+	upgradeTemplate    bool
+	upgradeShowValues  bool
 	upgradeWait        bool
 	upgradeTimeout     time.Duration
 	upgradeReuseValues bool
+	upgradeAtomic      bool
 )
 
 var upgradeCmd = &cobra.Command{
@@ -39,6 +48,21 @@ or updated configuration values.`,
     --namespace ingress-nginx \
     --version 4.8.0
 
+  # Upgrade a chart from an OCI registry, logging in with a token
+  kindplane chart upgrade podinfo \
+    --repo oci://ghcr.io/stefanprodan/charts \
+    --chart podinfo \
+    --namespace podinfo \
+    --username-env GHCR_USER --password-env GHCR_TOKEN
+
+  # Upgrade, rolling back automatically if the release does not become ready
+  kindplane chart upgrade nginx-ingress \
+    --repo https://kubernetes.github.io/ingress-nginx \
+    --chart ingress-nginx \
+    --namespace ingress-nginx \
+    --version 4.9.0 \
+    --atomic
+
   # Upgrade with new values
   kindplane chart upgrade prometheus \
     --repo https://prometheus-community.github.io/helm-charts \
@@ -57,19 +81,26 @@ or updated configuration values.`,
 }
 
 func init() {
-	upgradeCmd.Flags().StringVar(&upgradeRepo, "repo", "", "Helm repository URL (required)")
-	upgradeCmd.Flags().StringVar(&upgradeChart, "chart", "", "Chart name in the repository (required)")
+	upgradeCmd.Flags().StringVar(&upgradeRepo, "repo", "", "Helm repository URL, or oci:// registry URL")
+	upgradeCmd.Flags().StringVar(&upgradeChart, "chart", "", "Chart name in the repository (required with --repo)")
+	upgradeCmd.Flags().StringVar(&upgradePath, "path", "", "Local chart directory or .tgz archive, instead of --repo and --chart")
+	upgradeCmd.Flags().StringVar(&upgradeUserEnv, "username-env", "", "Environment variable holding the repository username")
+	upgradeCmd.Flags().StringVar(&upgradePassEnv, "password-env", "", "Environment variable holding the repository password or token")
+	upgradeCmd.Flags().StringVar(&upgradeCAFile, "ca-file", "", "CA certificate to verify the repository with")
+	upgradeCmd.Flags().BoolVar(&upgradeInsecure, "insecure-skip-tls-verify", false, "Skip verification of the repository's certificate")
 	upgradeCmd.Flags().StringVar(&upgradeVersion, "version", "", "Chart version (optional, latest if not specified)")
 	upgradeCmd.Flags().StringVarP(&upgradeNamespace, "namespace", "n", "", "Release namespace (required)")
 	upgradeCmd.Flags().StringArrayVarP(&upgradeValuesFiles, "values", "f", nil, "Path to values file (can be specified multiple times)")
 	upgradeCmd.Flags().StringArrayVar(&upgradeSetValues, "set", nil, "Set values on command line (key=value, can be specified multiple times)")
+	upgradeCmd.Flags().BoolVar(&upgradeTemplate, "template-values", false, "Render values files and --set values as Go templates with cluster facts")
+	upgradeCmd.Flags().BoolVar(&upgradeShowValues, "show-values", false, "Display the rendered values before the upgrade")
 	upgradeCmd.Flags().BoolVar(&upgradeWait, "wait", true, "Wait for resources to be ready")
 	upgradeCmd.Flags().DurationVar(&upgradeTimeout, "timeout", 5*time.Minute, "Timeout for upgrade")
 	upgradeCmd.Flags().BoolVar(&upgradeReuseValues, "reuse-values", false, "Reuse the last release's values and merge any overrides")
+	upgradeCmd.Flags().BoolVar(&upgradeAtomic, "atomic", false, "Roll back to the previous revision if the upgrade fails (implies --wait)")
 
-	_ = upgradeCmd.MarkFlagRequired("repo")
-	_ = upgradeCmd.MarkFlagRequired("chart")
 	_ = upgradeCmd.MarkFlagRequired("namespace")
+	markSourceFlags(upgradeCmd)
 }
 
 func runUpgrade(cmd *cobra.Command, args []string) error {
@@ -82,11 +113,18 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), upgradeTimeout)
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -97,13 +135,13 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
 	}
 
-	helmInstaller := helm.NewInstaller(kubeClient)
+	helmInstaller := helm.NewInstaller(conn)
 
 	// Check if release exists
 	installed, err := helmInstaller.IsInstalled(ctx, releaseName, upgradeNamespace)
@@ -123,35 +161,43 @@ func runUpgrade(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
-	// Merge values: files first, then --set values (highest priority)
-	mergedValues, err := helm.MergeValues(upgradeValuesFiles, setValues)
+	// Merge values: files first, then --set values (highest priority),
+	// rendering templates with the cluster's facts for --template-values
+	var vc *helm.ValuesContext
+	if upgradeTemplate {
+		vc = valuesContext(ctx, cfg, conn)
+	}
+	mergedValues, err := helm.MergeValues(upgradeValuesFiles, setValues, vc)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to merge values: %v", err))
 		return err
 	}
-
-	// Build chart spec for upgrade
-	spec := helm.ChartSpec{
-		RepoURL:     upgradeRepo,
-		ChartName:   upgradeChart,
-		ReleaseName: releaseName,
-		Namespace:   upgradeNamespace,
-		Version:     upgradeVersion,
-		Values:      mergedValues,
-		Wait:        upgradeWait,
-		Timeout:     upgradeTimeout,
+	if upgradeShowValues {
+		printValues(releaseName, mergedValues)
 	}
 
-	// Add repo
-	repoName := helm.GenerateRepoName(upgradeRepo)
-	spec.RepoName = repoName
-	if err := helmInstaller.AddRepo(ctx, repoName, upgradeRepo); err != nil {
-		fmt.Println(ui.Error("Failed to add helm repo: %v", err))
+	// Resolve the chart's source, adding its repository if needed
+	spec, err := helmInstaller.ChartSource(ctx, config.ChartConfig{
+		Repo:    upgradeRepo,
+		Chart:   upgradeChart,
+		Path:    upgradePath,
+		Auth:    chartAuth(upgradeUserEnv, upgradePassEnv),
+		TLS:     chartTLS(upgradeCAFile, upgradeInsecure),
+		Version: upgradeVersion,
+	})
+	if err != nil {
+		fmt.Println(ui.Error("Failed to resolve chart: %v", err))
 		return err
 	}
+	spec.ReleaseName = releaseName
+	spec.Namespace = upgradeNamespace
+	spec.Values = mergedValues
+	spec.Wait = upgradeWait
+	spec.Timeout = upgradeTimeout
+	spec.Atomic = upgradeAtomic
 
 	// Upgrade chart
-	fmt.Println(ui.Info("Upgrading release %s (%s/%s)...", releaseName, upgradeRepo, upgradeChart))
+	fmt.Println(ui.Info("Upgrading release %s (%s)...", releaseName, chartLabel(upgradeRepo, upgradeChart, upgradePath)))
 	if err := helmInstaller.Upgrade(ctx, spec); err != nil {
 		fmt.Println(ui.Error("Failed to upgrade release: %v", err))
 		return err
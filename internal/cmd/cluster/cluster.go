@@ -11,9 +11,11 @@ var ClusterCmd = &cobra.Command{
 	Long: `Manage Kind clusters created by kindplane.
 
 Available subcommands:
-  list - List all Kind clusters`,
+  list     - List all Kind clusters
+  snapshot - Save and restore cluster snapshots`,
 }
 
 func init() {
 	ClusterCmd.AddCommand(listCmd)
+	ClusterCmd.AddCommand(snapshotCmd)
 }
@@ -8,7 +8,6 @@ import (
 
 	"github.com/spf13/cobra"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
-	"sigs.k8s.io/kind/pkg/cluster"
 
 	"github.com/kanzi/kindplane/internal/kind"
 	"github.com/kanzi/kindplane/internal/ui"
@@ -33,26 +32,27 @@ Use --format to change the output format.`,
   # List all Kind clusters (including non-kindplane)
   kindplane cluster list --all
 
-  # List in JSON format
-  kindplane cluster list --format json`,
+  # List in JSON format, including the host ports of each cluster
+  kindplane cluster list -o json`,
 	RunE: runList,
 }
 
 func init() {
 	listCmd.Flags().BoolVarP(&listAll, "all", "a", false, "Show all Kind clusters (not just kindplane-managed)")
-	listCmd.Flags().StringVar(&listFormat, "format", "table", "Output format (table, json)")
+	listCmd.Flags().StringVarP(&listFormat, "format", "o", "table", "Output format (table, json)")
 	listCmd.Flags().DurationVar(&listTimeout, "timeout", 30*time.Second, "Timeout for listing clusters")
 }
 
 // ClusterInfo contains information about a Kind cluster
 type ClusterInfo struct {
-	Name              string `json:"name"`
-	Status            string `json:"status"`
-	KubernetesVersion string `json:"kubernetesVersion,omitempty"`
-	Nodes             int    `json:"nodes"`
-	ControlPlanes     int    `json:"controlPlanes"`
-	Workers           int    `json:"workers"`
-	Context           string `json:"context"`
+	Name              string        `json:"name"`
+	Status            string        `json:"status"`
+	KubernetesVersion string        `json:"kubernetesVersion,omitempty"`
+	Nodes             int           `json:"nodes"`
+	ControlPlanes     int           `json:"controlPlanes"`
+	Workers           int           `json:"workers"`
+	Context           string        `json:"context"`
+	Ports             *kind.PortMap `json:"ports,omitempty"`
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -60,7 +60,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	defer cancel()
 
 	// Get Kind provider
-	provider := cluster.NewProvider()
+	provider := kind.NewProvider()
 
 	// List clusters
 	clusters, err := provider.List()
@@ -138,6 +138,11 @@ func runList(cmd *cobra.Command, args []string) error {
 					if !listAll && !isKindplaneManaged {
 						continue
 					}
+
+					// Host ports recorded by kindplane up
+					if ports, err := kind.LoadPortMap(ctx, kubeClient); err == nil {
+						info.Ports = ports
+					}
 				} else if !listAll {
 					// If we can't list nodes and --all is false, skip this cluster
 					continue
@@ -0,0 +1,356 @@
+package cluster
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
+	"github.com/kanzi/kindplane/internal/snapshot"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	snapshotTimeout    time.Duration
+	snapshotForce      bool
+	snapshotListFormat string
+	snapshotListAll    bool
+)
+
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save and restore cluster snapshots",
+	Long: `Save and restore snapshots of a bootstrapped cluster.
+
+A snapshot captures every node container: the root filesystem is committed
+to a local image and the /var volume (etcd data and the containerd image
+store) is archived under the kindplane state directory. Restoring a
+snapshot is much faster than a full bootstrap.
+
+Each snapshot records a hash of kindplane.yaml, so 'kindplane up' can offer
+to restore a matching snapshot instead of bootstrapping from scratch.
+
+Available subcommands:
+  save    - Save a snapshot of the cluster
+  restore - Restore the cluster from a snapshot
+  list    - List snapshots
+  rm      - Remove a snapshot`,
+}
+
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save <name>",
+	Short: "Save a snapshot of the cluster",
+	Long: `Save a snapshot of the cluster defined in kindplane.yaml.
+
+The cluster nodes are stopped while the snapshot is taken and started again
+afterwards.`,
+	Example: `  # Snapshot a freshly bootstrapped cluster
+  kindplane cluster snapshot save baseline`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotSave,
+}
+
+var snapshotRestoreCmd = &cobra.Command{
+	Use:   "restore <name>",
+	Short: "Restore the cluster from a snapshot",
+	Long: `Restore the cluster defined in kindplane.yaml from a snapshot.
+
+If the cluster exists it must be deleted first, or pass --force to delete it.`,
+	Example: `  # Restore a snapshot
+  kindplane cluster snapshot restore baseline
+
+  # Replace the running cluster with a snapshot
+  kindplane cluster snapshot restore baseline --force`,
+	Args: cobra.ExactArgs(1),
+	RunE: runSnapshotRestore,
+}
+
+var snapshotListCmd = &cobra.Command{
+	Use:     "list",
+	Aliases: []string{"ls"},
+	Short:   "List snapshots",
+	Long: `List snapshots of the cluster defined in kindplane.yaml.
+
+Snapshots whose recorded config hash matches the current kindplane.yaml are
+marked as matching. Use --all to list snapshots of every cluster.`,
+	Example: `  # List snapshots of the configured cluster
+  kindplane cluster snapshot list
+
+  # List snapshots of all clusters in JSON format
+  kindplane cluster snapshot list --all --format json`,
+	RunE: runSnapshotList,
+}
+
+var snapshotRmCmd = &cobra.Command{
+	Use:     "rm <name>",
+	Aliases: []string{"remove"},
+	Short:   "Remove a snapshot",
+	Long:    `Remove a snapshot's images and archives.`,
+	Example: `  kindplane cluster snapshot rm baseline`,
+	Args:    cobra.ExactArgs(1),
+	RunE:    runSnapshotRm,
+}
+
+func init() {
+	snapshotCmd.PersistentFlags().DurationVar(&snapshotTimeout, "timeout", 15*time.Minute, "timeout for the operation")
+	snapshotRestoreCmd.Flags().BoolVarP(&snapshotForce, "force", "f", false, "delete the existing cluster before restoring")
+	snapshotListCmd.Flags().StringVar(&snapshotListFormat, "format", "table", "Output format (table, json)")
+	snapshotListCmd.Flags().BoolVarP(&snapshotListAll, "all", "a", false, "List snapshots of all clusters")
+
+	snapshotCmd.AddCommand(snapshotSaveCmd)
+	snapshotCmd.AddCommand(snapshotRestoreCmd)
+	snapshotCmd.AddCommand(snapshotListCmd)
+	snapshotCmd.AddCommand(snapshotRmCmd)
+}
+
+// SnapshotInfo is the JSON representation of a snapshot in list output
+type SnapshotInfo struct {
+	Name              string    `json:"name"`
+	Cluster           string    `json:"cluster"`
+	KubernetesVersion string    `json:"kubernetesVersion,omitempty"`
+	Nodes             int       `json:"nodes"`
+	CreatedAt         time.Time `json:"createdAt"`
+	ConfigHash        string    `json:"configHash"`
+	MatchesConfig     bool      `json:"matchesConfig"`
+}
+
+func runSnapshotSave(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("Failed to load config: %v", err))
+		return err
+	}
+
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
+	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check cluster: %v", err))
+		return err
+	}
+	if !exists {
+		fmt.Println(ui.Error("Cluster '%s' does not exist", cfg.Cluster.Name))
+		return fmt.Errorf("cluster not found")
+	}
+
+	store, err := snapshot.NewStore()
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	var meta *snapshot.Metadata
+	err = ui.RunSpinnerWithContext(ctx, fmt.Sprintf("Saving snapshot %s", args[0]), func(ctx context.Context) error {
+		var saveErr error
+		meta, saveErr = snapshot.Save(ctx, store, cfg, args[0], nil)
+		return saveErr
+	})
+	if err != nil {
+		fmt.Println(ui.Error("Failed to save snapshot: %v", err))
+		return err
+	}
+
+	fmt.Println(ui.Success("Snapshot '%s' saved (%d node(s))", meta.Name, len(meta.Nodes)))
+	fmt.Println(ui.Muted("  Location: %s", store.Dir(meta.Cluster, meta.Name)))
+	return nil
+}
+
+func runSnapshotRestore(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("Failed to load config: %v", err))
+		return err
+	}
+
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
+	store, err := snapshot.NewStore()
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	meta, err := store.Get(cfg.Cluster.Name, args[0])
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	if hash, err := snapshot.ConfigHash(cfg); err == nil && hash != meta.ConfigHash {
+		fmt.Println(ui.Warning("kindplane.yaml has changed since snapshot '%s' was taken", meta.Name))
+	}
+
+	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check cluster: %v", err))
+		return err
+	}
+	if exists {
+		if !snapshotForce {
+			fmt.Println(ui.Error("Cluster '%s' already exists", cfg.Cluster.Name))
+			fmt.Println(ui.Muted("  Delete it with 'kindplane down' or pass --force"))
+			return fmt.Errorf("cluster already exists")
+		}
+		fmt.Println(ui.Info("Deleting cluster '%s'...", cfg.Cluster.Name))
+		if err := kind.DeleteCluster(ctx, cfg.Cluster.Name, cfg.GetKubeconfigPath()); err != nil {
+			fmt.Println(ui.Error("Failed to delete cluster: %v", err))
+			return err
+		}
+	}
+
+	err = ui.RunSpinnerWithContext(ctx, fmt.Sprintf("Restoring snapshot %s", meta.Name), func(ctx context.Context) error {
+		if err := snapshot.Restore(ctx, store, meta, nil); err != nil {
+			return err
+		}
+		return kind.ExportKubeConfig(cfg.Cluster.Name, cfg.GetKubeconfigPath(), cfg.Cluster.Kubeconfig.Internal)
+	})
+	if err != nil {
+		fmt.Println(ui.Error("Failed to restore snapshot: %v", err))
+		return err
+	}
+
+	fmt.Println(ui.Success("Cluster '%s' restored from snapshot '%s'", meta.Cluster, meta.Name))
+	return nil
+}
+
+func runSnapshotList(cmd *cobra.Command, args []string) error {
+	store, err := snapshot.NewStore()
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	// The config is optional here: without it every cluster is listed
+	var clusterName, hash string
+	if cfg, err := config.Load(""); err == nil {
+		hash, _ = snapshot.ConfigHash(cfg)
+		if !snapshotListAll {
+			clusterName = cfg.Cluster.Name
+		}
+	}
+
+	snapshots, err := store.List(clusterName)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to list snapshots: %v", err))
+		return err
+	}
+
+	infos := make([]SnapshotInfo, 0, len(snapshots))
+	for _, s := range snapshots {
+		infos = append(infos, SnapshotInfo{
+			Name:              s.Name,
+			Cluster:           s.Cluster,
+			KubernetesVersion: s.KubernetesVersion,
+			Nodes:             len(s.Nodes),
+			CreatedAt:         s.CreatedAt,
+			ConfigHash:        s.ConfigHash,
+			MatchesConfig:     hash != "" && s.ConfigHash == hash,
+		})
+	}
+
+	switch snapshotListFormat {
+	case "json":
+		output, err := json.MarshalIndent(infos, "", "  ")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to marshal output: %v", err))
+			return err
+		}
+		fmt.Println(string(output))
+
+	case "table":
+		if len(infos) == 0 {
+			fmt.Println(ui.Warning("No snapshots found"))
+			fmt.Println()
+			fmt.Println(ui.InfoBox("Hint", "Run 'kindplane cluster snapshot save <name>' to create one."))
+			return nil
+		}
+		printSnapshotTable(infos)
+
+	default:
+		fmt.Println(ui.Error("Unknown format: %s. Use 'table' or 'json'.", snapshotListFormat))
+		return fmt.Errorf("unknown format: %s", snapshotListFormat)
+	}
+
+	return nil
+}
+
+func printSnapshotTable(infos []SnapshotInfo) {
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconCluster + " Cluster Snapshots"))
+	fmt.Println(ui.Divider())
+
+	headers := []string{"NAME", "CLUSTER", "NODES", "CREATED", "CONFIG"}
+	var rows [][]string
+	for _, s := range infos {
+		match := ui.Muted("changed")
+		if s.MatchesConfig {
+			match = ui.IconSuccess + " matches"
+		}
+		rows = append(rows, []string{
+			s.Name,
+			s.Cluster,
+			fmt.Sprintf("%d", s.Nodes),
+			s.CreatedAt.Format("2006-01-02 15:04"),
+			match,
+		})
+	}
+
+	fmt.Println(ui.RenderTable(headers, rows))
+}
+
+func runSnapshotRm(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), snapshotTimeout)
+	defer cancel()
+
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("Failed to load config: %v", err))
+		return err
+	}
+
+	store, err := snapshot.NewStore()
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	meta, err := store.Get(cfg.Cluster.Name, args[0])
+	if err != nil {
+		if errors.Is(err, snapshot.ErrNotFound) {
+			fmt.Println(ui.Warning("Snapshot '%s' not found", args[0]))
+		} else {
+			fmt.Println(ui.Error("%v", err))
+		}
+		return err
+	}
+
+	if err := snapshot.Remove(ctx, store, meta); err != nil {
+		fmt.Println(ui.Error("Failed to remove snapshot: %v", err))
+		return err
+	}
+
+	fmt.Println(ui.Success("Snapshot '%s' removed", meta.Name))
+	return nil
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/kanzi/kindplane/internal/config"
 	"github.com/kanzi/kindplane/internal/crossplane"
 	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
 	"github.com/kanzi/kindplane/internal/ui"
 )
 
@@ -47,6 +48,13 @@ func runReload(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("loading config failed: %w", err)
 	}
 
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	// Check if there are any composition sources
 	if len(cfg.Compositions.Sources) == 0 {
 		fmt.Println()
@@ -59,7 +67,7 @@ func runReload(cmd *cobra.Command, args []string) error {
 	}
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return fmt.Errorf("checking cluster existence failed: %w", err)
@@ -70,7 +78,7 @@ func runReload(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return fmt.Errorf("getting kube client failed: %w", err)
@@ -97,7 +105,7 @@ func runReload(cmd *cobra.Command, args []string) error {
 
 	// Work function that reloads compositions
 	workFn := func(ctx context.Context, ctrl *ui.DashboardController) error {
-		installer := crossplane.NewInstaller(kubeClient)
+		installer := crossplane.NewInstaller(conn)
 
 		for _, source := range cfg.Compositions.Sources {
 			phaseName := formatSourceName(source)
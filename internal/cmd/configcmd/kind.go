@@ -12,7 +12,8 @@ import (
 )
 
 var (
-	kindOutput string
+	kindOutput          string
+	kindShowCredentials bool
 )
 
 var kindCmd = &cobra.Command{
@@ -27,7 +28,12 @@ This allows you to use the configuration with the kind CLI directly:
 
 The output includes all settings from kindplane.yaml translated to Kind's
 configuration format, including node images, port mappings, mounts, and
-containerd patches for registries.`,
+containerd patches for registries.
+
+Credentials from cluster.registryAuth are replaced with placeholders
+unless --show-credentials is set. Registry mirrors are configured on the
+nodes by 'kindplane up' after the cluster is created, so a cluster created
+with the kind CLI only gets the hosts directory patch.`,
 	Example: `  # Output to stdout
   kindplane config kind
 
@@ -35,12 +41,16 @@ containerd patches for registries.`,
   kindplane config kind -o kind-config.yaml
 
   # Use directly with kind CLI (stdin)
-  kindplane config kind | kind create cluster --config -`,
+  kindplane config kind | kind create cluster --config -
+
+  # Include registry credentials
+  kindplane config kind --show-credentials -o kind-config.yaml`,
 	RunE: runKindConfig,
 }
 
 func init() {
 	kindCmd.Flags().StringVarP(&kindOutput, "output", "o", "", "Write output to file instead of stdout")
+	kindCmd.Flags().BoolVar(&kindShowCredentials, "show-credentials", false, "Include registry credentials from cluster.registryAuth")
 }
 
 func runKindConfig(cmd *cobra.Command, args []string) error {
@@ -52,7 +62,11 @@ func runKindConfig(cmd *cobra.Command, args []string) error {
 	}
 
 	// Build Kind config
-	kindConfig, err := kind.BuildKindConfig(cfg)
+	build := kind.BuildRedactedKindConfig
+	if kindShowCredentials {
+		build = kind.BuildKindConfig
+	}
+	kindConfig, err := build(cfg)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to build Kind configuration: %v", err))
 		return err
@@ -60,7 +74,7 @@ func runKindConfig(cmd *cobra.Command, args []string) error {
 
 	// Output to file or stdout
 	if kindOutput != "" {
-		if err := os.WriteFile(kindOutput, []byte(kindConfig), 0644); err != nil {
+		if err := os.WriteFile(kindOutput, []byte(kindConfig), 0600); err != nil {
 			fmt.Println(ui.Error("Failed to write file: %v", err))
 			return err
 		}
@@ -11,6 +11,7 @@ import (
 	"github.com/kanzi/kindplane/internal/config"
 	"github.com/kanzi/kindplane/internal/credentials"
 	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
 	"github.com/kanzi/kindplane/internal/ui"
 )
 
@@ -53,11 +54,18 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), configureTimeout)
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -68,13 +76,13 @@ func runConfigure(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
 	}
 
-	credManager := credentials.NewManager(kubeClient)
+	credManager := credentials.NewManager(conn)
 
 	// Determine which providers to configure
 	var providers []string
@@ -50,7 +50,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -61,13 +61,13 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
 	}
 
-	credManager := credentials.NewManager(kubeClient)
+	credManager := credentials.NewManager(conn)
 
 	// List credentials
 	creds, err := credManager.ListCredentials(ctx, listProvider)
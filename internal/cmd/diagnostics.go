@@ -7,7 +7,6 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/dynamic"
 
 	"github.com/kanzi/kindplane/internal/diagnostics"
 	"github.com/kanzi/kindplane/internal/kind"
@@ -61,7 +60,7 @@ func runDiagnostics(cmd *cobra.Command, args []string) error {
 	clusterName := cfg.Cluster.Name
 
 	// Check if cluster exists
-	exists, err := kind.ClusterExists(clusterName)
+	exists, err := kind.ClusterAvailable(clusterName)
 	if err != nil {
 		printError("Failed to check cluster status: %v", err)
 		return err
@@ -72,28 +71,22 @@ func runDiagnostics(cmd *cobra.Command, args []string) error {
 		return fmt.Errorf("cluster not found")
 	}
 
-	// Get kubernetes client
-	kubeClient, err := kind.GetKubeClient(clusterName)
+	// Get kubernetes clients
+	conn, err := kind.Connect(clusterName)
 	if err != nil {
 		printError("Failed to connect to cluster: %v", err)
 		return err
 	}
 
 	// Get dynamic client for provider diagnostics
-	restConfig, err := kind.GetRESTConfig(clusterName)
+	dynamicClient, err := conn.Dynamic()
 	if err != nil {
-		printError("Failed to get REST config: %v", err)
-		return err
-	}
-
-	dynamicClient, err := dynamic.NewForConfig(restConfig)
-	if err != nil {
-		printError("Failed to create dynamic client: %v", err)
+		printError("%v", err)
 		return err
 	}
 
 	// Create diagnostics collector
-	collector := diagnostics.NewCollector(kubeClient, dynamicClient)
+	collector := diagnostics.NewCollector(conn.Client, dynamicClient, conn.RESTClientGetter())
 
 	// Determine components to diagnose
 	components := []diagnostics.Component{}
@@ -25,10 +25,12 @@ var doctorCmd = &cobra.Command{
 requirements for running kindplane.
 
 This command checks:
-  - Docker daemon is running
+  - Container runtime (Docker or Podman) is running
   - Required binaries are available (kind, kubectl)
   - Sufficient disk space
   - Optional tools (helm)
+  - Registry credentials and mirror endpoints (cluster.registryAuth,
+    cluster.registryMirrors)
   - Cluster connectivity (if a cluster exists)
   - Crossplane installation status`,
 	Example: `  # Run all pre-flight checks
@@ -68,6 +70,11 @@ func runDoctor(cmd *cobra.Command, args []string) error {
 
 	// Run all checks
 	results := doctor.RunAllChecks(ctx, kubeClient)
+	if cfg != nil {
+		for _, check := range doctor.RegistryChecks(cfg) {
+			results = append(results, check(ctx))
+		}
+	}
 
 	// Print header
 	if !doctorQuiet {
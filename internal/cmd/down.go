@@ -42,6 +42,12 @@ func runDown(cmd *cobra.Command, args []string) error {
 	// Require config
 	requireConfig()
 
+	clusterLock, err := lockCluster(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), downTimeout)
 	defer cancel()
 
@@ -78,7 +84,7 @@ func runDown(cmd *cobra.Command, args []string) error {
 
 	printInfo("Deleting cluster '%s'...", clusterName)
 
-	if err := kind.DeleteCluster(ctx, clusterName); err != nil {
+	if err := kind.DeleteCluster(ctx, clusterName, cfg.GetKubeconfigPath()); err != nil {
 		printError("Failed to delete cluster: %v", err)
 		return err
 	}
@@ -98,6 +104,9 @@ func runDown(cmd *cobra.Command, args []string) error {
 	} else if cfg.Cluster.Registry.Enabled && cfg.Cluster.Registry.Persistent {
 		printInfo("Local registry preserved (persistent mode)")
 	}
+	if cfg.Cluster.Registry.PullThrough.Enabled {
+		printInfo("Pull-through caches preserved (shared by all kindplane clusters)")
+	}
 
 	return nil
 }
@@ -7,8 +7,6 @@ import (
 	"time"
 
 	"github.com/spf13/cobra"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 
 	"github.com/kanzi/kindplane/internal/dump"
 	"github.com/kanzi/kindplane/internal/kind"
@@ -107,7 +105,7 @@ func runDump(cmd *cobra.Command, args []string) error {
 	defer cancel()
 
 	// Check if cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		printError("Failed to check cluster: %v", err)
 		return err
@@ -150,7 +148,7 @@ func runDump(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get REST config for the cluster
-	restConfig, err := getRESTConfig(cfg.Cluster.Name)
+	restConfig, err := kind.GetRESTConfig(cfg.Cluster.Name)
 	if err != nil {
 		printError("Failed to get kubernetes config: %v", err)
 		return err
@@ -173,7 +171,7 @@ func runDump(cmd *cobra.Command, args []string) error {
 
 	// Generate Kind config if requested
 	if !dumpNoKindConfig {
-		kindConfig, err := kind.BuildKindConfig(cfg)
+		kindConfig, err := kind.BuildRedactedKindConfig(cfg)
 		if err != nil {
 			printWarn("Warning: Failed to generate Kind config: %v", err)
 		} else {
@@ -282,19 +280,3 @@ func runDumpDryRun(opts dump.DumpOptions) error {
 
 	return nil
 }
-
-// getRESTConfig returns a REST config for the specified Kind cluster
-func getRESTConfig(clusterName string) (*rest.Config, error) {
-	kubeconfigPath := kind.GetKubeConfigPath(clusterName)
-	contextName := kind.GetContextName(clusterName)
-
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
-		&clientcmd.ConfigOverrides{CurrentContext: contextName},
-	).ClientConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
-	}
-
-	return config, nil
-}
@@ -0,0 +1,21 @@
+package images
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// ImagesCmd is the parent command for image subcommands
+var ImagesCmd = &cobra.Command{
+	Use:   "images",
+	Short: "Inspect the images kindplane preloads",
+	Long: `Inspect the container images kindplane preloads into the cluster.
+
+Available subcommands:
+  list  - List every image to preload and where it comes from
+  learn - Add images the cluster pulled to the image cache list`,
+}
+
+func init() {
+	ImagesCmd.AddCommand(listCmd)
+	ImagesCmd.AddCommand(learnCmd)
+}
@@ -0,0 +1,116 @@
+package images
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	learnDryRun  bool
+	learnTimeout time.Duration
+)
+
+var learnCmd = &cobra.Command{
+	Use:   "learn",
+	Short: "Add images the cluster pulled to the image cache list",
+	Long: `Find the images the cluster's nodes pulled that kindplane did not preload
+and add them to crossplane.imageCache.additionalImages in kindplane.yaml.
+
+Run it after a successful 'kindplane up' so the next bootstrap preloads
+everything it needs. Images bundled with Kind nodes are ignored, and
+comments in kindplane.yaml are kept. 'kindplane up --learn-images' does
+the same once the bootstrap completes.`,
+	Example: `  # Record images pulled during the last bootstrap
+  kindplane images learn
+
+  # Show what would be added without editing kindplane.yaml
+  kindplane images learn --dry-run`,
+	RunE: runLearn,
+}
+
+func init() {
+	learnCmd.Flags().BoolVar(&learnDryRun, "dry-run", false, "Show the images without editing the config file")
+	learnCmd.Flags().DurationVar(&learnTimeout, "timeout", 2*time.Minute, "Timeout for inspecting nodes and resolving images")
+}
+
+func runLearn(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check cluster: %v", err))
+		return err
+	}
+	if !exists {
+		fmt.Println(ui.Error("Cluster '%s' not found. Run 'kindplane up' first.", cfg.Cluster.Name))
+		return fmt.Errorf("cluster not found")
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), learnTimeout)
+	defer cancel()
+
+	return Learn(ctx, cfg, config.DefaultConfigFile, learnDryRun)
+}
+
+// Learn adds the images the cluster's nodes hold but cfg does not preload to
+// crossplane.imageCache.additionalImages in the config file at path, and
+// prints them. With dryRun the file is left unchanged.
+func Learn(ctx context.Context, cfg *config.Config, path string, dryRun bool) error {
+	images, err := kind.LearnImages(ctx, cfg.Cluster.Name, cfg, func(msg string) {
+		fmt.Fprintln(os.Stderr, ui.Warning("%s", strings.TrimPrefix(msg, "Warning: ")))
+	})
+	if err != nil {
+		fmt.Println(ui.Error("Failed to inspect node images: %v", err))
+		return err
+	}
+
+	if len(images) == 0 {
+		fmt.Println(ui.Success("Every image in the cluster is already preloaded"))
+		return nil
+	}
+
+	if !dryRun {
+		added, err := config.AddAdditionalImages(path, images)
+		if err != nil {
+			fmt.Println(ui.Error("Failed to update %s: %v", path, err))
+			return err
+		}
+		if len(added) == 0 {
+			fmt.Println(ui.Success("Every image in the cluster is already listed"))
+			return nil
+		}
+		images = added
+	}
+
+	fmt.Println()
+	if dryRun {
+		fmt.Println(ui.Title(ui.IconPackage + " Images to Learn"))
+	} else {
+		fmt.Println(ui.Title(ui.IconPackage + " Learned Images"))
+	}
+	fmt.Println(ui.Divider())
+	fmt.Println(ui.List(images...))
+
+	if dryRun {
+		fmt.Println(ui.Muted("%d images would be added to crossplane.imageCache.additionalImages", len(images)))
+	} else {
+		fmt.Println(ui.Success("Added %d images to crossplane.imageCache.additionalImages in %s", len(images), path))
+	}
+	if !cfg.Crossplane.ImageCache.IsEnabled() {
+		fmt.Println(ui.Warning("Image preloading is disabled (crossplane.imageCache.enabled: false); these images will not be preloaded"))
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package images
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	listFormat  string
+	listTimeout time.Duration
+)
+
+var listCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List the images to preload and their sources",
+	Long: `List every image 'kindplane up' preloads, resolved from the configuration.
+
+Images come from:
+  - crossplane        Crossplane core images for crossplane.version
+  - provider <name>   Provider packages, read from the package metadata
+  - chart <name>      Charts in the charts list, rendered with their values
+  - additionalImages  crossplane.imageCache.additionalImages
+
+An image needed by several entries is listed once with all of its sources.
+No cluster is required.`,
+	Example: `  # List images to preload
+  kindplane images list
+
+  # List images as JSON
+  kindplane images list -o json`,
+	RunE: runList,
+}
+
+func init() {
+	listCmd.Flags().StringVarP(&listFormat, "format", "o", "table", "Output format (table, json)")
+	listCmd.Flags().DurationVar(&listTimeout, "timeout", 2*time.Minute, "Timeout for resolving images")
+}
+
+func runList(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	if listFormat != "table" && listFormat != "json" {
+		fmt.Println(ui.Error("Unknown format: %s. Use 'table' or 'json'.", listFormat))
+		return fmt.Errorf("unknown format: %s", listFormat)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), listTimeout)
+	defer cancel()
+
+	// Keep stdout clean for JSON output
+	sources := kind.CollectImages(ctx, cfg, func(msg string) {
+		fmt.Fprintln(os.Stderr, ui.Warning("%s", strings.TrimPrefix(msg, "Warning: ")))
+	})
+
+	if listFormat == "json" {
+		output, err := json.MarshalIndent(sources, "", "  ")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to marshal output: %v", err))
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(sources) == 0 {
+		fmt.Println(ui.Warning("No images to preload"))
+		return nil
+	}
+	if !cfg.Crossplane.ImageCache.IsEnabled() {
+		fmt.Println(ui.Warning("Image preloading is disabled (crossplane.imageCache.enabled: false); these images will not be preloaded"))
+	}
+
+	headers := []string{"IMAGE", "SOURCE"}
+	var rows [][]string
+	for _, s := range sources {
+		rows = append(rows, []string{s.Image, strings.Join(s.Sources, ", ")})
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconPackage + " Images to Preload"))
+	fmt.Println(ui.Divider())
+	fmt.Println(ui.RenderTable(headers, rows))
+	fmt.Println(ui.Muted("%d images", len(sources)))
+
+	return nil
+}
@@ -0,0 +1,90 @@
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	exportOutput   string
+	exportInternal bool
+)
+
+var exportCmd = &cobra.Command{
+	Use:   "export",
+	Short: "Write the cluster's kubeconfig",
+	Long: `Write the kubeconfig for the Kind cluster.
+
+The cluster's context is merged into the target file and made the current
+context. Use --output - to print a standalone kubeconfig instead.
+
+With --internal the server address is the control plane's address on the
+Docker network, for tools running in sibling containers (for example a CI
+job container attached to the "kind" network).`,
+	Example: `  # Merge the context into the configured kubeconfig
+  kindplane kubeconfig export
+
+  # Write an isolated kubeconfig for CI
+  kindplane kubeconfig export --output ./kubeconfig
+
+  # Print a kubeconfig usable from containers on the kind network
+  kindplane kubeconfig export --internal --output -`,
+	RunE: runExport,
+}
+
+func init() {
+	exportCmd.Flags().StringVarP(&exportOutput, "output", "o", "", "kubeconfig file to write, or - for stdout (default: configured path)")
+	exportCmd.Flags().BoolVar(&exportInternal, "internal", false, "use the node's Docker network address")
+}
+
+func runExport(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("Failed to load config: %v", err))
+		return err
+	}
+
+	clusterName := cfg.Cluster.Name
+	exists, err := kind.ClusterExists(clusterName)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check cluster: %v", err))
+		return err
+	}
+	if !exists {
+		fmt.Println(ui.Error("Cluster '%s' does not exist", clusterName))
+		return fmt.Errorf("cluster not found")
+	}
+
+	internal := exportInternal || (!cmd.Flags().Changed("internal") && cfg.Cluster.Kubeconfig.Internal)
+
+	if exportOutput == "-" {
+		kubeconfig, err := kind.KubeConfig(clusterName, internal)
+		if err != nil {
+			fmt.Println(ui.Error("%v", err))
+			return err
+		}
+		fmt.Print(kubeconfig)
+		return nil
+	}
+
+	path := exportOutput
+	if path == "" {
+		path = cfg.GetKubeconfigPath()
+	}
+
+	if err := kind.ExportKubeConfig(clusterName, path, internal); err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	fmt.Println(ui.Success("Context '%s' written to %s", cfg.GetKubeContext(), path))
+	if internal {
+		fmt.Println(ui.Muted("  Server address is only reachable from the kind Docker network"))
+	}
+	return nil
+}
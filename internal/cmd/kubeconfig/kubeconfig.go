@@ -0,0 +1,26 @@
+package kubeconfig
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// KubeconfigCmd is the parent command for kubeconfig subcommands
+var KubeconfigCmd = &cobra.Command{
+	Use:   "kubeconfig",
+	Short: "Manage the cluster's kubeconfig",
+	Long: `Manage the kubeconfig entry for the Kind cluster.
+
+The kubeconfig is written to cluster.kubeconfig.path when set, otherwise to
+the first file in $KUBECONFIG, otherwise to ~/.kube/config.
+
+Available subcommands:
+  export - Write the cluster's kubeconfig
+  path   - Print the kubeconfig path kindplane uses
+  remove - Remove the cluster's context from a kubeconfig`,
+}
+
+func init() {
+	KubeconfigCmd.AddCommand(exportCmd)
+	KubeconfigCmd.AddCommand(pathCmd)
+	KubeconfigCmd.AddCommand(removeCmd)
+}
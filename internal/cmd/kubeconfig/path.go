@@ -0,0 +1,31 @@
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var pathCmd = &cobra.Command{
+	Use:   "path",
+	Short: "Print the kubeconfig path kindplane uses",
+	Long: `Print the kubeconfig file the cluster's context is written to.
+
+The output is a bare path, suitable for scripts.`,
+	Example: `  export KUBECONFIG=$(kindplane kubeconfig path)`,
+	RunE:    runPath,
+}
+
+func runPath(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("Failed to load config: %v", err))
+		return err
+	}
+
+	fmt.Println(cfg.GetKubeconfigPath())
+	return nil
+}
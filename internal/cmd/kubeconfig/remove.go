@@ -0,0 +1,59 @@
+package kubeconfig
+
+import (
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var removePath string
+
+var removeCmd = &cobra.Command{
+	Use:   "remove",
+	Short: "Remove the cluster's context from a kubeconfig",
+	Long: `Remove the cluster's context, cluster and user entries from a kubeconfig file.
+
+'kindplane down' does this automatically for the configured kubeconfig.
+Use this command to clean up files written with 'kindplane kubeconfig export --output'.`,
+	Example: `  # Remove from the configured kubeconfig
+  kindplane kubeconfig remove
+
+  # Remove from an exported file
+  kindplane kubeconfig remove --path ./kubeconfig`,
+	RunE: runRemove,
+}
+
+func init() {
+	removeCmd.Flags().StringVar(&removePath, "path", "", "kubeconfig file to edit (default: configured path)")
+}
+
+func runRemove(cmd *cobra.Command, args []string) error {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("Failed to load config: %v", err))
+		return err
+	}
+
+	path := removePath
+	if path == "" {
+		path = cfg.GetKubeconfigPath()
+	}
+
+	removed, err := kind.RemoveKubeConfig(cfg.Cluster.Name, path)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	if !removed {
+		fmt.Println(ui.Warning("Context '%s' not found in %s", cfg.GetKubeContext(), path))
+		return nil
+	}
+
+	fmt.Println(ui.Success("Context '%s' removed from %s", cfg.GetKubeContext(), path))
+	return nil
+}
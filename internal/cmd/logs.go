@@ -77,7 +77,7 @@ func runLogs(cmd *cobra.Command, args []string) error {
 	clusterName := cfg.Cluster.Name
 
 	// Check if cluster exists
-	exists, err := kind.ClusterExists(clusterName)
+	exists, err := kind.ClusterAvailable(clusterName)
 	if err != nil {
 		printError("Failed to check cluster status: %v", err)
 		return err
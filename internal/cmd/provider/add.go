@@ -10,6 +10,7 @@ import (
 	"github.com/kanzi/kindplane/internal/config"
 	"github.com/kanzi/kindplane/internal/crossplane"
 	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
 	"github.com/kanzi/kindplane/internal/ui"
 )
 
@@ -55,11 +56,18 @@ func runAdd(cmd *cobra.Command, args []string) error {
 		return err
 	}
 
+	clusterLock, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
 	ctx, cancel := context.WithTimeout(context.Background(), addTimeout)
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -70,7 +78,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
@@ -78,7 +86,7 @@ func runAdd(cmd *cobra.Command, args []string) error {
 
 	// Install provider
 	fmt.Println(ui.Info("Installing %s (%s)...", providerName, addPackage))
-	installer := crossplane.NewInstaller(kubeClient)
+	installer := crossplane.NewInstaller(conn)
 	if err := installer.InstallProvider(ctx, providerName, addPackage); err != nil {
 		fmt.Println(ui.Error("Failed to install provider: %v", err))
 		return err
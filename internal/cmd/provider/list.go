@@ -3,6 +3,7 @@ package provider
 import (
 	"context"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -15,6 +16,7 @@ import (
 
 var (
 	listTimeout time.Duration
+	listImages  bool
 )
 
 var listCmd = &cobra.Command{
@@ -22,14 +24,21 @@ var listCmd = &cobra.Command{
 	Short: "List installed Crossplane providers",
 	Long: `List all Crossplane providers installed in the cluster.
 
-Shows provider name, version, and health status.`,
+Shows provider name, version, and health status. With --images, also shows
+the images each provider needs, read from the package metadata in its
+registry. Images that could only be guessed from the package name are
+marked as such.`,
 	Example: `  # List all providers
-  kindplane provider list`,
+  kindplane provider list
+
+  # Include the package and controller images of each provider
+  kindplane provider list --images`,
 	RunE: runList,
 }
 
 func init() {
 	listCmd.Flags().DurationVar(&listTimeout, "timeout", 30*time.Second, "timeout for listing providers")
+	listCmd.Flags().BoolVar(&listImages, "images", false, "show the images each provider needs")
 }
 
 func runList(cmd *cobra.Command, args []string) error {
@@ -44,7 +53,7 @@ func runList(cmd *cobra.Command, args []string) error {
 	defer cancel()
 
 	// Check cluster exists
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to check cluster: %v", err))
 		return err
@@ -55,14 +64,14 @@ func runList(cmd *cobra.Command, args []string) error {
 	}
 
 	// Get kube client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to connect to cluster: %v", err))
 		return err
 	}
 
 	// Get provider status
-	installer := crossplane.NewInstaller(kubeClient)
+	installer := crossplane.NewInstaller(conn)
 	providers, err := installer.GetProviderStatus(ctx)
 	if err != nil {
 		fmt.Println(ui.Error("Failed to get providers: %v", err))
@@ -76,6 +85,9 @@ func runList(cmd *cobra.Command, args []string) error {
 
 	// Build table data
 	headers := []string{"NAME", "VERSION", "PACKAGE", "STATUS"}
+	if listImages {
+		headers = append(headers, "IMAGES")
+	}
 	var rows [][]string
 
 	for _, p := range providers {
@@ -87,12 +99,21 @@ func runList(cmd *cobra.Command, args []string) error {
 			}
 		}
 
-		rows = append(rows, []string{
+		row := []string{
 			p.Name,
 			p.Version,
 			p.Package,
 			status,
-		})
+		}
+		if listImages {
+			images, err := kind.ProviderImages(ctx, p.Package)
+			cell := strings.Join(images, ", ")
+			if err != nil {
+				cell += " (guessed)"
+			}
+			row = append(row, cell)
+		}
+		rows = append(rows, row)
 	}
 
 	fmt.Println()
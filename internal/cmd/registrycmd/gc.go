@@ -0,0 +1,80 @@
+package registrycmd
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/registry"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	gcDryRun         bool
+	gcDeleteUntagged bool
+	gcTimeout        time.Duration
+)
+
+var gcCmd = &cobra.Command{
+	Use:   "gc",
+	Short: "Free the disk space of deleted images",
+	Long: `Run the registry's garbage collector inside the registry container.
+
+Blobs no longer referenced by any manifest are removed. Avoid pushing while
+garbage collection runs, as blobs of an in-flight push may be collected.
+
+--delete-untagged also removes manifests that have no tags left, such as
+those of overwritten tags. The registry's collector treats the per-platform
+manifests of a multi-platform image as untagged too, so it breaks
+multi-platform images, including the pinned images and bundled packages
+kindplane copies with all their platforms. Only use it for a registry that
+holds single-platform images.`,
+	Example: `  # Show what would be removed
+  kindplane registry gc --dry-run
+
+  # Collect garbage
+  kindplane registry gc
+
+  # Also remove manifests of overwritten tags (single-platform images only)
+  kindplane registry gc --delete-untagged`,
+	Args: cobra.NoArgs,
+	RunE: runGC,
+}
+
+func init() {
+	gcCmd.Flags().BoolVar(&gcDryRun, "dry-run", false, "Show what would be removed without removing it")
+	gcCmd.Flags().BoolVar(&gcDeleteUntagged, "delete-untagged", false, "Also remove untagged manifests; breaks multi-platform images")
+	gcCmd.Flags().DurationVar(&gcTimeout, "timeout", 5*time.Minute, "Timeout for garbage collection")
+}
+
+func runGC(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), gcTimeout)
+	defer cancel()
+
+	m, err := localRegistry(ctx)
+	if err != nil {
+		return err
+	}
+
+	out, err := m.GarbageCollect(ctx, registry.GCOptions{DryRun: gcDryRun, DeleteUntagged: gcDeleteUntagged})
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	// The collector ends with a summary such as
+	// "12 blobs marked, 3 blobs and 1 manifests eligible for deletion"
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	if summary := lines[len(lines)-1]; summary != "" {
+		fmt.Println(ui.Muted("  %s", summary))
+	}
+	if gcDryRun {
+		fmt.Println(ui.Info("Dry run complete; nothing was removed"))
+		return nil
+	}
+	fmt.Println(ui.Success("Garbage collection complete"))
+	return nil
+}
@@ -0,0 +1,133 @@
+package registrycmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/crossplane"
+	"github.com/kanzi/kindplane/internal/registry"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	loginInfoFormat       string
+	loginInfoShowPassword bool
+)
+
+var loginInfoCmd = &cobra.Command{
+	Use:   "login-info",
+	Short: "Show how to connect to the local registry",
+	Long: `Show the hosts, credentials and CA certificate of the local registry.
+
+With cluster.registry.tls the registry serves HTTPS with a certificate
+signed by a CA kindplane generates; with cluster.registry.auth it requires
+the credentials kindplane generates. Both are kept in the kindplane state
+directory and reused across clusters. 'kindplane up' logs the container
+runtime in to the registry and creates the pull secret in crossplane-system.
+
+The password is hidden in table output unless --show-password is set.`,
+	Example: `  # Show the registry connection details
+  kindplane registry login-info
+
+  # Log in with another tool
+  kindplane registry login-info -o json | jq -r .password | \
+    crane auth login localhost:5001 -u kindplane --password-stdin`,
+	Args: cobra.NoArgs,
+	RunE: runLoginInfo,
+}
+
+func init() {
+	loginInfoCmd.Flags().StringVarP(&loginInfoFormat, "format", "o", "table", "Output format (table, json)")
+	loginInfoCmd.Flags().BoolVar(&loginInfoShowPassword, "show-password", false, "Show the password in table output")
+}
+
+// loginInfo is the JSON output of login-info
+type loginInfo struct {
+	Host         string `json:"host"`
+	InternalHost string `json:"internalHost"`
+	TLS          bool   `json:"tls"`
+	CAFile       string `json:"caFile,omitempty"`
+	Auth         bool   `json:"auth"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+	PullSecret   string `json:"pullSecret,omitempty"`
+	PullSecretNS string `json:"pullSecretNamespace,omitempty"`
+}
+
+func runLoginInfo(cmd *cobra.Command, args []string) error {
+	if loginInfoFormat != "table" && loginInfoFormat != "json" {
+		fmt.Println(ui.Error("Unknown format: %s. Use 'table' or 'json'.", loginInfoFormat))
+		return fmt.Errorf("unknown format: %s", loginInfoFormat)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	m, err := localRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	info := loginInfo{
+		Host:         m.GetRegistryHost(),
+		InternalHost: m.GetInternalHost(),
+	}
+	if m.Secured() {
+		assets, err := m.Assets()
+		if err != nil {
+			fmt.Println(ui.Error("%v", err))
+			return err
+		}
+		if len(m.TrustedCAs(assets)) > 0 {
+			info.TLS, info.CAFile = true, assets.CAFile
+		}
+		if assets.Username != "" {
+			info.Auth = true
+			info.Username, info.Password = assets.Username, assets.Password
+			info.PullSecret, info.PullSecretNS = registry.PullSecretName, crossplane.CrossplaneNamespace
+		}
+	}
+
+	if loginInfoFormat == "json" {
+		output, err := json.MarshalIndent(info, "", "  ")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to marshal output: %v", err))
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	scheme := "http"
+	if info.TLS {
+		scheme = "https"
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconPackage + " Registry " + info.Host))
+	fmt.Println(ui.Divider())
+	fmt.Println(ui.KeyValue("URL", scheme+"://"+info.Host))
+	fmt.Println(ui.KeyValue("In-cluster host", info.InternalHost))
+	if info.TLS {
+		fmt.Println(ui.KeyValue("CA certificate", info.CAFile))
+	}
+	if !info.Auth {
+		fmt.Println(ui.KeyValue("Authentication", "none"))
+		return nil
+	}
+
+	password := "******** (--show-password to reveal)"
+	if loginInfoShowPassword {
+		password = info.Password
+	}
+	fmt.Println(ui.KeyValue("Username", info.Username))
+	fmt.Println(ui.KeyValue("Password", password))
+	fmt.Println(ui.KeyValue("Pull secret", info.PullSecretNS+"/"+info.PullSecret))
+	fmt.Println()
+	fmt.Println(ui.Muted("  Use the pull secret in other namespaces by copying it, or reference it"))
+	fmt.Println(ui.Muted("  from Crossplane packages with spec.packagePullSecrets."))
+	return nil
+}
@@ -0,0 +1,118 @@
+package registrycmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	lsFormat  string
+	lsTimeout time.Duration
+)
+
+var lsCmd = &cobra.Command{
+	Use:     "ls [filter]",
+	Aliases: []string{"list"},
+	Short:   "List repositories and tags in the local registry",
+	Long: `List the repositories in the local registry with their tags, digests
+and sizes, read over the registry HTTP API.
+
+The size of a tag is the manifest and all blobs it references, across
+every platform of a multi-platform image. Blobs shared between tags are
+counted for each tag.
+
+When a filter is given, only repositories whose name contains it are listed.`,
+	Example: `  # List everything in the registry
+  kindplane registry ls
+
+  # List crossplane repositories as JSON
+  kindplane registry ls crossplane -o json`,
+	Args: cobra.MaximumNArgs(1),
+	RunE: runLs,
+}
+
+func init() {
+	lsCmd.Flags().StringVarP(&lsFormat, "format", "o", "table", "Output format (table, json)")
+	lsCmd.Flags().DurationVar(&lsTimeout, "timeout", time.Minute, "Timeout for registry requests")
+}
+
+func runLs(cmd *cobra.Command, args []string) error {
+	if lsFormat != "table" && lsFormat != "json" {
+		fmt.Println(ui.Error("Unknown format: %s. Use 'table' or 'json'.", lsFormat))
+		return fmt.Errorf("unknown format: %s", lsFormat)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), lsTimeout)
+	defer cancel()
+
+	m, err := localRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := m.Client()
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	var filter string
+	if len(args) == 1 {
+		filter = args[0]
+	}
+	repos, err := client.List(ctx, filter)
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	if lsFormat == "json" {
+		output, err := json.MarshalIndent(repos, "", "  ")
+		if err != nil {
+			fmt.Println(ui.Error("Failed to marshal output: %v", err))
+			return err
+		}
+		fmt.Println(string(output))
+		return nil
+	}
+
+	if len(repos) == 0 {
+		fmt.Println(ui.Warning("No repositories in %s", client.Host()))
+		return nil
+	}
+
+	headers := []string{"REPOSITORY", "TAG", "DIGEST", "SIZE"}
+	var rows [][]string
+	tags := 0
+	for _, repo := range repos {
+		for _, tag := range repo.Tags {
+			rows = append(rows, []string{repo.Name, tag.Tag, shortDigest(tag.Digest), ui.FormatBytes(tag.Size)})
+			tags++
+		}
+		if len(repo.Tags) == 0 {
+			rows = append(rows, []string{repo.Name, "<none>", "", ""})
+		}
+	}
+
+	fmt.Println()
+	fmt.Println(ui.Title(ui.IconPackage + " Registry " + client.Host()))
+	fmt.Println(ui.Divider())
+	fmt.Println(ui.RenderTable(headers, rows))
+	fmt.Println(ui.Muted("%d repositories, %d tags", len(repos), tags))
+
+	return nil
+}
+
+// shortDigest shortens "sha256:<hex>" to the first 12 hex digits
+func shortDigest(d string) string {
+	if _, hex, ok := strings.Cut(d, ":"); ok && len(hex) > 12 {
+		return hex[:12]
+	}
+	return d
+}
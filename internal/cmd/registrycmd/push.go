@@ -0,0 +1,86 @@
+package registrycmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var pushTimeout time.Duration
+
+var pushCmd = &cobra.Command{
+	Use:   "push <image>...",
+	Short: "Push local images to the local registry",
+	Long: `Push images from the local Docker or Podman image store to the local
+registry, so Kind nodes can pull them.
+
+Images are retagged the same way image preloading does it: a registry host
+is replaced by the local registry, and Docker Hub images keep their path.
+For example, with the registry on port 5001:
+
+  ghcr.io/org/app:v1            -> localhost:5001/org/app:v1
+  crossplane/crossplane:v2.1.0  -> localhost:5001/crossplane/crossplane:v2.1.0`,
+	Example: `  # Push a locally built image
+  kindplane registry push my-app:dev
+
+  # Push several images
+  kindplane registry push my-app:dev ghcr.io/org/function:v0.1.0`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runPush,
+}
+
+func init() {
+	pushCmd.Flags().DurationVar(&pushTimeout, "timeout", 10*time.Minute, "Timeout for pushing images")
+}
+
+func runPush(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), pushTimeout)
+	defer cancel()
+
+	m, err := localRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	rt := container.Default()
+	host := m.GetRegistryHost()
+
+	failed := 0
+	for _, image := range args {
+		target := kind.RetagForRegistry(image, host)
+		if err := pushImage(ctx, rt, image, target); err != nil {
+			fmt.Println(ui.Error("%s: %v", image, err))
+			failed++
+			continue
+		}
+		fmt.Println(ui.Success("Pushed %s as %s", image, target))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to push %d of %d images", failed, len(args))
+	}
+	return nil
+}
+
+// pushImage tags a local image as target and pushes it
+func pushImage(ctx context.Context, rt container.Runtime, image, target string) error {
+	exists, err := rt.ImageExists(ctx, image)
+	if err != nil {
+		return fmt.Errorf("failed to check image: %w", err)
+	}
+	if !exists {
+		return fmt.Errorf("image not found locally (build or pull it first)")
+	}
+	if err := rt.TagImage(ctx, image, target); err != nil {
+		return fmt.Errorf("failed to tag: %w", err)
+	}
+	if err := rt.PushImage(ctx, target); err != nil {
+		return fmt.Errorf("failed to push: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package registrycmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/registry"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+// RegistryCmd is the parent command for local registry subcommands
+var RegistryCmd = &cobra.Command{
+	Use:   "registry",
+	Short: "Manage the local container registry",
+	Long: `Inspect and manage the local container registry (cluster.registry).
+
+Available subcommands:
+  ls   - List repositories and tags with their sizes
+  push - Push local images to the registry
+  rm   - Delete tags from the registry
+  gc   - Run garbage collection to free deleted image data
+  login-info - Show the registry hosts, credentials and CA`,
+}
+
+func init() {
+	RegistryCmd.AddCommand(lsCmd)
+	RegistryCmd.AddCommand(pushCmd)
+	RegistryCmd.AddCommand(rmCmd)
+	RegistryCmd.AddCommand(gcCmd)
+	RegistryCmd.AddCommand(loginInfoCmd)
+}
+
+// localRegistry loads the config and returns the manager of the running
+// local registry, with its port read from the container
+func localRegistry(ctx context.Context) (*registry.Manager, error) {
+	cfg, err := config.Load("")
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return nil, err
+	}
+	if !cfg.Cluster.Registry.Enabled {
+		fmt.Println(ui.Error("The local registry is not enabled"))
+		fmt.Println(ui.Muted("  Set cluster.registry.enabled: true and run 'kindplane up'"))
+		return nil, fmt.Errorf("local registry not enabled")
+	}
+
+	m := registry.NewManager(&cfg.Cluster.Registry)
+	running, err := m.IsRunning(ctx)
+	if err != nil {
+		fmt.Println(ui.Error("Failed to check registry status: %v", err))
+		return nil, err
+	}
+	if !running {
+		fmt.Println(ui.Error("Local registry '%s' is not running", cfg.Cluster.Registry.GetName()))
+		fmt.Println(ui.Muted("  Start it with 'kindplane up'"))
+		return nil, fmt.Errorf("local registry not running")
+	}
+	if _, err := m.ResolvePort(ctx, cfg.Cluster.AutoPorts()); err != nil {
+		fmt.Println(ui.Error("Failed to read registry port: %v", err))
+		return nil, err
+	}
+	return m, nil
+}
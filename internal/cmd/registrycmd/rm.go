@@ -0,0 +1,96 @@
+package registrycmd
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/registry"
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+var (
+	rmForce   bool
+	rmTimeout time.Duration
+)
+
+var rmCmd = &cobra.Command{
+	Use:     "rm <repository:tag>...",
+	Aliases: []string{"delete"},
+	Short:   "Delete tags from the local registry",
+	Long: `Delete images from the local registry by tag or digest.
+
+The registry deletes the manifest a tag points to, so other tags of the
+same manifest are deleted too. The image data stays on disk until
+'kindplane registry gc' runs.
+
+References may include the registry host, e.g. localhost:5001/my-app:dev.`,
+	Example: `  # Delete a tag
+  kindplane registry rm my-app:dev
+
+  # Delete by digest without confirmation
+  kindplane registry rm my-app@sha256:0123... --force`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: runRm,
+}
+
+func init() {
+	rmCmd.Flags().BoolVarP(&rmForce, "force", "f", false, "Delete without confirmation")
+	rmCmd.Flags().DurationVar(&rmTimeout, "timeout", time.Minute, "Timeout for registry requests")
+}
+
+func runRm(cmd *cobra.Command, args []string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), rmTimeout)
+	defer cancel()
+
+	m, err := localRegistry(ctx)
+	if err != nil {
+		return err
+	}
+	client, err := m.Client()
+	if err != nil {
+		fmt.Println(ui.Error("%v", err))
+		return err
+	}
+
+	// Confirm unless --force
+	if !rmForce {
+		confirm, err := ui.ConfirmWithContext(ctx, fmt.Sprintf("Delete %s from %s?", strings.Join(args, ", "), client.Host()))
+		if err != nil {
+			if errors.Is(err, ui.ErrCancelled) {
+				fmt.Println(ui.Warning("Deletion cancelled"))
+				return nil
+			}
+			fmt.Println(ui.Error("Prompt failed: %v", err))
+			return err
+		}
+		if !confirm {
+			fmt.Println(ui.Warning("Deletion cancelled"))
+			return nil
+		}
+	}
+
+	failed := 0
+	for _, ref := range args {
+		digest, err := client.Delete(ctx, strings.TrimPrefix(ref, client.Host()+"/"))
+		if err != nil {
+			fmt.Println(ui.Error("%v", err))
+			if errors.Is(err, registry.ErrDeleteDisabled) {
+				fmt.Println(ui.Muted("  The registry container predates delete support; recreate it with 'kindplane down' and 'kindplane up'"))
+			}
+			failed++
+			continue
+		}
+		fmt.Println(ui.Success("Deleted %s (%s)", ref, shortDigest(digest)))
+	}
+
+	if failed > 0 {
+		return fmt.Errorf("failed to delete %d of %d images", failed, len(args))
+	}
+	fmt.Println(ui.Muted("  Run 'kindplane registry gc' to free the disk space"))
+	return nil
+}
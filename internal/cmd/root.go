@@ -7,13 +7,22 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/kanzi/kindplane/internal/cache"
+	"github.com/kanzi/kindplane/internal/cmd/bundlecmd"
+	"github.com/kanzi/kindplane/internal/cmd/cachecmd"
 	"github.com/kanzi/kindplane/internal/cmd/chart"
 	"github.com/kanzi/kindplane/internal/cmd/cluster"
 	"github.com/kanzi/kindplane/internal/cmd/compositions"
 	"github.com/kanzi/kindplane/internal/cmd/configcmd"
 	"github.com/kanzi/kindplane/internal/cmd/credentials"
+	"github.com/kanzi/kindplane/internal/cmd/images"
+	"github.com/kanzi/kindplane/internal/cmd/kubeconfig"
 	"github.com/kanzi/kindplane/internal/cmd/provider"
+	"github.com/kanzi/kindplane/internal/cmd/registrycmd"
 	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/lock"
 	"github.com/kanzi/kindplane/internal/ui"
 	"github.com/kanzi/kindplane/internal/version"
 )
@@ -23,8 +32,11 @@ var (
 	Version = "dev"
 
 	// Global flags
-	cfgFile string
-	verbose bool
+	cfgFile        string
+	verbose        bool
+	kubeconfigPath string
+	kubeContext    string
+	offline        bool
 
 	// Global config
 	cfg *config.Config
@@ -45,9 +57,14 @@ It automates the tedious process of setting up a local Kubernetes
 development environment with Crossplane for infrastructure management.`,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
 		// Run version check asynchronously (only once per CLI invocation)
-		versionCheckOnce.Do(func() {
-			go checkForUpdates()
-		})
+		if !offline {
+			versionCheckOnce.Do(func() {
+				go checkForUpdates()
+			})
+		}
+		cache.SetOffline(offline)
+		selectContainerRuntime()
+		kind.SetKubeconfigOverride(kubeconfigPath, kubeContext)
 	},
 }
 
@@ -58,6 +75,10 @@ func init() {
 	// Note: Using -V for verbose to avoid conflict with fang's -v/--version
 	RootCmd.PersistentFlags().StringVarP(&cfgFile, "config", "c", "", "config file (default is ./kindplane.yaml)")
 	RootCmd.PersistentFlags().BoolVarP(&verbose, "verbose", "V", false, "verbose output")
+	RootCmd.PersistentFlags().StringVar(&kubeconfigPath, "kubeconfig", "", "kubeconfig file to use instead of the Kind cluster's own")
+	RootCmd.PersistentFlags().StringVar(&kubeContext, "context", "", "kubeconfig context to use instead of the Kind cluster's own")
+	RootCmd.PersistentFlags().BoolVar(&offline, "offline", false, "use cached charts, git sources and schemas only, never download them (see 'kindplane cache')")
+	RootCmd.PersistentFlags().Duration(lock.WaitFlag, 0, "wait up to this long for another kindplane command on the same cluster to finish (default: fail immediately)")
 
 	// Add subcommands
 	RootCmd.AddCommand(initCmd)
@@ -76,6 +97,11 @@ func init() {
 	RootCmd.AddCommand(provider.ProviderCmd)
 	RootCmd.AddCommand(chart.ChartCmd)
 	RootCmd.AddCommand(credentials.CredentialsCmd)
+	RootCmd.AddCommand(kubeconfig.KubeconfigCmd)
+	RootCmd.AddCommand(images.ImagesCmd)
+	RootCmd.AddCommand(registrycmd.RegistryCmd)
+	RootCmd.AddCommand(bundlecmd.BundleCmd)
+	RootCmd.AddCommand(cachecmd.CacheCmd)
 }
 
 // initConfig reads in config file if set
@@ -95,9 +121,23 @@ func loadConfig() error {
 	if err != nil {
 		return err
 	}
+	container.SetPreference(cfg.Cluster.ContainerRuntime)
 	return nil
 }
 
+// selectContainerRuntime applies the configured container runtime before any
+// subcommand runs. Subcommands load the config themselves, so a missing or
+// invalid file is ignored here and left for them to report.
+func selectContainerRuntime() {
+	path := cfgFile
+	if path == "" {
+		path = config.DefaultConfigFile
+	}
+	if c, err := config.Load(path); err == nil {
+		container.SetPreference(c.Cluster.ContainerRuntime)
+	}
+}
+
 // requireConfig ensures config is loaded, exits if not found
 func requireConfig() {
 	if err := loadConfig(); err != nil {
@@ -106,6 +146,17 @@ func requireConfig() {
 	}
 }
 
+// lockCluster takes the advisory lock for the configured cluster so that
+// mutating commands from different terminals do not interleave
+func lockCluster(cmd *cobra.Command) (*lock.Lock, error) {
+	l, err := lock.ForCommand(cmd, cfg.Cluster.Name)
+	if err != nil {
+		printError("%v", err)
+		return nil, err
+	}
+	return l, nil
+}
+
 // Helper print functions using the new UI package
 func printSuccess(format string, a ...interface{}) {
 	fmt.Println(ui.Success(format, a...))
@@ -25,7 +25,7 @@ var statusCmd = &cobra.Command{
 	Long: `Display the status of the Kind cluster and installed components.
 
 Shows:
-  - Cluster status (exists/running)
+  - Cluster status (exists/running) and host ports
   - Crossplane installation status
   - Provider health`,
 	Example: `  # Show basic status
@@ -96,7 +96,7 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	statusContent.WriteString(statusSectionStyle.Render(ui.IconCluster + " Cluster"))
 	statusContent.WriteString("\n\n")
 
-	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	exists, err := kind.ClusterAvailable(cfg.Cluster.Name)
 	if err != nil {
 		printError("Failed to check cluster: %v", err)
 		return err
@@ -130,18 +130,40 @@ func runStatus(cmd *cobra.Command, args []string) error {
 	statusContent.WriteString("\n")
 
 	// Get kubernetes client
-	kubeClient, err := kind.GetKubeClient(cfg.Cluster.Name)
+	conn, err := kind.Connect(cfg.Cluster.Name)
 	if err != nil {
 		printError("Failed to connect to cluster: %v", err)
 		return err
 	}
+	kubeClient := conn.Client
+
+	// Show the host ports the cluster was created with
+	portMap, err := kind.LoadPortMap(ctx, kubeClient)
+	if err != nil || portMap == nil {
+		portMap = kind.ConfiguredPortMap(cfg)
+	}
+	for _, a := range portMap.Mappings {
+		statusContent.WriteString("  ")
+		statusContent.WriteString(statusLabelStyle.Render("Port:"))
+		statusContent.WriteString(fmt.Sprintf("localhost:%d → %d/%s", a.HostPort, a.ContainerPort, a.Protocol))
+		if a.HostPort != a.RequestedPort {
+			statusContent.WriteString(statusMutedStyle.Render(fmt.Sprintf(" (configured %d)", a.RequestedPort)))
+		}
+		statusContent.WriteString("\n")
+	}
+	if portMap.RegistryPort != 0 {
+		statusContent.WriteString("  ")
+		statusContent.WriteString(statusLabelStyle.Render("Registry:"))
+		statusContent.WriteString(ui.Code(fmt.Sprintf("localhost:%d", portMap.RegistryPort)))
+		statusContent.WriteString("\n")
+	}
 
 	// Check Crossplane status
 	statusContent.WriteString("\n")
 	statusContent.WriteString(statusSectionStyle.Render(ui.IconPackage + " Crossplane"))
 	statusContent.WriteString("\n\n")
 
-	cpInstaller := crossplane.NewInstaller(kubeClient)
+	cpInstaller := crossplane.NewInstaller(conn)
 	cpStatus, err := cpInstaller.GetStatus(ctx)
 	if err != nil {
 		statusContent.WriteString("  ")
@@ -4,7 +4,9 @@ import (
 	"context"
 	"fmt"
 	"os"
+	"slices"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/spf13/cobra"
@@ -16,12 +18,17 @@ import (
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/util/retry"
 
+	"github.com/kanzi/kindplane/internal/bundle"
+	"github.com/kanzi/kindplane/internal/cmd/images"
 	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
 	"github.com/kanzi/kindplane/internal/crossplane"
 	"github.com/kanzi/kindplane/internal/diagnostics"
 	"github.com/kanzi/kindplane/internal/helm"
 	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/kube"
 	"github.com/kanzi/kindplane/internal/registry"
+	"github.com/kanzi/kindplane/internal/snapshot"
 	"github.com/kanzi/kindplane/internal/ui"
 )
 
@@ -44,6 +51,15 @@ var (
 	upRollbackOnFailure bool
 	upShowValues        bool
 	upPullImages        bool
+	upRestoreSnapshot   bool
+	upNoSnapshot        bool
+	upLearnImages       bool
+	upBundle            string
+	upChartConcurrency  int
+	upRecreateRegistry  bool
+
+	// upBundleContents is the extracted --bundle, nil without one
+	upBundleContents *bundle.Bundle
 )
 
 var upCmd = &cobra.Command{
@@ -74,7 +90,16 @@ The bootstrap process:
   kindplane up --skip-charts
 
   # Rollback (delete cluster) on failure
-  kindplane up --rollback-on-failure`,
+  kindplane up --rollback-on-failure
+
+  # Restore a snapshot matching kindplane.yaml without prompting
+  kindplane up --restore-snapshot
+
+  # Record images pulled during the bootstrap for the next run to preload
+  kindplane up --learn-images
+
+  # Bootstrap without network access from 'kindplane bundle create' output
+  kindplane up --bundle lab.tar.gz`,
 	RunE: runUp,
 }
 
@@ -85,8 +110,14 @@ func init() {
 	upCmd.Flags().BoolVar(&upSkipCompositions, "skip-compositions", false, "skip applying compositions")
 	upCmd.Flags().DurationVar(&upTimeout, "timeout", 10*time.Minute, "timeout for the entire operation")
 	upCmd.Flags().BoolVar(&upRollbackOnFailure, "rollback-on-failure", false, "delete cluster if bootstrap fails")
-	upCmd.Flags().BoolVar(&upShowValues, "show-values", false, "display merged Helm values before installation")
+	upCmd.Flags().BoolVar(&upShowValues, "show-values", false, "display rendered Helm values before installation")
 	upCmd.Flags().BoolVar(&upPullImages, "pull-images", false, "automatically pull missing images without prompting")
+	upCmd.Flags().BoolVar(&upRestoreSnapshot, "restore-snapshot", false, "restore a snapshot matching the config without prompting")
+	upCmd.Flags().BoolVar(&upNoSnapshot, "no-snapshot", false, "never offer to restore a matching snapshot")
+	upCmd.Flags().BoolVar(&upLearnImages, "learn-images", false, "after a successful bootstrap, add images the nodes pulled to crossplane.imageCache.additionalImages")
+	upCmd.Flags().IntVar(&upChartConcurrency, "chart-concurrency", helm.DefaultChartConcurrency, "maximum number of charts of a phase installed at once")
+	upCmd.Flags().BoolVar(&upRecreateRegistry, "recreate-registry", false, "recreate a persistent local registry created with other settings, keeping its images")
+	upCmd.Flags().StringVar(&upBundle, "bundle", "", "bootstrap without network access from a bundle created by 'kindplane bundle create'")
 }
 
 // bootstrapContext holds shared resources during bootstrap
@@ -122,8 +153,8 @@ func buildPhases() *ui.PhaseTracker {
 	)
 
 	// Add phases conditionally based on configuration
-	pt.AddPhaseIf(cfg.Cluster.Registry.Enabled, phaseRegistry)
-	pt.AddPhaseIf(kind.HasTrustedCAs(cfg), phaseTrustedCAs)
+	pt.AddPhaseIf(cfg.Cluster.Registry.UsesHostsDir(), phaseRegistry)
+	pt.AddPhaseIf(kind.HasTrustedCAs(cfg) || (cfg.Cluster.Registry.Enabled && cfg.Cluster.Registry.TLS), phaseTrustedCAs)
 	pt.AddPhase(phaseCluster)
 	pt.AddPhaseIf(shouldPreloadImages(cfg), phaseImageCache)
 	pt.AddPhase(phaseConnect)
@@ -158,8 +189,9 @@ func shouldPreloadImages(cfg *config.Config) bool {
 	hasProviders := len(cfg.Crossplane.Providers) > 0 && (cfg.Crossplane.ImageCache == nil || cfg.Crossplane.ImageCache.ShouldPreloadProviders())
 	hasCrossplane := cfg.Crossplane.ImageCache == nil || cfg.Crossplane.ImageCache.ShouldPreloadCrossplane()
 	hasAdditional := cfg.Crossplane.ImageCache != nil && len(cfg.Crossplane.ImageCache.AdditionalImages) > 0
+	hasCharts := len(cfg.Charts) > 0 && (cfg.Crossplane.ImageCache == nil || cfg.Crossplane.ImageCache.ShouldPreloadCharts())
 
-	if hasProviders || hasCrossplane || hasAdditional {
+	if hasProviders || hasCrossplane || hasAdditional || hasCharts {
 		return true
 	}
 
@@ -176,6 +208,27 @@ func runUp(cmd *cobra.Command, args []string) error {
 	// Require config
 	requireConfig()
 
+	clusterLock, err := lockCluster(cmd)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = clusterLock.Release() }()
+
+	if upBundle != "" {
+		if upBundleContents, err = openBundle(); err != nil {
+			return err
+		}
+	}
+
+	// A snapshot of the same configuration is much faster than a full bootstrap
+	restored, err := restoreMatchingSnapshot()
+	if err != nil {
+		return err
+	}
+	if restored {
+		return nil
+	}
+
 	// Determine bootstrap mode based on TTY
 	mode := bootstrapModePrint
 	if ui.IsTTY() {
@@ -186,9 +239,160 @@ func runUp(cmd *cobra.Command, args []string) error {
 	pt := buildPhases()
 
 	if mode == bootstrapModeDashboard {
-		return runUpDashboard(pt)
+		err = runUpDashboard(pt)
+	} else {
+		err = runUpPrint(pt)
+	}
+	if err != nil || !upLearnImages {
+		return err
 	}
-	return runUpPrint(pt)
+
+	configPath := cfgFile
+	if configPath == "" {
+		configPath = config.DefaultConfigFile
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Minute)
+	defer cancel()
+	return images.Learn(ctx, cfg, configPath, false)
+}
+
+// openBundle extracts the --bundle file, loads its node and registry images
+// and points the configuration at it. Anything the configuration needs that
+// the bundle lacks fails the command before anything is created.
+func openBundle() (*bundle.Bundle, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), upTimeout)
+	defer cancel()
+
+	var b *bundle.Bundle
+	err := ui.RunSpinnerWithContext(ctx, fmt.Sprintf("Extracting bundle %s", upBundle), func(ctx context.Context) error {
+		var err error
+		b, err = bundle.Open(upBundle)
+		return err
+	})
+	if err != nil {
+		printError("%v", err)
+		return nil, err
+	}
+	if err := b.CheckPlatform(); err != nil {
+		printError("%v", err)
+		return nil, err
+	}
+	if missing := b.Missing(ctx, cfg); len(missing) > 0 {
+		printError("Bundle %s lacks %d item(s) the configuration needs:", upBundle, len(missing))
+		for _, m := range missing {
+			fmt.Printf("  - %s\n", m)
+		}
+		fmt.Println(ui.Muted("  Recreate it with 'kindplane bundle create' from this kindplane.yaml"))
+		return nil, fmt.Errorf("bundle does not match the configuration")
+	}
+	if err := b.Configure(cfg); err != nil {
+		printError("%v", err)
+		return nil, err
+	}
+
+	err = ui.RunSpinnerWithContext(ctx, "Loading node and registry images", func(ctx context.Context) error {
+		return b.LoadHostImages(ctx, container.Default())
+	})
+	if err != nil {
+		printError("%v", err)
+		return nil, err
+	}
+	return b, nil
+}
+
+// restoreMatchingSnapshot restores the newest snapshot taken from the current
+// configuration when the cluster does not exist yet. It prompts in TTY mode
+// unless --restore-snapshot is set, and returns true if a snapshot was restored.
+func restoreMatchingSnapshot() (bool, error) {
+	if upNoSnapshot {
+		return false, nil
+	}
+
+	exists, err := kind.ClusterExists(cfg.Cluster.Name)
+	if err != nil || exists {
+		return false, nil
+	}
+
+	store, err := snapshot.NewStore()
+	if err != nil {
+		printWarn("Skipping snapshot lookup: %v", err)
+		return false, nil
+	}
+	hash, err := snapshot.ConfigHash(cfg)
+	if err != nil {
+		printWarn("Skipping snapshot lookup: %v", err)
+		return false, nil
+	}
+	meta, err := store.FindMatching(cfg.Cluster.Name, hash)
+	if err != nil {
+		printWarn("Skipping snapshot lookup: %v", err)
+		return false, nil
+	}
+	if meta == nil {
+		return false, nil
+	}
+
+	if !upRestoreSnapshot {
+		if !ui.IsTTY() {
+			printInfo("Snapshot '%s' matches this configuration; use --restore-snapshot to restore it", meta.Name)
+			return false, nil
+		}
+		printInfo("Found snapshot '%s' matching this configuration (created %s)", meta.Name, meta.CreatedAt.Format("2006-01-02 15:04"))
+		confirm, err := ui.Confirm("Restore it instead of running a full bootstrap?", ui.WithConfirmDefault(true))
+		if err != nil || !confirm {
+			return false, nil
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), upTimeout)
+	defer cancel()
+
+	var registryManager *registry.Manager
+	if cfg.Cluster.Registry.Enabled {
+		registryManager = registry.NewManager(&cfg.Cluster.Registry)
+		if err := checkRegistry(ctx, registryManager, func(msg string) { printWarn("%s", msg) }); err != nil {
+			printError("%v", err)
+			return false, err
+		}
+	}
+
+	err = ui.RunSpinnerWithContext(ctx, fmt.Sprintf("Restoring snapshot %s", meta.Name), func(ctx context.Context) error {
+		if registryManager != nil {
+			if err := registryManager.Create(ctx); err != nil {
+				return fmt.Errorf("failed to create registry: %w", err)
+			}
+		}
+		var cacheManager *registry.PullThroughManager
+		if cfg.Cluster.Registry.PullThrough.Enabled {
+			cacheManager = registry.NewPullThroughManager(&cfg.Cluster.Registry.PullThrough)
+			if err := cacheManager.Create(ctx); err != nil {
+				return err
+			}
+		}
+		if err := snapshot.Restore(ctx, store, meta, nil); err != nil {
+			return err
+		}
+		if err := kind.ExportKubeConfig(cfg.Cluster.Name, cfg.GetKubeconfigPath(), cfg.Cluster.Kubeconfig.Internal); err != nil {
+			return err
+		}
+		if registryManager != nil {
+			if err := registryManager.ConnectToNetwork(ctx, "kind"); err != nil {
+				return err
+			}
+		}
+		if cacheManager != nil {
+			return cacheManager.ConnectToNetwork(ctx, "kind")
+		}
+		return nil
+	})
+	if err != nil {
+		printError("Failed to restore snapshot: %v", err)
+		return false, err
+	}
+
+	printSuccess("Cluster '%s' restored from snapshot '%s'", cfg.Cluster.Name, meta.Name)
+	fmt.Println(ui.Muted("  Next: %s", clusterInfoHint()))
+	return true, nil
 }
 
 // runUpDashboard runs the bootstrap with the TUI dashboard
@@ -196,7 +400,7 @@ func runUpDashboard(pt *ui.PhaseTracker) error {
 	ctx := context.Background()
 
 	// Build the next step hint
-	nextStepHint := fmt.Sprintf("kubectl cluster-info --context %s", cfg.GetKubeContext())
+	nextStepHint := clusterInfoHint()
 
 	result, err := ui.RunBootstrapDashboard(
 		ctx,
@@ -241,10 +445,20 @@ func runUpPrint(pt *ui.PhaseTracker) error {
 	}
 
 	// Success!
-	pt.PrintSuccessWithHint("Cluster ready!", fmt.Sprintf("kubectl cluster-info --context %s", cfg.GetKubeContext()))
+	pt.PrintSuccessWithHint("Cluster ready!", clusterInfoHint())
 	return nil
 }
 
+// clusterInfoHint returns the kubectl command shown once the cluster is ready,
+// pointing at the configured kubeconfig when it is not the default one
+func clusterInfoHint() string {
+	hint := fmt.Sprintf("kubectl cluster-info --context %s", cfg.GetKubeContext())
+	if cfg.Cluster.Kubeconfig.Path != "" {
+		hint += fmt.Sprintf(" --kubeconfig %s", cfg.GetKubeconfigPath())
+	}
+	return hint
+}
+
 // createValuesLogger creates a ValuesLogger based on the current mode.
 // If upShowValues is false, returns nil (no logging).
 // If ctrl is non-nil (dashboard mode), logs to the dashboard log buffer.
@@ -262,7 +476,7 @@ func createValuesLogger(ctrl *ui.DashboardController) helm.ValuesLogger {
 
 		if ctrl != nil {
 			// Dashboard mode: add to log buffer
-			ctrl.Log(fmt.Sprintf("Merged values for %s:", releaseName))
+			ctrl.Log(fmt.Sprintf("Rendered values for %s:", releaseName))
 			for _, line := range strings.Split(string(valuesYAML), "\n") {
 				if line != "" {
 					ctrl.Log("  " + line)
@@ -270,12 +484,40 @@ func createValuesLogger(ctrl *ui.DashboardController) helm.ValuesLogger {
 			}
 		} else {
 			// Print mode: styled output
-			fmt.Println(ui.Info("Merged values for %s:", releaseName))
+			fmt.Println(ui.Info("Rendered values for %s:", releaseName))
 			fmt.Println(ui.Code(string(valuesYAML)))
 		}
 	}
 }
 
+// bufferValuesLogger holds the values logged by charts installing
+// concurrently until flush is called with the chart's name, so the values
+// of one chart are logged in one piece rather than interleaved with
+// another's. flush must be called from a single goroutine.
+func bufferValuesLogger(logger helm.ValuesLogger) (buffered helm.ValuesLogger, flush func(name string)) {
+	if logger == nil {
+		return nil, func(string) {}
+	}
+
+	var mu sync.Mutex
+	pending := map[string]map[string]interface{}{}
+	buffered = func(releaseName string, values map[string]interface{}) {
+		mu.Lock()
+		defer mu.Unlock()
+		pending[releaseName] = values
+	}
+	flush = func(name string) {
+		mu.Lock()
+		values, ok := pending[name]
+		delete(pending, name)
+		mu.Unlock()
+		if ok {
+			logger(name, values)
+		}
+	}
+	return buffered, flush
+}
+
 // executeBootstrap performs the actual bootstrap operations
 // If ctrl is non-nil, sends updates to the dashboard; otherwise uses print-based output
 func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.DashboardController) error {
@@ -298,7 +540,7 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 			} else {
 				fmt.Println(ui.Warning("Rolling back: deleting cluster..."))
 			}
-			if delErr := kind.DeleteCluster(ctx, cfg.Cluster.Name); delErr != nil {
+			if delErr := kind.DeleteCluster(ctx, cfg.Cluster.Name, cfg.GetKubeconfigPath()); delErr != nil {
 				if ctrl != nil {
 					ctrl.Log(fmt.Sprintf("Failed to delete cluster: %v", delErr))
 				} else {
@@ -360,16 +602,53 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 		}
 	}
 
-	// Phase: Create local registry
+	// Phase: Create local registry and pull-through caches
 	var registryManager *registry.Manager
-	if cfg.Cluster.Registry.Enabled {
+	var cacheManager *registry.PullThroughManager
+	if cfg.Cluster.Registry.UsesHostsDir() {
 		startPhase(phaseRegistry)
-		updateOp("Creating registry container...", -1)
-		registryManager = registry.NewManager(&cfg.Cluster.Registry)
-		if err := registryManager.Create(ctx); err != nil {
-			return handleFailure(phaseRegistry, fmt.Errorf("failed to create registry: %w", err))
+		var summary []string
+		if cfg.Cluster.Registry.Enabled {
+			updateOp("Creating registry container...", -1)
+			registryManager = registry.NewManager(&cfg.Cluster.Registry)
+			autoPorts := cfg.Cluster.AutoPorts()
+			if _, err := registryManager.ResolvePort(ctx, autoPorts); err != nil {
+				return handleFailure(phaseRegistry, fmt.Errorf("failed to resolve registry port: %w", err))
+			}
+			if err := checkRegistry(ctx, registryManager, func(msg string) { log("Warning: " + msg) }); err != nil {
+				return handleFailure(phaseRegistry, err)
+			}
+			if err := registryManager.Create(ctx); err != nil {
+				return handleFailure(phaseRegistry, fmt.Errorf("failed to create registry: %w", err))
+			}
+			// A stopped registry is published on its port again once started
+			if autoPorts {
+				if _, err := registryManager.ResolvePort(ctx, autoPorts); err != nil {
+					return handleFailure(phaseRegistry, fmt.Errorf("failed to resolve registry port: %w", err))
+				}
+			}
+			if registryManager.Secured() {
+				if err := secureRegistry(ctx, registryManager, log); err != nil {
+					return handleFailure(phaseRegistry, err)
+				}
+			}
+			if upBundleContents != nil && len(cfg.Crossplane.Providers) > 0 {
+				updateOp("Pushing bundled provider packages...", -1)
+				if err := upBundleContents.PushPackages(ctx, cfg, registryManager.GetRegistryHost(), registryManager.GetInternalHost()); err != nil {
+					return handleFailure(phaseRegistry, fmt.Errorf("failed to push bundled packages: %w", err))
+				}
+			}
+			summary = append(summary, fmt.Sprintf("Registry available at %s", registryManager.GetRegistryHost()))
+		}
+		if cfg.Cluster.Registry.PullThrough.Enabled {
+			updateOp("Creating pull-through cache containers...", -1)
+			cacheManager = registry.NewPullThroughManager(&cfg.Cluster.Registry.PullThrough)
+			if err := cacheManager.Create(ctx); err != nil {
+				return handleFailure(phaseRegistry, err)
+			}
+			summary = append(summary, fmt.Sprintf("%d pull-through cache(s)", len(cacheManager.Caches())))
 		}
-		completePhase(phaseRegistry, fmt.Sprintf("Registry available at localhost:%d", cfg.Cluster.Registry.GetPort()))
+		completePhase(phaseRegistry, strings.Join(summary, ", "))
 	}
 
 	// Phase: Configure trusted CAs
@@ -402,9 +681,21 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 		return handleFailure(phaseCluster, fmt.Errorf("failed to check cluster status: %w", err))
 	}
 
+	var portMap *kind.PortMap
 	if exists {
+		// Keep the configured kubeconfig in sync with the existing cluster
+		if err := kind.ExportKubeConfig(cfg.Cluster.Name, cfg.GetKubeconfigPath(), cfg.Cluster.Kubeconfig.Internal); err != nil {
+			log(fmt.Sprintf("Warning: %v", err))
+		}
 		skipPhase(phaseCluster, "already exists")
 	} else {
+		// Settle host ports before the Kind config is built
+		portMap, err = kind.ResolvePortMappings(cfg)
+		if err != nil {
+			return handleFailure(phaseCluster, err)
+		}
+		portMap.Apply(cfg)
+
 		// Display cluster configuration details
 		nodeImage, imageSource := kind.GetNodeImage(cfg)
 		if ctrl != nil {
@@ -421,6 +712,19 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 				fmt.Println(ui.KeyValueIndented("Node Image", ui.Muted("Kind default"), 2))
 				fmt.Println(ui.KeyValueIndented("Source", imageSource, 2))
 			}
+		}
+		for _, a := range portMap.Mappings {
+			if a.HostPort == a.RequestedPort {
+				continue
+			}
+			msg := fmt.Sprintf("%d in use, using %d for container port %d/%s", a.RequestedPort, a.HostPort, a.ContainerPort, a.Protocol)
+			if ctrl != nil {
+				log("Host port " + msg)
+			} else {
+				fmt.Println(ui.KeyValueIndented("Host Port", msg, 2))
+			}
+		}
+		if ctrl == nil {
 			fmt.Println()
 		}
 
@@ -460,25 +764,49 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 				return handleFailure(phaseCluster, fmt.Errorf("failed to update CA certificates: %w", updateCAErr))
 			}
 		}
+
+		// Point containerd at the registry mirrors
+		if kind.HasRegistryMirrors(cfg) {
+			updateOp("Configuring registry mirrors on nodes...", -1)
+			var mirrorErr error
+			if ctrl != nil {
+				mirrorErr = kind.ConfigureRegistryMirrors(ctx, cfg)
+			} else {
+				mirrorErr = ui.RunSpinnerWithContext(ctx, "Configuring registry mirrors on nodes", func(spinnerCtx context.Context) error {
+					return kind.ConfigureRegistryMirrors(spinnerCtx, cfg)
+				})
+			}
+			if mirrorErr != nil {
+				return handleFailure(phaseCluster, fmt.Errorf("failed to configure registry mirrors: %w", mirrorErr))
+			}
+		}
 	}
 
-	// Configure registry for cluster nodes if enabled
-	if cfg.Cluster.Registry.Enabled && registryManager != nil {
+	// Configure the registry and pull-through caches for cluster nodes if enabled
+	if registryManager != nil || cacheManager != nil {
 		updateOp("Configuring registry on cluster nodes...", -1)
-		var configErr error
-		if ctrl != nil {
-			if err := registryManager.ConfigureNodes(ctx, cfg.Cluster.Name); err != nil {
-				configErr = err
-			} else {
-				configErr = registryManager.ConnectToNetwork(ctx, "kind")
+		configure := func(ctx context.Context) error {
+			if registryManager != nil {
+				if err := registryManager.ConfigureNodes(ctx, cfg.Cluster.Name); err != nil {
+					return err
+				}
+				if err := registryManager.ConnectToNetwork(ctx, "kind"); err != nil {
+					return err
+				}
 			}
-		} else {
-			configErr = ui.RunSpinnerWithContext(ctx, "Configuring registry on cluster nodes", func(spinnerCtx context.Context) error {
-				if err := registryManager.ConfigureNodes(spinnerCtx, cfg.Cluster.Name); err != nil {
+			if cacheManager != nil {
+				if err := cacheManager.ConfigureNodes(ctx, cfg.Cluster.Name); err != nil {
 					return err
 				}
-				return registryManager.ConnectToNetwork(spinnerCtx, "kind")
-			})
+				return cacheManager.ConnectToNetwork(ctx, "kind")
+			}
+			return nil
+		}
+		var configErr error
+		if ctrl != nil {
+			configErr = configure(ctx)
+		} else {
+			configErr = ui.RunSpinnerWithContext(ctx, "Configuring registry on cluster nodes", configure)
 		}
 		if configErr != nil {
 			return handleFailure(phaseCluster, fmt.Errorf("failed to configure registry: %w", configErr))
@@ -493,16 +821,29 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 		var result *kind.PreloadResult
 		var imageCacheErr error
 
-		// First attempt: Check for local images
-		imageCacheFn := func(spinnerCtx context.Context) error {
-			var err error
-			result, err = kind.PreloadImages(spinnerCtx, cfg.Cluster.Name, cfg, func(msg string) {
+		// Loader messages go to the operation line or the log; the dashboard
+		// also gets a progress bar per image
+		preloadOpts := kind.PreloadOptions{
+			Log: func(msg string) {
 				if ctrl != nil {
 					updateOp(msg, -1)
 				} else {
 					log(msg)
 				}
-			})
+			},
+		}
+		// Pods use the bundled images under their own names
+		preloadOpts.ImportToNodes = upBundleContents != nil
+		if ctrl != nil {
+			preloadOpts.Progress = func(p kind.ImageProgress) {
+				ctrl.UpdateTask(p.Image, p.Status, p.Progress, p.Done, p.Failed)
+			}
+		}
+
+		// First attempt: Check for local images
+		imageCacheFn := func(spinnerCtx context.Context) error {
+			var err error
+			result, err = kind.PreloadImagesWithOptions(spinnerCtx, cfg.Cluster.Name, cfg, preloadOpts)
 			return err
 		}
 
@@ -512,8 +853,9 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 			imageCacheErr = ui.RunSpinnerWithContext(ctx, "Pre-loading images", imageCacheFn)
 		}
 
-		// If no images were loaded but some are missing, offer to pull them
-		if imageCacheErr == nil && result != nil && result.LoadedCount == 0 && len(result.MissingImages) > 0 {
+		// If no images were loaded but some are missing from the daemon, offer to pull them
+		if imageCacheErr == nil && result != nil && result.LoadedCount == 0 && len(result.MissingImages) > 0 &&
+			cfg.Crossplane.ImageCache.GetSource() == config.ImageSourceDaemon {
 			shouldPull := upPullImages // Check flag first
 
 			// If flag not set, prompt in TTY mode
@@ -550,13 +892,7 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 				if pulledCount > 0 {
 					updateOp("Loading pulled images into cluster...", -1)
 					reloadFn := func(spinnerCtx context.Context) error {
-						_, err := kind.PreloadImages(spinnerCtx, cfg.Cluster.Name, cfg, func(msg string) {
-							if ctrl != nil {
-								updateOp(msg, -1)
-							} else {
-								log(msg)
-							}
-						})
+						_, err := kind.PreloadImagesWithOptions(spinnerCtx, cfg.Cluster.Name, cfg, preloadOpts)
 						return err
 					}
 
@@ -583,27 +919,19 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 	// Phase: Connect to cluster
 	startPhase(phaseConnect)
 	updateOp("Connecting to cluster...", -1)
-	var kubeClient *kubernetes.Clientset
+	var conn *kube.Connection
 	var dynamicClient dynamic.Interface
 	var connectErr error
 
 	connectFn := func() error {
 		var err error
-		kubeClient, err = kind.GetKubeClient(cfg.Cluster.Name)
+		conn, err = kind.Connect(cfg.Cluster.Name)
 		if err != nil {
 			return fmt.Errorf("failed to get kubernetes client: %w", err)
 		}
 
-		restConfig, err := kind.GetRESTConfig(cfg.Cluster.Name)
-		if err != nil {
-			return fmt.Errorf("failed to get REST config: %w", err)
-		}
-
-		dynamicClient, err = dynamic.NewForConfig(restConfig)
-		if err != nil {
-			return fmt.Errorf("failed to create dynamic client: %w", err)
-		}
-		return nil
+		dynamicClient, err = conn.Dynamic()
+		return err
 	}
 
 	if ctrl != nil {
@@ -618,7 +946,8 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 	}
 
 	// Create diagnostics collector
-	diagCollector := diagnostics.NewCollector(kubeClient, dynamicClient)
+	kubeClient := conn.Client
+	diagCollector := diagnostics.NewCollector(kubeClient, dynamicClient, conn.RESTClientGetter())
 
 	// Initialize bootstrap context
 	bc = &bootstrapContext{
@@ -634,10 +963,23 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 		if err := createRegistryConfigMap(ctx, kubeClient, &cfg.Cluster.Registry); err != nil {
 			log(fmt.Sprintf("Warning: Failed to create registry ConfigMap: %v", err))
 		}
+		if cfg.Cluster.Registry.Auth {
+			if err := createRegistryPullSecret(ctx, kubeClient, registryManager); err != nil {
+				return handleFailure(phaseConnect, err)
+			}
+		}
+	}
+
+	// Record the host ports on the cluster so status and scripts can find them
+	if err := recordPortMap(ctx, kubeClient, portMap); err != nil {
+		log(fmt.Sprintf("Warning: %v", err))
 	}
 
 	// Create Helm installer for chart installations
-	helmInstaller := helm.NewInstaller(kubeClient)
+	helmInstaller := helm.NewInstaller(conn)
+	if upBundleContents != nil {
+		helmInstaller.SetLocalCharts(upBundleContents.ChartPaths())
+	}
 
 	// Phase: Install pre-crossplane charts
 	if pt.GetPhase(phasePreCrossplane) != nil {
@@ -649,7 +991,7 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 	// Phase: Install Crossplane
 	if !upSkipCrossplane {
 		startPhase(phaseCrossplane)
-		installer := crossplane.NewInstaller(kubeClient)
+		installer := crossplane.NewInstaller(conn)
 		crossplaneCfg := cfg.Crossplane
 
 		// Determine repository URL and name
@@ -665,10 +1007,15 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 		// Create values logger for displaying merged values
 		valuesLogger := createValuesLogger(ctrl)
 
-		// Build installation steps
-		steps := []string{
-			"Adding Helm repository",
-			"Creating namespace",
+		// Build installation steps; a bundled chart or OCI registry needs no
+		// repository, and one with credentials or TLS settings is not added
+		// to the Helm repositories file
+		steps := []string{"Adding Helm repository", "Creating namespace"}
+		if upBundleContents != nil {
+			installer.SetLocalCharts(upBundleContents.ChartPaths())
+		}
+		if upBundleContents != nil || config.IsOCIRepo(repoURL) || crossplaneCfg.RepoAuth != nil || crossplaneCfg.RepoTLS != nil {
+			steps = steps[1:]
 		}
 		if crossplaneCfg.RegistryCaBundle != nil {
 			steps = append(steps, "Creating registry CA bundle")
@@ -692,7 +1039,8 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 					installErr = installer.CreateRegistryCaBundle(ctx, crossplaneCfg.RegistryCaBundle, cfg.Cluster.TrustedCAs.Workloads)
 				case "Installing Helm chart":
 					opts := helm.InstallOptions{
-						ValuesLogger: valuesLogger,
+						ValuesLogger:  valuesLogger,
+						ValuesContext: helm.NewValuesContext(cfg),
 					}
 					installErr = installer.InstallHelmChartWithOptions(ctx, crossplaneCfg, repoURL, repoName, opts)
 				}
@@ -712,7 +1060,8 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 					return installer.CreateRegistryCaBundle(stepCtx, crossplaneCfg.RegistryCaBundle, cfg.Cluster.TrustedCAs.Workloads)
 				case "Installing Helm chart":
 					opts := helm.InstallOptions{
-						ValuesLogger: valuesLogger,
+						ValuesLogger:  valuesLogger,
+						ValuesContext: helm.NewValuesContext(cfg),
 					}
 					return installer.InstallHelmChartWithOptions(stepCtx, crossplaneCfg, repoURL, repoName, opts)
 				default:
@@ -778,7 +1127,7 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 		}
 
 		// Install providers
-		installer := crossplane.NewInstaller(kubeClient)
+		installer := crossplane.NewInstaller(conn)
 		var providerErr error
 
 		if ctrl != nil {
@@ -786,7 +1135,7 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 			for i, name := range providerNames {
 				updateOp(fmt.Sprintf("Installing %s...", name), float64(i)/float64(len(providerNames)))
 				provider := providerMap[name]
-				if err := installer.InstallProvider(ctx, provider.Name, provider.Package); err != nil {
+				if err := installer.InstallProvider(ctx, provider.Name, provider.Package, providerPullSecrets(registryManager, provider.Package)...); err != nil {
 					providerErr = err
 					break
 				}
@@ -795,7 +1144,7 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 			// Print mode: use progress bar
 			providerErr = ui.RunProgress("Installing providers", providerNames, func(name string) error {
 				provider := providerMap[name]
-				return installer.InstallProvider(ctx, provider.Name, provider.Package)
+				return installer.InstallProvider(ctx, provider.Name, provider.Package, providerPullSecrets(registryManager, provider.Package)...)
 			})
 		}
 
@@ -856,7 +1205,7 @@ func executeBootstrap(ctx context.Context, pt *ui.PhaseTracker, ctrl *ui.Dashboa
 	// Phase: Apply compositions
 	if pt.GetPhase(phaseCompositions) != nil {
 		startPhase(phaseCompositions)
-		installer := crossplane.NewInstaller(kubeClient)
+		installer := crossplane.NewInstaller(conn)
 		for _, source := range cfg.Compositions.Sources {
 			updateOp(fmt.Sprintf("Applying %s...", source.Path), -1)
 			if err := installer.ApplyCompositions(ctx, source); err != nil {
@@ -964,8 +1313,9 @@ func waitForProviders(ctx context.Context, installer *crossplane.Installer, ctrl
 	}
 }
 
-// installChartsForPhase installs all charts configured for a specific phase
-// installChartsForPhaseWithTracker installs charts and tracks progress with the PhaseTracker
+// installChartsForPhaseWithTracker installs the charts of a phase in
+// dependency order, independent charts concurrently, and tracks progress
+// with the PhaseTracker
 func installChartsForPhaseWithTracker(ctx context.Context, helmInstaller *helm.Installer, phase string, bc *bootstrapContext, pt *ui.PhaseTracker, phaseName string, ctrl *ui.DashboardController) error {
 	charts := getChartsForPhase(phase)
 	if len(charts) == 0 {
@@ -979,53 +1329,77 @@ func installChartsForPhaseWithTracker(ctx context.Context, helmInstaller *helm.I
 		pt.StartPhase(phaseName)
 	}
 
-	// Create values logger for displaying merged values
-	valuesLogger := createValuesLogger(ctrl)
-
-	// Build chart names for progress display
-	chartNames := make([]string, len(charts))
-	chartMap := make(map[string]config.ChartConfig)
-	for i, chart := range charts {
-		chartNames[i] = chart.Name
-		chartMap[chart.Name] = chart
+	// Create install options rendering values templates, with a logger for
+	// displaying the rendered values once each chart is done
+	valuesLogger, flushValues := bufferValuesLogger(createValuesLogger(ctrl))
+	opts := helm.InstallOptions{
+		ValuesLogger:  valuesLogger,
+		ValuesContext: helm.NewValuesContext(cfg),
 	}
 
 	// Track failed chart for diagnostics
 	var failedChart config.ChartConfig
-	var installErr error
-
-	// Create install options with values logger
-	opts := helm.InstallOptions{
-		ValuesLogger: valuesLogger,
+	var inFlight []string
+	installed := 0
+	graphOpts := helm.GraphOptions{
+		Concurrency: upChartConcurrency,
+		OnStart: func(chart config.ChartConfig) {
+			inFlight = append(inFlight, chart.Name)
+			if ctrl != nil {
+				ctrl.UpdateTask(chart.Name, "installing", 0, false, false)
+				ctrl.UpdateOperation(fmt.Sprintf("Installing %s...", strings.Join(inFlight, ", ")), float64(installed)/float64(len(charts)))
+			}
+		},
+		OnDone: func(chart config.ChartConfig, err error) {
+			flushValues(chart.Name)
+			inFlight = slices.DeleteFunc(inFlight, func(name string) bool { return name == chart.Name })
+			if err != nil {
+				if failedChart.Name == "" {
+					failedChart = chart
+				}
+			} else {
+				installed++
+			}
+			if ctrl != nil {
+				if err != nil {
+					ctrl.UpdateTask(chart.Name, "failed", 0, true, true)
+				} else {
+					ctrl.UpdateTask(chart.Name, "installed", 1, true, false)
+				}
+			}
+		},
+	}
+	install := func(ctx context.Context, chart config.ChartConfig) error {
+		return helmInstaller.InstallChartFromConfigWithOptions(ctx, chart, opts)
 	}
 
+	var installErr error
 	if ctrl != nil {
-		// Dashboard mode: install with updates
-		for i, name := range chartNames {
-			ctrl.UpdateOperation(fmt.Sprintf("Installing %s...", name), float64(i)/float64(len(chartNames)))
-			chart := chartMap[name]
-			if err := helmInstaller.InstallChartFromConfigWithOptions(ctx, chart, opts); err != nil {
-				failedChart = chart
-				installErr = err
-				break
+		// Dashboard mode: queued charts are listed until they start
+		for _, chart := range charts {
+			status := "queued"
+			deps := slices.DeleteFunc(slices.Clone(chart.DependsOn), func(dep string) bool {
+				return !slices.ContainsFunc(charts, func(c config.ChartConfig) bool { return c.Name == dep })
+			})
+			if len(deps) > 0 {
+				status = "waiting for " + strings.Join(deps, ", ")
 			}
+			ctrl.UpdateTask(chart.Name, status, 0, false, false)
 		}
+		installErr = helm.InstallGraph(ctx, charts, graphOpts, install)
 	} else {
-		// Print mode: use progress bar
+		// Print mode: charts install concurrently, so a spinner replaces the
+		// per-chart progress bar
 		title := fmt.Sprintf("Installing %s charts", phase)
-		installErr = ui.RunProgress(title, chartNames, func(name string) error {
-			chart := chartMap[name]
-			if err := helmInstaller.InstallChartFromConfigWithOptions(ctx, chart, opts); err != nil {
-				failedChart = chart
-				return err
-			}
-			return nil
+		installErr = ui.RunSpinnerWithContext(ctx, title, func(spinnerCtx context.Context) error {
+			return helm.InstallGraph(spinnerCtx, charts, graphOpts, install)
 		})
 	}
 
 	if installErr != nil {
 		if failedChart.Name != "" {
 			showChartDiagnostics(bc, failedChart)
+			return fmt.Errorf("chart %s: %w", failedChart.Name, installErr)
 		}
 		return installErr
 	}
@@ -1134,6 +1508,119 @@ func showChartDiagnostics(bc *bootstrapContext, chart config.ChartConfig) {
 	report.Print(os.Stdout)
 }
 
+// recordPortMap saves the host ports the cluster uses. For an existing cluster
+// (pm is nil) the previously recorded map is kept, or the configured ports
+// are recorded for clusters created before port maps existed.
+func recordPortMap(ctx context.Context, client *kubernetes.Clientset, pm *kind.PortMap) error {
+	if pm == nil {
+		existing, err := kind.LoadPortMap(ctx, client)
+		if err != nil {
+			return err
+		}
+		if existing != nil {
+			pm = existing
+		} else {
+			pm = kind.ConfiguredPortMap(cfg)
+		}
+	}
+	pm.RegistryPort = 0
+	// Chart values templates see the host ports the cluster actually uses
+	pm.Apply(cfg)
+	if cfg.Cluster.Registry.Enabled {
+		pm.RegistryPort = cfg.Cluster.Registry.GetPort()
+	}
+	return kind.SavePortMap(ctx, client, pm)
+}
+
+// secureRegistry makes the rest of the bootstrap trust the TLS registry's CA
+// and logs in to an authenticated registry so images can be pushed to it.
+// The CA joins cluster.trustedCAs.registries, so nodes get it the same way
+// as any other registry CA, and the Crossplane registry CA bundle, so
+// provider packages can be pulled from the registry.
+func secureRegistry(ctx context.Context, m *registry.Manager, log func(string)) error {
+	assets, err := m.Assets()
+	if err != nil {
+		return err
+	}
+	if cas := m.TrustedCAs(assets); len(cas) > 0 {
+		cfg.Cluster.TrustedCAs.Registries = append(cfg.Cluster.TrustedCAs.Registries, cas...)
+		if cfg.Crossplane.RegistryCaBundle == nil {
+			cfg.Crossplane.RegistryCaBundle = &config.RegistryCaBundleConfig{}
+		}
+		cfg.Crossplane.RegistryCaBundle.CAFiles = append(cfg.Crossplane.RegistryCaBundle.CAFiles, assets.CAFile)
+	}
+	// Without a login image pushes fail, but the cluster still works
+	if err := m.Login(ctx, assets); err != nil {
+		log(fmt.Sprintf("Warning: %v", err))
+	}
+	return nil
+}
+
+// checkRegistry allows m to recreate a persistent registry with
+// --recreate-registry and warns about a registry container Create will
+// recreate, or keep with settings it cannot check
+func checkRegistry(ctx context.Context, m *registry.Manager, warn func(string)) error {
+	if upRecreateRegistry {
+		m.AllowRecreate()
+	}
+	state, err := m.State(ctx)
+	if err != nil {
+		return err
+	}
+	name := cfg.Cluster.Registry.GetName()
+	keep := cfg.Cluster.Registry.Persistent && !upRecreateRegistry
+	switch {
+	case state == registry.ContainerOutdated && !keep:
+		warn(fmt.Sprintf("Recreating registry %s with changed settings; its images are kept in volume %s", name, m.Volume()))
+	case state == registry.ContainerUnlabelled && keep:
+		warn(fmt.Sprintf("Persistent registry %s was created by an older kindplane and is kept as it is; run 'kindplane up --recreate-registry' to apply the configured settings", name))
+	case state == registry.ContainerUnlabelled:
+		warn(fmt.Sprintf("Moving the images of registry %s into volume %s and recreating it with its settings labelled", name, m.Volume()))
+	}
+	return nil
+}
+
+// createRegistryPullSecret creates the pull secret for the authenticated
+// local registry in the Crossplane namespace
+func createRegistryPullSecret(ctx context.Context, client *kubernetes.Clientset, m *registry.Manager) error {
+	assets, err := m.Assets()
+	if err != nil {
+		return err
+	}
+	secret, err := m.PullSecret(assets, crossplane.CrossplaneNamespace)
+	if err != nil {
+		return err
+	}
+
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: crossplane.CrossplaneNamespace}}
+	if _, err := client.CoreV1().Namespaces().Create(ctx, ns, metav1.CreateOptions{}); err != nil && !apierrors.IsAlreadyExists(err) {
+		return fmt.Errorf("failed to create namespace %s: %w", crossplane.CrossplaneNamespace, err)
+	}
+
+	secrets := client.CoreV1().Secrets(crossplane.CrossplaneNamespace)
+	if _, err := secrets.Create(ctx, secret, metav1.CreateOptions{}); err != nil {
+		if !apierrors.IsAlreadyExists(err) {
+			return fmt.Errorf("failed to create registry pull secret: %w", err)
+		}
+		if _, err := secrets.Update(ctx, secret, metav1.UpdateOptions{}); err != nil {
+			return fmt.Errorf("failed to update registry pull secret: %w", err)
+		}
+	}
+	return nil
+}
+
+// providerPullSecrets returns the pull secrets of a provider package: the
+// registry pull secret when the package is in the authenticated local registry
+func providerPullSecrets(m *registry.Manager, pkg string) []string {
+	if m == nil || !cfg.Cluster.Registry.Auth {
+		return nil
+	}
+	if strings.HasPrefix(pkg, m.GetInternalHost()+"/") || strings.HasPrefix(pkg, m.GetRegistryHost()+"/") {
+		return []string{registry.PullSecretName}
+	}
+	return nil
+}
+
 // createRegistryConfigMap creates the local-registry-hosting ConfigMap
 // This follows the KEP-1755 standard for documenting local registries
 // https://github.com/kubernetes/enhancements/tree/master/keps/sig-cluster-lifecycle/generic/1755-communicating-a-local-registry
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"gopkg.in/yaml.v3"
 )
@@ -28,20 +29,69 @@ type ClusterConfig struct {
 	KubernetesVersion string           `yaml:"kubernetesVersion" comment:"Kubernetes version to use (optional, uses Kind default if not specified)"`
 	Nodes             NodesConfig      `yaml:"nodes" comment:"Node configuration"`
 	PortMappings      []PortMapping    `yaml:"portMappings,omitempty" comment:"Port mappings from container to host" doc:"Format: containerPort:hostPort/protocol"`
+	PortStrategy      string           `yaml:"portStrategy,omitempty" comment:"How host ports are chosen: fixed (default) or auto" doc:"auto keeps each configured host port (port mappings and registry) when it is free and picks a free port otherwise,\nso several clusters can run side by side. The chosen ports are shown by 'kindplane status'"`
 	ExtraMounts       []ExtraMount     `yaml:"extraMounts,omitempty" comment:"Mount host paths into Kind nodes"`
 	Ingress           IngressConfig    `yaml:"ingress" comment:"Ingress controller readiness configuration" doc:"When enabled, adds required labels and port mappings for ingress controllers"`
 	Registry          RegistryConfig   `yaml:"registry,omitempty"`
 	TrustedCAs        TrustedCAsConfig `yaml:"trustedCAs,omitempty" comment:"Trusted CA certificates for private registries and workloads"`
+	RegistryMirrors   []RegistryMirror `yaml:"registryMirrors,omitempty" comment:"Mirrors nodes pull images through (e.g., route docker.io through Artifactory)" doc:"Mirror endpoints are tried in order before the registry itself. Use trustedCAs for mirrors with custom certificates"`
+	RegistryAuth      []RegistryAuth   `yaml:"registryAuth,omitempty" comment:"Credentials nodes use to pull from private registries and mirrors" doc:"Secrets are read from environment variables or Docker config files when the cluster is created, never from kindplane.yaml"`
 	RawConfigPath     string           `yaml:"rawConfigPath,omitempty" comment:"Optional: path to a raw Kind config file" doc:"Settings from kindplane.yaml will be merged on top (kindplane wins)"`
+	ContainerRuntime  string           `yaml:"containerRuntime,omitempty" comment:"Container runtime for Kind nodes and the registry: auto, docker or podman" doc:"Defaults to auto: KIND_EXPERIMENTAL_PROVIDER if set, otherwise Docker, then Podman"`
+	Kubeconfig        KubeconfigConfig `yaml:"kubeconfig,omitempty" comment:"Where to write the cluster's kubeconfig"`
 	NodeImage         string           `yaml:"nodeImage,omitempty" comment:"Full Kind node image path (optional)" doc:"Use when pulling images through a proxy registry like Artifactory\nIf not specified, defaults to \"kindest/node:v<kubernetesVersion>\"\nExamples:\n  - \"artifactory.example.com/kindest/node:v1.29.0\"\n  - \"artifactory.example.com/docker.io/kindest/node:v1.29.0\"\nNote: Ensure the proxy registry is configured in trustedCAs if using custom certificates"`
 }
 
+const (
+	// PortStrategyFixed uses the configured host ports as-is
+	PortStrategyFixed = "fixed"
+	// PortStrategyAuto replaces configured host ports that are already in use
+	PortStrategyAuto = "auto"
+)
+
+// AutoPorts reports whether host ports should be resolved automatically
+func (c *ClusterConfig) AutoPorts() bool {
+	return c.PortStrategy == PortStrategyAuto
+}
+
 // RegistryConfig contains local container registry configuration
 type RegistryConfig struct {
 	Enabled    bool   `yaml:"enabled"`              // Enable local container registry
 	Port       int    `yaml:"port,omitempty"`       // Host port for the registry (default: 5001)
 	Persistent bool   `yaml:"persistent,omitempty"` // Keep registry container after kindplane down
 	Name       string `yaml:"name,omitempty"`       // Registry container name (default: kind-registry)
+	TLS        bool   `yaml:"tls,omitempty"`        // Serve over HTTPS with a generated CA that nodes trust
+	Auth       bool   `yaml:"auth,omitempty"`       // Require htpasswd authentication (requires tls)
+
+	// PullThrough runs pull-through cache registries that nodes use as mirrors
+	PullThrough PullThroughConfig `yaml:"pullThrough,omitempty" comment:"Pull-through cache registries for upstream registries" doc:"Caches are shared by all kindplane clusters and keep their images across kindplane down"`
+}
+
+// DefaultPullThroughRegistries are the upstream registries cached when
+// pullThrough.registries is not set
+var DefaultPullThroughRegistries = []string{"docker.io", "ghcr.io", "xpkg.upbound.io", "quay.io"}
+
+// PullThroughConfig configures pull-through cache registries. Each upstream
+// registry gets its own registry container in proxy mode, with its storage
+// in a named volume, and nodes are configured to pull through it.
+type PullThroughConfig struct {
+	Enabled    bool     `yaml:"enabled"`              // Run pull-through caches (does not need registry.enabled)
+	Registries []string `yaml:"registries,omitempty"` // Upstream registry hosts (default: docker.io, ghcr.io, xpkg.upbound.io, quay.io)
+}
+
+// GetRegistries returns the upstream registries to cache, defaulting to
+// DefaultPullThroughRegistries
+func (p *PullThroughConfig) GetRegistries() []string {
+	if len(p.Registries) == 0 {
+		return DefaultPullThroughRegistries
+	}
+	return p.Registries
+}
+
+// UsesHostsDir reports whether nodes need containerd's registry hosts
+// directory, for the local registry or pull-through caches
+func (r *RegistryConfig) UsesHostsDir() bool {
+	return r.Enabled || r.PullThrough.Enabled
 }
 
 // GetPort returns the registry port, defaulting to 5001
@@ -60,6 +110,18 @@ func (r *RegistryConfig) GetName() string {
 	return r.Name
 }
 
+// GetInternalHost returns the registry host within the Kind network: the
+// registry container's name and the port the registry listens on in it
+func (r *RegistryConfig) GetInternalHost() string {
+	return fmt.Sprintf("%s:5000", r.GetName())
+}
+
+// KubeconfigConfig controls how the cluster's kubeconfig is written
+type KubeconfigConfig struct {
+	Path     string `yaml:"path,omitempty"`     // Kubeconfig file to write (default: $KUBECONFIG or ~/.kube/config)
+	Internal bool   `yaml:"internal,omitempty"` // Use the node's Docker network address instead of localhost
+}
+
 // NodesConfig defines the number of nodes in the cluster
 type NodesConfig struct {
 	ControlPlane int `yaml:"controlPlane"`
@@ -97,6 +159,26 @@ type RegistryCA struct {
 	CAFile string `yaml:"caFile" comment:"Path to CA certificate file on the host"`
 }
 
+// NodePath returns the path the CA is mounted at on the Kind nodes, in the
+// system CA certificates directory
+func (w WorkloadCA) NodePath() string {
+	return fmt.Sprintf("/usr/local/share/ca-certificates/%s.crt", w.Name)
+}
+
+// RegistryMirror routes image pulls from a registry through mirror endpoints
+type RegistryMirror struct {
+	Host      string   `yaml:"host" comment:"Registry whose pulls are mirrored (e.g., \"docker.io\")"`
+	Endpoints []string `yaml:"endpoints" comment:"Mirror URLs, tried in order (e.g., \"https://artifactory.example.com/v2/docker-remote\")"`
+}
+
+// RegistryAuth defines the credentials nodes use for a registry host
+type RegistryAuth struct {
+	Host         string `yaml:"host" comment:"Registry or mirror host (e.g., \"artifactory.example.com\")"`
+	UsernameEnv  string `yaml:"usernameEnv,omitempty" comment:"Environment variable holding the username"`
+	PasswordEnv  string `yaml:"passwordEnv,omitempty" comment:"Environment variable holding the password or token"`
+	DockerConfig string `yaml:"dockerConfig,omitempty" comment:"Docker config file to read the host's credentials from" doc:"Used when usernameEnv and passwordEnv are not set. Defaults to the Docker config of the current user;\ncredential helpers are supported"`
+}
+
 // WorkloadCA defines a CA certificate to mount for workloads
 type WorkloadCA struct {
 	Name   string `yaml:"name" comment:"Identifier for the CA (used in the mount path)"`
@@ -143,9 +225,12 @@ func (r *RegistryCaBundleConfig) ResolveCAFiles(workloadCAs []WorkloadCA) ([]str
 // CrossplaneConfig contains Crossplane installation settings
 type CrossplaneConfig struct {
 	Version          string                  `yaml:"version" comment:"Crossplane version to install"`
-	Repo             string                  `yaml:"repo,omitempty" comment:"Custom Helm repository URL (optional)" doc:"Use when pulling charts from a private registry or air-gapped environment\nIf not specified, defaults to https://charts.crossplane.io/stable"`
+	Repo             string                  `yaml:"repo,omitempty" comment:"Custom Helm repository URL (optional)" doc:"Use when pulling charts from a private registry or air-gapped environment\nAn oci:// URL pulls the chart from an OCI registry, e.g. oci://registry.example.com/charts\nIf not specified, defaults to https://charts.crossplane.io/stable"`
+	RepoAuth         *ChartAuth              `yaml:"repoAuth,omitempty" comment:"Credentials for the repository (optional)"`
+	RepoTLS          *ChartRepoTLS           `yaml:"repoTLS,omitempty" comment:"TLS settings for the repository (optional)"`
 	Values           map[string]interface{}  `yaml:"values,omitempty" comment:"Inline Helm values for Crossplane installation (optional)" doc:"These values will be merged with any values files specified below"`
 	ValuesFiles      []string                `yaml:"valuesFiles,omitempty" comment:"External values files (optional)" doc:"Values from files are loaded first, then inline values override them"`
+	TemplateValues   bool                    `yaml:"templateValues,omitempty" comment:"Render values and values files as Go templates with cluster facts"`
 	Providers        []ProviderConfig        `yaml:"providers,omitempty" comment:"Crossplane providers to install" doc:"Use full OCI package path with version tag"`
 	RegistryCaBundle *RegistryCaBundleConfig `yaml:"registryCaBundle,omitempty" comment:"Registry CA bundle for Crossplane (optional)" doc:"Required when pulling Configuration and Provider packages from private registries with custom certificates\nMultiple certificates can be specified and will be bundled together into one ConfigMap"`
 	ImageCache       *ImageCacheConfig       `yaml:"imageCache,omitempty" comment:"Image caching configuration"`
@@ -176,16 +261,50 @@ type ImageCacheConfig struct {
 	// Default: true
 	PreloadCrossplane *bool `yaml:"preloadCrossplane,omitempty"`
 
+	// PreloadCharts attempts to load the images of the charts in the charts list,
+	// found by rendering each chart with its values
+	// Default: true
+	PreloadCharts *bool `yaml:"preloadCharts,omitempty"`
+
 	// AdditionalImages is a list of extra images to pre-load (e.g., functions, custom images)
 	AdditionalImages []string `yaml:"additionalImages,omitempty"`
 
+	// Source selects where images are loaded from:
+	//   - daemon: the local Docker or Podman image store (default)
+	//   - registry: pulled by kindplane from their registries, using
+	//     cluster.trustedCAs and Docker credentials, without the daemon's image store
+	//   - layout: only the OCI image layout in LayoutDir, for air-gapped mirrors
+	Source string `yaml:"source,omitempty"`
+
+	// LayoutDir is an OCI image-layout directory searched for images before
+	// their registries; used with source registry or layout
+	LayoutDir string `yaml:"layoutDir,omitempty"`
+
 	// ImageOverrides maps provider packages to their actual container images
-	// Useful for providers that don't follow standard naming conventions
+	// Images are normally read from the package metadata; overrides are only
+	// needed when that is unavailable or should be replaced
 	// Key: provider package (e.g., "xpkg.upbound.io/upbound/provider-aws:v1.1.0")
 	// Value: list of actual container images
 	ImageOverrides map[string][]string `yaml:"imageOverrides,omitempty"`
 }
 
+const (
+	// ImageSourceDaemon loads images from the local container daemon
+	ImageSourceDaemon = "daemon"
+	// ImageSourceRegistry pulls images from registries without a daemon
+	ImageSourceRegistry = "registry"
+	// ImageSourceLayout reads images from an OCI image layout only
+	ImageSourceLayout = "layout"
+)
+
+// GetSource returns where images are loaded from (default: daemon)
+func (i *ImageCacheConfig) GetSource() string {
+	if i == nil || i.Source == "" {
+		return ImageSourceDaemon
+	}
+	return i.Source
+}
+
 // IsEnabled returns whether image caching is enabled (default: true)
 func (i *ImageCacheConfig) IsEnabled() bool {
 	if i == nil || i.Enabled == nil {
@@ -210,6 +329,14 @@ func (i *ImageCacheConfig) ShouldPreloadCrossplane() bool {
 	return *i.PreloadCrossplane
 }
 
+// ShouldPreloadCharts returns whether to preload images of configured charts (default: true)
+func (i *ImageCacheConfig) ShouldPreloadCharts() bool {
+	if i == nil || i.PreloadCharts == nil {
+		return true
+	}
+	return *i.PreloadCharts
+}
+
 // ProviderConfig defines a Crossplane provider
 type ProviderConfig struct {
 	Name    string `yaml:"name"`
@@ -255,16 +382,88 @@ type CompositionSource struct {
 // ChartConfig defines a Helm chart to install
 type ChartConfig struct {
 	Name            string                 `yaml:"name" comment:"Helm release name"`
-	Repo            string                 `yaml:"repo" comment:"Helm repository URL"`
-	Chart           string                 `yaml:"chart" comment:"Chart name in the repository"`
+	Repo            string                 `yaml:"repo,omitempty" comment:"Helm repository URL, or oci:// registry URL" doc:"For oci:// URLs the chart is pulled from <repo>/<chart>"`
+	Chart           string                 `yaml:"chart,omitempty" comment:"Chart name in the repository"`
+	Path            string                 `yaml:"path,omitempty" comment:"Local chart directory or .tgz archive, instead of repo and chart"`
+	Auth            *ChartAuth             `yaml:"auth,omitempty" comment:"Credentials for the repository (optional)" doc:"For oci:// repos without auth, credentials saved by helm registry login or docker login are used"`
+	TLS             *ChartRepoTLS          `yaml:"tls,omitempty" comment:"TLS settings for the repository (optional)"`
 	Version         string                 `yaml:"version,omitempty" comment:"Chart version (optional, latest if omitted)"`
 	Namespace       string                 `yaml:"namespace" comment:"Target namespace"`
 	CreateNamespace *bool                  `yaml:"createNamespace,omitempty" comment:"Create namespace if not exists (default: true)"`
 	Phase           string                 `yaml:"phase,omitempty" comment:"Installation phase: pre-crossplane, post-crossplane, post-providers, final (default), post-eso (deprecated)"`
+	DependsOn       []string               `yaml:"dependsOn,omitempty" comment:"Charts that must be installed first" doc:"Dependencies must be in the same or an earlier phase. Charts of a phase without\npending dependencies are installed in parallel"`
 	Wait            *bool                  `yaml:"wait,omitempty" comment:"Wait for resources to be ready (default: true)"`
 	Timeout         string                 `yaml:"timeout,omitempty" comment:"Installation timeout (default: 5m)"`
 	Values          map[string]interface{} `yaml:"values,omitempty" comment:"Inline values"`
 	ValuesFiles     []string               `yaml:"valuesFiles,omitempty" comment:"Paths to values files"`
+	TemplateValues  bool                   `yaml:"templateValues,omitempty" comment:"Render values and values files as Go templates with cluster facts" doc:"Off by default, so templates meant for charts that render values with tpl reach the chart as written"`
+	PostRender      *PostRenderConfig      `yaml:"postRender,omitempty" comment:"Patches applied to the rendered manifests (optional)" doc:"For settings a chart does not expose as values. Hook resources are not patched"`
+}
+
+// PostRenderConfig holds the patches applied to a chart's rendered
+// manifests before they are installed
+type PostRenderConfig struct {
+	Patches []ChartPatch `yaml:"patches,omitempty" comment:"Strategic-merge or JSON6902 patches, applied in order"`
+}
+
+// ChartPatch is a kustomize-style patch: a strategic-merge patch (a
+// partial resource) or a JSON6902 patch (a list of operations), applied to
+// the resources its target selects
+type ChartPatch struct {
+	Patch  string       `yaml:"patch,omitempty" comment:"Inline patch"`
+	Path   string       `yaml:"path,omitempty" comment:"File holding the patch, instead of patch"`
+	Target *PatchTarget `yaml:"target,omitempty" comment:"Resources to patch" doc:"Required for JSON6902 patches. A strategic-merge patch without a target patches the resource it names"`
+}
+
+// PatchTarget selects the resources a patch applies to; every field set
+// must match. Name and namespace may be regular expressions.
+type PatchTarget struct {
+	Group              string `yaml:"group,omitempty" comment:"API group (e.g., \"apps\")"`
+	Version            string `yaml:"version,omitempty" comment:"API version (e.g., \"v1\")"`
+	Kind               string `yaml:"kind,omitempty" comment:"Resource kind (e.g., \"Deployment\")"`
+	Name               string `yaml:"name,omitempty" comment:"Resource name"`
+	Namespace          string `yaml:"namespace,omitempty" comment:"Resource namespace"`
+	LabelSelector      string `yaml:"labelSelector,omitempty" comment:"Label selector (e.g., \"app.kubernetes.io/component=controller\")"`
+	AnnotationSelector string `yaml:"annotationSelector,omitempty" comment:"Annotation selector"`
+}
+
+// ChartAuth says where the credentials for a chart repository or registry
+// are read from: an environment variable or a file for each of the username
+// and password
+type ChartAuth struct {
+	UsernameEnv        string `yaml:"usernameEnv,omitempty" comment:"Environment variable holding the username"`
+	PasswordEnv        string `yaml:"passwordEnv,omitempty" comment:"Environment variable holding the password or token"`
+	UsernameFile       string `yaml:"usernameFile,omitempty" comment:"File holding the username, instead of usernameEnv"`
+	PasswordFile       string `yaml:"passwordFile,omitempty" comment:"File holding the password or token, instead of passwordEnv"`
+	PassCredentialsAll bool   `yaml:"passCredentialsAll,omitempty" comment:"Send the credentials to every host charts are downloaded from, not only the repository's" doc:"Only applies to https:// repos whose index points at chart archives on another host"`
+}
+
+// ChartRepoTLS configures how the certificate of a chart repository or
+// registry is verified
+type ChartRepoTLS struct {
+	CAFile                string `yaml:"caFile,omitempty" comment:"Path to the CA certificate the repository is verified with"`
+	WorkloadCARef         string `yaml:"workloadCARef,omitempty" comment:"Reference a workload CA by name (must be defined in cluster.trustedCAs.workloads)"`
+	InsecureSkipTLSVerify bool   `yaml:"insecureSkipTLSVerify,omitempty" comment:"Skip verification of the repository's certificate"`
+
+	// workloadCAFile is the CA file of WorkloadCARef, resolved by Load
+	workloadCAFile string
+}
+
+// GetCAFile returns the CA file the repository is verified with: CAFile, or
+// the file of the workload CA WorkloadCARef names
+func (t *ChartRepoTLS) GetCAFile() string {
+	if t == nil {
+		return ""
+	}
+	if t.CAFile != "" {
+		return t.CAFile
+	}
+	return t.workloadCAFile
+}
+
+// IsOCIRepo reports whether a chart repository URL is an OCI registry
+func IsOCIRepo(repo string) bool {
+	return strings.HasPrefix(repo, "oci://")
 }
 
 // ChartPhase constants
@@ -276,6 +475,14 @@ const (
 	ChartPhasePostESO        = "post-eso" // Deprecated: kept for backwards compatibility, use "final" instead
 )
 
+// chartPhaseOrder gives the position of each chart phase in a bootstrap
+var chartPhaseOrder = map[string]int{
+	ChartPhasePrecrossplane:  0,
+	ChartPhasePostCrossplane: 1,
+	ChartPhasePostProviders:  2,
+	ChartPhaseFinal:          3,
+}
+
 // GetPhase returns the chart phase, defaulting to final
 func (c *ChartConfig) GetPhase() string {
 	if c.Phase == "" {
@@ -334,10 +541,31 @@ func Load(path string) (*Config, error) {
 	if err := cfg.Validate(); err != nil {
 		return nil, fmt.Errorf("invalid configuration: %w", err)
 	}
+	cfg.resolveChartCAs()
 
 	return &cfg, nil
 }
 
+// resolveChartCAs resolves the workloadCARef of chart repositories to CA
+// files; Validate has checked that the references exist
+func (c *Config) resolveChartCAs() {
+	repoTLS := []*ChartRepoTLS{c.Crossplane.RepoTLS}
+	for _, chart := range c.Charts {
+		repoTLS = append(repoTLS, chart.TLS)
+	}
+	for _, t := range repoTLS {
+		if t == nil || t.WorkloadCARef == "" {
+			continue
+		}
+		for _, wl := range c.Cluster.TrustedCAs.Workloads {
+			if wl.Name == t.WorkloadCARef {
+				t.workloadCAFile = wl.CAFile
+				break
+			}
+		}
+	}
+}
+
 // Save writes the configuration to a file
 func (c *Config) Save(path string) error {
 	if path == "" {
@@ -375,11 +603,41 @@ func (c *Config) GetKubeContext() string {
 	return fmt.Sprintf("kind-%s", c.Cluster.Name)
 }
 
-// GetKubeconfigPath returns the path to the kubeconfig file
+// GetKubeconfigPath returns the path to the kubeconfig file the cluster's
+// context is written to. cluster.kubeconfig.path takes precedence, then the
+// first entry of $KUBECONFIG, then ~/.kube/config.
 func (c *Config) GetKubeconfigPath() string {
+	if p := c.Cluster.Kubeconfig.Path; p != "" {
+		return expandHome(p)
+	}
+	if env := os.Getenv("KUBECONFIG"); env != "" {
+		for _, p := range filepath.SplitList(env) {
+			if p != "" {
+				return p
+			}
+		}
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return ""
 	}
 	return filepath.Join(home, ".kube", "config")
 }
+
+// GetDockerConfig returns the Docker config path with ~ expanded, or "" for
+// the current user's Docker config
+func (a RegistryAuth) GetDockerConfig() string {
+	return expandHome(a.DockerConfig)
+}
+
+// expandHome replaces a leading ~ with the user's home directory
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") {
+		return path
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return path
+	}
+	return filepath.Join(home, strings.TrimPrefix(path, "~"))
+}
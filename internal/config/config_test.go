@@ -0,0 +1,53 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestGetKubeconfigPath(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("no home directory")
+	}
+
+	sep := string(os.PathListSeparator)
+	tests := []struct {
+		name       string
+		path       string
+		kubeconfig string
+		expected   string
+	}{
+		{
+			name:     "default",
+			expected: filepath.Join(home, ".kube", "config"),
+		},
+		{
+			name:       "first KUBECONFIG entry",
+			kubeconfig: "/tmp/a" + sep + "/tmp/b",
+			expected:   "/tmp/a",
+		},
+		{
+			name:       "configured path wins over KUBECONFIG",
+			path:       "/ci/kubeconfig",
+			kubeconfig: "/tmp/a",
+			expected:   "/ci/kubeconfig",
+		},
+		{
+			name:     "home is expanded",
+			path:     "~/.kube/kindplane",
+			expected: filepath.Join(home, ".kube", "kindplane"),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("KUBECONFIG", tt.kubeconfig)
+			cfg := &Config{Cluster: ClusterConfig{Kubeconfig: KubeconfigConfig{Path: tt.path}}}
+			if got := cfg.GetKubeconfigPath(); got != tt.expected {
+				t.Errorf("GetKubeconfigPath() = %q, want %q", got, tt.expected)
+			}
+		})
+	}
+}
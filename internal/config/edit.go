@@ -0,0 +1,161 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+
+	"gopkg.in/yaml.v3"
+)
+
+// AddAdditionalImages appends images to crossplane.imageCache.additionalImages
+// in the config file at path, skipping ones already listed, and returns the
+// images it added. When the list already has items, the new ones are
+// inserted after the last of them and the rest of the file is kept byte for
+// byte. Otherwise the file is edited as a YAML node tree, which keeps
+// comments and the order of existing keys but re-indents the file and drops
+// blank lines. The file is not written when there is nothing to add.
+func AddAdditionalImages(path string, images []string) ([]string, error) {
+	if path == "" {
+		path = DefaultConfigFile
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read config file: %w", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse config file: %w", err)
+	}
+	if doc.Kind != yaml.DocumentNode || len(doc.Content) == 0 || doc.Content[0].Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("config file %s is not a YAML mapping", path)
+	}
+
+	crossplane := childNode(doc.Content[0], "crossplane", yaml.MappingNode)
+	imageCache := childNode(crossplane, "imageCache", yaml.MappingNode)
+	list := childNode(imageCache, "additionalImages", yaml.SequenceNode)
+
+	listed := make(map[string]bool, len(list.Content))
+	for _, item := range list.Content {
+		listed[item.Value] = true
+	}
+	var added []string
+	for _, image := range images {
+		if listed[image] {
+			continue
+		}
+		listed[image] = true
+		added = append(added, image)
+	}
+	if len(added) == 0 {
+		return nil, nil
+	}
+
+	if out, ok := spliceItems(data, list, added); ok {
+		if err := os.WriteFile(path, out, info.Mode().Perm()); err != nil {
+			return nil, fmt.Errorf("failed to write config file: %w", err)
+		}
+		return added, nil
+	}
+
+	// An empty flow sequence ([]) reads better in block style once filled
+	if len(list.Content) == 0 {
+		list.Style = 0
+	}
+	for _, image := range added {
+		list.Content = append(list.Content, &yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: image})
+	}
+
+	var buf bytes.Buffer
+	encoder := yaml.NewEncoder(&buf)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := encoder.Close(); err != nil {
+		return nil, fmt.Errorf("failed to encode config: %w", err)
+	}
+	if err := os.WriteFile(path, buf.Bytes(), info.Mode().Perm()); err != nil {
+		return nil, fmt.Errorf("failed to write config file: %w", err)
+	}
+	return added, nil
+}
+
+// spliceItems inserts values into data after the last item of the block
+// sequence list, with that item's indentation. It reports false when the
+// list has no single-line item to follow, such as an empty or flow list.
+func spliceItems(data []byte, list *yaml.Node, values []string) ([]byte, bool) {
+	if len(list.Content) == 0 || list.Style&yaml.FlowStyle != 0 {
+		return nil, false
+	}
+	last := list.Content[len(list.Content)-1]
+	if last.Kind != yaml.ScalarNode || last.Style&(yaml.LiteralStyle|yaml.FoldedStyle) != 0 {
+		return nil, false
+	}
+
+	lines := bytes.SplitAfter(data, []byte("\n"))
+	if last.Line < 1 || last.Line > len(lines) {
+		return nil, false
+	}
+	line := lines[last.Line-1]
+	if last.Column < 1 || last.Column > len(line) {
+		return nil, false
+	}
+	prefix := line[:last.Column-1]
+	if !bytes.HasSuffix(bytes.TrimRight(prefix, " "), []byte("-")) {
+		return nil, false
+	}
+	newline := []byte("\n")
+	if bytes.HasSuffix(line, []byte("\r\n")) {
+		newline = []byte("\r\n")
+	}
+
+	var out bytes.Buffer
+	for _, l := range lines[:last.Line] {
+		out.Write(l)
+	}
+	if !bytes.HasSuffix(line, newline) {
+		out.Write(newline)
+	}
+	for _, value := range values {
+		item, err := yaml.Marshal(value)
+		if err != nil {
+			return nil, false
+		}
+		out.Write(prefix)
+		out.Write(bytes.TrimSuffix(item, []byte("\n")))
+		out.Write(newline)
+	}
+	for _, l := range lines[last.Line:] {
+		out.Write(l)
+	}
+	return out.Bytes(), true
+}
+
+// childNode returns the value of key in a mapping node, adding the key, or
+// replacing an empty value, with a node of the given kind
+func childNode(mapping *yaml.Node, key string, kind yaml.Kind) *yaml.Node {
+	for i := 0; i+1 < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value != key {
+			continue
+		}
+		value := mapping.Content[i+1]
+		if value.Kind == yaml.ScalarNode && value.Tag == "!!null" {
+			value.Kind, value.Tag, value.Value = kind, "", ""
+		}
+		return value
+	}
+
+	value := &yaml.Node{Kind: kind}
+	mapping.Content = append(mapping.Content,
+		&yaml.Node{Kind: yaml.ScalarNode, Tag: "!!str", Value: key},
+		value,
+	)
+	return value
+}
@@ -0,0 +1,149 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestAddAdditionalImages(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		images   []string
+		want     []string
+		contains []string
+	}{
+		{
+			name: "appends to existing list and keeps comments",
+			input: `# kindplane configuration
+cluster:
+  name: dev # the cluster name
+crossplane:
+  version: "2.1.0"
+  imageCache:
+    # Images needed by our functions
+    additionalImages:
+      - xpkg.upbound.io/crossplane-contrib/function-patch-and-transform:v0.8.0
+`,
+			images: []string{"xpkg.upbound.io/crossplane-contrib/function-patch-and-transform:v0.8.0", "ghcr.io/org/app:v1"},
+			want:   []string{"ghcr.io/org/app:v1"},
+			contains: []string{
+				"# kindplane configuration",
+				"name: dev # the cluster name",
+				"# Images needed by our functions",
+				"- ghcr.io/org/app:v1",
+			},
+		},
+		{
+			name: "keeps blank lines and indentation",
+			input: `cluster:
+    name: dev
+
+crossplane:
+    imageCache:
+        additionalImages:
+        -   busybox:1.36 # shell
+
+        # trailing comment
+    version: "2.1.0"
+`,
+			images: []string{"ghcr.io/org/app:v1", "ghcr.io/org/app:v2"},
+			want:   []string{"ghcr.io/org/app:v1", "ghcr.io/org/app:v2"},
+			contains: []string{`cluster:
+    name: dev
+
+crossplane:
+    imageCache:
+        additionalImages:
+        -   busybox:1.36 # shell
+        -   ghcr.io/org/app:v1
+        -   ghcr.io/org/app:v2
+
+        # trailing comment
+    version: "2.1.0"
+`},
+		},
+		{
+			name: "creates imageCache",
+			input: `crossplane:
+  version: "2.1.0"
+`,
+			images:   []string{"busybox:1.36"},
+			want:     []string{"busybox:1.36"},
+			contains: []string{"imageCache:\n    additionalImages:\n      - busybox:1.36"},
+		},
+		{
+			name: "fills empty flow list",
+			input: `crossplane:
+  imageCache:
+    additionalImages: []
+`,
+			images:   []string{"busybox:1.36"},
+			want:     []string{"busybox:1.36"},
+			contains: []string{"additionalImages:\n      - busybox:1.36"},
+		},
+		{
+			name: "fills null imageCache",
+			input: `crossplane:
+  imageCache:
+`,
+			images: []string{"busybox:1.36"},
+			want:   []string{"busybox:1.36"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "kindplane.yaml")
+			if err := os.WriteFile(path, []byte(tt.input), 0600); err != nil {
+				t.Fatal(err)
+			}
+
+			added, err := AddAdditionalImages(path, tt.images)
+			if err != nil {
+				t.Fatalf("AddAdditionalImages() error = %v", err)
+			}
+			if !reflect.DeepEqual(added, tt.want) {
+				t.Errorf("added = %v, want %v", added, tt.want)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, want := range tt.contains {
+				if !strings.Contains(string(data), want) {
+					t.Errorf("expected file to contain %q, got:\n%s", want, data)
+				}
+			}
+			if info, _ := os.Stat(path); info.Mode().Perm() != 0600 {
+				t.Errorf("file mode = %v, want 0600", info.Mode().Perm())
+			}
+
+			// The result still parses as a config with the images listed
+			var cfg Config
+			if err := yaml.Unmarshal(data, &cfg); err != nil {
+				t.Fatalf("edited config does not parse: %v", err)
+			}
+			for _, img := range tt.images {
+				found := false
+				for _, listed := range cfg.Crossplane.ImageCache.AdditionalImages {
+					found = found || listed == img
+				}
+				if !found {
+					t.Errorf("expected %s in additionalImages", img)
+				}
+			}
+
+			// Adding the same images again is a no-op
+			if again, err := AddAdditionalImages(path, tt.images); err != nil || len(again) != 0 {
+				t.Errorf("second AddAdditionalImages() = %v, %v; want nothing added", again, err)
+			}
+		})
+	}
+}
@@ -2,9 +2,14 @@ package config
 
 import (
 	"fmt"
+	"net/url"
 	"os"
+	"path/filepath"
+	"slices"
 	"strings"
 	"time"
+
+	"gopkg.in/yaml.v3"
 )
 
 // Validate checks the configuration for errors
@@ -24,6 +29,18 @@ func (c *Config) Validate() error {
 		errs = append(errs, "cluster.nodes.workers cannot be negative")
 	}
 
+	switch c.Cluster.ContainerRuntime {
+	case "", "auto", "docker", "podman":
+	default:
+		errs = append(errs, fmt.Sprintf("cluster.containerRuntime must be auto, docker, or podman (got: %s)", c.Cluster.ContainerRuntime))
+	}
+
+	switch c.Cluster.PortStrategy {
+	case "", PortStrategyFixed, PortStrategyAuto:
+	default:
+		errs = append(errs, fmt.Sprintf("cluster.portStrategy must be fixed or auto (got: %s)", c.Cluster.PortStrategy))
+	}
+
 	// Validate port mappings
 	for i, pm := range c.Cluster.PortMappings {
 		if pm.ContainerPort <= 0 {
@@ -50,6 +67,26 @@ func (c *Config) Validate() error {
 		if port < 1 || port > 65535 {
 			errs = append(errs, fmt.Sprintf("cluster.registry.port must be between 1 and 65535 (got: %d)", port))
 		}
+		if c.Cluster.Registry.Auth && !c.Cluster.Registry.TLS {
+			errs = append(errs, "cluster.registry.auth requires cluster.registry.tls, as credentials must not be sent in clear text")
+		}
+	} else if c.Cluster.Registry.TLS || c.Cluster.Registry.Auth {
+		errs = append(errs, "cluster.registry.tls and cluster.registry.auth require cluster.registry.enabled")
+	}
+
+	if c.Cluster.Registry.PullThrough.Enabled {
+		upstreams := make(map[string]bool)
+		for i, host := range c.Cluster.Registry.PullThrough.Registries {
+			switch {
+			case host == "":
+				errs = append(errs, fmt.Sprintf("cluster.registry.pullThrough.registries[%d] is empty", i))
+			case strings.Contains(host, "/"):
+				errs = append(errs, fmt.Sprintf("cluster.registry.pullThrough.registries[%d] must be a registry host, not a URL or repository (got: %s)", i, host))
+			case upstreams[host]:
+				errs = append(errs, fmt.Sprintf("cluster.registry.pullThrough.registries has duplicate host: %s", host))
+			}
+			upstreams[host] = true
+		}
 	}
 
 	// Validate trusted CAs config
@@ -77,6 +114,58 @@ func (c *Config) Validate() error {
 		}
 	}
 
+	// Validate registry mirrors and credentials
+	mirrorHosts := make(map[string]bool)
+	cachedHosts := make(map[string]bool)
+	if c.Cluster.Registry.PullThrough.Enabled {
+		for _, host := range c.Cluster.Registry.PullThrough.GetRegistries() {
+			cachedHosts[host] = true
+		}
+	}
+	for i, mirror := range c.Cluster.RegistryMirrors {
+		if mirror.Host == "" {
+			errs = append(errs, fmt.Sprintf("cluster.registryMirrors[%d].host is required", i))
+		} else {
+			if mirrorHosts[mirror.Host] {
+				errs = append(errs, fmt.Sprintf("cluster.registryMirrors[%d].host '%s' is duplicated", i, mirror.Host))
+			}
+			if cachedHosts[mirror.Host] {
+				errs = append(errs, fmt.Sprintf("cluster.registryMirrors[%d].host '%s' is also cached by cluster.registry.pullThrough", i, mirror.Host))
+			}
+			mirrorHosts[mirror.Host] = true
+		}
+		if len(mirror.Endpoints) == 0 {
+			errs = append(errs, fmt.Sprintf("cluster.registryMirrors[%d].endpoints must not be empty", i))
+		}
+		for j, endpoint := range mirror.Endpoints {
+			u, err := url.Parse(endpoint)
+			if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				errs = append(errs, fmt.Sprintf("cluster.registryMirrors[%d].endpoints[%d] must be an http or https URL (got: %s)", i, j, endpoint))
+			}
+		}
+	}
+
+	authHosts := make(map[string]bool)
+	for i, auth := range c.Cluster.RegistryAuth {
+		if auth.Host == "" {
+			errs = append(errs, fmt.Sprintf("cluster.registryAuth[%d].host is required", i))
+		} else {
+			if strings.Contains(auth.Host, "/") {
+				errs = append(errs, fmt.Sprintf("cluster.registryAuth[%d].host must be a host without scheme or path (got: %s)", i, auth.Host))
+			}
+			if authHosts[auth.Host] {
+				errs = append(errs, fmt.Sprintf("cluster.registryAuth[%d].host '%s' is duplicated", i, auth.Host))
+			}
+			authHosts[auth.Host] = true
+		}
+		if (auth.UsernameEnv == "") != (auth.PasswordEnv == "") {
+			errs = append(errs, fmt.Sprintf("cluster.registryAuth[%d] needs both usernameEnv and passwordEnv", i))
+		}
+		if auth.UsernameEnv != "" && auth.DockerConfig != "" {
+			errs = append(errs, fmt.Sprintf("cluster.registryAuth[%d] cannot set both environment variables and dockerConfig", i))
+		}
+	}
+
 	workloadCANames := make(map[string]bool)
 	for i, wl := range c.Cluster.TrustedCAs.Workloads {
 		if wl.Name == "" {
@@ -105,6 +194,28 @@ func (c *Config) Validate() error {
 	if c.Crossplane.Version == "" {
 		errs = append(errs, "crossplane.version is required")
 	}
+	errs = append(errs, validateChartRepo("crossplane.repo", "crossplane.repoAuth", "crossplane.repoTLS", c.Crossplane.Repo, c.Crossplane.RepoAuth, c.Crossplane.RepoTLS, c.Cluster.TrustedCAs.Workloads)...)
+
+	if ic := c.Crossplane.ImageCache; ic != nil {
+		switch ic.GetSource() {
+		case ImageSourceDaemon:
+			if ic.LayoutDir != "" {
+				errs = append(errs, "crossplane.imageCache.layoutDir requires source registry or layout")
+			}
+		case ImageSourceRegistry:
+		case ImageSourceLayout:
+			if ic.LayoutDir == "" {
+				errs = append(errs, "crossplane.imageCache.layoutDir is required when source is layout")
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("crossplane.imageCache.source must be daemon, registry, or layout (got: %s)", ic.Source))
+		}
+		if ic.LayoutDir != "" {
+			if _, err := os.Stat(filepath.Join(ic.LayoutDir, "index.json")); err != nil {
+				errs = append(errs, fmt.Sprintf("crossplane.imageCache.layoutDir is not an OCI image layout: %s", ic.LayoutDir))
+			}
+		}
+	}
 
 	for i, p := range c.Crossplane.Providers {
 		if p.Name == "" {
@@ -190,11 +301,17 @@ func (c *Config) Validate() error {
 			}
 			chartNames[chart.Name] = true
 		}
-		if chart.Repo == "" {
-			errs = append(errs, fmt.Sprintf("charts[%d].repo is required", i))
-		}
-		if chart.Chart == "" {
-			errs = append(errs, fmt.Sprintf("charts[%d].chart is required", i))
+		if chart.Path != "" {
+			errs = append(errs, validateChartPath(i, chart)...)
+		} else {
+			if chart.Repo == "" {
+				errs = append(errs, fmt.Sprintf("charts[%d].repo is required (or set path for a local chart)", i))
+			}
+			if chart.Chart == "" {
+				errs = append(errs, fmt.Sprintf("charts[%d].chart is required", i))
+			}
+			errs = append(errs, validateChartRepo(fmt.Sprintf("charts[%d].repo", i), fmt.Sprintf("charts[%d].auth", i), fmt.Sprintf("charts[%d].tls", i),
+				chart.Repo, chart.Auth, chart.TLS, c.Cluster.TrustedCAs.Workloads)...)
 		}
 		if chart.Namespace == "" {
 			errs = append(errs, fmt.Sprintf("charts[%d].namespace is required", i))
@@ -214,8 +331,13 @@ func (c *Config) Validate() error {
 				errs = append(errs, fmt.Sprintf("charts[%d].valuesFiles[%d] file not found: %s", i, j, vf))
 			}
 		}
+		if chart.PostRender != nil {
+			errs = append(errs, validatePostRender(fmt.Sprintf("charts[%d].postRender", i), chart.PostRender)...)
+		}
 	}
 
+	errs = append(errs, validateChartDependencies(c.Charts)...)
+
 	// Validate compositions config
 	for i, s := range c.Compositions.Sources {
 		if s.Type != "local" && s.Type != "git" {
@@ -240,3 +362,214 @@ func (c *Config) Validate() error {
 
 	return nil
 }
+
+// validateChartDependencies checks that dependsOn names other charts of the
+// same or an earlier phase, and that the dependencies have no cycles
+func validateChartDependencies(charts []ChartConfig) []string {
+	var errs []string
+	index := make(map[string]int)
+	for i, chart := range charts {
+		if _, ok := index[chart.Name]; !ok && chart.Name != "" {
+			index[chart.Name] = i
+		}
+	}
+
+	// deps holds the dependencies within each chart's own phase; only these
+	// can form a cycle, as dependencies on later phases are rejected
+	deps := make([][]int, len(charts))
+	for i, chart := range charts {
+		phase := chart.GetPhase()
+		for j, dep := range chart.DependsOn {
+			k, ok := index[dep]
+			switch {
+			case dep == chart.Name:
+				errs = append(errs, fmt.Sprintf("charts[%d].dependsOn[%d] '%s' refers to the chart itself", i, j, dep))
+			case !ok:
+				errs = append(errs, fmt.Sprintf("charts[%d].dependsOn[%d] '%s' is not a configured chart", i, j, dep))
+			case charts[k].GetPhase() == phase:
+				if !slices.Contains(deps[i], k) {
+					deps[i] = append(deps[i], k)
+				}
+			default:
+				depOrder, depKnown := chartPhaseOrder[charts[k].GetPhase()]
+				order, known := chartPhaseOrder[phase]
+				if depKnown && known && depOrder > order {
+					errs = append(errs, fmt.Sprintf("charts[%d].dependsOn[%d] '%s' is installed in a later phase (%s) than '%s' (%s)",
+						i, j, dep, charts[k].GetPhase(), chart.Name, phase))
+				}
+			}
+		}
+	}
+
+	// Depth-first search; reaching a chart that is still on the path closes
+	// a cycle
+	const (
+		unvisited = iota
+		onPath
+		visited
+	)
+	state := make([]int, len(charts))
+	var path []int
+	var visit func(i int)
+	visit = func(i int) {
+		state[i] = onPath
+		path = append(path, i)
+		for _, k := range deps[i] {
+			switch state[k] {
+			case unvisited:
+				visit(k)
+			case onPath:
+				var names []string
+				for n := len(path) - 1; n >= 0; n-- {
+					names = append([]string{charts[path[n]].Name}, names...)
+					if path[n] == k {
+						break
+					}
+				}
+				names = append(names, charts[k].Name)
+				errs = append(errs, fmt.Sprintf("charts have a dependency cycle: %s", strings.Join(names, " -> ")))
+			}
+		}
+		path = path[:len(path)-1]
+		state[i] = visited
+	}
+	for i := range charts {
+		if state[i] == unvisited {
+			visit(i)
+		}
+	}
+	return errs
+}
+
+// validateChartRepo checks an oci:// chart repository URL and the
+// credentials and TLS settings given for a repository
+func validateChartRepo(repoField, authField, tlsField, repo string, auth *ChartAuth, tls *ChartRepoTLS, workloadCAs []WorkloadCA) []string {
+	var errs []string
+	if IsOCIRepo(repo) {
+		if u, err := url.Parse(repo); err != nil || u.Host == "" || u.RawQuery != "" || u.Fragment != "" {
+			errs = append(errs, fmt.Sprintf("%s must be oci://<host>[/<path>] (got: %s)", repoField, repo))
+		}
+	}
+	if auth != nil {
+		errs = append(errs, validateSecretSource(authField, "username", auth.UsernameEnv, auth.UsernameFile)...)
+		errs = append(errs, validateSecretSource(authField, "password", auth.PasswordEnv, auth.PasswordFile)...)
+		if auth.PassCredentialsAll && IsOCIRepo(repo) {
+			errs = append(errs, fmt.Sprintf("%s.passCredentialsAll does not apply to oci:// repos", authField))
+		}
+	}
+	if tls != nil {
+		if tls.CAFile != "" && tls.WorkloadCARef != "" {
+			errs = append(errs, fmt.Sprintf("%s: set caFile or workloadCARef, not both", tlsField))
+		}
+		if tls.InsecureSkipTLSVerify && (tls.CAFile != "" || tls.WorkloadCARef != "") {
+			errs = append(errs, fmt.Sprintf("%s.insecureSkipTLSVerify cannot be combined with a CA", tlsField))
+		}
+		if tls.CAFile != "" {
+			if _, err := os.Stat(tls.CAFile); os.IsNotExist(err) {
+				errs = append(errs, fmt.Sprintf("%s.caFile not found: %s", tlsField, tls.CAFile))
+			} else if err != nil {
+				errs = append(errs, fmt.Sprintf("%s.caFile cannot be accessed: %s (%v)", tlsField, tls.CAFile, err))
+			}
+		}
+		if ref := tls.WorkloadCARef; ref != "" && !slices.ContainsFunc(workloadCAs, func(wl WorkloadCA) bool { return wl.Name == ref }) {
+			errs = append(errs, fmt.Sprintf("%s.workloadCARef '%s' does not match any workload CA in cluster.trustedCAs.workloads", tlsField, ref))
+		}
+	}
+	return errs
+}
+
+// validateSecretSource checks that a secret is read from exactly one of an
+// environment variable and a file, and that the file exists
+func validateSecretSource(field, name, env, file string) []string {
+	switch {
+	case env == "" && file == "":
+		return []string{fmt.Sprintf("%s needs %sEnv or %sFile", field, name, name)}
+	case env != "" && file != "":
+		return []string{fmt.Sprintf("%s: set %sEnv or %sFile, not both", field, name, name)}
+	case file != "":
+		if _, err := os.Stat(file); os.IsNotExist(err) {
+			return []string{fmt.Sprintf("%s.%sFile not found: %s", field, name, file)}
+		} else if err != nil {
+			return []string{fmt.Sprintf("%s.%sFile cannot be accessed: %s (%v)", field, name, file, err)}
+		}
+	}
+	return nil
+}
+
+// validatePostRender checks that each patch parses, and that patches
+// which cannot name their resource have a target
+func validatePostRender(field string, pr *PostRenderConfig) []string {
+	var errs []string
+	for i, patch := range pr.Patches {
+		patchField := fmt.Sprintf("%s.patches[%d]", field, i)
+		content := patch.Patch
+		switch {
+		case patch.Patch == "" && patch.Path == "":
+			errs = append(errs, fmt.Sprintf("%s needs patch or path", patchField))
+			continue
+		case patch.Patch != "" && patch.Path != "":
+			errs = append(errs, fmt.Sprintf("%s: set patch or path, not both", patchField))
+			continue
+		case patch.Path != "":
+			data, err := os.ReadFile(patch.Path)
+			if err != nil {
+				errs = append(errs, fmt.Sprintf("%s.path cannot be read: %s (%v)", patchField, patch.Path, err))
+				continue
+			}
+			content = string(data)
+		}
+
+		if t := patch.Target; t != nil && *t == (PatchTarget{}) {
+			errs = append(errs, fmt.Sprintf("%s.target must set at least one field", patchField))
+		}
+		var parsed interface{}
+		if err := yaml.Unmarshal([]byte(content), &parsed); err != nil {
+			errs = append(errs, fmt.Sprintf("%s is not valid YAML: %v", patchField, err))
+			continue
+		}
+		switch p := parsed.(type) {
+		case []interface{}:
+			if patch.Target == nil {
+				errs = append(errs, fmt.Sprintf("%s is a JSON6902 patch and needs a target", patchField))
+			}
+		case map[string]interface{}:
+			metadata, _ := p["metadata"].(map[string]interface{})
+			if patch.Target == nil && (p["kind"] == nil || metadata["name"] == nil) {
+				errs = append(errs, fmt.Sprintf("%s is a strategic-merge patch without a target, so it must set kind and metadata.name", patchField))
+			}
+		default:
+			errs = append(errs, fmt.Sprintf("%s must be a strategic-merge patch (a resource) or a JSON6902 patch (a list of operations)", patchField))
+		}
+	}
+	return errs
+}
+
+// validateChartPath checks a chart installed from a local directory or
+// archive, without loading the chart
+func validateChartPath(i int, chart ChartConfig) []string {
+	var errs []string
+	if chart.Repo != "" || chart.Chart != "" {
+		errs = append(errs, fmt.Sprintf("charts[%d].path cannot be combined with repo or chart", i))
+	}
+	if chart.Version != "" {
+		errs = append(errs, fmt.Sprintf("charts[%d].version cannot be used with path; the chart's own version is installed", i))
+	}
+	if chart.Auth != nil || chart.TLS != nil {
+		errs = append(errs, fmt.Sprintf("charts[%d].auth and tls cannot be used with path", i))
+	}
+
+	info, err := os.Stat(chart.Path)
+	switch {
+	case os.IsNotExist(err):
+		errs = append(errs, fmt.Sprintf("charts[%d].path not found: %s", i, chart.Path))
+	case err != nil:
+		errs = append(errs, fmt.Sprintf("charts[%d].path cannot be accessed: %s (%v)", i, chart.Path, err))
+	case info.IsDir():
+		if _, err := os.Stat(filepath.Join(chart.Path, "Chart.yaml")); err != nil {
+			errs = append(errs, fmt.Sprintf("charts[%d].path is not a chart directory (no Chart.yaml): %s", i, chart.Path))
+		}
+	case !strings.HasSuffix(chart.Path, ".tgz") && !strings.HasSuffix(chart.Path, ".tar.gz"):
+		errs = append(errs, fmt.Sprintf("charts[%d].path must be a chart directory or a .tgz archive: %s", i, chart.Path))
+	}
+	return errs
+}
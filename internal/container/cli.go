@@ -0,0 +1,275 @@
+package container
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os/exec"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// cliRuntime drives Docker or Podman through their command line interface
+type cliRuntime struct {
+	binary string
+}
+
+// NewDocker returns a runtime backed by the docker CLI
+func NewDocker() Runtime {
+	return &cliRuntime{binary: RuntimeDocker}
+}
+
+// NewPodman returns a runtime backed by the podman CLI
+func NewPodman() Runtime {
+	return &cliRuntime{binary: RuntimePodman}
+}
+
+func (r *cliRuntime) Name() string {
+	return r.binary
+}
+
+func (r *cliRuntime) isPodman() bool {
+	return r.binary == RuntimePodman
+}
+
+func (r *cliRuntime) Command(ctx context.Context, stdin io.Reader, stdout io.Writer, args ...string) error {
+	cmd := exec.CommandContext(ctx, r.binary, args...)
+	var stderr bytes.Buffer
+	cmd.Stdin = stdin
+	cmd.Stdout = stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
+
+// output runs a command and returns its stdout
+func (r *cliRuntime) output(ctx context.Context, args ...string) (string, error) {
+	var out bytes.Buffer
+	if err := r.Command(ctx, nil, &out, args...); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+func (r *cliRuntime) ContainerExists(ctx context.Context, name string) (bool, error) {
+	// inspect fails for missing containers; that is not an error for the caller
+	return r.Command(ctx, nil, nil, "container", "inspect", name) == nil, nil
+}
+
+func (r *cliRuntime) ContainerRunning(ctx context.Context, name string) (bool, error) {
+	out, err := r.output(ctx, "container", "inspect", "-f", "{{.State.Running}}", name)
+	if err != nil {
+		return false, nil
+	}
+	return strings.TrimSpace(out) == "true", nil
+}
+
+func (r *cliRuntime) ContainerLabel(ctx context.Context, name, key string) (string, error) {
+	out, err := r.output(ctx, "container", "inspect", "-f", fmt.Sprintf("{{index .Config.Labels %q}}", key), name)
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect container %s: %w", name, err)
+	}
+	return strings.TrimSpace(out), nil
+}
+
+func (r *cliRuntime) ListContainers(ctx context.Context, label string) ([]string, error) {
+	out, err := r.output(ctx, "ps", "--filter", "label="+label, "--format", "{{.Names}}")
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+
+	names := []string{}
+	for _, name := range strings.Split(strings.TrimSpace(out), "\n") {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+func (r *cliRuntime) RunContainer(ctx context.Context, opts RunOptions) error {
+	return r.Command(ctx, nil, nil, runArgs(opts)...)
+}
+
+// runArgs builds the arguments of a detached `run`
+func runArgs(opts RunOptions) []string {
+	args := []string{"run", "-d", "--name", opts.Name}
+	if opts.Restart != "" {
+		args = append(args, "--restart="+opts.Restart)
+	}
+	for _, p := range opts.Ports {
+		args = append(args, "-p", p)
+	}
+	if opts.Network != "" {
+		args = append(args, "--network", opts.Network)
+	}
+	for _, e := range opts.Env {
+		args = append(args, "-e", e)
+	}
+	for _, v := range opts.Volumes {
+		args = append(args, "-v", v)
+	}
+	for _, l := range opts.Labels {
+		args = append(args, "--label", l)
+	}
+	args = append(args, opts.Image)
+	return append(args, opts.Args...)
+}
+
+func (r *cliRuntime) StartContainer(ctx context.Context, name string) error {
+	return r.Command(ctx, nil, nil, "start", name)
+}
+
+func (r *cliRuntime) StopContainer(ctx context.Context, name string) error {
+	return r.Command(ctx, nil, nil, "stop", name)
+}
+
+func (r *cliRuntime) RemoveContainer(ctx context.Context, name string) error {
+	return r.Command(ctx, nil, nil, "rm", "-v", name)
+}
+
+func (r *cliRuntime) Exec(ctx context.Context, container string, stdin io.Reader, cmd ...string) ([]byte, error) {
+	args := []string{"exec"}
+	if stdin != nil {
+		args = append(args, "-i")
+	}
+	args = append(args, container)
+	args = append(args, cmd...)
+
+	c := exec.CommandContext(ctx, r.binary, args...)
+	c.Stdin = stdin
+	return c.CombinedOutput()
+}
+
+func (r *cliRuntime) CopyToContainer(ctx context.Context, src, container, dst string) error {
+	return r.Command(ctx, nil, nil, "cp", src, container+":"+dst)
+}
+
+func (r *cliRuntime) ContainerNetworks(ctx context.Context, name string) ([]string, error) {
+	out, err := r.output(ctx, "container", "inspect", "-f", "{{json .NetworkSettings.Networks}}", name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to inspect container %s: %w", name, err)
+	}
+
+	var networks map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(strings.TrimSpace(out)), &networks); err != nil {
+		return nil, fmt.Errorf("failed to parse networks of %s: %w", name, err)
+	}
+
+	names := make([]string, 0, len(networks))
+	for n := range networks {
+		names = append(names, n)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+func (r *cliRuntime) ConnectNetwork(ctx context.Context, network, container string) error {
+	return r.Command(ctx, nil, nil, "network", "connect", network, container)
+}
+
+func (r *cliRuntime) PublishedPort(ctx context.Context, container string, containerPort int) (int, error) {
+	out, err := r.output(ctx, "port", container, fmt.Sprintf("%d/tcp", containerPort))
+	if err != nil {
+		return 0, fmt.Errorf("failed to get published port of %s: %w", container, err)
+	}
+	return parsePortOutput(out)
+}
+
+// parsePortOutput extracts the host port from `port` output such as
+// "127.0.0.1:5001" or "0.0.0.0:5001\n[::]:5001"
+func parsePortOutput(out string) (int, error) {
+	line, _, _ := strings.Cut(strings.TrimSpace(out), "\n")
+	idx := strings.LastIndex(line, ":")
+	if idx < 0 {
+		return 0, fmt.Errorf("unexpected port output %q", out)
+	}
+	port, err := strconv.Atoi(strings.TrimSpace(line[idx+1:]))
+	if err != nil {
+		return 0, fmt.Errorf("unexpected port output %q", out)
+	}
+	return port, nil
+}
+
+func (r *cliRuntime) ImageExists(ctx context.Context, image string) (bool, error) {
+	// image inspect exits non-zero for missing images on both runtimes
+	return r.Command(ctx, nil, nil, "image", "inspect", image) == nil, nil
+}
+
+func (r *cliRuntime) ImagePlatform(ctx context.Context, image string) (Platform, error) {
+	out, err := r.output(ctx, "image", "inspect", image, "--format", "{{json .}}")
+	if err != nil {
+		return Platform{}, fmt.Errorf("failed to inspect image: %w", err)
+	}
+
+	var inspect struct {
+		Architecture string `json:"Architecture"`
+		Os           string `json:"Os"`
+		Variant      string `json:"Variant"`
+	}
+	if err := json.Unmarshal([]byte(out), &inspect); err != nil {
+		return Platform{}, fmt.Errorf("failed to parse image inspect output: %w", err)
+	}
+
+	return Platform{OS: inspect.Os, Architecture: inspect.Architecture, Variant: inspect.Variant}, nil
+}
+
+func (r *cliRuntime) ImageID(ctx context.Context, image string) (string, error) {
+	out, err := r.output(ctx, "image", "inspect", image, "--format", "{{.Id}}")
+	if err != nil {
+		return "", fmt.Errorf("failed to inspect image: %w", err)
+	}
+	// Docker prefixes the ID with its algorithm, Podman does not
+	return strings.TrimPrefix(strings.TrimSpace(out), "sha256:"), nil
+}
+
+func (r *cliRuntime) PullImage(ctx context.Context, image, platform string) error {
+	args := []string{"pull"}
+	if platform != "" {
+		args = append(args, "--platform", platform)
+	}
+	return r.Command(ctx, nil, nil, append(args, image)...)
+}
+
+func (r *cliRuntime) TagImage(ctx context.Context, source, target string) error {
+	return r.Command(ctx, nil, nil, "tag", source, target)
+}
+
+func (r *cliRuntime) PushImage(ctx context.Context, image string) error {
+	args := []string{"push"}
+	// Docker treats localhost registries as insecure implicitly; Podman needs telling
+	if r.isPodman() && isLocalhostRef(image) {
+		args = append(args, "--tls-verify=false")
+	}
+	return r.Command(ctx, nil, nil, append(args, image)...)
+}
+
+func (r *cliRuntime) SaveImages(ctx context.Context, path string, images ...string) error {
+	args := []string{"save", "-o", path}
+	if r.isPodman() && len(images) > 1 {
+		args = append(args, "--multi-image-archive")
+	}
+	return r.Command(ctx, nil, nil, append(args, images...)...)
+}
+
+func (r *cliRuntime) LoadImages(ctx context.Context, path string) error {
+	return r.Command(ctx, nil, nil, "load", "-i", path)
+}
+
+// isLocalhostRef reports whether an image reference points at a registry on localhost
+func isLocalhostRef(image string) bool {
+	host, _, found := strings.Cut(image, "/")
+	if !found {
+		return false
+	}
+	hostname, _, _ := strings.Cut(host, ":")
+	return hostname == "localhost" || hostname == "127.0.0.1"
+}
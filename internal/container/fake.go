@@ -0,0 +1,377 @@
+package container
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Fake is an in-memory Runtime for unit tests. It records every call and
+// tracks containers, networks and images so callers can assert on effects
+// without a container engine.
+type Fake struct {
+	mu sync.Mutex
+
+	// RuntimeName is returned by Name (defaults to "docker")
+	RuntimeName string
+	// Containers maps container name to running state
+	Containers map[string]bool
+	// Networks maps container name to attached networks
+	Networks map[string][]string
+	// Labels maps container name to its labels (key=value)
+	Labels map[string][]string
+	// Images holds locally present images
+	Images map[string]Platform
+	// ImageIDs overrides the ID of an image; by default it is derived from the name
+	ImageIDs map[string]string
+	// Files records copied files as "container:dst" -> src
+	Files map[string]string
+	// Ports maps container name to published ports (container port -> host port)
+	Ports map[string]map[int]int
+	// ExecFunc, when set, produces the output of Exec
+	ExecFunc func(container string, stdin []byte, cmd ...string) ([]byte, error)
+	// Errors forces a method (by name, e.g. "PullImage") to fail
+	Errors map[string]error
+
+	// Calls records each call as "Method arg1 arg2 ..."
+	Calls []string
+}
+
+// NewFake returns an empty Fake runtime
+func NewFake() *Fake {
+	return &Fake{
+		Containers: map[string]bool{},
+		Networks:   map[string][]string{},
+		Labels:     map[string][]string{},
+		Images:     map[string]Platform{},
+		ImageIDs:   map[string]string{},
+		Files:      map[string]string{},
+		Ports:      map[string]map[int]int{},
+		Errors:     map[string]error{},
+	}
+}
+
+func (f *Fake) record(method string, args ...string) error {
+	f.Calls = append(f.Calls, strings.TrimSpace(method+" "+strings.Join(args, " ")))
+	return f.Errors[method]
+}
+
+// Called reports whether a call with the given prefix was recorded
+func (f *Fake) Called(prefix string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.Calls {
+		if strings.HasPrefix(c, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *Fake) Name() string {
+	if f.RuntimeName == "" {
+		return RuntimeDocker
+	}
+	return f.RuntimeName
+}
+
+func (f *Fake) Command(ctx context.Context, stdin io.Reader, stdout io.Writer, args ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record("Command", args...)
+}
+
+func (f *Fake) ContainerExists(ctx context.Context, name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ContainerExists", name); err != nil {
+		return false, err
+	}
+	_, ok := f.Containers[name]
+	return ok, nil
+}
+
+func (f *Fake) ContainerRunning(ctx context.Context, name string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ContainerRunning", name); err != nil {
+		return false, err
+	}
+	return f.Containers[name], nil
+}
+
+func (f *Fake) ContainerLabel(ctx context.Context, name, key string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ContainerLabel", name, key); err != nil {
+		return "", err
+	}
+	if _, ok := f.Containers[name]; !ok {
+		return "", fmt.Errorf("no such container: %s", name)
+	}
+	for _, l := range f.Labels[name] {
+		if k, v, _ := strings.Cut(l, "="); k == key {
+			return v, nil
+		}
+	}
+	return "", nil
+}
+
+func (f *Fake) ListContainers(ctx context.Context, label string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ListContainers", label); err != nil {
+		return nil, err
+	}
+	names := []string{}
+	for name, labels := range f.Labels {
+		for _, l := range labels {
+			if l == label && f.Containers[name] {
+				names = append(names, name)
+				break
+			}
+		}
+	}
+	return names, nil
+}
+
+func (f *Fake) RunContainer(ctx context.Context, opts RunOptions) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RunContainer", runArgs(opts)...); err != nil {
+		return err
+	}
+	if _, ok := f.Containers[opts.Name]; ok {
+		return fmt.Errorf("container %s already exists", opts.Name)
+	}
+	f.Containers[opts.Name] = true
+	f.Labels[opts.Name] = opts.Labels
+	if opts.Network != "" {
+		f.Networks[opts.Name] = []string{opts.Network}
+	}
+	for _, p := range opts.Ports {
+		// [ip:]hostPort:containerPort
+		parts := strings.Split(p, ":")
+		if len(parts) < 2 {
+			continue
+		}
+		host, _ := strconv.Atoi(parts[len(parts)-2])
+		ctr, _ := strconv.Atoi(strings.TrimSuffix(parts[len(parts)-1], "/tcp"))
+		if f.Ports[opts.Name] == nil {
+			f.Ports[opts.Name] = map[int]int{}
+		}
+		f.Ports[opts.Name][ctr] = host
+	}
+	return nil
+}
+
+func (f *Fake) StartContainer(ctx context.Context, name string) error {
+	return f.setRunning("StartContainer", name, true)
+}
+
+func (f *Fake) StopContainer(ctx context.Context, name string) error {
+	return f.setRunning("StopContainer", name, false)
+}
+
+func (f *Fake) setRunning(method, name string, running bool) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record(method, name); err != nil {
+		return err
+	}
+	if _, ok := f.Containers[name]; !ok {
+		return fmt.Errorf("no such container: %s", name)
+	}
+	f.Containers[name] = running
+	return nil
+}
+
+func (f *Fake) RemoveContainer(ctx context.Context, name string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("RemoveContainer", name); err != nil {
+		return err
+	}
+	if _, ok := f.Containers[name]; !ok {
+		return fmt.Errorf("no such container: %s", name)
+	}
+	delete(f.Containers, name)
+	delete(f.Networks, name)
+	delete(f.Labels, name)
+	delete(f.Ports, name)
+	return nil
+}
+
+func (f *Fake) Exec(ctx context.Context, container string, stdin io.Reader, cmd ...string) ([]byte, error) {
+	var input []byte
+	if stdin != nil {
+		var err error
+		if input, err = io.ReadAll(stdin); err != nil {
+			return nil, err
+		}
+	}
+
+	f.mu.Lock()
+	err := f.record("Exec", append([]string{container}, cmd...)...)
+	execFn := f.ExecFunc
+	f.mu.Unlock()
+	if err != nil {
+		return nil, err
+	}
+	if execFn != nil {
+		return execFn(container, input, cmd...)
+	}
+	return nil, nil
+}
+
+func (f *Fake) CopyToContainer(ctx context.Context, src, container, dst string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("CopyToContainer", src, container, dst); err != nil {
+		return err
+	}
+	f.Files[container+":"+dst] = src
+	return nil
+}
+
+func (f *Fake) ContainerNetworks(ctx context.Context, name string) ([]string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ContainerNetworks", name); err != nil {
+		return nil, err
+	}
+	if _, ok := f.Containers[name]; !ok {
+		return nil, fmt.Errorf("no such container: %s", name)
+	}
+	return append([]string(nil), f.Networks[name]...), nil
+}
+
+func (f *Fake) ConnectNetwork(ctx context.Context, network, container string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ConnectNetwork", network, container); err != nil {
+		return err
+	}
+	f.Networks[container] = append(f.Networks[container], network)
+	return nil
+}
+
+func (f *Fake) PublishedPort(ctx context.Context, container string, containerPort int) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("PublishedPort", container, strconv.Itoa(containerPort)); err != nil {
+		return 0, err
+	}
+	port, ok := f.Ports[container][containerPort]
+	if !ok {
+		return 0, fmt.Errorf("no public port %d published for %s", containerPort, container)
+	}
+	return port, nil
+}
+
+func (f *Fake) ImageExists(ctx context.Context, image string) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ImageExists", image); err != nil {
+		return false, err
+	}
+	_, ok := f.Images[image]
+	return ok, nil
+}
+
+func (f *Fake) ImagePlatform(ctx context.Context, image string) (Platform, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ImagePlatform", image); err != nil {
+		return Platform{}, err
+	}
+	p, ok := f.Images[image]
+	if !ok {
+		return Platform{}, fmt.Errorf("no such image: %s", image)
+	}
+	return p, nil
+}
+
+func (f *Fake) ImageID(ctx context.Context, image string) (string, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("ImageID", image); err != nil {
+		return "", err
+	}
+	if _, ok := f.Images[image]; !ok {
+		return "", fmt.Errorf("no such image: %s", image)
+	}
+	if id, ok := f.ImageIDs[image]; ok {
+		return id, nil
+	}
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(image))), nil
+}
+
+func (f *Fake) PullImage(ctx context.Context, image, platform string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("PullImage", image, platform); err != nil {
+		return err
+	}
+	p := Platform{OS: "linux", Architecture: "amd64"}
+	if os, arch, ok := strings.Cut(platform, "/"); ok {
+		p = Platform{OS: os, Architecture: arch}
+	}
+	f.Images[image] = p
+	return nil
+}
+
+func (f *Fake) TagImage(ctx context.Context, source, target string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("TagImage", source, target); err != nil {
+		return err
+	}
+	p, ok := f.Images[source]
+	if !ok {
+		return fmt.Errorf("no such image: %s", source)
+	}
+	f.Images[target] = p
+	return nil
+}
+
+func (f *Fake) PushImage(ctx context.Context, image string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.record("PushImage", image)
+}
+
+// SaveImages writes a placeholder archive naming the images to path
+func (f *Fake) SaveImages(ctx context.Context, path string, images ...string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("SaveImages", append([]string{path}, images...)...); err != nil {
+		return err
+	}
+	return os.WriteFile(path, []byte(strings.Join(images, "\n")), 0644)
+}
+
+// LoadImages adds the images named in a placeholder archive written by
+// SaveImages, as linux/amd64 like PullImage
+func (f *Fake) LoadImages(ctx context.Context, path string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if err := f.record("LoadImages", path); err != nil {
+		return err
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	for _, image := range strings.Split(string(data), "\n") {
+		if image != "" {
+			f.Images[image] = Platform{OS: "linux", Architecture: "amd64"}
+		}
+	}
+	return nil
+}
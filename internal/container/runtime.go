@@ -0,0 +1,180 @@
+package container
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+)
+
+const (
+	// RuntimeDocker selects the Docker runtime
+	RuntimeDocker = "docker"
+	// RuntimePodman selects the Podman runtime
+	RuntimePodman = "podman"
+	// RuntimeAuto detects the runtime from the environment
+	RuntimeAuto = "auto"
+
+	// ProviderEnvVar is the variable Kind reads to select its node provider.
+	// kindplane honours it so both tools agree on the runtime.
+	ProviderEnvVar = "KIND_EXPERIMENTAL_PROVIDER"
+)
+
+// Platform describes the OS and architecture of an image
+type Platform struct {
+	OS           string
+	Architecture string
+	Variant      string
+}
+
+// RunOptions describes a detached container to start
+type RunOptions struct {
+	Name    string
+	Image   string
+	Restart string   // restart policy, e.g. "always"
+	Network string   // network to attach to
+	Ports   []string // published ports, e.g. "127.0.0.1:5001:5000"
+	Env     []string // KEY=value pairs
+	Volumes []string // bind mounts or named volumes, e.g. "data:/var/lib/registry"
+	Labels  []string // KEY=value pairs
+	Args    []string // arguments passed to the image entrypoint
+}
+
+// Runtime is a container engine driven through its CLI. Docker and Podman
+// share the command syntax kindplane relies on, so implementations mostly
+// differ in binary name and a few flags.
+type Runtime interface {
+	// Name returns the runtime name ("docker" or "podman")
+	Name() string
+
+	// Command runs an arbitrary runtime command, wiring stdin and stdout.
+	// Stderr is included in the returned error.
+	Command(ctx context.Context, stdin io.Reader, stdout io.Writer, args ...string) error
+
+	// ContainerExists reports whether a container exists, running or not
+	ContainerExists(ctx context.Context, name string) (bool, error)
+	// ContainerRunning reports whether a container exists and is running
+	ContainerRunning(ctx context.Context, name string) (bool, error)
+	// ContainerLabel returns the value of a container's label, empty when it isn't set
+	ContainerLabel(ctx context.Context, name, key string) (string, error)
+	// ListContainers returns the names of running containers with the given label (key=value)
+	ListContainers(ctx context.Context, label string) ([]string, error)
+	// RunContainer creates and starts a detached container
+	RunContainer(ctx context.Context, opts RunOptions) error
+	// StartContainer starts an existing container
+	StartContainer(ctx context.Context, name string) error
+	// StopContainer stops a running container
+	StopContainer(ctx context.Context, name string) error
+	// RemoveContainer removes a stopped container and its anonymous volumes
+	RemoveContainer(ctx context.Context, name string) error
+	// Exec runs a command inside a container and returns its combined output
+	Exec(ctx context.Context, container string, stdin io.Reader, cmd ...string) ([]byte, error)
+	// CopyToContainer copies a host file into a container
+	CopyToContainer(ctx context.Context, src, container, dst string) error
+	// ContainerNetworks returns the networks a container is attached to
+	ContainerNetworks(ctx context.Context, name string) ([]string, error)
+	// ConnectNetwork attaches a container to a network
+	ConnectNetwork(ctx context.Context, network, container string) error
+	// PublishedPort returns the host port a container's TCP port is published on
+	PublishedPort(ctx context.Context, container string, containerPort int) (int, error)
+
+	// ImageExists reports whether an image is present locally
+	ImageExists(ctx context.Context, image string) (bool, error)
+	// ImagePlatform returns the platform of a local image
+	ImagePlatform(ctx context.Context, image string) (Platform, error)
+	// ImageID returns the content-addressed ID of a local image, without
+	// the algorithm prefix
+	ImageID(ctx context.Context, image string) (string, error)
+	// PullImage pulls an image, optionally for a specific platform (e.g. "linux/arm64")
+	PullImage(ctx context.Context, image, platform string) error
+	// TagImage adds a tag to a local image
+	TagImage(ctx context.Context, source, target string) error
+	// PushImage pushes a local image to its registry
+	PushImage(ctx context.Context, image string) error
+	// SaveImages writes one or more images to a tar archive
+	SaveImages(ctx context.Context, path string, images ...string) error
+	// LoadImages loads the images of a tar archive written by SaveImages
+	LoadImages(ctx context.Context, path string) error
+}
+
+var (
+	defaultMu         sync.Mutex
+	defaultRuntime    Runtime
+	defaultPreference string
+)
+
+// SetPreference records the runtime requested by configuration ("docker",
+// "podman", "auto" or empty) and resets the cached default
+func SetPreference(preference string) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if preference != defaultPreference {
+		defaultPreference = preference
+		defaultRuntime = nil
+	}
+}
+
+// Default returns the process-wide runtime, detecting it on first use.
+// Detection failures fall back to Docker so callers get a meaningful error
+// from the first command they run.
+func Default() Runtime {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	if defaultRuntime == nil {
+		rt, err := Detect(defaultPreference)
+		if err != nil {
+			rt = NewDocker()
+		}
+		defaultRuntime = rt
+	}
+	return defaultRuntime
+}
+
+// SetDefault overrides the process-wide runtime, mainly for tests
+func SetDefault(rt Runtime) {
+	defaultMu.Lock()
+	defer defaultMu.Unlock()
+	defaultRuntime = rt
+}
+
+// Detect selects a runtime. An explicit preference wins, then
+// KIND_EXPERIMENTAL_PROVIDER, then whichever engine responds (Docker first).
+func Detect(preference string) (Runtime, error) {
+	return detect(preference, os.Getenv(ProviderEnvVar), engineResponds)
+}
+
+// detect implements Detect with injectable environment and probe for tests
+func detect(preference, envProvider string, responds func(binary string) bool) (Runtime, error) {
+	for _, choice := range []string{preference, envProvider} {
+		switch strings.ToLower(strings.TrimSpace(choice)) {
+		case "", RuntimeAuto:
+			continue
+		case RuntimeDocker:
+			return NewDocker(), nil
+		case RuntimePodman:
+			return NewPodman(), nil
+		default:
+			return nil, fmt.Errorf("unsupported container runtime %q (use docker or podman)", choice)
+		}
+	}
+
+	if responds(RuntimeDocker) {
+		return NewDocker(), nil
+	}
+	if responds(RuntimePodman) {
+		return NewPodman(), nil
+	}
+	return nil, fmt.Errorf("no container runtime found: install Docker or Podman")
+}
+
+// engineResponds reports whether the runtime binary exists and can reach its engine
+func engineResponds(binary string) bool {
+	path, err := exec.LookPath(binary)
+	if err != nil {
+		return false
+	}
+	return exec.Command(path, "info").Run() == nil
+}
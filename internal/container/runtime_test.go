@@ -0,0 +1,115 @@
+package container
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDetect(t *testing.T) {
+	tests := []struct {
+		name       string
+		preference string
+		env        string
+		responding []string
+		want       string
+		wantErr    bool
+	}{
+		{name: "explicit docker", preference: "docker", env: "podman", want: RuntimeDocker},
+		{name: "explicit podman", preference: "podman", responding: []string{"docker"}, want: RuntimePodman},
+		{name: "case insensitive", preference: "Podman", want: RuntimePodman},
+		{name: "env provider", preference: "auto", env: "podman", responding: []string{"docker"}, want: RuntimePodman},
+		{name: "auto prefers docker", responding: []string{"docker", "podman"}, want: RuntimeDocker},
+		{name: "auto falls back to podman", responding: []string{"podman"}, want: RuntimePodman},
+		{name: "nothing available", wantErr: true},
+		{name: "unsupported preference", preference: "containerd", wantErr: true},
+		{name: "unsupported env", env: "nerdctl", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			responds := func(binary string) bool {
+				for _, r := range tt.responding {
+					if r == binary {
+						return true
+					}
+				}
+				return false
+			}
+
+			rt, err := detect(tt.preference, tt.env, responds)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("detect() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err == nil && rt.Name() != tt.want {
+				t.Errorf("detect() = %s, want %s", rt.Name(), tt.want)
+			}
+		})
+	}
+}
+
+func TestRunArgs(t *testing.T) {
+	got := runArgs(RunOptions{
+		Name:    "kind-registry",
+		Image:   "registry:2",
+		Restart: "always",
+		Network: "bridge",
+		Ports:   []string{"127.0.0.1:5001:5000"},
+		Env:     []string{"A=1"},
+		Volumes: []string{"data:/var/lib/registry"},
+		Labels:  []string{"app=kindplane"},
+		Args:    []string{"serve", "/etc/config.yml"},
+	})
+	want := []string{
+		"run", "-d", "--name", "kind-registry", "--restart=always",
+		"-p", "127.0.0.1:5001:5000", "--network", "bridge",
+		"-e", "A=1", "-v", "data:/var/lib/registry", "--label", "app=kindplane",
+		"registry:2", "serve", "/etc/config.yml",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("runArgs() = %v, want %v", got, want)
+	}
+}
+
+func TestParsePortOutput(t *testing.T) {
+	tests := []struct {
+		out     string
+		want    int
+		wantErr bool
+	}{
+		{out: "127.0.0.1:5001\n", want: 5001},
+		{out: "0.0.0.0:32768\n[::]:32768\n", want: 32768},
+		{out: "", wantErr: true},
+		{out: "garbage", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		got, err := parsePortOutput(tt.out)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("parsePortOutput(%q) error = %v, wantErr %v", tt.out, err, tt.wantErr)
+			continue
+		}
+		if got != tt.want {
+			t.Errorf("parsePortOutput(%q) = %d, want %d", tt.out, got, tt.want)
+		}
+	}
+}
+
+func TestIsLocalhostRef(t *testing.T) {
+	tests := []struct {
+		image string
+		want  bool
+	}{
+		{"localhost:5001/crossplane/crossplane:v1", true},
+		{"127.0.0.1:5001/app:v1", true},
+		{"localhost/app:v1", true},
+		{"ghcr.io/org/app:v1", false},
+		{"crossplane/crossplane:v1", false},
+		{"nginx", false},
+	}
+
+	for _, tt := range tests {
+		if got := isLocalhostRef(tt.image); got != tt.want {
+			t.Errorf("isLocalhostRef(%q) = %v, want %v", tt.image, got, tt.want)
+		}
+	}
+}
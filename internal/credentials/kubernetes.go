@@ -3,7 +3,6 @@ package credentials
 import (
 	"context"
 	"fmt"
-	"os"
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -20,21 +19,12 @@ func (m *Manager) ConfigureKubernetesInCluster(ctx context.Context) error {
 	return m.createKubernetesProviderConfig(ctx, "InjectedIdentity", nil)
 }
 
-// ConfigureKubernetesFromKubeconfig creates Kubernetes provider config using kubeconfig
+// ConfigureKubernetesFromKubeconfig creates Kubernetes provider config using
+// the kubeconfig of the manager's cluster connection
 func (m *Manager) ConfigureKubernetesFromKubeconfig(ctx context.Context) error {
-	// Read kubeconfig file
-	kubeconfigPath := os.Getenv("KUBECONFIG")
-	if kubeconfigPath == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return fmt.Errorf("failed to get home directory: %w", err)
-		}
-		kubeconfigPath = fmt.Sprintf("%s/.kube/config", home)
-	}
-
-	kubeconfigData, err := os.ReadFile(kubeconfigPath)
+	kubeconfigData, err := m.conn.Kubeconfig()
 	if err != nil {
-		return fmt.Errorf("failed to read kubeconfig: %w", err)
+		return err
 	}
 
 	// Create secret with kubeconfig
@@ -3,15 +3,14 @@ package credentials
 import (
 	"context"
 	"fmt"
-	"os"
 
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
-	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kanzi/kindplane/internal/kube"
 )
 
 const (
@@ -21,49 +20,21 @@ const (
 
 // Manager handles credential configuration for Crossplane providers
 type Manager struct {
-	kubeClient    *kubernetes.Clientset
-	dynamicClient dynamic.Interface
+	kubeClient *kubernetes.Clientset
+	conn       *kube.Connection
 }
 
-// NewManager creates a new credentials manager
-func NewManager(kubeClient *kubernetes.Clientset) *Manager {
+// NewManager creates a new credentials manager for the cluster of conn
+func NewManager(conn *kube.Connection) *Manager {
 	return &Manager{
-		kubeClient: kubeClient,
+		kubeClient: conn.Client,
+		conn:       conn,
 	}
 }
 
-// getDynamicClient creates a dynamic Kubernetes client
+// getDynamicClient returns the connection's dynamic client
 func (m *Manager) getDynamicClient() (dynamic.Interface, error) {
-	if m.dynamicClient != nil {
-		return m.dynamicClient, nil
-	}
-
-	config, err := getRestConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	m.dynamicClient, err = dynamic.NewForConfig(config)
-	return m.dynamicClient, err
-}
-
-// getRestConfig returns the Kubernetes REST config
-func getRestConfig() (*rest.Config, error) {
-	config, err := rest.InClusterConfig()
-	if err == nil {
-		return config, nil
-	}
-
-	kubeconfigPath := os.Getenv("KUBECONFIG")
-	if kubeconfigPath == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		kubeconfigPath = fmt.Sprintf("%s/.kube/config", home)
-	}
-
-	return clientcmd.BuildConfigFromFlags("", kubeconfigPath)
+	return m.conn.Dynamic()
 }
 
 // createSecret creates a Kubernetes secret
@@ -26,8 +26,8 @@ func (i *Installer) ApplyCompositions(ctx context.Context, source config.Composi
 	case "local":
 		basePath = source.Path
 	case "git":
-		// Clone the repository
-		clonePath, err := git.CloneRepo(ctx, source.Repo, source.Branch)
+		// Clone the repository into the git cache
+		clonePath, err := git.CachedClone(ctx, source.Repo, source.Branch)
 		if err != nil {
 			return fmt.Errorf("failed to clone repository: %w", err)
 		}
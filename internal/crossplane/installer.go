@@ -16,6 +16,7 @@ import (
 
 	"github.com/kanzi/kindplane/internal/config"
 	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/kube"
 )
 
 const (
@@ -36,7 +37,7 @@ const (
 // Installer handles Crossplane installation and management
 type Installer struct {
 	kubeClient    *kubernetes.Clientset
-	dynamicClient dynamic.Interface
+	conn          *kube.Connection
 	helmInstaller *helm.Installer
 }
 
@@ -72,12 +73,16 @@ type ProviderStatus struct {
 	Message string
 }
 
-// NewInstaller creates a new Crossplane installer
-func NewInstaller(kubeClient *kubernetes.Clientset) *Installer {
-	return &Installer{
-		kubeClient:    kubeClient,
-		helmInstaller: helm.NewInstaller(kubeClient),
+// NewInstaller creates a new Crossplane installer for the cluster of conn
+func NewInstaller(conn *kube.Connection) *Installer {
+	installer := &Installer{
+		conn:          conn,
+		helmInstaller: helm.NewInstaller(conn),
 	}
+	if conn != nil {
+		installer.kubeClient = conn.Client
+	}
+	return installer
 }
 
 // Install installs Crossplane using Helm
@@ -98,9 +103,12 @@ func (i *Installer) Install(ctx context.Context, fullConfig *config.Config) erro
 		repoName = helm.GenerateRepoName(repoURL)
 	}
 
-	// Add Helm repository
-	if err := i.AddHelmRepo(ctx, repoName, repoURL); err != nil {
-		return err
+	// Add Helm repository; one with credentials or TLS settings is located
+	// by URL when the chart is installed
+	if cfg.RepoAuth == nil && cfg.RepoTLS == nil {
+		if err := i.AddHelmRepo(ctx, repoName, repoURL); err != nil {
+			return err
+		}
 	}
 
 	// Ensure namespace exists
@@ -116,13 +124,20 @@ func (i *Installer) Install(ctx context.Context, fullConfig *config.Config) erro
 	}
 
 	// Install Helm chart
-	if err := i.InstallHelmChart(ctx, cfg, repoURL, repoName); err != nil {
+	opts := helm.InstallOptions{ValuesContext: helm.NewValuesContext(fullConfig)}
+	if err := i.InstallHelmChartWithOptions(ctx, cfg, repoURL, repoName, opts); err != nil {
 		return err
 	}
 
 	return nil
 }
 
+// SetLocalCharts provides chart archives to install instead of downloading
+// the Crossplane chart; see helm.Installer.SetLocalCharts
+func (i *Installer) SetLocalCharts(charts map[string]string) {
+	i.helmInstaller.SetLocalCharts(charts)
+}
+
 // AddHelmRepo adds the Crossplane Helm repository
 func (i *Installer) AddHelmRepo(ctx context.Context, repoName, repoURL string) error {
 	if err := i.helmInstaller.AddRepo(ctx, repoName, repoURL); err != nil {
@@ -154,16 +169,7 @@ func (i *Installer) InstallHelmChart(ctx context.Context, cfg config.CrossplaneC
 // The opts parameter allows for value transformation, logging, and pre-install hooks.
 // Note: This method automatically injects registryCaBundleConfig values if cfg.RegistryCaBundle is set.
 func (i *Installer) InstallHelmChartWithOptions(ctx context.Context, cfg config.CrossplaneConfig, repoURL, repoName string, opts helm.InstallOptions) error {
-	// Build ChartConfig from CrossplaneConfig
-	chartCfg := config.ChartConfig{
-		Name:        "crossplane",
-		Repo:        repoURL,
-		Chart:       CrossplaneChartName,
-		Version:     cfg.Version,
-		Namespace:   CrossplaneNamespace,
-		Values:      cfg.Values,
-		ValuesFiles: cfg.ValuesFiles,
-	}
+	chartCfg := chartConfig(cfg, repoURL)
 
 	// Wrap the provided transformer to also inject registryCaBundleConfig
 	originalTransformer := opts.ValuesTransformer
@@ -172,17 +178,7 @@ func (i *Installer) InstallHelmChartWithOptions(ctx context.Context, cfg config.
 		if originalTransformer != nil {
 			values = originalTransformer(values)
 		}
-		// Inject registryCaBundleConfig if configured
-		if cfg.RegistryCaBundle != nil {
-			if values == nil {
-				values = make(map[string]interface{})
-			}
-			values["registryCaBundleConfig"] = map[string]interface{}{
-				"name": RegistryCaBundleConfigMapName,
-				"key":  RegistryCaBundleConfigMapKey,
-			}
-		}
-		return values
+		return injectRegistryCaBundle(cfg, values)
 	}
 
 	// Use the helm installer with options
@@ -193,6 +189,51 @@ func (i *Installer) InstallHelmChartWithOptions(ctx context.Context, cfg config.
 	return nil
 }
 
+// ChartConfig returns the Crossplane chart as a chart entry, with the values
+// InstallHelmChartWithOptions installs it with, so it can be rendered like
+// the charts in kindplane.yaml
+func ChartConfig(cfg config.CrossplaneConfig) config.ChartConfig {
+	chartCfg := chartConfig(cfg, cfg.GetRepo())
+	values := make(map[string]interface{}, len(cfg.Values)+1)
+	for k, v := range cfg.Values {
+		values[k] = v
+	}
+	chartCfg.Values = injectRegistryCaBundle(cfg, values)
+	return chartCfg
+}
+
+// chartConfig builds the chart entry of the Crossplane chart in repoURL
+func chartConfig(cfg config.CrossplaneConfig, repoURL string) config.ChartConfig {
+	return config.ChartConfig{
+		Name:           "crossplane",
+		Repo:           repoURL,
+		Auth:           cfg.RepoAuth,
+		TLS:            cfg.RepoTLS,
+		Chart:          CrossplaneChartName,
+		Version:        cfg.Version,
+		Namespace:      CrossplaneNamespace,
+		Values:         cfg.Values,
+		ValuesFiles:    cfg.ValuesFiles,
+		TemplateValues: cfg.TemplateValues,
+	}
+}
+
+// injectRegistryCaBundle points the chart at the registry CA bundle
+// ConfigMap when one is configured
+func injectRegistryCaBundle(cfg config.CrossplaneConfig, values map[string]interface{}) map[string]interface{} {
+	if cfg.RegistryCaBundle == nil {
+		return values
+	}
+	if values == nil {
+		values = make(map[string]interface{})
+	}
+	values["registryCaBundleConfig"] = map[string]interface{}{
+		"name": RegistryCaBundleConfigMapName,
+		"key":  RegistryCaBundleConfigMapKey,
+	}
+	return values
+}
+
 // createRegistryCaBundleConfigMap creates a ConfigMap containing the CA bundle for Crossplane registry access
 // Multiple CA certificates are bundled together into a single PEM file
 // Note: The namespace must already exist before calling this function.
@@ -396,7 +437,8 @@ func (i *Installer) GetPodStatus(ctx context.Context) ([]PodInfo, bool, error) {
 // InstallProvider installs a Crossplane provider
 // name is the Kubernetes resource name for the provider
 // pkg is the full OCI package path (e.g., xpkg.upbound.io/upbound/provider-aws:v1.1.0)
-func (i *Installer) InstallProvider(ctx context.Context, name, pkg string) error {
+// pullSecrets name secrets in the Crossplane namespace used to pull the package
+func (i *Installer) InstallProvider(ctx context.Context, name, pkg string, pullSecrets ...string) error {
 	// Get dynamic client
 	dynamicClient, err := i.getDynamicClient()
 	if err != nil {
@@ -409,13 +451,22 @@ func (i *Installer) InstallProvider(ctx context.Context, name, pkg string) error
 		Resource: "providers",
 	}
 
+	spec := map[string]interface{}{
+		"package": pkg,
+	}
+	if len(pullSecrets) > 0 {
+		var refs []interface{}
+		for _, secret := range pullSecrets {
+			refs = append(refs, map[string]interface{}{"name": secret})
+		}
+		spec["packagePullSecrets"] = refs
+	}
+
 	// Check if provider already exists
 	existing, err := dynamicClient.Resource(gvr).Get(ctx, name, metav1.GetOptions{})
 	if err == nil {
 		// Provider exists - update it with the current resourceVersion
-		existing.Object["spec"] = map[string]interface{}{
-			"package": pkg,
-		}
+		existing.Object["spec"] = spec
 		_, err = dynamicClient.Resource(gvr).Update(ctx, existing, metav1.UpdateOptions{})
 		if err != nil {
 			return fmt.Errorf("failed to update provider: %w", err)
@@ -431,9 +482,7 @@ func (i *Installer) InstallProvider(ctx context.Context, name, pkg string) error
 			"metadata": map[string]interface{}{
 				"name": name,
 			},
-			"spec": map[string]interface{}{
-				"package": pkg,
-			},
+			"spec": spec,
 		},
 	}
 
@@ -642,19 +691,10 @@ func (i *Installer) GetProviderStatus(ctx context.Context) ([]ProviderStatus, er
 	return statuses, nil
 }
 
-// getDynamicClient creates a dynamic Kubernetes client
+// getDynamicClient returns the connection's dynamic client
 func (i *Installer) getDynamicClient() (dynamic.Interface, error) {
-	if i.dynamicClient != nil {
-		return i.dynamicClient, nil
+	if i.conn == nil {
+		return nil, fmt.Errorf("not connected to a cluster")
 	}
-
-	// Get rest config from kubeClient
-	// This is a simplified approach - in production you'd want to share the rest.Config
-	config, err := getRestConfig()
-	if err != nil {
-		return nil, err
-	}
-
-	i.dynamicClient, err = dynamic.NewForConfig(config)
-	return i.dynamicClient, err
+	return i.conn.Dynamic()
 }
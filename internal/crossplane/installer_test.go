@@ -130,17 +130,39 @@ func createValuesTransformer(cfg config.CrossplaneConfig, originalTransformer he
 		if originalTransformer != nil {
 			values = originalTransformer(values)
 		}
-		// Inject registryCaBundleConfig if configured
-		if cfg.RegistryCaBundle != nil {
-			if values == nil {
-				values = make(map[string]interface{})
-			}
-			values["registryCaBundleConfig"] = map[string]interface{}{
+		return injectRegistryCaBundle(cfg, values)
+	}
+}
+
+func TestChartConfig(t *testing.T) {
+	cfg := config.CrossplaneConfig{
+		Version:          "2.1.0",
+		Values:           map[string]interface{}{"replicas": 2},
+		ValuesFiles:      []string{"crossplane-values.yaml"},
+		RegistryCaBundle: &config.RegistryCaBundleConfig{CAFiles: []string{"/path/to/ca.crt"}},
+	}
+
+	got := ChartConfig(cfg)
+	want := config.ChartConfig{
+		Name:        "crossplane",
+		Repo:        CrossplaneRepoURL,
+		Chart:       CrossplaneChartName,
+		Version:     "2.1.0",
+		Namespace:   CrossplaneNamespace,
+		ValuesFiles: []string{"crossplane-values.yaml"},
+		Values: map[string]interface{}{
+			"replicas": 2,
+			"registryCaBundleConfig": map[string]interface{}{
 				"name": RegistryCaBundleConfigMapName,
 				"key":  RegistryCaBundleConfigMapKey,
-			}
-		}
-		return values
+			},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ChartConfig() = %+v, want %+v", got, want)
+	}
+	if _, ok := cfg.Values["registryCaBundleConfig"]; ok {
+		t.Error("ChartConfig() modified the configured values")
 	}
 }
 
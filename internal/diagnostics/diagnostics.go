@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/dynamic"
 	"k8s.io/client-go/kubernetes"
 
@@ -98,13 +99,17 @@ type EventInfo struct {
 type Collector struct {
 	kubeClient    kubernetes.Interface
 	dynamicClient dynamic.Interface
+	// helmGetter connects Helm release lookups to the same cluster
+	helmGetter genericclioptions.RESTClientGetter
 }
 
-// NewCollector creates a new diagnostics Collector
-func NewCollector(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface) *Collector {
+// NewCollector creates a new diagnostics Collector. helmGetter is used for
+// Helm release diagnostics; without it they are reported as errors.
+func NewCollector(kubeClient kubernetes.Interface, dynamicClient dynamic.Interface, helmGetter genericclioptions.RESTClientGetter) *Collector {
 	return &Collector{
 		kubeClient:    kubeClient,
 		dynamicClient: dynamicClient,
+		helmGetter:    helmGetter,
 	}
 }
 
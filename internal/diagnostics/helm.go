@@ -5,7 +5,6 @@ import (
 	"fmt"
 
 	"helm.sh/helm/v3/pkg/action"
-	"helm.sh/helm/v3/pkg/cli"
 )
 
 // HelmDiagnostic contains diagnostic information about a Helm release
@@ -32,11 +31,11 @@ func (c *Collector) CollectHelmDiagnostics(ctx context.Context, diagCtx Context)
 		namespace = "default"
 	}
 
-	// Create Helm settings and action configuration
-	settings := cli.New()
+	if c.helmGetter == nil {
+		return nil, fmt.Errorf("no cluster connection for Helm diagnostics")
+	}
 	actionConfig := new(action.Configuration)
-
-	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, "secret", nil); err != nil {
+	if err := actionConfig.Init(c.helmGetter, namespace, "secret", nil); err != nil {
 		return nil, fmt.Errorf("initializing helm config: %w", err)
 	}
 
@@ -72,51 +71,6 @@ func (c *Collector) CollectHelmDiagnostics(ctx context.Context, diagCtx Context)
 	return diag, nil
 }
 
-// CollectHelmDiagnosticsSimple collects Helm diagnostics without requiring the Collector
-// This is useful when you don't have a full Collector set up
-func CollectHelmDiagnosticsSimple(releaseName, namespace string) (*HelmDiagnostic, error) {
-	if namespace == "" {
-		namespace = "default"
-	}
-
-	settings := cli.New()
-	actionConfig := new(action.Configuration)
-
-	if err := actionConfig.Init(settings.RESTClientGetter(), namespace, "secret", nil); err != nil {
-		return nil, fmt.Errorf("initializing helm config: %w", err)
-	}
-
-	statusAction := action.NewStatus(actionConfig)
-	release, err := statusAction.Run(releaseName)
-	if err != nil {
-		return &HelmDiagnostic{
-			Name:      releaseName,
-			Namespace: namespace,
-			Status:    "not-found",
-			Error:     err.Error(),
-		}, nil
-	}
-
-	diag := &HelmDiagnostic{
-		Name:      release.Name,
-		Namespace: release.Namespace,
-		Version:   release.Version,
-	}
-
-	if release.Chart != nil && release.Chart.Metadata != nil {
-		diag.Chart = fmt.Sprintf("%s-%s", release.Chart.Metadata.Name, release.Chart.Metadata.Version)
-		diag.AppVersion = release.Chart.Metadata.AppVersion
-	}
-
-	if release.Info != nil {
-		diag.Status = string(release.Info.Status)
-		diag.Description = release.Info.Description
-		diag.Notes = release.Info.Notes
-	}
-
-	return diag, nil
-}
-
 // IsFailed returns true if the Helm release is in a failed state
 func (h *HelmDiagnostic) IsFailed() bool {
 	return h.Status == "failed" ||
@@ -125,13 +79,3 @@ func (h *HelmDiagnostic) IsFailed() bool {
 		h.Status == "pending-rollback" ||
 		h.Status == "not-found"
 }
-
-// GetHelmReleaseStatus gets the status of a Helm release
-// Returns status string, description, and any error
-func GetHelmReleaseStatus(releaseName, namespace string) (status string, description string, err error) {
-	diag, err := CollectHelmDiagnosticsSimple(releaseName, namespace)
-	if err != nil {
-		return "", "", err
-	}
-	return diag.Status, diag.Description, nil
-}
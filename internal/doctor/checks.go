@@ -10,6 +10,8 @@ import (
 
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/client-go/kubernetes"
+
+	"github.com/kanzi/kindplane/internal/container"
 )
 
 // CheckResult represents the result of a pre-flight check
@@ -28,7 +30,7 @@ type Check func(ctx context.Context) CheckResult
 // RunAllChecks runs all pre-flight checks
 func RunAllChecks(ctx context.Context, kubeClient *kubernetes.Clientset) []CheckResult {
 	checks := []Check{
-		CheckDocker,
+		CheckContainerRuntime,
 		CheckKubectl,
 		CheckHelm,
 		CheckDiskSpace,
@@ -48,6 +50,44 @@ func RunAllChecks(ctx context.Context, kubeClient *kubernetes.Clientset) []Check
 	return results
 }
 
+// CheckContainerRuntime checks the container runtime kindplane will use
+func CheckContainerRuntime(ctx context.Context) CheckResult {
+	if container.Default().Name() == container.RuntimePodman {
+		return CheckPodman(ctx)
+	}
+	return CheckDocker(ctx)
+}
+
+// CheckPodman checks if Podman is installed and can reach its engine
+func CheckPodman(ctx context.Context) CheckResult {
+	result := CheckResult{
+		Name:     "Podman engine",
+		Required: true,
+	}
+
+	podmanPath, err := exec.LookPath("podman")
+	if err != nil {
+		result.Passed = false
+		result.Message = "Podman not found in PATH"
+		result.Suggestion = "Install Podman from https://podman.io/docs/installation"
+		return result
+	}
+
+	cmd := exec.CommandContext(ctx, podmanPath, "info", "--format", "{{.Version.Version}}")
+	output, err := cmd.Output()
+	if err != nil {
+		result.Passed = false
+		result.Message = "Podman engine not reachable"
+		result.Suggestion = "Start the Podman machine (podman machine start) or socket (systemctl --user start podman.socket)"
+		return result
+	}
+
+	version := strings.TrimSpace(string(output))
+	result.Passed = true
+	result.Message = fmt.Sprintf("Running (v%s)", version)
+	return result
+}
+
 // CheckDocker checks if Docker is running and accessible
 func CheckDocker(ctx context.Context) CheckResult {
 	result := CheckResult{
@@ -3,6 +3,8 @@ package doctor
 import (
 	"context"
 	"testing"
+
+	"github.com/kanzi/kindplane/internal/container"
 )
 
 // TestCheckKind_Removed verifies that CheckKind has been removed from RunAllChecks
@@ -135,3 +137,19 @@ func TestCheckResult_Structure(t *testing.T) {
 		t.Log("Note: Message can be empty for passed checks")
 	}
 }
+
+// TestCheckContainerRuntime verifies the check follows the selected runtime
+func TestCheckContainerRuntime(t *testing.T) {
+	defer container.SetDefault(nil)
+	ctx := context.Background()
+
+	container.SetDefault(container.NewPodman())
+	if result := CheckContainerRuntime(ctx); result.Name != "Podman engine" {
+		t.Errorf("Expected check name 'Podman engine', got '%s'", result.Name)
+	}
+
+	container.SetDefault(container.NewDocker())
+	if result := CheckContainerRuntime(ctx); result.Name != "Docker daemon" {
+		t.Errorf("Expected check name 'Docker daemon', got '%s'", result.Name)
+	}
+}
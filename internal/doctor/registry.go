@@ -0,0 +1,153 @@
+package doctor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/kind"
+	"github.com/kanzi/kindplane/internal/oci"
+)
+
+// RegistryChecks returns checks for the credentials in cluster.registryAuth
+// and every endpoint in cluster.registryMirrors
+func RegistryChecks(cfg *config.Config) []Check {
+	var checks []Check
+	for _, a := range cfg.Cluster.RegistryAuth {
+		checks = append(checks, CheckRegistryAuth(a))
+	}
+	for _, mirror := range cfg.Cluster.RegistryMirrors {
+		for _, endpoint := range mirror.Endpoints {
+			checks = append(checks, CheckRegistryMirror(cfg, mirror.Host, endpoint))
+		}
+	}
+	return checks
+}
+
+// CheckRegistryAuth checks that the credentials of a registryAuth entry can
+// be read, as cluster creation fails without them
+func CheckRegistryAuth(a config.RegistryAuth) Check {
+	return func(ctx context.Context) CheckResult {
+		result := CheckResult{
+			Name:     fmt.Sprintf("Registry credentials %s", a.Host),
+			Required: true,
+		}
+
+		if _, err := kind.ResolveRegistryCredential(ctx, a); err != nil {
+			result.Passed = false
+			result.Message = "Not available"
+			result.Details = err.Error()
+			if a.UsernameEnv != "" {
+				result.Suggestion = fmt.Sprintf("Export %s and %s", a.UsernameEnv, a.PasswordEnv)
+			} else {
+				result.Suggestion = fmt.Sprintf("Run: docker login %s", a.Host)
+			}
+			return result
+		}
+
+		result.Passed = true
+		if a.UsernameEnv != "" {
+			result.Message = fmt.Sprintf("Found in %s", a.UsernameEnv)
+		} else {
+			result.Message = "Found in Docker config"
+		}
+		return result
+	}
+}
+
+// CheckRegistryMirror checks that a mirror endpoint answers the registry
+// API, trusting the CAs in cluster.trustedCAs and sending the credentials in
+// cluster.registryAuth for its host. A mirror that asks for credentials
+// counts as reachable unless kindplane has credentials it rejects.
+func CheckRegistryMirror(cfg *config.Config, registryHost, endpoint string) Check {
+	return func(ctx context.Context) CheckResult {
+		result := CheckResult{
+			Name:     fmt.Sprintf("Mirror %s for %s", endpoint, registryHost),
+			Required: false,
+		}
+
+		apiURL, err := kind.MirrorAPIURL(endpoint)
+		if err != nil {
+			result.Passed = false
+			result.Message = "Invalid endpoint"
+			result.Details = err.Error()
+			return result
+		}
+		u, _ := url.Parse(apiURL)
+
+		transport, err := oci.Transport(u.Host, cfg.Cluster.TrustedCAs.Registries)
+		if err != nil {
+			result.Passed = false
+			result.Message = "Invalid CA"
+			result.Details = err.Error()
+			return result
+		}
+		// Without credentials, a challenge is an answer in itself
+		httpClient := &http.Client{Transport: transport}
+		do := httpClient.Do
+		authenticated := false
+		for _, a := range cfg.Cluster.RegistryAuth {
+			if a.Host != u.Host {
+				continue
+			}
+			cred, err := kind.ResolveRegistryCredential(ctx, a)
+			if err != nil {
+				break
+			}
+			client := &auth.Client{
+				Client: httpClient,
+				Cache:  auth.NewCache(),
+				Credential: auth.StaticCredential(u.Host, auth.Credential{
+					Username:     cred.Username,
+					Password:     cred.Password,
+					RefreshToken: cred.IdentityToken,
+				}),
+			}
+			do = client.Do
+			authenticated = true
+		}
+
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+		if err != nil {
+			result.Passed = false
+			result.Message = "Invalid endpoint"
+			result.Details = err.Error()
+			return result
+		}
+		resp, err := do(req)
+		if err != nil {
+			result.Passed = false
+			result.Message = "Unreachable"
+			result.Details = err.Error()
+			result.Suggestion = fmt.Sprintf("Check the URL, or add the CA of %s to cluster.trustedCAs.registries", u.Host)
+			return result
+		}
+		_ = resp.Body.Close()
+
+		switch {
+		case resp.StatusCode == http.StatusOK && authenticated:
+			result.Passed = true
+			result.Message = "Reachable, credentials accepted"
+		case resp.StatusCode == http.StatusOK:
+			result.Passed = true
+			result.Message = "Reachable"
+		case resp.StatusCode == http.StatusUnauthorized && authenticated:
+			result.Passed = false
+			result.Message = "Credentials rejected"
+			result.Suggestion = fmt.Sprintf("Check the cluster.registryAuth entry for %s", u.Host)
+		case resp.StatusCode == http.StatusUnauthorized:
+			result.Passed = true
+			result.Message = "Reachable (requires authentication)"
+			result.Details = fmt.Sprintf("Add %s to cluster.registryAuth if it does not allow anonymous pulls", u.Host)
+		default:
+			result.Passed = false
+			result.Message = fmt.Sprintf("Unexpected response from %s: %s", apiURL, resp.Status)
+			result.Suggestion = "Check that the endpoint serves the registry API (/v2/)"
+		}
+		return result
+	}
+}
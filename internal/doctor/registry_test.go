@@ -0,0 +1,97 @@
+package doctor
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// TestCheckRegistryMirror tests mirror probes with and without credentials
+func TestCheckRegistryMirror(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/":
+			w.WriteHeader(http.StatusOK)
+		case "/private/v2/":
+			if user, pass, ok := r.BasicAuth(); ok && user == "user" && pass == "secret" {
+				w.WriteHeader(http.StatusOK)
+				return
+			}
+			w.Header().Set("WWW-Authenticate", `Basic realm="test"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	host := server.Listener.Addr().String()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		password string
+		wantPass bool
+		wantMsg  string
+	}{
+		{name: "anonymous", endpoint: server.URL, wantPass: true, wantMsg: "Reachable"},
+		{name: "requires auth", endpoint: server.URL + "/private", wantPass: true, wantMsg: "Reachable (requires authentication)"},
+		{name: "credentials accepted", endpoint: server.URL + "/private", password: "secret", wantPass: true, wantMsg: "Reachable, credentials accepted"},
+		{name: "credentials rejected", endpoint: server.URL + "/private", password: "wrong", wantPass: false, wantMsg: "Credentials rejected"},
+		{name: "not a registry", endpoint: server.URL + "/other", wantPass: false},
+		{name: "unreachable", endpoint: "http://127.0.0.1:1", wantPass: false, wantMsg: "Unreachable"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{}
+			if tt.password != "" {
+				t.Setenv("TEST_MIRROR_USER", "user")
+				t.Setenv("TEST_MIRROR_PASSWORD", tt.password)
+				cfg.Cluster.RegistryAuth = []config.RegistryAuth{{Host: host, UsernameEnv: "TEST_MIRROR_USER", PasswordEnv: "TEST_MIRROR_PASSWORD"}}
+			}
+
+			result := CheckRegistryMirror(cfg, "docker.io", tt.endpoint)(context.Background())
+			if result.Passed != tt.wantPass {
+				t.Errorf("Passed = %v, want %v (%s: %s)", result.Passed, tt.wantPass, result.Message, result.Details)
+			}
+			if tt.wantMsg != "" && result.Message != tt.wantMsg {
+				t.Errorf("Message = %q, want %q", result.Message, tt.wantMsg)
+			}
+		})
+	}
+}
+
+// TestCheckRegistryAuth tests that missing credentials fail the check
+func TestCheckRegistryAuth(t *testing.T) {
+	t.Setenv("TEST_REGISTRY_USER", "user")
+	t.Setenv("TEST_REGISTRY_PASSWORD", "secret")
+
+	result := CheckRegistryAuth(config.RegistryAuth{Host: "registry.example.com", UsernameEnv: "TEST_REGISTRY_USER", PasswordEnv: "TEST_REGISTRY_PASSWORD"})(context.Background())
+	if !result.Passed {
+		t.Errorf("expected credentials from the environment to pass: %s", result.Details)
+	}
+
+	result = CheckRegistryAuth(config.RegistryAuth{Host: "registry.example.com", UsernameEnv: "TEST_REGISTRY_USER", PasswordEnv: "TEST_REGISTRY_MISSING"})(context.Background())
+	if result.Passed || !result.Required {
+		t.Errorf("expected a required failure, got %+v", result)
+	}
+}
+
+// TestRegistryChecks tests that every mirror endpoint and credential is checked
+func TestRegistryChecks(t *testing.T) {
+	endpoint := "https://mirror.example.com"
+	cfg := &config.Config{Cluster: config.ClusterConfig{
+		RegistryMirrors: []config.RegistryMirror{
+			{Host: "docker.io", Endpoints: []string{endpoint, "https://backup.example.com"}},
+			{Host: "ghcr.io", Endpoints: []string{endpoint}},
+		},
+		RegistryAuth: []config.RegistryAuth{{Host: "mirror.example.com"}},
+	}}
+
+	if got := len(RegistryChecks(cfg)); got != 4 {
+		t.Errorf("RegistryChecks() returned %d checks, want 4", got)
+	}
+}
@@ -2,12 +2,15 @@ package git
 
 import (
 	"context"
+	"crypto/sha256"
 	"fmt"
 	"os"
 	"path/filepath"
 
 	"github.com/go-git/go-git/v5"
 	"github.com/go-git/go-git/v5/plumbing"
+
+	"github.com/kanzi/kindplane/internal/cache"
 )
 
 // CloneRepo clones a git repository and returns the path
@@ -73,3 +76,58 @@ func CloneRepoToPath(ctx context.Context, repoURL, branch, destPath string) erro
 func CleanupTempDir(path string) error {
 	return os.RemoveAll(path)
 }
+
+// cacheMeta describes a checkout in the git cache
+type cacheMeta struct {
+	Name   string `json:"name"`
+	Repo   string `json:"repo"`
+	Branch string `json:"branch,omitempty"`
+}
+
+// CachedClone returns a checkout of a branch of a git repository in the git
+// cache, cloning it afresh so the branch's latest commit is used. Offline it
+// returns the checkout of the last clone instead. The checkout is owned by
+// the cache and must not be removed.
+func CachedClone(ctx context.Context, repoURL, branch string) (string, error) {
+	dir, err := cache.Dir(cache.Git)
+	if err != nil {
+		return "", err
+	}
+	entry := filepath.Join(dir, fmt.Sprintf("%x", sha256.Sum256([]byte(repoURL+"\n"+branch)))[:16])
+
+	if cache.Offline() {
+		var meta cacheMeta
+		if err := cache.ReadMeta(entry, &meta); err != nil {
+			return "", fmt.Errorf("repository %s is not in the git cache; run once without --offline to cache it", repoURL)
+		}
+		cache.Touch(entry)
+		return filepath.Join(entry, "checkout"), nil
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("failed to create git cache: %w", err)
+	}
+	tmp, err := os.MkdirTemp(dir, ".clone-*")
+	if err != nil {
+		return "", fmt.Errorf("failed to create temp directory: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	if err := CloneRepoToPath(ctx, repoURL, branch, filepath.Join(tmp, "checkout")); err != nil {
+		return "", err
+	}
+	name := repoURL
+	if branch != "" {
+		name += "@" + branch
+	}
+	if err := cache.WriteMeta(tmp, cacheMeta{Name: name, Repo: repoURL, Branch: branch}); err != nil {
+		return "", err
+	}
+
+	// Replace the previous clone
+	_ = os.RemoveAll(entry)
+	if err := os.Rename(tmp, entry); err != nil {
+		return "", fmt.Errorf("failed to store clone: %w", err)
+	}
+	return filepath.Join(entry, "checkout"), nil
+}
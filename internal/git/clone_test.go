@@ -0,0 +1,81 @@
+package git
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-git/go-git/v5"
+	"github.com/go-git/go-git/v5/plumbing/object"
+
+	"github.com/kanzi/kindplane/internal/cache"
+)
+
+// commitFile commits a file with content to the repository at dir
+func commitFile(t *testing.T, repo *git.Repository, dir, name, content string) {
+	t.Helper()
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wt, err := repo.Worktree()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := wt.Add(name); err != nil {
+		t.Fatal(err)
+	}
+	sig := &object.Signature{Name: "test", Email: "test@example.com", When: time.Now()}
+	if _, err := wt.Commit("update "+name, &git.CommitOptions{Author: sig}); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestCachedClone(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() { cache.SetOffline(false) })
+
+	src := t.TempDir()
+	repo, err := git.PlainInit(src, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	commitFile(t, repo, src, "composition.yaml", "v1")
+
+	ctx := context.Background()
+	checkout, err := CachedClone(ctx, src, "")
+	if err != nil {
+		t.Fatalf("CachedClone() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(checkout, "composition.yaml")); string(data) != "v1" {
+		t.Errorf("checkout has %q, want v1", data)
+	}
+
+	// Online, every call picks up new commits
+	commitFile(t, repo, src, "composition.yaml", "v2")
+	checkout, err = CachedClone(ctx, src, "")
+	if err != nil {
+		t.Fatalf("CachedClone() error = %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(checkout, "composition.yaml")); string(data) != "v2" {
+		t.Errorf("checkout has %q, want v2", data)
+	}
+	if entries, _ := cache.List(cache.Git); len(entries) != 1 || entries[0].Name != src {
+		t.Errorf("git cache = %+v, want one entry named %s", entries, src)
+	}
+
+	// Offline, the last clone is used even without the repository
+	if err := os.RemoveAll(src); err != nil {
+		t.Fatal(err)
+	}
+	cache.SetOffline(true)
+	offline, err := CachedClone(ctx, src, "")
+	if err != nil || offline != checkout {
+		t.Errorf("offline CachedClone() = %s, %v; want %s", offline, err, checkout)
+	}
+	if _, err := CachedClone(ctx, src, "main"); err == nil || !strings.Contains(err.Error(), "not in the git cache") {
+		t.Errorf("offline CachedClone() of an uncached branch error = %v, want a cache miss", err)
+	}
+}
@@ -0,0 +1,208 @@
+package helm
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/Masterminds/semver/v3"
+	"helm.sh/helm/v3/pkg/chart/loader"
+
+	"github.com/kanzi/kindplane/internal/cache"
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// chartMeta describes an entry of the chart cache. Entries are directories
+// named <key>-<version>, where the key identifies the repository and chart,
+// holding the chart archive and this metadata.
+type chartMeta struct {
+	Name    string `json:"name"`
+	Repo    string `json:"repo"`
+	Chart   string `json:"chart"`
+	Version string `json:"version"`
+	// Archive is the file name of the chart archive in the entry
+	Archive string `json:"archive"`
+	// Digest is the manifest digest of an OCI chart when it was cached
+	Digest string `json:"digest,omitempty"`
+}
+
+// chartCacheKey identifies a repository chart in the chart cache
+func chartCacheKey(repoURL, chartName string) string {
+	sum := sha256.Sum256([]byte(strings.TrimSuffix(repoURL, "/") + "\n" + chartName))
+	return fmt.Sprintf("%x", sum)[:16]
+}
+
+// isExactVersion reports whether a chart version names one version rather
+// than a range or the latest version
+func isExactVersion(version string) bool {
+	_, err := semver.StrictNewVersion(strings.TrimPrefix(version, "v"))
+	return err == nil
+}
+
+// cachedLocate returns the archive of spec's repository chart from the chart
+// cache. On a miss, or when an OCI tag now points at a different digest, it
+// calls download and stores the result. Offline it never downloads: a chart
+// without an exact version resolves to the newest cached version matching
+// it.
+func (i *Installer) cachedLocate(spec ChartSpec, download func() (string, error)) (string, error) {
+	dir, err := cache.Dir(cache.Charts)
+	if err != nil {
+		return "", err
+	}
+	key := chartCacheKey(spec.RepoURL, spec.ChartName)
+	oci := config.IsOCIRepo(spec.RepoURL)
+
+	if isExactVersion(spec.Version) {
+		entry := filepath.Join(dir, key+"-"+spec.Version)
+		var meta chartMeta
+		if err := cache.ReadMeta(entry, &meta); err == nil {
+			// A tag in an OCI registry can be pushed again; an unreachable
+			// registry keeps the cached chart
+			current := ""
+			if oci && !cache.Offline() {
+				current, _ = i.ociDigest(spec, spec.Version)
+			}
+			if current == "" || current == meta.Digest {
+				cache.Touch(entry)
+				return filepath.Join(entry, meta.Archive), nil
+			}
+			// Make way for the chart the tag points at now
+			_ = os.RemoveAll(entry)
+		}
+	} else if cache.Offline() {
+		if entry, meta, ok := newestCachedChart(dir, key, spec.Version); ok {
+			cache.Touch(entry)
+			return filepath.Join(entry, meta.Archive), nil
+		}
+	}
+
+	if cache.Offline() {
+		version := spec.Version
+		if version == "" {
+			version = "(latest)"
+		}
+		return "", fmt.Errorf("chart %s %s is not in the chart cache; run once without --offline to cache it", spec.ChartName, version)
+	}
+
+	downloaded, err := download()
+	if err != nil {
+		return "", err
+	}
+	stored, err := i.storeChart(dir, key, spec, downloaded)
+	if err != nil {
+		debugLog("failed to cache chart %s: %v", spec.ChartName, err)
+		return downloaded, nil
+	}
+	return stored, nil
+}
+
+// newestCachedChart returns the newest cached version of the chart with key
+// matching a version constraint; an empty constraint matches any version
+func newestCachedChart(dir, key, constraint string) (entry string, meta chartMeta, ok bool) {
+	var c *semver.Constraints
+	if constraint != "" {
+		var err error
+		if c, err = semver.NewConstraint(constraint); err != nil {
+			return "", chartMeta{}, false
+		}
+	}
+
+	matches, _ := filepath.Glob(filepath.Join(dir, key+"-*"))
+	var newest *semver.Version
+	for _, m := range matches {
+		var candidate chartMeta
+		if err := cache.ReadMeta(m, &candidate); err != nil {
+			continue
+		}
+		v, err := semver.NewVersion(candidate.Version)
+		if err != nil || (c != nil && !c.Check(v)) {
+			continue
+		}
+		if newest == nil || v.GreaterThan(newest) {
+			newest, entry, meta, ok = v, m, candidate, true
+		}
+	}
+	return entry, meta, ok
+}
+
+// storeChart copies a downloaded chart archive into the chart cache and
+// returns its cached path. The entry is written under a temporary name and
+// renamed, so concurrent installs never see a partial entry. An existing
+// entry is never replaced, since a parallel install may be loading it; it
+// is returned instead.
+func (i *Installer) storeChart(dir, key string, spec ChartSpec, archive string) (string, error) {
+	loaded, err := loader.Load(archive)
+	if err != nil {
+		return "", err
+	}
+	meta := chartMeta{
+		Name:    spec.ChartName + " " + loaded.Metadata.Version,
+		Repo:    spec.RepoURL,
+		Chart:   spec.ChartName,
+		Version: loaded.Metadata.Version,
+		Archive: filepath.Base(archive),
+	}
+	if config.IsOCIRepo(spec.RepoURL) {
+		meta.Digest, _ = i.ociDigest(spec, meta.Version)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	tmp, err := os.MkdirTemp(dir, ".chart-*")
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = os.RemoveAll(tmp) }()
+
+	if err := copyFile(archive, filepath.Join(tmp, meta.Archive)); err != nil {
+		return "", err
+	}
+	if err := cache.WriteMeta(tmp, meta); err != nil {
+		return "", err
+	}
+
+	entry := filepath.Join(dir, key+"-"+meta.Version)
+	if cached, ok := cachedArchive(entry); ok {
+		return cached, nil
+	}
+	if err := os.Rename(tmp, entry); err != nil {
+		// Another install stored the same version first
+		if cached, ok := cachedArchive(entry); ok {
+			return cached, nil
+		}
+		return "", err
+	}
+	return filepath.Join(entry, meta.Archive), nil
+}
+
+// cachedArchive returns the archive of a complete chart cache entry
+func cachedArchive(entry string) (string, bool) {
+	var meta chartMeta
+	if err := cache.ReadMeta(entry, &meta); err != nil {
+		return "", false
+	}
+	archive := filepath.Join(entry, meta.Archive)
+	if _, err := os.Stat(archive); err != nil {
+		return "", false
+	}
+	cache.Touch(entry)
+	return archive, true
+}
+
+// ociDigest returns the manifest digest of a version of spec's OCI chart.
+// OCI tags cannot contain '+', which Helm replaces with '_'.
+func (i *Installer) ociDigest(spec ChartSpec, version string) (string, error) {
+	client, err := i.newRegistryClient(spec)
+	if err != nil {
+		return "", err
+	}
+	ref := strings.TrimPrefix(ChartRef(spec.RepoURL, spec.RepoName, spec.ChartName), "oci://")
+	desc, err := client.Resolve(ref + ":" + strings.ReplaceAll(version, "+", "_"))
+	if err != nil {
+		return "", err
+	}
+	return desc.Digest.String(), nil
+}
@@ -0,0 +1,151 @@
+package helm
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/cli"
+
+	"github.com/kanzi/kindplane/internal/cache"
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func TestIsExactVersion(t *testing.T) {
+	tests := []struct {
+		version string
+		want    bool
+	}{
+		{"1.2.3", true},
+		{"v1.2.3", true},
+		{"1.2.3-rc.1+build.5", true},
+		{"", false},
+		{"1.2", false},
+		{"^1.2.0", false},
+		{">=1.0.0 <2.0.0", false},
+	}
+	for _, tt := range tests {
+		if got := isExactVersion(tt.version); got != tt.want {
+			t.Errorf("isExactVersion(%q) = %v, want %v", tt.version, got, tt.want)
+		}
+	}
+}
+
+func TestChartCache(t *testing.T) {
+	helmHome := t.TempDir()
+	t.Setenv("HELM_REPOSITORY_CONFIG", helmHome+"/repositories.yaml")
+	t.Setenv("HELM_REPOSITORY_CACHE", helmHome+"/cache")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() { cache.SetOffline(false) })
+
+	repoURL := serveTestChart(t)
+	ctx := context.Background()
+	chartCfg := config.ChartConfig{Repo: repoURL, Chart: "demo", Version: "0.1.0"}
+
+	if _, err := FetchChart(ctx, chartCfg, t.TempDir()); err != nil {
+		t.Fatalf("FetchChart() error = %v", err)
+	}
+	entries, err := cache.List(cache.Charts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 || entries[0].Name != "demo 0.1.0" {
+		t.Fatalf("chart cache = %+v, want one entry demo 0.1.0", entries)
+	}
+
+	// Offline, charts come from the chart cache alone
+	if err := os.RemoveAll(helmHome); err != nil {
+		t.Fatal(err)
+	}
+	cache.SetOffline(true)
+
+	for _, version := range []string{"0.1.0", "", "^0.1.0"} {
+		chartCfg.Version = version
+		path, err := FetchChart(ctx, chartCfg, t.TempDir())
+		if err != nil {
+			t.Errorf("offline FetchChart(version %q) error = %v", version, err)
+		} else if filepath.Base(path) != "demo-0.1.0.tgz" {
+			t.Errorf("offline FetchChart(version %q) = %s, want demo-0.1.0.tgz", version, path)
+		}
+	}
+
+	for _, cfg := range []config.ChartConfig{
+		{Repo: repoURL, Chart: "demo", Version: "0.2.0"},
+		{Repo: repoURL, Chart: "demo", Version: "^1.0.0"},
+		{Repo: repoURL, Chart: "other", Version: "0.1.0"},
+	} {
+		_, err := FetchChart(ctx, cfg, t.TempDir())
+		if err == nil || !strings.Contains(err.Error(), "not in the chart cache") {
+			t.Errorf("offline FetchChart(%s %s) error = %v, want a cache miss", cfg.Chart, cfg.Version, err)
+		}
+	}
+}
+
+func TestStoreChart_Concurrent(t *testing.T) {
+	helmHome := t.TempDir()
+	t.Setenv("HELM_REPOSITORY_CONFIG", helmHome+"/repositories.yaml")
+	t.Setenv("HELM_REPOSITORY_CACHE", helmHome+"/cache")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	repoURL := serveTestChart(t)
+	archive, err := FetchChart(context.Background(), config.ChartConfig{Repo: repoURL, Chart: "demo", Version: "0.1.0"}, t.TempDir())
+	if err != nil {
+		t.Fatalf("FetchChart() error = %v", err)
+	}
+	if _, err := cache.Prune(0, cache.Charts); err != nil {
+		t.Fatal(err)
+	}
+
+	// Installs of releases sharing a chart store it at the same time; none
+	// may lose the entry another has just resolved
+	i := &Installer{settings: cli.New()}
+	dir, err := cache.Dir(cache.Charts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	spec := ChartSpec{RepoURL: repoURL, ChartName: "demo", Version: "0.1.0"}
+	var wg sync.WaitGroup
+	errs := make(chan error, 8)
+	for range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			path, err := i.storeChart(dir, chartCacheKey(repoURL, "demo"), spec, archive)
+			if err == nil {
+				_, err = loader.Load(path)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+	for err := range errs {
+		if err != nil {
+			t.Errorf("storeChart() and load error = %v", err)
+		}
+	}
+
+	// A stored entry stays as it is rather than being replaced under a reader
+	entries, err := cache.List(cache.Charts)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("chart cache = %+v, %v; want one entry", entries, err)
+	}
+	marker := filepath.Join(entries[0].Path, "in-use")
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := i.storeChart(dir, chartCacheKey(repoURL, "demo"), spec, archive); err != nil {
+		t.Fatalf("storeChart() error = %v", err)
+	}
+	if _, err := os.Stat(marker); err != nil {
+		t.Errorf("existing entry was replaced: %v", err)
+	}
+
+	if tmp, _ := filepath.Glob(filepath.Join(dir, ".chart-*")); len(tmp) != 0 {
+		t.Errorf("temporary entries left behind: %v", tmp)
+	}
+}
@@ -0,0 +1,164 @@
+package helm
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/storage/driver"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// DeployedRelease describes the latest revision of a release in the cluster
+type DeployedRelease struct {
+	Name       string
+	Namespace  string
+	Revision   int
+	Status     string
+	Chart      string
+	Version    string
+	AppVersion string
+	// Values are the values supplied when the release was installed or
+	// upgraded, without the chart's defaults
+	Values map[string]interface{}
+}
+
+// GetRelease returns the latest revision of a release, or nil when the
+// release is not installed
+func (i *Installer) GetRelease(ctx context.Context, releaseName, namespace string) (*DeployedRelease, error) {
+	actionConfig, err := i.releaseActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return getRelease(actionConfig, releaseName)
+}
+
+// getRelease implements GetRelease on an action configuration
+func getRelease(actionConfig *action.Configuration, releaseName string) (*DeployedRelease, error) {
+	rel, err := action.NewGet(actionConfig).Run(releaseName)
+	if errors.Is(err, driver.ErrReleaseNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release: %w", err)
+	}
+
+	deployed := &DeployedRelease{
+		Name:      rel.Name,
+		Namespace: rel.Namespace,
+		Revision:  rel.Version,
+		Values:    rel.Config,
+	}
+	if rel.Info != nil {
+		deployed.Status = string(rel.Info.Status)
+	}
+	if rel.Chart != nil && rel.Chart.Metadata != nil {
+		deployed.Chart = rel.Chart.Metadata.Name
+		deployed.Version = rel.Chart.Metadata.Version
+		deployed.AppVersion = rel.Chart.Metadata.AppVersion
+	}
+	return deployed, nil
+}
+
+// ConfiguredVersion returns the chart version a ChartConfig installs: its
+// version, or the version of its local chart. It is empty for repository
+// charts without a version, which install the latest one.
+func ConfiguredVersion(chartCfg config.ChartConfig) (string, error) {
+	if chartCfg.Path == "" {
+		return chartCfg.Version, nil
+	}
+	loaded, err := loader.Load(chartCfg.Path)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart: %w", err)
+	}
+	return loaded.Metadata.Version, nil
+}
+
+// Kinds of ValueChange
+const (
+	// ValueChanged is a value set differently in the config and the release
+	ValueChanged = "changed"
+	// ValueNotDeployed is a value set in the config but not in the release
+	ValueNotDeployed = "not-deployed"
+	// ValueNotConfigured is a value set in the release but not in the config
+	ValueNotConfigured = "not-configured"
+)
+
+// ValueChange is one difference between configured and deployed values
+type ValueChange struct {
+	// Path is the dotted key of the value, e.g. controller.replicaCount
+	Path     string      `json:"path"`
+	Kind     string      `json:"kind"`
+	Config   interface{} `json:"config,omitempty"`
+	Deployed interface{} `json:"deployed,omitempty"`
+}
+
+// DiffValues compares configured values with the values of a release, by
+// key path, descending into maps. Lists are compared whole. Values are
+// compared as JSON, the form Helm stores them in, so 2 and 2.0 are equal.
+func DiffValues(configured, deployed map[string]interface{}) ([]ValueChange, error) {
+	want, err := normalizeValues(configured)
+	if err != nil {
+		return nil, err
+	}
+	got, err := normalizeValues(deployed)
+	if err != nil {
+		return nil, err
+	}
+
+	var changes []ValueChange
+	diffMaps(want, got, "", &changes)
+	return changes, nil
+}
+
+// normalizeValues round-trips values through JSON
+func normalizeValues(values map[string]interface{}) (map[string]interface{}, error) {
+	data, err := json.Marshal(values)
+	if err != nil {
+		return nil, fmt.Errorf("failed to compare values: %w", err)
+	}
+	var normalized map[string]interface{}
+	if err := json.Unmarshal(data, &normalized); err != nil {
+		return nil, fmt.Errorf("failed to compare values: %w", err)
+	}
+	return normalized, nil
+}
+
+// diffMaps appends the differences between want and got, in key order
+func diffMaps(want, got map[string]interface{}, prefix string, changes *[]ValueChange) {
+	keys := make([]string, 0, len(want)+len(got))
+	for key := range want {
+		keys = append(keys, key)
+	}
+	for key := range got {
+		if _, ok := want[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		path := prefix + key
+		w, inWant := want[key]
+		g, inGot := got[key]
+		wantMap, wantIsMap := w.(map[string]interface{})
+		gotMap, gotIsMap := g.(map[string]interface{})
+
+		switch {
+		case !inGot:
+			*changes = append(*changes, ValueChange{Path: path, Kind: ValueNotDeployed, Config: w})
+		case !inWant:
+			*changes = append(*changes, ValueChange{Path: path, Kind: ValueNotConfigured, Deployed: g})
+		case wantIsMap && gotIsMap:
+			diffMaps(wantMap, gotMap, path+".", changes)
+		case !reflect.DeepEqual(w, g):
+			*changes = append(*changes, ValueChange{Path: path, Kind: ValueChanged, Config: w, Deployed: g})
+		}
+	}
+}
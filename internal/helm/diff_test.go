@@ -0,0 +1,109 @@
+package helm
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func TestDiffValues(t *testing.T) {
+	tests := []struct {
+		name       string
+		configured map[string]interface{}
+		deployed   map[string]interface{}
+		want       []ValueChange
+	}{
+		{
+			name:       "equal with different number types",
+			configured: map[string]interface{}{"replicas": 2, "image": map[string]interface{}{"tag": "v1"}},
+			deployed:   map[string]interface{}{"replicas": 2.0, "image": map[string]interface{}{"tag": "v1"}},
+		},
+		{
+			name:       "both empty",
+			configured: nil,
+			deployed:   map[string]interface{}{},
+		},
+		{
+			name: "nested changes",
+			configured: map[string]interface{}{
+				"controller": map[string]interface{}{"replicaCount": 2, "args": []interface{}{"a", "b"}},
+				"rbac":       map[string]interface{}{"create": true},
+			},
+			deployed: map[string]interface{}{
+				"controller": map[string]interface{}{"replicaCount": "3", "args": []interface{}{"a"}},
+				"extra":      "set by hand",
+			},
+			want: []ValueChange{
+				{Path: "controller.args", Kind: ValueChanged, Config: []interface{}{"a", "b"}, Deployed: []interface{}{"a"}},
+				{Path: "controller.replicaCount", Kind: ValueChanged, Config: float64(2), Deployed: "3"},
+				{Path: "extra", Kind: ValueNotConfigured, Deployed: "set by hand"},
+				{Path: "rbac", Kind: ValueNotDeployed, Config: map[string]interface{}{"create": true}},
+			},
+		},
+		{
+			name:       "map replaced by scalar",
+			configured: map[string]interface{}{"service": map[string]interface{}{"type": "NodePort"}},
+			deployed:   map[string]interface{}{"service": "none"},
+			want: []ValueChange{
+				{Path: "service", Kind: ValueChanged, Config: map[string]interface{}{"type": "NodePort"}, Deployed: "none"},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DiffValues(tt.configured, tt.deployed)
+			if err != nil {
+				t.Fatalf("DiffValues() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("DiffValues() = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetRelease(t *testing.T) {
+	actionConfig := testReleaseConfig(t, [2]string{"0.1.0", "superseded"}, [2]string{"0.2.0", "deployed"})
+
+	rel, err := getRelease(actionConfig, "demo")
+	if err != nil {
+		t.Fatalf("getRelease() error = %v", err)
+	}
+	if rel == nil || rel.Revision != 2 || rel.Chart != "demo" || rel.Version != "0.2.0" || rel.Status != "deployed" {
+		t.Errorf("getRelease() = %+v, want revision 2 of demo 0.2.0", rel)
+	}
+
+	missing, err := getRelease(actionConfig, "missing")
+	if err != nil || missing != nil {
+		t.Errorf("getRelease(missing) = %+v, %v; want nil, nil", missing, err)
+	}
+}
+
+func TestConfiguredVersion(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "demo")
+	if err := chartutil.SaveDir(testChart(), filepath.Dir(dir)); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name     string
+		chartCfg config.ChartConfig
+		want     string
+	}{
+		{"pinned", config.ChartConfig{Repo: "https://charts.example.com", Chart: "demo", Version: "1.2.3"}, "1.2.3"},
+		{"latest", config.ChartConfig{Repo: "https://charts.example.com", Chart: "demo"}, ""},
+		{"local chart", config.ChartConfig{Path: dir}, "0.1.0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ConfiguredVersion(tt.chartCfg)
+			if err != nil || got != tt.want {
+				t.Errorf("ConfiguredVersion() = %q, %v; want %q", got, err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,52 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"helm.sh/helm/v3/pkg/cli"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// FetchChart downloads the archive of a chart from its repository or OCI
+// registry into dir and returns its path. An empty version fetches the
+// latest version.
+func FetchChart(ctx context.Context, chartCfg config.ChartConfig, dir string) (string, error) {
+	installer := &Installer{settings: cli.New()}
+	cached, err := installer.locateChart(ctx, chartCfg)
+	if err != nil {
+		return "", err
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", err
+	}
+	dst := filepath.Join(dir, filepath.Base(cached))
+	if err := copyFile(cached, dst); err != nil {
+		return "", fmt.Errorf("failed to copy chart %s: %w", chartCfg.Chart, err)
+	}
+	return dst, nil
+}
+
+// copyFile copies the file at src to dst
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = in.Close() }()
+
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		_ = out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,40 @@
+package helm
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart/loader"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func TestFetchChart(t *testing.T) {
+	helmHome := t.TempDir()
+	t.Setenv("HELM_REPOSITORY_CONFIG", helmHome+"/repositories.yaml")
+	t.Setenv("HELM_REPOSITORY_CACHE", helmHome+"/cache")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	repoURL := serveTestChart(t)
+	dir := t.TempDir()
+
+	path, err := FetchChart(context.Background(), config.ChartConfig{Repo: repoURL, Chart: "demo"}, dir)
+	if err != nil {
+		t.Fatalf("FetchChart() error = %v", err)
+	}
+	if filepath.Dir(path) != dir || filepath.Base(path) != "demo-0.1.0.tgz" {
+		t.Errorf("FetchChart() = %s, want demo-0.1.0.tgz in %s", path, dir)
+	}
+	ch, err := loader.Load(path)
+	if err != nil {
+		t.Fatalf("fetched chart does not load: %v", err)
+	}
+	if ch.Metadata.Version != "0.1.0" {
+		t.Errorf("chart version = %s, want 0.1.0", ch.Metadata.Version)
+	}
+
+	if _, err := FetchChart(context.Background(), config.ChartConfig{Repo: repoURL, Chart: "demo", Version: "9.9.9"}, dir); err == nil {
+		t.Error("FetchChart() of a missing version succeeded")
+	}
+}
@@ -0,0 +1,112 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// DefaultChartConcurrency is how many charts of a phase are installed at
+// once when GraphOptions.Concurrency is not set
+const DefaultChartConcurrency = 4
+
+// GraphOptions controls InstallGraph
+type GraphOptions struct {
+	// Concurrency bounds how many charts are installed at once
+	// (default DefaultChartConcurrency)
+	Concurrency int
+	// OnStart is called when a chart's installation starts
+	OnStart func(chart config.ChartConfig)
+	// OnDone is called when a chart's installation ends, with its error
+	OnDone func(chart config.ChartConfig, err error)
+}
+
+// InstallGraph installs charts in dependency order: a chart starts once the
+// charts it depends on have been installed, and charts without pending
+// dependencies are installed concurrently. Dependencies on charts not in
+// charts belong to earlier phases and are taken as installed. After a
+// failure no further charts are started; the first error is returned once
+// the running installs end. The callbacks are only called from the calling
+// goroutine.
+func InstallGraph(ctx context.Context, charts []config.ChartConfig, opts GraphOptions, install func(context.Context, config.ChartConfig) error) error {
+	concurrency := opts.Concurrency
+	if concurrency <= 0 {
+		concurrency = DefaultChartConcurrency
+	}
+
+	index := make(map[string]int, len(charts))
+	for i, chart := range charts {
+		index[chart.Name] = i
+	}
+	pending := make([]int, len(charts))
+	dependents := make([][]int, len(charts))
+	for i, chart := range charts {
+		for _, dep := range chart.DependsOn {
+			if j, ok := index[dep]; ok && j != i {
+				pending[i]++
+				dependents[j] = append(dependents[j], i)
+			}
+		}
+	}
+	var ready []int
+	for i := range charts {
+		if pending[i] == 0 {
+			ready = append(ready, i)
+		}
+	}
+
+	type result struct {
+		i   int
+		err error
+	}
+	results := make(chan result)
+	running, installed := 0, 0
+	var firstErr error
+	for {
+		for firstErr == nil && ctx.Err() == nil && running < concurrency && len(ready) > 0 {
+			i := ready[0]
+			ready = ready[1:]
+			running++
+			if opts.OnStart != nil {
+				opts.OnStart(charts[i])
+			}
+			go func() {
+				results <- result{i: i, err: install(ctx, charts[i])}
+			}()
+		}
+		if running == 0 {
+			break
+		}
+
+		r := <-results
+		running--
+		if opts.OnDone != nil {
+			opts.OnDone(charts[r.i], r.err)
+		}
+		if r.err != nil {
+			if firstErr == nil {
+				firstErr = r.err
+			}
+			continue
+		}
+		installed++
+		for _, j := range dependents[r.i] {
+			if pending[j]--; pending[j] == 0 {
+				ready = append(ready, j)
+			}
+		}
+	}
+
+	if firstErr != nil {
+		return firstErr
+	}
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	if installed < len(charts) {
+		// Validate rejects cycles, so this only guards against misuse
+		return fmt.Errorf("%d chart(s) not installed: their dependencies form a cycle", len(charts)-installed)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package helm
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func TestInstallGraph_Order(t *testing.T) {
+	charts := []config.ChartConfig{
+		{Name: "trust-manager", DependsOn: []string{"cert-manager"}},
+		{Name: "cert-manager"},
+		{Name: "issuers", DependsOn: []string{"cert-manager", "trust-manager"}},
+		{Name: "ingress", DependsOn: []string{"crossplane-earlier-phase"}},
+	}
+
+	var mu sync.Mutex
+	done := make(map[string]bool)
+	err := InstallGraph(context.Background(), charts, GraphOptions{}, func(_ context.Context, chart config.ChartConfig) error {
+		mu.Lock()
+		defer mu.Unlock()
+		for _, dep := range chart.DependsOn {
+			if dep != "crossplane-earlier-phase" && !done[dep] {
+				t.Errorf("%s installed before its dependency %s", chart.Name, dep)
+			}
+		}
+		done[chart.Name] = true
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("InstallGraph failed: %v", err)
+	}
+	if len(done) != len(charts) {
+		t.Errorf("installed %d chart(s), want %d", len(done), len(charts))
+	}
+}
+
+func TestInstallGraph_Concurrency(t *testing.T) {
+	var charts []config.ChartConfig
+	for _, name := range []string{"a", "b", "c", "d", "e", "f"} {
+		charts = append(charts, config.ChartConfig{Name: name})
+	}
+
+	var mu sync.Mutex
+	running, peak := 0, 0
+	var started []string
+	err := InstallGraph(context.Background(), charts, GraphOptions{
+		Concurrency: 2,
+		OnStart:     func(chart config.ChartConfig) { started = append(started, chart.Name) },
+	}, func(context.Context, config.ChartConfig) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(10 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("InstallGraph failed: %v", err)
+	}
+	if peak != 2 {
+		t.Errorf("peak concurrency = %d, want 2", peak)
+	}
+	if len(started) != len(charts) {
+		t.Errorf("OnStart called %d time(s), want %d", len(started), len(charts))
+	}
+}
+
+func TestInstallGraph_Failure(t *testing.T) {
+	charts := []config.ChartConfig{
+		{Name: "cert-manager"},
+		{Name: "trust-manager", DependsOn: []string{"cert-manager"}},
+	}
+	boom := errors.New("boom")
+
+	var failed []string
+	err := InstallGraph(context.Background(), charts, GraphOptions{
+		OnDone: func(chart config.ChartConfig, err error) {
+			if err != nil {
+				failed = append(failed, chart.Name)
+			}
+		},
+	}, func(_ context.Context, chart config.ChartConfig) error {
+		if chart.Name == "trust-manager" {
+			t.Error("dependent of a failed chart was installed")
+		}
+		return boom
+	})
+	if !errors.Is(err, boom) {
+		t.Fatalf("InstallGraph error = %v, want %v", err, boom)
+	}
+	if len(failed) != 1 || failed[0] != "cert-manager" {
+		t.Errorf("failed charts = %v, want [cert-manager]", failed)
+	}
+}
+
+func TestInstallGraph_Cycle(t *testing.T) {
+	charts := []config.ChartConfig{
+		{Name: "a", DependsOn: []string{"b"}},
+		{Name: "b", DependsOn: []string{"a"}},
+	}
+	err := InstallGraph(context.Background(), charts, GraphOptions{}, func(context.Context, config.ChartConfig) error {
+		t.Error("chart in a cycle was installed")
+		return nil
+	})
+	if err == nil {
+		t.Fatal("expected an error for a dependency cycle")
+	}
+}
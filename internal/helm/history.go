@@ -0,0 +1,104 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+)
+
+// RevisionInfo describes one revision of a Helm release
+type RevisionInfo struct {
+	Revision    int
+	Status      string
+	Chart       string
+	AppVersion  string
+	Description string
+	Updated     time.Time
+}
+
+// RollbackOptions controls a rollback
+type RollbackOptions struct {
+	// Revision to roll back to; 0 rolls back to the previous revision
+	Revision int
+	Wait     bool
+	Timeout  time.Duration
+}
+
+// releaseActionConfig returns an action configuration for the releases of
+// namespace, on the installer's cluster
+func (i *Installer) releaseActionConfig(namespace string) (*action.Configuration, error) {
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(i.getter, namespace, "secret", debugLog); err != nil {
+		return nil, fmt.Errorf("failed to init action config: %w", err)
+	}
+	return actionConfig, nil
+}
+
+// History returns up to max of the latest revisions of a release, oldest
+// first. A max of 0 returns every revision.
+func (i *Installer) History(ctx context.Context, releaseName, namespace string, max int) ([]RevisionInfo, error) {
+	actionConfig, err := i.releaseActionConfig(namespace)
+	if err != nil {
+		return nil, err
+	}
+	return history(actionConfig, releaseName, max)
+}
+
+// history implements History on an action configuration
+func history(actionConfig *action.Configuration, releaseName string, max int) ([]RevisionInfo, error) {
+	historyAction := action.NewHistory(actionConfig)
+	releases, err := historyAction.Run(releaseName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get release history: %w", err)
+	}
+
+	sort.Slice(releases, func(a, b int) bool { return releases[a].Version < releases[b].Version })
+	if max > 0 && len(releases) > max {
+		releases = releases[len(releases)-max:]
+	}
+
+	revisions := make([]RevisionInfo, 0, len(releases))
+	for _, rel := range releases {
+		info := RevisionInfo{Revision: rel.Version}
+		if rel.Info != nil {
+			info.Status = string(rel.Info.Status)
+			info.Description = rel.Info.Description
+			info.Updated = rel.Info.LastDeployed.Time
+		}
+		if rel.Chart != nil && rel.Chart.Metadata != nil {
+			info.Chart = fmt.Sprintf("%s-%s", rel.Chart.Metadata.Name, rel.Chart.Metadata.Version)
+			info.AppVersion = rel.Chart.Metadata.AppVersion
+		}
+		revisions = append(revisions, info)
+	}
+	return revisions, nil
+}
+
+// Rollback rolls a release back to an earlier revision, recording the
+// rollback as a new revision
+func (i *Installer) Rollback(ctx context.Context, releaseName, namespace string, opts RollbackOptions) error {
+	actionConfig, err := i.releaseActionConfig(namespace)
+	if err != nil {
+		return err
+	}
+	return rollback(actionConfig, releaseName, opts)
+}
+
+// rollback implements Rollback on an action configuration
+func rollback(actionConfig *action.Configuration, releaseName string, opts RollbackOptions) error {
+	rollbackAction := action.NewRollback(actionConfig)
+	rollbackAction.Version = opts.Revision
+	rollbackAction.Wait = opts.Wait
+	rollbackAction.Timeout = opts.Timeout
+	if rollbackAction.Timeout == 0 {
+		rollbackAction.Timeout = 5 * time.Minute
+	}
+
+	if err := rollbackAction.Run(releaseName); err != nil {
+		return fmt.Errorf("failed to roll back release: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,112 @@
+package helm
+
+import (
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	kubefake "helm.sh/helm/v3/pkg/kube/fake"
+	"helm.sh/helm/v3/pkg/release"
+	"helm.sh/helm/v3/pkg/storage"
+	"helm.sh/helm/v3/pkg/storage/driver"
+	helmtime "helm.sh/helm/v3/pkg/time"
+)
+
+// testReleaseConfig returns an action configuration with in-memory release
+// storage holding revisions of "demo" with the given chart versions and
+// statuses
+func testReleaseConfig(t *testing.T, revisions ...[2]string) *action.Configuration {
+	t.Helper()
+	actionConfig := &action.Configuration{
+		Releases:     storage.Init(driver.NewMemory()),
+		KubeClient:   &kubefake.PrintingKubeClient{Out: io.Discard},
+		Capabilities: chartutil.DefaultCapabilities,
+		Log:          func(string, ...interface{}) {},
+	}
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i, rev := range revisions {
+		rel := &release.Release{
+			Name:      "demo",
+			Namespace: "default",
+			Version:   i + 1,
+			Chart:     &chart.Chart{Metadata: &chart.Metadata{Name: "demo", Version: rev[0], AppVersion: "1.0"}},
+			Info: &release.Info{
+				Status:       release.Status(rev[1]),
+				Description:  "Revision " + rev[0],
+				LastDeployed: helmtime.Time{Time: start.Add(time.Duration(i) * time.Hour)},
+			},
+		}
+		if err := actionConfig.Releases.Create(rel); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return actionConfig
+}
+
+func TestHistory(t *testing.T) {
+	actionConfig := testReleaseConfig(t, [2]string{"0.1.0", "superseded"}, [2]string{"0.2.0", "superseded"}, [2]string{"0.3.0", "deployed"})
+
+	revisions, err := history(actionConfig, "demo", 2)
+	if err != nil {
+		t.Fatalf("history() error = %v", err)
+	}
+	want := []RevisionInfo{
+		{Revision: 2, Status: "superseded", Chart: "demo-0.2.0", AppVersion: "1.0", Description: "Revision 0.2.0", Updated: time.Date(2024, 1, 1, 1, 0, 0, 0, time.UTC)},
+		{Revision: 3, Status: "deployed", Chart: "demo-0.3.0", AppVersion: "1.0", Description: "Revision 0.3.0", Updated: time.Date(2024, 1, 1, 2, 0, 0, 0, time.UTC)},
+	}
+	if !reflect.DeepEqual(revisions, want) {
+		t.Errorf("history() = %+v, want %+v", revisions, want)
+	}
+
+	all, err := history(actionConfig, "demo", 0)
+	if err != nil || len(all) != 3 {
+		t.Errorf("history(max 0) = %d revisions, %v; want 3", len(all), err)
+	}
+
+	if _, err := history(actionConfig, "missing", 0); err == nil {
+		t.Error("history() of a missing release succeeded")
+	}
+}
+
+func TestRollback(t *testing.T) {
+	tests := []struct {
+		name        string
+		revision    int
+		wantChart   string
+		wantErrText string
+	}{
+		{name: "previous revision", revision: 0, wantChart: "demo-0.2.0"},
+		{name: "explicit revision", revision: 1, wantChart: "demo-0.1.0"},
+		{name: "unknown revision", revision: 7, wantErrText: "failed to roll back release"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			actionConfig := testReleaseConfig(t, [2]string{"0.1.0", "superseded"}, [2]string{"0.2.0", "superseded"}, [2]string{"0.3.0", "deployed"})
+
+			err := rollback(actionConfig, "demo", RollbackOptions{Revision: tt.revision})
+			if tt.wantErrText != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErrText) {
+					t.Fatalf("rollback() error = %v, want %q", err, tt.wantErrText)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("rollback() error = %v", err)
+			}
+
+			revisions, err := history(actionConfig, "demo", 0)
+			if err != nil {
+				t.Fatal(err)
+			}
+			latest := revisions[len(revisions)-1]
+			if latest.Revision != 4 || latest.Status != "deployed" || latest.Chart != tt.wantChart {
+				t.Errorf("latest revision = %+v, want revision 4 of %s deployed", latest, tt.wantChart)
+			}
+		})
+	}
+}
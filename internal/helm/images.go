@@ -0,0 +1,82 @@
+package helm
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"sort"
+	"strings"
+
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"sigs.k8s.io/yaml"
+)
+
+// containerListKeys are the pod spec fields holding containers
+var containerListKeys = []string{"containers", "initContainers", "ephemeralContainers"}
+
+// ExtractImages returns the container images referenced by a rendered
+// manifest, in order of first appearance. Pod specs are found wherever they
+// are nested, so workloads, jobs, cron jobs and custom resources embedding a
+// pod template are all covered.
+func ExtractImages(manifest string) ([]string, error) {
+	seen := map[string]bool{}
+	var images []string
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(manifest)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		var obj interface{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return nil, err
+		}
+		for _, img := range findImages(obj) {
+			if !seen[img] {
+				seen[img] = true
+				images = append(images, img)
+			}
+		}
+	}
+	return images, nil
+}
+
+// findImages walks a decoded object collecting the image of every entry in
+// a container list
+func findImages(obj interface{}) []string {
+	var images []string
+	switch v := obj.(type) {
+	case map[string]interface{}:
+		for _, key := range containerListKeys {
+			containers, ok := v[key].([]interface{})
+			if !ok {
+				continue
+			}
+			for _, c := range containers {
+				if container, ok := c.(map[string]interface{}); ok {
+					if img, ok := container["image"].(string); ok && img != "" {
+						images = append(images, img)
+					}
+				}
+			}
+		}
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			images = append(images, findImages(v[k])...)
+		}
+	case []interface{}:
+		for _, child := range v {
+			images = append(images, findImages(child)...)
+		}
+	}
+	return images
+}
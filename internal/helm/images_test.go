@@ -0,0 +1,94 @@
+package helm
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestExtractImages(t *testing.T) {
+	manifest := `---
+# Source: app/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: app
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: busybox:1.36
+      containers:
+        - name: app
+          image: ghcr.io/example/app:v1.0.0
+        - name: sidecar
+          image: ghcr.io/example/sidecar:v2
+---
+# Source: app/templates/service.yaml
+apiVersion: v1
+kind: Service
+metadata:
+  name: app
+spec:
+  ports:
+    - port: 80
+---
+# Source: app/templates/cronjob.yaml
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: cleanup
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+            - name: cleanup
+              image: ghcr.io/example/app:v1.0.0
+---
+# Source: app/templates/hooks/migrate.yaml
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: migrate
+  annotations:
+    helm.sh/hook: pre-install
+spec:
+  template:
+    spec:
+      containers:
+        - name: migrate
+          image: ghcr.io/example/migrate:v1
+`
+
+	got, err := ExtractImages(manifest)
+	if err != nil {
+		t.Fatalf("ExtractImages() error = %v", err)
+	}
+	want := []string{
+		"ghcr.io/example/app:v1.0.0",
+		"ghcr.io/example/sidecar:v2",
+		"busybox:1.36",
+		"ghcr.io/example/migrate:v1",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ExtractImages() = %v, want %v", got, want)
+	}
+}
+
+func TestExtractImages_Empty(t *testing.T) {
+	got, err := ExtractImages("")
+	if err != nil {
+		t.Fatalf("ExtractImages() error = %v", err)
+	}
+	if len(got) != 0 {
+		t.Errorf("ExtractImages() = %v, want none", got)
+	}
+}
+
+func TestExtractImages_InvalidYAML(t *testing.T) {
+	if _, err := ExtractImages("kind: [unclosed"); err == nil {
+		t.Error("ExtractImages() should fail on invalid YAML")
+	}
+}
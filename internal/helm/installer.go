@@ -3,25 +3,42 @@ package helm
 import (
 	"context"
 	"crypto/sha256"
+	"errors"
 	"fmt"
-	"os"
+	"io/fs"
 	"strings"
+	"sync"
 	"time"
 
 	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart"
 	"helm.sh/helm/v3/pkg/chart/loader"
 	"helm.sh/helm/v3/pkg/cli"
 	"helm.sh/helm/v3/pkg/getter"
+	"helm.sh/helm/v3/pkg/postrender"
 	"helm.sh/helm/v3/pkg/repo"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
 	"k8s.io/client-go/kubernetes"
 
+	"github.com/kanzi/kindplane/internal/cache"
 	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/kube"
 )
 
 // Installer handles Helm chart installations
 type Installer struct {
 	kubeClient *kubernetes.Clientset
-	settings   *cli.EnvSettings
+	// getter gives Helm actions the installer's cluster connection, never
+	// the current kubeconfig context
+	getter   genericclioptions.RESTClientGetter
+	settings *cli.EnvSettings
+	// localCharts maps LocalChartKey to chart archives used instead of
+	// downloading the chart from its repository
+	localCharts map[string]string
+	// repoMu serialises updates of the repositories file and repository
+	// indexes by concurrent installs; charts are located from them under
+	// its read lock, so they are never read half-written
+	repoMu sync.RWMutex
 }
 
 // ChartSpec defines a Helm chart to install
@@ -35,6 +52,25 @@ type ChartSpec struct {
 	Values      map[string]interface{}
 	Wait        bool
 	Timeout     time.Duration
+	// ChartPath is a local chart archive or directory; when set the chart is
+	// loaded from it and the repository is not used
+	ChartPath string
+	// Username and Password log in to the repository
+	Username string
+	Password string
+	// PassCredentialsAll sends the credentials to every host charts are
+	// downloaded from
+	PassCredentialsAll bool
+	// CAFile and InsecureSkipTLSVerify control how the repository's
+	// certificate is verified
+	CAFile                string
+	InsecureSkipTLSVerify bool
+	// PostRenderer, when set, modifies the rendered manifests before they
+	// are applied
+	PostRenderer postrender.PostRenderer
+	// Atomic rolls an upgrade back to the previous revision when it fails,
+	// and implies Wait
+	Atomic bool
 }
 
 // ValuesLogger is called with the release name and final merged values before installation.
@@ -58,24 +94,50 @@ type InstallOptions struct {
 	ValuesLogger ValuesLogger
 	// PreInstall is called before chart installation (after values are prepared)
 	PreInstall PreInstallFunc
+	// ValuesContext renders the chart's values as templates when set
+	ValuesContext *ValuesContext
 }
 
-// NewInstaller creates a new Helm installer
-func NewInstaller(kubeClient *kubernetes.Clientset) *Installer {
-	settings := cli.New()
-	return &Installer{
-		kubeClient: kubeClient,
-		settings:   settings,
+// NewInstaller creates a new Helm installer for the cluster of conn
+func NewInstaller(conn *kube.Connection) *Installer {
+	installer := &Installer{settings: cli.New()}
+	if conn != nil {
+		installer.kubeClient = conn.Client
+		installer.getter = conn.RESTClientGetter()
 	}
+	return installer
 }
 
-// AddRepo adds a Helm repository
+// LocalChartKey identifies a chart version in SetLocalCharts
+func LocalChartKey(repoURL, chartName, version string) string {
+	return strings.TrimSuffix(repoURL, "/") + "/" + chartName + ":" + version
+}
+
+// SetLocalCharts provides chart archives, keyed by LocalChartKey, to install
+// instead of downloading the charts from their repositories
+func (i *Installer) SetLocalCharts(charts map[string]string) {
+	i.localCharts = charts
+}
+
+// LocalChart returns the local archive provided for a chart version, or ""
+func (i *Installer) LocalChart(repoURL, chartName, version string) string {
+	return i.localCharts[LocalChartKey(repoURL, chartName, version)]
+}
+
+// AddRepo adds a Helm repository. OCI registries have no index to add, so
+// for oci:// URLs it does nothing; offline, charts come from the chart cache
+// and it does nothing either.
 func (i *Installer) AddRepo(ctx context.Context, name, url string) error {
+	if config.IsOCIRepo(url) || cache.Offline() {
+		return nil
+	}
+	i.repoMu.Lock()
+	defer i.repoMu.Unlock()
 	repoFile := i.settings.RepositoryConfig
 
 	// Load existing repo file
 	r, err := repo.LoadFile(repoFile)
-	if err != nil && !os.IsNotExist(err) {
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
 		return fmt.Errorf("failed to load repo file: %w", err)
 	}
 	if r == nil {
@@ -110,6 +172,7 @@ func (i *Installer) AddRepo(ctx context.Context, name, url string) error {
 	if err != nil {
 		return fmt.Errorf("failed to create chart repository: %w", err)
 	}
+	chartRepo.CachePath = i.settings.RepositoryCache
 
 	if _, err := chartRepo.DownloadIndexFile(); err != nil {
 		return fmt.Errorf("failed to download index: %w", err)
@@ -121,9 +184,9 @@ func (i *Installer) AddRepo(ctx context.Context, name, url string) error {
 // Install installs a Helm chart
 func (i *Installer) Install(ctx context.Context, spec ChartSpec) error {
 	// Create action configuration
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(i.settings.RESTClientGetter(), spec.Namespace, "secret", debugLog); err != nil {
-		return fmt.Errorf("failed to init action config: %w", err)
+	actionConfig, err := i.newActionConfig(spec)
+	if err != nil {
+		return err
 	}
 
 	// Check if release already exists
@@ -145,6 +208,7 @@ func (i *Installer) Install(ctx context.Context, spec ChartSpec) error {
 	installAction.Namespace = spec.Namespace
 	installAction.CreateNamespace = true
 	installAction.Wait = spec.Wait
+	installAction.PostRenderer = spec.PostRenderer
 	if spec.Timeout > 0 {
 		installAction.Timeout = spec.Timeout
 	} else {
@@ -154,19 +218,10 @@ func (i *Installer) Install(ctx context.Context, spec ChartSpec) error {
 		installAction.Version = spec.Version
 	}
 
-	// Locate chart
-	chartPath, err := installAction.LocateChart(
-		fmt.Sprintf("%s/%s", spec.RepoName, spec.ChartName),
-		i.settings,
-	)
+	// Locate and load chart
+	chart, err := i.loadChart(spec, &installAction.ChartPathOptions)
 	if err != nil {
-		return fmt.Errorf("failed to locate chart: %w", err)
-	}
-
-	// Load chart
-	chart, err := loader.Load(chartPath)
-	if err != nil {
-		return fmt.Errorf("failed to load chart: %w", err)
+		return err
 	}
 
 	// Install chart
@@ -181,15 +236,17 @@ func (i *Installer) Install(ctx context.Context, spec ChartSpec) error {
 // Upgrade upgrades a Helm release
 func (i *Installer) Upgrade(ctx context.Context, spec ChartSpec) error {
 	// Create action configuration
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(i.settings.RESTClientGetter(), spec.Namespace, "secret", debugLog); err != nil {
-		return fmt.Errorf("failed to init action config: %w", err)
+	actionConfig, err := i.newActionConfig(spec)
+	if err != nil {
+		return err
 	}
 
 	// Create upgrade action
 	upgradeAction := action.NewUpgrade(actionConfig)
 	upgradeAction.Namespace = spec.Namespace
 	upgradeAction.Wait = spec.Wait
+	upgradeAction.Atomic = spec.Atomic
+	upgradeAction.PostRenderer = spec.PostRenderer
 	if spec.Timeout > 0 {
 		upgradeAction.Timeout = spec.Timeout
 	} else {
@@ -199,19 +256,10 @@ func (i *Installer) Upgrade(ctx context.Context, spec ChartSpec) error {
 		upgradeAction.Version = spec.Version
 	}
 
-	// Locate chart
-	chartPath, err := upgradeAction.LocateChart(
-		fmt.Sprintf("%s/%s", spec.RepoName, spec.ChartName),
-		i.settings,
-	)
-	if err != nil {
-		return fmt.Errorf("failed to locate chart: %w", err)
-	}
-
-	// Load chart
-	chart, err := loader.Load(chartPath)
+	// Locate and load chart
+	chart, err := i.loadChart(spec, &upgradeAction.ChartPathOptions)
 	if err != nil {
-		return fmt.Errorf("failed to load chart: %w", err)
+		return err
 	}
 
 	// Upgrade release
@@ -223,19 +271,34 @@ func (i *Installer) Upgrade(ctx context.Context, spec ChartSpec) error {
 	return nil
 }
 
+// loadChart loads the chart of a spec from its ChartPath, or locates it in
+// its repository with opts
+func (i *Installer) loadChart(spec ChartSpec, opts *action.ChartPathOptions) (*chart.Chart, error) {
+	chartPath, err := i.locate(spec, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	loaded, err := loader.Load(chartPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load chart: %w", err)
+	}
+	return loaded, nil
+}
+
 // Uninstall removes a Helm release
 func (i *Installer) Uninstall(ctx context.Context, releaseName, namespace string) error {
 	// Create action configuration
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(i.settings.RESTClientGetter(), namespace, "secret", debugLog); err != nil {
-		return fmt.Errorf("failed to init action config: %w", err)
+	actionConfig, err := i.releaseActionConfig(namespace)
+	if err != nil {
+		return err
 	}
 
 	// Create uninstall action
 	uninstallAction := action.NewUninstall(actionConfig)
 
 	// Uninstall release
-	_, err := uninstallAction.Run(releaseName)
+	_, err = uninstallAction.Run(releaseName)
 	if err != nil {
 		return fmt.Errorf("failed to uninstall release: %w", err)
 	}
@@ -246,9 +309,9 @@ func (i *Installer) Uninstall(ctx context.Context, releaseName, namespace string
 // IsInstalled checks if a release is installed
 func (i *Installer) IsInstalled(ctx context.Context, releaseName, namespace string) (bool, error) {
 	// Create action configuration
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(i.settings.RESTClientGetter(), namespace, "secret", debugLog); err != nil {
-		return false, fmt.Errorf("failed to init action config: %w", err)
+	actionConfig, err := i.releaseActionConfig(namespace)
+	if err != nil {
+		return false, err
 	}
 
 	// Check if release exists
@@ -277,16 +340,14 @@ func (i *Installer) InstallChartFromConfig(ctx context.Context, chartCfg config.
 // InstallChartFromConfigWithOptions installs a Helm chart from a ChartConfig with optional callbacks.
 // This allows for value transformation, logging, and pre-install hooks.
 func (i *Installer) InstallChartFromConfigWithOptions(ctx context.Context, chartCfg config.ChartConfig, opts InstallOptions) error {
-	// Generate a unique repo name from the URL
-	repoName := GenerateRepoName(chartCfg.Repo)
-
-	// Add the repo
-	if err := i.AddRepo(ctx, repoName, chartCfg.Repo); err != nil {
-		return fmt.Errorf("failed to add repo: %w", err)
+	// Resolve the chart's source, adding its repository if needed
+	spec, err := i.ChartSource(ctx, chartCfg)
+	if err != nil {
+		return err
 	}
 
 	// Merge values from files and inline values
-	values, err := MergeValues(chartCfg.ValuesFiles, chartCfg.Values)
+	values, err := chartValues(chartCfg, opts.ValuesContext)
 	if err != nil {
 		return fmt.Errorf("failed to merge values: %w", err)
 	}
@@ -318,27 +379,27 @@ func (i *Installer) InstallChartFromConfigWithOptions(ctx context.Context, chart
 		timeout = parsed
 	}
 
-	// Create chart spec
-	spec := ChartSpec{
-		RepoURL:     chartCfg.Repo,
-		RepoName:    repoName,
-		ChartName:   chartCfg.Chart,
-		ReleaseName: chartCfg.Name,
-		Namespace:   chartCfg.Namespace,
-		Version:     chartCfg.Version,
-		Values:      values,
-		Wait:        chartCfg.ShouldWait(),
-		Timeout:     timeout,
+	postRenderer, err := NewPostRenderer(chartCfg.PostRender)
+	if err != nil {
+		return err
 	}
 
+	spec.ReleaseName = chartCfg.Name
+	spec.Namespace = chartCfg.Namespace
+	spec.Values = values
+	spec.Wait = chartCfg.ShouldWait()
+	spec.Timeout = timeout
+	spec.PostRenderer = postRenderer
+
 	// Install with CreateNamespace option
 	return i.InstallWithOptions(ctx, spec, chartCfg.ShouldCreateNamespace())
 }
 
 // GetMergedValuesFromConfig returns the merged values for a chart config without installing.
 // This is useful for displaying values before installation or for validation.
-func (i *Installer) GetMergedValuesFromConfig(chartCfg config.ChartConfig) (map[string]interface{}, error) {
-	return MergeValues(chartCfg.ValuesFiles, chartCfg.Values)
+// The values are rendered with vc when it is not nil.
+func (i *Installer) GetMergedValuesFromConfig(chartCfg config.ChartConfig, vc *ValuesContext) (map[string]interface{}, error) {
+	return chartValues(chartCfg, vc)
 }
 
 // GetKubeClient returns the Kubernetes client used by this installer.
@@ -349,9 +410,9 @@ func (i *Installer) GetKubeClient() *kubernetes.Clientset {
 // InstallWithOptions installs a Helm chart with additional options
 func (i *Installer) InstallWithOptions(ctx context.Context, spec ChartSpec, createNamespace bool) error {
 	// Create action configuration
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(i.settings.RESTClientGetter(), spec.Namespace, "secret", debugLog); err != nil {
-		return fmt.Errorf("failed to init action config: %w", err)
+	actionConfig, err := i.newActionConfig(spec)
+	if err != nil {
+		return err
 	}
 
 	// Check if release already exists
@@ -373,6 +434,7 @@ func (i *Installer) InstallWithOptions(ctx context.Context, spec ChartSpec, crea
 	installAction.Namespace = spec.Namespace
 	installAction.CreateNamespace = createNamespace
 	installAction.Wait = spec.Wait
+	installAction.PostRenderer = spec.PostRenderer
 	if spec.Timeout > 0 {
 		installAction.Timeout = spec.Timeout
 	} else {
@@ -382,19 +444,10 @@ func (i *Installer) InstallWithOptions(ctx context.Context, spec ChartSpec, crea
 		installAction.Version = spec.Version
 	}
 
-	// Locate chart
-	chartPath, err := installAction.LocateChart(
-		fmt.Sprintf("%s/%s", spec.RepoName, spec.ChartName),
-		i.settings,
-	)
+	// Locate and load chart
+	chart, err := i.loadChart(spec, &installAction.ChartPathOptions)
 	if err != nil {
-		return fmt.Errorf("failed to locate chart: %w", err)
-	}
-
-	// Load chart
-	chart, err := loader.Load(chartPath)
-	if err != nil {
-		return fmt.Errorf("failed to load chart: %w", err)
+		return err
 	}
 
 	// Install chart
@@ -421,9 +474,9 @@ type ReleaseInfo struct {
 func (i *Installer) ListReleases(ctx context.Context, namespace string) ([]ReleaseInfo, error) {
 	// Create action configuration
 	// Use empty namespace to list all namespaces
-	actionConfig := new(action.Configuration)
-	if err := actionConfig.Init(i.settings.RESTClientGetter(), namespace, "secret", debugLog); err != nil {
-		return nil, fmt.Errorf("failed to init action config: %w", err)
+	actionConfig, err := i.releaseActionConfig(namespace)
+	if err != nil {
+		return nil, err
 	}
 
 	// Create list action
@@ -477,8 +530,8 @@ func GenerateRepoName(url string) string {
 	if idx := strings.Index(name, "/"); idx > 0 {
 		name = name[:idx]
 	}
-	// Replace dots with dashes
-	name = strings.ReplaceAll(name, ".", "-")
+	// Replace dots and a port separator with dashes
+	name = strings.NewReplacer(".", "-", ":", "-").Replace(name)
 	// Truncate if too long
 	if len(name) > 20 {
 		name = name[:20]
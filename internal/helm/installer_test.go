@@ -25,6 +25,11 @@ func TestGenerateRepoName(t *testing.T) {
 			url:      "http://example.com/charts",
 			expected: "example-com-",
 		},
+		{
+			name:     "URL with port",
+			url:      "http://127.0.0.1:8080/charts",
+			expected: "127-0-0-1-8080-",
+		},
 		{
 			name:     "long domain name",
 			url:      "https://a-very-long-domain-name-that-exceeds-twenty-chars.example.com/charts",
@@ -251,3 +256,20 @@ func TestInstallOptions_WithAllCallbacks(t *testing.T) {
 		t.Error("PreInstall was not called")
 	}
 }
+
+func TestLocalChart(t *testing.T) {
+	installer := &Installer{}
+	if got := installer.LocalChart("https://charts.example.com", "demo", "1.0.0"); got != "" {
+		t.Errorf("LocalChart() without local charts = %q", got)
+	}
+
+	installer.SetLocalCharts(map[string]string{
+		LocalChartKey("https://charts.example.com/", "demo", "1.0.0"): "/bundle/charts/demo-1.0.0.tgz",
+	})
+	if got := installer.LocalChart("https://charts.example.com", "demo", "1.0.0"); got != "/bundle/charts/demo-1.0.0.tgz" {
+		t.Errorf("LocalChart() = %q, want the bundled archive", got)
+	}
+	if got := installer.LocalChart("https://charts.example.com", "demo", "1.1.0"); got != "" {
+		t.Errorf("LocalChart() of another version = %q, want none", got)
+	}
+}
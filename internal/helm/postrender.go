@@ -0,0 +1,144 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/postrender"
+	"sigs.k8s.io/kustomize/api/krusty"
+	"sigs.k8s.io/kustomize/api/resmap"
+	"sigs.k8s.io/kustomize/api/types"
+	"sigs.k8s.io/kustomize/kyaml/filesys"
+	"sigs.k8s.io/kustomize/kyaml/resid"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// patchPostRenderer applies kustomize patches to the manifests Helm
+// renders
+type patchPostRenderer struct {
+	patches []types.Patch
+}
+
+// NewPostRenderer returns a Helm post-renderer applying the patches of pr,
+// reading patch files now. A nil or empty pr returns nil.
+func NewPostRenderer(pr *config.PostRenderConfig) (postrender.PostRenderer, error) {
+	if pr == nil || len(pr.Patches) == 0 {
+		return nil, nil
+	}
+	renderer := &patchPostRenderer{}
+	for i, p := range pr.Patches {
+		patch := types.Patch{Patch: p.Patch}
+		if p.Path != "" {
+			data, err := os.ReadFile(p.Path)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read patch %d: %w", i, err)
+			}
+			patch.Patch = string(data)
+		}
+		target := p.Target
+		if target == nil {
+			target = namedTarget(patch.Patch)
+		}
+		if target != nil {
+			patch.Target = &types.Selector{
+				ResId: resid.ResId{
+					Gvk:       resid.Gvk{Group: target.Group, Version: target.Version, Kind: target.Kind},
+					Name:      target.Name,
+					Namespace: target.Namespace,
+				},
+				LabelSelector:      target.LabelSelector,
+				AnnotationSelector: target.AnnotationSelector,
+			}
+		}
+		renderer.patches = append(renderer.patches, patch)
+	}
+	return renderer, nil
+}
+
+// namedTarget returns a target selecting the resource a strategic-merge
+// patch names. Kustomize would otherwise only match resources whose
+// namespace is the patch's, but charts often set the namespace only on
+// some resources; the patch's namespace is matched only when it sets one.
+func namedTarget(patch string) *config.PatchTarget {
+	var resource struct {
+		APIVersion string `json:"apiVersion"`
+		Kind       string `json:"kind"`
+		Metadata   struct {
+			Name      string `json:"name"`
+			Namespace string `json:"namespace"`
+		} `json:"metadata"`
+	}
+	if err := yaml.Unmarshal([]byte(patch), &resource); err != nil || resource.Kind == "" || resource.Metadata.Name == "" {
+		return nil
+	}
+	gv := strings.SplitN(resource.APIVersion, "/", 2)
+	target := &config.PatchTarget{Version: gv[len(gv)-1], Kind: resource.Kind, Name: regexp.QuoteMeta(resource.Metadata.Name)}
+	if len(gv) == 2 {
+		target.Group = gv[0]
+	}
+	if resource.Metadata.Namespace != "" {
+		target.Namespace = regexp.QuoteMeta(resource.Metadata.Namespace)
+	}
+	return target
+}
+
+// Run applies the patches with kustomize, in an in-memory file system. A
+// patch that selects no resource is an error rather than a silent no-op.
+func (r *patchPostRenderer) Run(renderedManifests *bytes.Buffer) (*bytes.Buffer, error) {
+	if len(bytes.TrimSpace(renderedManifests.Bytes())) == 0 {
+		return renderedManifests, nil
+	}
+
+	rendered, err := kustomize(renderedManifests.Bytes(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rendered manifests: %w", err)
+	}
+	for i, patch := range r.patches {
+		if patch.Target == nil {
+			continue
+		}
+		matches, err := rendered.Select(*patch.Target)
+		if err != nil {
+			return nil, fmt.Errorf("patch %d: %w", i, err)
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("patch %d matches no resource (target %s)", i, patch.Target)
+		}
+	}
+
+	resources, err := kustomize(renderedManifests.Bytes(), r.patches)
+	if err != nil {
+		return nil, fmt.Errorf("failed to apply patches: %w", err)
+	}
+	patched, err := resources.AsYaml()
+	if err != nil {
+		return nil, fmt.Errorf("failed to write patched manifests: %w", err)
+	}
+	return bytes.NewBuffer(patched), nil
+}
+
+// kustomize builds a kustomization of manifests and patches
+func kustomize(manifests []byte, patches []types.Patch) (resmap.ResMap, error) {
+	const dir = "/chart"
+	fs := filesys.MakeFsInMemory()
+	kustomization, err := yaml.Marshal(types.Kustomization{
+		TypeMeta:  types.TypeMeta{APIVersion: types.KustomizationVersion, Kind: types.KustomizationKind},
+		Resources: []string{"manifests.yaml"},
+		Patches:   patches,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to write kustomization: %w", err)
+	}
+	if err := fs.WriteFile(dir+"/kustomization.yaml", kustomization); err != nil {
+		return nil, err
+	}
+	if err := fs.WriteFile(dir+"/manifests.yaml", manifests); err != nil {
+		return nil, err
+	}
+	return krusty.MakeKustomizer(krusty.MakeDefaultOptions()).Run(fs, dir)
+}
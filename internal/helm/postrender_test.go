@@ -0,0 +1,108 @@
+package helm
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+const testManifests = `---
+# Source: demo/templates/deployment.yaml
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: demo
+  namespace: demo
+  labels:
+    app.kubernetes.io/component: controller
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          image: ghcr.io/example/demo:v1
+---
+# Source: demo/templates/job.yaml
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: demo-migrate
+  namespace: demo
+spec:
+  template:
+    spec:
+      containers:
+        - name: migrate
+          image: ghcr.io/example/migrate:v1
+`
+
+func TestPostRenderer(t *testing.T) {
+	patchFile := filepath.Join(t.TempDir(), "tolerations.yaml")
+	if err := os.WriteFile(patchFile, []byte(`- op: add
+  path: /spec/template/spec/tolerations
+  value:
+    - key: dedicated
+      operator: Exists
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	renderer, err := NewPostRenderer(&config.PostRenderConfig{Patches: []config.ChartPatch{
+		{Patch: `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: demo
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          env:
+            - name: LOG_LEVEL
+              value: debug
+`},
+		{Path: patchFile, Target: &config.PatchTarget{Kind: "Job", Name: "demo-.*"}},
+		{Patch: `[{"op": "add", "path": "/metadata/annotations", "value": {"patched": "true"}}]`,
+			Target: &config.PatchTarget{LabelSelector: "app.kubernetes.io/component=controller"}},
+	}})
+	if err != nil {
+		t.Fatalf("NewPostRenderer() error = %v", err)
+	}
+
+	out, err := renderer.Run(bytes.NewBufferString(testManifests))
+	if err != nil {
+		t.Fatalf("Run() error = %v", err)
+	}
+	patched := out.String()
+	for _, want := range []string{"LOG_LEVEL", "image: ghcr.io/example/demo:v1", "key: dedicated", "patched: \"true\""} {
+		if !strings.Contains(patched, want) {
+			t.Errorf("patched manifests lack %q:\n%s", want, patched)
+		}
+	}
+	if strings.Count(patched, "key: dedicated") != 1 {
+		t.Errorf("expected only the job to get tolerations:\n%s", patched)
+	}
+}
+
+func TestPostRenderer_None(t *testing.T) {
+	renderer, err := NewPostRenderer(&config.PostRenderConfig{})
+	if err != nil || renderer != nil {
+		t.Errorf("NewPostRenderer(empty) = %v, %v; want nil, nil", renderer, err)
+	}
+}
+
+func TestPostRenderer_NoMatch(t *testing.T) {
+	renderer, err := NewPostRenderer(&config.PostRenderConfig{Patches: []config.ChartPatch{
+		{Patch: "apiVersion: v1\nkind: ConfigMap\nmetadata:\n  name: missing\ndata:\n  a: b\n"},
+	}})
+	if err != nil {
+		t.Fatalf("NewPostRenderer() error = %v", err)
+	}
+	if _, err := renderer.Run(bytes.NewBufferString(testManifests)); err == nil {
+		t.Error("expected an error for a patch that matches no resource")
+	}
+}
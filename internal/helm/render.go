@@ -0,0 +1,74 @@
+package helm
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/chart/loader"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/cli"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// RenderChart renders a chart from a ChartConfig with its merged values and
+// post-render patches, client-side like `helm template`, without contacting
+// the cluster. kubeVersion sets .Capabilities.KubeVersion when not empty, and
+// the values are rendered with vc when it is not nil.
+// The returned manifest includes hook resources, which are not patched, but
+// not the chart's crds/ directory.
+func RenderChart(ctx context.Context, chartCfg config.ChartConfig, kubeVersion string, vc *ValuesContext) (string, error) {
+	installer := &Installer{settings: cli.New()}
+	chartPath, err := installer.locateChart(ctx, chartCfg)
+	if err != nil {
+		return "", err
+	}
+
+	values, err := chartValues(chartCfg, vc)
+	if err != nil {
+		return "", fmt.Errorf("failed to merge values: %w", err)
+	}
+
+	postRenderer, err := NewPostRenderer(chartCfg.PostRender)
+	if err != nil {
+		return "", err
+	}
+
+	installAction := action.NewInstall(&action.Configuration{Log: debugLog})
+	installAction.PostRenderer = postRenderer
+	installAction.ClientOnly = true
+	installAction.DryRun = true
+	installAction.Replace = true
+	installAction.ReleaseName = chartCfg.Name
+	installAction.Namespace = chartCfg.Namespace
+	installAction.Version = chartCfg.Version
+	if kubeVersion != "" {
+		if !strings.HasPrefix(kubeVersion, "v") {
+			kubeVersion = "v" + kubeVersion
+		}
+		kv, err := chartutil.ParseKubeVersion(kubeVersion)
+		if err != nil {
+			return "", fmt.Errorf("invalid kubernetes version: %w", err)
+		}
+		installAction.KubeVersion = kv
+	}
+
+	chart, err := loader.Load(chartPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to load chart: %w", err)
+	}
+
+	rel, err := installAction.RunWithContext(ctx, chart, values)
+	if err != nil {
+		return "", fmt.Errorf("failed to render chart: %w", err)
+	}
+
+	var manifest strings.Builder
+	manifest.WriteString(rel.Manifest)
+	for _, hook := range rel.Hooks {
+		fmt.Fprintf(&manifest, "\n---\n# Source: %s\n%s\n", hook.Path, hook.Manifest)
+	}
+	return manifest.String(), nil
+}
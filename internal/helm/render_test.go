@@ -0,0 +1,166 @@
+package helm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chart"
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// testChart returns a chart with a deployment and a pre-install hook job
+func testChart() *chart.Chart {
+	return &chart.Chart{
+		Metadata: &chart.Metadata{APIVersion: chart.APIVersionV2, Name: "demo", Version: "0.1.0"},
+		Raw: []*chart.File{
+			{Name: chartutil.ValuesfileName, Data: []byte("image: ghcr.io/example/demo:v1\ninitImage: busybox:1.36\n")},
+		},
+		Templates: []*chart.File{
+			{Name: "templates/deployment.yaml", Data: []byte(`apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ .Release.Name }}
+spec:
+  template:
+    spec:
+      initContainers:
+        - name: init
+          image: {{ .Values.initImage }}
+      containers:
+        - name: app
+          image: {{ .Values.image }}
+`)},
+			{Name: "templates/hook.yaml", Data: []byte(`apiVersion: batch/v1
+kind: Job
+metadata:
+  name: {{ .Release.Name }}-migrate
+  annotations:
+    helm.sh/hook: pre-install
+spec:
+  template:
+    spec:
+      containers:
+        - name: migrate
+          image: ghcr.io/example/migrate:v1
+`)},
+		},
+	}
+}
+
+// serveTestChart serves a chart repository holding testChart and returns
+// its URL
+func serveTestChart(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := chartutil.Save(testChart(), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	srv := httptest.NewServer(http.FileServer(http.Dir(dir)))
+	t.Cleanup(srv.Close)
+
+	index, err := repo.IndexDirectory(dir, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(dir+"/index.yaml", 0644); err != nil {
+		t.Fatal(err)
+	}
+	return srv.URL
+}
+
+func TestRenderChart(t *testing.T) {
+	helmHome := t.TempDir()
+	t.Setenv("HELM_REPOSITORY_CONFIG", helmHome+"/repositories.yaml")
+	t.Setenv("HELM_REPOSITORY_CACHE", helmHome+"/cache")
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+
+	repoURL := serveTestChart(t)
+	chartCfg := config.ChartConfig{
+		Name:      "demo",
+		Repo:      repoURL,
+		Chart:     "demo",
+		Version:   "0.1.0",
+		Namespace: "demo",
+		Values:    map[string]interface{}{"image": "ghcr.io/example/demo:v2"},
+	}
+
+	manifest, err := RenderChart(context.Background(), chartCfg, "1.29.0", nil)
+	if err != nil {
+		t.Fatalf("RenderChart() error = %v", err)
+	}
+
+	images, err := ExtractImages(manifest)
+	if err != nil {
+		t.Fatalf("ExtractImages() error = %v", err)
+	}
+	want := []string{"ghcr.io/example/demo:v2", "busybox:1.36", "ghcr.io/example/migrate:v1"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("images = %v, want %v", images, want)
+	}
+}
+
+func TestRenderChart_Path(t *testing.T) {
+	dir := t.TempDir()
+	if err := chartutil.SaveDir(testChart(), dir); err != nil {
+		t.Fatal(err)
+	}
+	archiveDir := t.TempDir()
+	archive, err := chartutil.Save(testChart(), archiveDir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	for name, path := range map[string]string{"directory": filepath.Join(dir, "demo"), "archive": archive} {
+		t.Run(name, func(t *testing.T) {
+			chartCfg := config.ChartConfig{Name: "demo", Path: path, Namespace: "demo"}
+			manifest, err := RenderChart(context.Background(), chartCfg, "", nil)
+			if err != nil {
+				t.Fatalf("RenderChart() error = %v", err)
+			}
+			images, err := ExtractImages(manifest)
+			if err != nil {
+				t.Fatalf("ExtractImages() error = %v", err)
+			}
+			if len(images) != 3 || images[0] != "ghcr.io/example/demo:v1" {
+				t.Errorf("images = %v, want the chart's default images", images)
+			}
+		})
+	}
+}
+
+func TestRenderChart_PostRender(t *testing.T) {
+	dir := t.TempDir()
+	if err := chartutil.SaveDir(testChart(), dir); err != nil {
+		t.Fatal(err)
+	}
+	chartCfg := config.ChartConfig{
+		Name:      "demo",
+		Path:      filepath.Join(dir, "demo"),
+		Namespace: "demo",
+		PostRender: &config.PostRenderConfig{Patches: []config.ChartPatch{{
+			Patch:  `[{"op": "replace", "path": "/spec/template/spec/containers/0/image", "value": "ghcr.io/example/demo:patched"}]`,
+			Target: &config.PatchTarget{Kind: "Deployment"},
+		}}},
+	}
+
+	manifest, err := RenderChart(context.Background(), chartCfg, "", nil)
+	if err != nil {
+		t.Fatalf("RenderChart() error = %v", err)
+	}
+	images, err := ExtractImages(manifest)
+	if err != nil {
+		t.Fatalf("ExtractImages() error = %v", err)
+	}
+	want := []string{"ghcr.io/example/demo:patched", "busybox:1.36", "ghcr.io/example/migrate:v1"}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("images = %v, want %v", images, want)
+	}
+}
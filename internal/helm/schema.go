@@ -0,0 +1,194 @@
+package helm
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	openapi_v2 "github.com/google/gnostic-models/openapiv2"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	utilyaml "k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/kube-openapi/pkg/util/proto/validation"
+	"k8s.io/kubectl/pkg/util/openapi"
+	"sigs.k8s.io/yaml"
+
+	"github.com/kanzi/kindplane/internal/cache"
+)
+
+// KubeSchemaURL is where the OpenAPI schema of a Kubernetes version is
+// downloaded from; %s is the version without a leading v
+const KubeSchemaURL = "https://raw.githubusercontent.com/kubernetes/kubernetes/v%s/api/openapi-spec/swagger.json"
+
+// KubeSchema holds the OpenAPI schemas of the built-in resources of a
+// Kubernetes version
+type KubeSchema struct {
+	resources openapi.Resources
+}
+
+// LoadKubeSchema loads the OpenAPI (v2) schema from location, a file or an
+// http(s) URL. Without a location the schema of kubeVersion is downloaded
+// from the Kubernetes repository and kept in the schema cache.
+func LoadKubeSchema(ctx context.Context, kubeVersion, location string) (*KubeSchema, error) {
+	if location != "" && !strings.HasPrefix(location, "http://") && !strings.HasPrefix(location, "https://") {
+		data, err := os.ReadFile(location)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read schema: %w", err)
+		}
+		return ParseKubeSchema(data)
+	}
+	if location != "" {
+		data, err := downloadSchema(ctx, location)
+		if err != nil {
+			return nil, err
+		}
+		return ParseKubeSchema(data)
+	}
+
+	kubeVersion = strings.TrimPrefix(kubeVersion, "v")
+	if kubeVersion == "" {
+		return nil, fmt.Errorf("no Kubernetes version to validate against")
+	}
+	dir, err := cache.Dir(cache.Schemas)
+	if err != nil {
+		return nil, err
+	}
+	path := filepath.Join(dir, "kubernetes-v"+kubeVersion+".json")
+	if data, err := os.ReadFile(path); err == nil {
+		cache.Touch(path)
+		return ParseKubeSchema(data)
+	}
+	if cache.Offline() {
+		return nil, fmt.Errorf("schema of Kubernetes %s is not in the schema cache; run once without --offline to cache it", kubeVersion)
+	}
+
+	data, err := downloadSchema(ctx, fmt.Sprintf(KubeSchemaURL, kubeVersion))
+	if err != nil {
+		return nil, err
+	}
+	schema, err := ParseKubeSchema(data)
+	if err != nil {
+		return nil, err
+	}
+
+	// Only a schema that parses is cached
+	if err := os.MkdirAll(dir, 0755); err == nil {
+		if tmp, err := os.CreateTemp(dir, ".schema-*"); err == nil {
+			_, werr := tmp.Write(data)
+			cerr := tmp.Close()
+			if werr != nil || cerr != nil || os.Rename(tmp.Name(), path) != nil {
+				_ = os.Remove(tmp.Name())
+			}
+		}
+	}
+	return schema, nil
+}
+
+// downloadSchema fetches a schema document
+func downloadSchema(ctx context.Context, url string) ([]byte, error) {
+	if cache.Offline() {
+		return nil, fmt.Errorf("cannot download schema %s offline", url)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download schema: %w", err)
+	}
+	defer func() { _ = resp.Body.Close() }()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download schema %s: %s", url, resp.Status)
+	}
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download schema: %w", err)
+	}
+	return data, nil
+}
+
+// ParseKubeSchema parses an OpenAPI (v2) schema document, such as the
+// swagger.json of a Kubernetes release or /openapi/v2 of an API server
+func ParseKubeSchema(data []byte) (*KubeSchema, error) {
+	doc, err := openapi_v2.ParseDocument(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	resources, err := openapi.NewOpenAPIData(doc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse schema: %w", err)
+	}
+	return &KubeSchema{resources: resources}, nil
+}
+
+// ValidationResult is the outcome of validating a manifest
+type ValidationResult struct {
+	// Validated counts the resources checked against a schema
+	Validated int `json:"validated"`
+	// Unknown lists resources of kinds the schema does not define, such as
+	// custom resources, which are not checked
+	Unknown []string `json:"unknown,omitempty"`
+	// Invalid lists the resources that do not match their schema
+	Invalid []InvalidResource `json:"invalid,omitempty"`
+}
+
+// InvalidResource is a resource that does not match its schema
+type InvalidResource struct {
+	// Resource is the kind and name of the resource, e.g. Deployment/web
+	Resource string   `json:"resource"`
+	Errors   []string `json:"errors"`
+}
+
+// Validate checks every resource of a manifest against its schema: field
+// names, types and required fields, like kubectl's client-side validation
+func (s *KubeSchema) Validate(manifest string) (ValidationResult, error) {
+	var result ValidationResult
+
+	reader := utilyaml.NewYAMLReader(bufio.NewReader(strings.NewReader(manifest)))
+	for {
+		doc, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return result, err
+		}
+
+		var obj map[string]interface{}
+		if err := yaml.Unmarshal(doc, &obj); err != nil {
+			return result, err
+		}
+		if len(obj) == 0 {
+			continue
+		}
+
+		u := unstructured.Unstructured{Object: obj}
+		gvk := u.GroupVersionKind()
+		resource := u.GetKind() + "/" + u.GetName()
+		if gvk.Kind == "" || gvk.Version == "" {
+			result.Invalid = append(result.Invalid, InvalidResource{Resource: resource, Errors: []string{"apiVersion and kind must be set"}})
+			continue
+		}
+
+		model := s.resources.LookupResource(gvk)
+		if model == nil {
+			result.Unknown = append(result.Unknown, resource)
+			continue
+		}
+		result.Validated++
+		if errs := validation.ValidateModel(obj, model, gvk.Kind); len(errs) > 0 {
+			invalid := InvalidResource{Resource: resource}
+			for _, err := range errs {
+				invalid.Errors = append(invalid.Errors, err.Error())
+			}
+			result.Invalid = append(result.Invalid, invalid)
+		}
+	}
+	return result, nil
+}
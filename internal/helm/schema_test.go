@@ -0,0 +1,159 @@
+package helm
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/cache"
+)
+
+// testSchema defines a ConfigMap and enough of a Deployment to check
+// required fields and nested types
+const testSchema = `{
+  "swagger": "2.0",
+  "info": {"title": "Kubernetes", "version": "v1.31.0"},
+  "paths": {},
+  "definitions": {
+    "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta": {
+      "type": "object",
+      "properties": {
+        "name": {"type": "string"},
+        "namespace": {"type": "string"},
+        "labels": {"type": "object", "additionalProperties": {"type": "string"}}
+      }
+    },
+    "io.k8s.api.core.v1.ConfigMap": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+        "data": {"type": "object", "additionalProperties": {"type": "string"}}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "", "kind": "ConfigMap", "version": "v1"}]
+    },
+    "io.k8s.api.apps.v1.DeploymentSpec": {
+      "type": "object",
+      "required": ["selector"],
+      "properties": {
+        "replicas": {"type": "integer", "format": "int32"},
+        "selector": {"type": "object"}
+      }
+    },
+    "io.k8s.api.apps.v1.Deployment": {
+      "type": "object",
+      "properties": {
+        "apiVersion": {"type": "string"},
+        "kind": {"type": "string"},
+        "metadata": {"$ref": "#/definitions/io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta"},
+        "spec": {"$ref": "#/definitions/io.k8s.api.apps.v1.DeploymentSpec"}
+      },
+      "x-kubernetes-group-version-kind": [{"group": "apps", "kind": "Deployment", "version": "v1"}]
+    }
+  }
+}`
+
+func TestKubeSchemaValidate(t *testing.T) {
+	schema, err := ParseKubeSchema([]byte(testSchema))
+	if err != nil {
+		t.Fatalf("ParseKubeSchema() error = %v", err)
+	}
+
+	manifest := `---
+# Source: demo/templates/configmap.yaml
+apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: settings
+data:
+  mode: fast
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  replicas: "two"
+  paused: true
+---
+apiVersion: example.com/v1
+kind: Widget
+metadata:
+  name: gadget
+---
+metadata:
+  name: nameless
+`
+	result, err := schema.Validate(manifest)
+	if err != nil {
+		t.Fatalf("Validate() error = %v", err)
+	}
+	if result.Validated != 2 {
+		t.Errorf("Validated = %d, want 2", result.Validated)
+	}
+	if !reflect.DeepEqual(result.Unknown, []string{"Widget/gadget"}) {
+		t.Errorf("Unknown = %v, want [Widget/gadget]", result.Unknown)
+	}
+	if len(result.Invalid) != 2 || result.Invalid[0].Resource != "Deployment/web" || result.Invalid[1].Resource != "/nameless" {
+		t.Fatalf("Invalid = %+v, want Deployment/web and the resource without a kind", result.Invalid)
+	}
+	errs := strings.Join(result.Invalid[0].Errors, "\n")
+	for _, want := range []string{"replicas", "paused", "selector"} {
+		if !strings.Contains(errs, want) {
+			t.Errorf("Deployment errors %q do not mention %s", errs, want)
+		}
+	}
+}
+
+func TestLoadKubeSchema(t *testing.T) {
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Cleanup(func() { cache.SetOffline(false) })
+	ctx := context.Background()
+
+	file := filepath.Join(t.TempDir(), "swagger.json")
+	if err := os.WriteFile(file, []byte(testSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadKubeSchema(ctx, "", file); err != nil {
+		t.Errorf("LoadKubeSchema(file) error = %v", err)
+	}
+
+	requests := 0
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(testSchema))
+	}))
+	defer srv.Close()
+	if _, err := LoadKubeSchema(ctx, "", srv.URL+"/swagger.json"); err != nil || requests != 1 {
+		t.Errorf("LoadKubeSchema(url) error = %v after %d requests", err, requests)
+	}
+
+	if _, err := LoadKubeSchema(ctx, "", ""); err == nil {
+		t.Error("LoadKubeSchema() without a version succeeded")
+	}
+
+	// A cached version is used without downloading, also offline
+	dir, err := cache.Dir(cache.Schemas)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "kubernetes-v1.31.0.json"), []byte(testSchema), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cache.SetOffline(true)
+	if _, err := LoadKubeSchema(ctx, "v1.31.0", ""); err != nil {
+		t.Errorf("LoadKubeSchema(cached) error = %v", err)
+	}
+	if _, err := LoadKubeSchema(ctx, "1.30.0", ""); err == nil || !strings.Contains(err.Error(), "not in the schema cache") {
+		t.Errorf("offline LoadKubeSchema(uncached) error = %v, want a cache miss", err)
+	}
+}
@@ -0,0 +1,224 @@
+package helm
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"helm.sh/helm/v3/pkg/action"
+	"helm.sh/helm/v3/pkg/registry"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// ChartRef returns the reference Helm resolves a repository chart by: the
+// chart's OCI reference for oci:// repositories, otherwise
+// <repoName>/<chartName> in the repositories added with AddRepo
+func ChartRef(repoURL, repoName, chartName string) string {
+	if config.IsOCIRepo(repoURL) {
+		return strings.TrimSuffix(repoURL, "/") + "/" + chartName
+	}
+	return repoName + "/" + chartName
+}
+
+// ResolveAuth reads the credentials of a chart repository from the
+// environment variables or files auth names. A nil auth returns no
+// credentials.
+func ResolveAuth(auth *config.ChartAuth) (username, password string, err error) {
+	if auth == nil {
+		return "", "", nil
+	}
+	if username, err = readSecret("username", auth.UsernameEnv, auth.UsernameFile); err != nil {
+		return "", "", err
+	}
+	if password, err = readSecret("password", auth.PasswordEnv, auth.PasswordFile); err != nil {
+		return "", "", err
+	}
+	return username, password, nil
+}
+
+// readSecret reads a credential from a file, trimming the trailing newline
+// editors add, or from an environment variable
+func readSecret(name, env, file string) (string, error) {
+	if file != "" {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return "", fmt.Errorf("failed to read chart repository %s: %w", name, err)
+		}
+		value := strings.TrimSpace(string(data))
+		if value == "" {
+			return "", fmt.Errorf("chart repository %s file %s is empty", name, file)
+		}
+		return value, nil
+	}
+	value := os.Getenv(env)
+	if value == "" {
+		return "", fmt.Errorf("chart repository %s: environment variable %s is not set", name, env)
+	}
+	return value, nil
+}
+
+// ChartSource returns a spec with the source of a configured chart: its
+// path or local copy, or its repository with the credentials and TLS
+// settings resolved. Plain repositories are added to the Helm repositories
+// file; repositories with credentials or TLS settings are not, so that
+// neither is ever written to it.
+func (i *Installer) ChartSource(ctx context.Context, chartCfg config.ChartConfig) (ChartSpec, error) {
+	spec := ChartSpec{
+		RepoURL:   chartCfg.Repo,
+		RepoName:  GenerateRepoName(chartCfg.Repo),
+		ChartName: chartCfg.Chart,
+		Version:   chartCfg.Version,
+		ChartPath: chartCfg.Path,
+	}
+	if spec.ChartPath == "" {
+		spec.ChartPath = i.LocalChart(chartCfg.Repo, chartCfg.Chart, chartCfg.Version)
+	}
+	if spec.ChartPath != "" {
+		return spec, nil
+	}
+
+	var err error
+	if spec.Username, spec.Password, err = ResolveAuth(chartCfg.Auth); err != nil {
+		return ChartSpec{}, err
+	}
+	if chartCfg.Auth != nil {
+		spec.PassCredentialsAll = chartCfg.Auth.PassCredentialsAll
+	}
+	if chartCfg.TLS != nil {
+		spec.CAFile = chartCfg.TLS.GetCAFile()
+		spec.InsecureSkipTLSVerify = chartCfg.TLS.InsecureSkipTLSVerify
+	}
+	if !spec.usesRepoURL() {
+		if err := i.AddRepo(ctx, spec.RepoName, spec.RepoURL); err != nil {
+			return ChartSpec{}, fmt.Errorf("failed to add repo: %w", err)
+		}
+	}
+	return spec, nil
+}
+
+// usesRepoURL reports whether the chart is located by its repository URL,
+// with credentials and TLS settings passed per request, instead of through
+// the Helm repositories file
+func (s ChartSpec) usesRepoURL() bool {
+	return !config.IsOCIRepo(s.RepoURL) && (s.Username != "" || s.CAFile != "" || s.InsecureSkipTLSVerify)
+}
+
+// newRegistryClient returns a client for the OCI registry of spec. Without
+// a username it uses the credentials saved by helm registry login, falling
+// back to the Docker config.
+func (i *Installer) newRegistryClient(spec ChartSpec) (*registry.Client, error) {
+	opts := []registry.ClientOption{
+		registry.ClientOptCredentialsFile(i.settings.RegistryConfig),
+		registry.ClientOptEnableCache(true),
+	}
+	if spec.Username != "" {
+		opts = append(opts, registry.ClientOptBasicAuth(spec.Username, spec.Password))
+	}
+	if spec.CAFile != "" || spec.InsecureSkipTLSVerify {
+		tlsConfig := &tls.Config{InsecureSkipVerify: spec.InsecureSkipTLSVerify, MinVersion: tls.VersionTLS12}
+		if spec.CAFile != "" {
+			pem, err := os.ReadFile(spec.CAFile)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read registry CA: %w", err)
+			}
+			pool, err := x509.SystemCertPool()
+			if err != nil {
+				pool = x509.NewCertPool()
+			}
+			if !pool.AppendCertsFromPEM(pem) {
+				return nil, fmt.Errorf("no certificates found in CA file %s", spec.CAFile)
+			}
+			tlsConfig.RootCAs = pool
+		}
+		transport := http.DefaultTransport.(*http.Transport).Clone()
+		transport.TLSClientConfig = tlsConfig
+		opts = append(opts, registry.ClientOptHTTPClient(&http.Client{Transport: transport}))
+	}
+	client, err := registry.NewClient(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create registry client: %w", err)
+	}
+	return client, nil
+}
+
+// newActionConfig returns an action configuration for the installer's
+// cluster, with a registry client when spec's chart is in an OCI registry
+func (i *Installer) newActionConfig(spec ChartSpec) (*action.Configuration, error) {
+	actionConfig := new(action.Configuration)
+	if err := actionConfig.Init(i.getter, spec.Namespace, "secret", debugLog); err != nil {
+		return nil, fmt.Errorf("failed to init action config: %w", err)
+	}
+	if spec.ChartPath == "" && config.IsOCIRepo(spec.RepoURL) {
+		client, err := i.newRegistryClient(spec)
+		if err != nil {
+			return nil, err
+		}
+		actionConfig.RegistryClient = client
+	}
+	return actionConfig, nil
+}
+
+// chartPathOptions returns options for locating a chart version. The
+// registry client of ChartPathOptions can only be set through an action,
+// so the options are taken from an install action.
+func chartPathOptions(client *registry.Client, version string) action.ChartPathOptions {
+	install := action.NewInstall(&action.Configuration{RegistryClient: client})
+	install.Version = version
+	return install.ChartPathOptions
+}
+
+// locate returns the local path of the chart of spec. Without a ChartPath
+// the chart comes from the chart cache, which downloads it from its
+// repository with opts on a miss.
+func (i *Installer) locate(spec ChartSpec, opts *action.ChartPathOptions) (string, error) {
+	if spec.ChartPath != "" {
+		return spec.ChartPath, nil
+	}
+	return i.cachedLocate(spec, func() (string, error) {
+		return i.download(spec, opts)
+	})
+}
+
+// download fetches the chart of spec from its repository with opts into
+// Helm's repository cache
+func (i *Installer) download(spec ChartSpec, opts *action.ChartPathOptions) (string, error) {
+	ref := ChartRef(spec.RepoURL, spec.RepoName, spec.ChartName)
+	if spec.usesRepoURL() {
+		opts.RepoURL = spec.RepoURL
+		opts.Username = spec.Username
+		opts.Password = spec.Password
+		opts.PassCredentialsAll = spec.PassCredentialsAll
+		opts.CaFile = spec.CAFile
+		opts.InsecureSkipTLSverify = spec.InsecureSkipTLSVerify
+		ref = spec.ChartName
+	}
+	i.repoMu.RLock()
+	chartPath, err := opts.LocateChart(ref, i.settings)
+	i.repoMu.RUnlock()
+	if err != nil {
+		return "", fmt.Errorf("failed to locate chart %s: %w", spec.ChartName, err)
+	}
+	return chartPath, nil
+}
+
+// locateChart returns the local path of a configured chart: its path, or
+// the archive downloaded from its repository or OCI registry
+func (i *Installer) locateChart(ctx context.Context, chartCfg config.ChartConfig) (string, error) {
+	spec, err := i.ChartSource(ctx, chartCfg)
+	if err != nil {
+		return "", err
+	}
+	var client *registry.Client
+	if spec.ChartPath == "" && config.IsOCIRepo(spec.RepoURL) {
+		if client, err = i.newRegistryClient(spec); err != nil {
+			return "", err
+		}
+	}
+	opts := chartPathOptions(client, spec.Version)
+	return i.locate(spec, &opts)
+}
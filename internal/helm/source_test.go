@@ -0,0 +1,161 @@
+package helm
+
+import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"helm.sh/helm/v3/pkg/chartutil"
+	"helm.sh/helm/v3/pkg/repo"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func TestChartRef(t *testing.T) {
+	tests := []struct {
+		repoURL, repoName, chart string
+		want                     string
+	}{
+		{"https://charts.example.com", "charts-example-com", "demo", "charts-example-com/demo"},
+		{"oci://ghcr.io/example/charts", "ignored", "demo", "oci://ghcr.io/example/charts/demo"},
+		{"oci://ghcr.io/example/charts/", "ignored", "demo", "oci://ghcr.io/example/charts/demo"},
+	}
+
+	for _, tt := range tests {
+		if got := ChartRef(tt.repoURL, tt.repoName, tt.chart); got != tt.want {
+			t.Errorf("ChartRef(%q, %q, %q) = %q, want %q", tt.repoURL, tt.repoName, tt.chart, got, tt.want)
+		}
+	}
+}
+
+func TestResolveAuth(t *testing.T) {
+	auth := &config.ChartAuth{UsernameEnv: "TEST_CHART_USER", PasswordEnv: "TEST_CHART_TOKEN"}
+
+	if u, p, err := ResolveAuth(nil); err != nil || u != "" || p != "" {
+		t.Errorf("ResolveAuth(nil) = %q, %q, %v; want no credentials", u, p, err)
+	}
+
+	t.Setenv("TEST_CHART_USER", "robot")
+	if _, _, err := ResolveAuth(auth); err == nil {
+		t.Error("expected an error when the password variable is unset")
+	}
+
+	t.Setenv("TEST_CHART_TOKEN", "s3cret")
+	u, p, err := ResolveAuth(auth)
+	if err != nil {
+		t.Fatalf("ResolveAuth() error = %v", err)
+	}
+	if u != "robot" || p != "s3cret" {
+		t.Errorf("ResolveAuth() = %q, %q; want robot, s3cret", u, p)
+	}
+}
+
+func TestResolveAuth_Files(t *testing.T) {
+	dir := t.TempDir()
+	userFile := filepath.Join(dir, "username")
+	passFile := filepath.Join(dir, "password")
+	if err := os.WriteFile(userFile, []byte("robot\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(passFile, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	auth := &config.ChartAuth{UsernameFile: userFile, PasswordFile: passFile}
+
+	if _, _, err := ResolveAuth(auth); err == nil {
+		t.Error("expected an error for an empty password file")
+	}
+
+	if err := os.WriteFile(passFile, []byte("s3cret\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	u, p, err := ResolveAuth(auth)
+	if err != nil {
+		t.Fatalf("ResolveAuth() error = %v", err)
+	}
+	if u != "robot" || p != "s3cret" {
+		t.Errorf("ResolveAuth() = %q, %q; want robot, s3cret", u, p)
+	}
+}
+
+// serveAuthTestChart serves a chart repository holding testChart over TLS
+// behind basic auth, and returns its URL and a file with its CA
+func serveAuthTestChart(t *testing.T, username, password string) (string, string) {
+	t.Helper()
+	dir := t.TempDir()
+	if _, err := chartutil.Save(testChart(), dir); err != nil {
+		t.Fatal(err)
+	}
+
+	files := http.FileServer(http.Dir(dir))
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if u, p, ok := r.BasicAuth(); !ok || u != username || p != password {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+	t.Cleanup(srv.Close)
+
+	index, err := repo.IndexDirectory(dir, srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := index.WriteFile(dir+"/index.yaml", 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	caFile := filepath.Join(t.TempDir(), "ca.pem")
+	caPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: srv.Certificate().Raw})
+	if err := os.WriteFile(caFile, caPEM, 0644); err != nil {
+		t.Fatal(err)
+	}
+	return srv.URL, caFile
+}
+
+func TestFetchChart_AuthAndTLS(t *testing.T) {
+	helmHome := t.TempDir()
+	repoConfig := filepath.Join(helmHome, "repositories.yaml")
+	t.Setenv("HELM_REPOSITORY_CONFIG", repoConfig)
+	t.Setenv("HELM_REPOSITORY_CACHE", filepath.Join(helmHome, "cache"))
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	t.Setenv("TEST_MUSEUM_USER", "robot")
+	t.Setenv("TEST_MUSEUM_PASSWORD", "s3cret")
+
+	repoURL, caFile := serveAuthTestChart(t, "robot", "s3cret")
+	chartCfg := config.ChartConfig{
+		Repo:    repoURL,
+		Chart:   "demo",
+		Version: "0.1.0",
+		Auth:    &config.ChartAuth{UsernameEnv: "TEST_MUSEUM_USER", PasswordEnv: "TEST_MUSEUM_PASSWORD"},
+		TLS:     &config.ChartRepoTLS{CAFile: caFile},
+	}
+
+	file, err := FetchChart(context.Background(), chartCfg, t.TempDir())
+	if err != nil {
+		t.Fatalf("FetchChart() error = %v", err)
+	}
+	if filepath.Base(file) != "demo-0.1.0.tgz" {
+		t.Errorf("FetchChart() = %s, want demo-0.1.0.tgz", file)
+	}
+	if data, err := os.ReadFile(repoConfig); err == nil && strings.Contains(string(data), "s3cret") {
+		t.Error("credentials were written to the repositories file")
+	}
+
+	// The chart cache would serve the chart without asking the repository
+	t.Setenv("XDG_CACHE_HOME", t.TempDir())
+	chartCfg.TLS = nil
+	if _, err := FetchChart(context.Background(), chartCfg, t.TempDir()); err == nil {
+		t.Error("expected an error without the repository's CA")
+	}
+	chartCfg.TLS = &config.ChartRepoTLS{InsecureSkipTLSVerify: true}
+	chartCfg.Auth = nil
+	if _, err := FetchChart(context.Background(), chartCfg, t.TempDir()); err == nil {
+		t.Error("expected an error without credentials")
+	}
+}
@@ -5,15 +5,31 @@ import (
 	"os"
 
 	"gopkg.in/yaml.v3"
+
+	"github.com/kanzi/kindplane/internal/config"
 )
 
 // LoadValuesFile loads a YAML values file and returns it as a map
 func LoadValuesFile(path string) (map[string]interface{}, error) {
+	return loadValuesFile(path, nil)
+}
+
+// loadValuesFile loads a values file, rendering it as a template first when
+// vc is not nil
+func loadValuesFile(path string, vc *ValuesContext) (map[string]interface{}, error) {
 	data, err := os.ReadFile(path)
 	if err != nil {
 		return nil, fmt.Errorf("failed to read values file %s: %w", path, err)
 	}
 
+	if vc != nil {
+		rendered, err := vc.render(path, string(data))
+		if err != nil {
+			return nil, fmt.Errorf("failed to render values file %s: %w", path, err)
+		}
+		data = []byte(rendered)
+	}
+
 	var values map[string]interface{}
 	if err := yaml.Unmarshal(data, &values); err != nil {
 		return nil, fmt.Errorf("failed to parse values file %s: %w", path, err)
@@ -22,14 +38,25 @@ func LoadValuesFile(path string) (map[string]interface{}, error) {
 	return values, nil
 }
 
+// chartValues merges the chart's values files and inline values, rendering
+// them with vc only when the chart sets templateValues
+func chartValues(chartCfg config.ChartConfig, vc *ValuesContext) (map[string]interface{}, error) {
+	if !chartCfg.TemplateValues {
+		vc = nil
+	}
+	return MergeValues(chartCfg.ValuesFiles, chartCfg.Values, vc)
+}
+
 // MergeValues merges multiple values maps, with later maps taking precedence
 // The order is: valuesFiles (in order), then inline values
-func MergeValues(valuesFiles []string, inlineValues map[string]interface{}) (map[string]interface{}, error) {
+// When vc is not nil, values files and inline string values are rendered as
+// Go templates with vc as their data before merging.
+func MergeValues(valuesFiles []string, inlineValues map[string]interface{}, vc *ValuesContext) (map[string]interface{}, error) {
 	result := make(map[string]interface{})
 
 	// Load and merge values files in order
 	for _, path := range valuesFiles {
-		fileValues, err := LoadValuesFile(path)
+		fileValues, err := loadValuesFile(path, vc)
 		if err != nil {
 			return nil, err
 		}
@@ -38,6 +65,13 @@ func MergeValues(valuesFiles []string, inlineValues map[string]interface{}) (map
 
 	// Merge inline values last (highest priority)
 	if inlineValues != nil {
+		if vc != nil {
+			rendered, err := vc.renderValues(inlineValues, "")
+			if err != nil {
+				return nil, fmt.Errorf("failed to render values: %w", err)
+			}
+			inlineValues = rendered
+		}
 		result = mergeMaps(result, inlineValues)
 	}
 
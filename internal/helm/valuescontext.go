@@ -0,0 +1,173 @@
+package helm
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/Masterminds/sprig/v3"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// ValuesContext is the data chart values are rendered with as Go templates,
+// e.g. {{ .Cluster.Name }} or {{ .Registry.InternalHost }}
+type ValuesContext struct {
+	Cluster  ClusterFacts
+	Registry RegistryFacts
+}
+
+// ClusterFacts describes the Kind cluster to values templates
+type ClusterFacts struct {
+	Name              string
+	KubernetesVersion string
+	KubeContext       string
+	Ingress           IngressFacts
+	PortMappings      []config.PortMapping
+	// WorkloadCAs maps the name of each workload CA to its files
+	WorkloadCAs map[string]WorkloadCAFacts
+}
+
+// IngressFacts describes the ingress port mappings. The ports are the host
+// ports mapped to container ports 80 and 443, or 0 when not mapped.
+type IngressFacts struct {
+	Enabled   bool
+	HTTPPort  int32
+	HTTPSPort int32
+}
+
+// WorkloadCAFacts locates a workload CA on the host and on the nodes
+type WorkloadCAFacts struct {
+	File     string
+	NodePath string
+}
+
+// RegistryFacts describes the local registry. Host is the address on the
+// host, InternalHost the address nodes and pods pull from.
+type RegistryFacts struct {
+	Enabled      bool
+	Host         string
+	InternalHost string
+}
+
+// NewValuesContext returns the values template context of the cluster cfg
+// describes. Host ports must already be resolved into cfg.
+func NewValuesContext(cfg *config.Config) *ValuesContext {
+	vc := &ValuesContext{
+		Cluster: ClusterFacts{
+			Name:              cfg.Cluster.Name,
+			KubernetesVersion: cfg.Cluster.KubernetesVersion,
+			KubeContext:       cfg.GetKubeContext(),
+			Ingress:           IngressFacts{Enabled: cfg.Cluster.Ingress.Enabled},
+			PortMappings:      cfg.Cluster.PortMappings,
+			WorkloadCAs:       map[string]WorkloadCAFacts{},
+		},
+		Registry: RegistryFacts{Enabled: cfg.Cluster.Registry.Enabled},
+	}
+	for _, m := range cfg.Cluster.PortMappings {
+		if m.Protocol != "" && !strings.EqualFold(m.Protocol, "TCP") {
+			continue
+		}
+		switch m.ContainerPort {
+		case 80:
+			vc.Cluster.Ingress.HTTPPort = m.HostPort
+		case 443:
+			vc.Cluster.Ingress.HTTPSPort = m.HostPort
+		}
+	}
+	for _, wl := range cfg.Cluster.TrustedCAs.Workloads {
+		file, err := filepath.Abs(wl.CAFile)
+		if err != nil {
+			file = wl.CAFile
+		}
+		vc.Cluster.WorkloadCAs[wl.Name] = WorkloadCAFacts{File: file, NodePath: wl.NodePath()}
+	}
+	if cfg.Cluster.Registry.Enabled {
+		vc.Registry.Host = fmt.Sprintf("localhost:%d", cfg.Cluster.Registry.GetPort())
+		vc.Registry.InternalHost = cfg.Cluster.Registry.GetInternalHost()
+	}
+	return vc
+}
+
+// escapeHint is added to template errors, since the value may be a template
+// the chart renders itself with tpl
+const escapeHint = `write {{ "{{ .Release.Name }}" }} to pass a template through to the chart`
+
+// render executes text as a template named name. Missing map keys are
+// errors, as are unset environment variables.
+func (vc *ValuesContext) render(name, text string) (string, error) {
+	funcs := sprig.TxtFuncMap()
+	funcs["env"] = func(key string) (string, error) {
+		value, ok := os.LookupEnv(key)
+		if !ok {
+			return "", fmt.Errorf("environment variable %s is not set", key)
+		}
+		return value, nil
+	}
+	funcs["file"] = func(path string) (string, error) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return "", err
+		}
+		return string(data), nil
+	}
+
+	tmpl, err := template.New(name).Funcs(funcs).Option("missingkey=error").Parse(text)
+	if err != nil {
+		return "", fmt.Errorf("%w (%s)", err, escapeHint)
+	}
+	var out bytes.Buffer
+	if err := tmpl.Execute(&out, vc); err != nil {
+		return "", fmt.Errorf("%w (%s)", err, escapeHint)
+	}
+	return out.String(), nil
+}
+
+// renderValues renders every string in values containing a template action,
+// naming each template by its key path
+func (vc *ValuesContext) renderValues(values map[string]interface{}, prefix string) (map[string]interface{}, error) {
+	keys := make([]string, 0, len(values))
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	rendered := make(map[string]interface{}, len(values))
+	for _, key := range keys {
+		value, err := vc.renderValue(values[key], prefix+key)
+		if err != nil {
+			return nil, err
+		}
+		rendered[key] = value
+	}
+	return rendered, nil
+}
+
+// renderValue renders one value of renderValues
+func (vc *ValuesContext) renderValue(value interface{}, path string) (interface{}, error) {
+	switch v := value.(type) {
+	case string:
+		if !strings.Contains(v, "{{") {
+			return v, nil
+		}
+		return vc.render(path, v)
+	case map[string]interface{}:
+		return vc.renderValues(v, path+".")
+	case []interface{}:
+		rendered := make([]interface{}, len(v))
+		for i, item := range v {
+			r, err := vc.renderValue(item, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			rendered[i] = r
+		}
+		return rendered, nil
+	default:
+		return value, nil
+	}
+}
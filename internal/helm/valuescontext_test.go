@@ -0,0 +1,149 @@
+package helm
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func testValuesConfig() *config.Config {
+	return &config.Config{
+		Cluster: config.ClusterConfig{
+			Name:              "dev",
+			KubernetesVersion: "1.29.0",
+			Ingress:           config.IngressConfig{Enabled: true},
+			PortMappings: []config.PortMapping{
+				{ContainerPort: 80, HostPort: 8080},
+				{ContainerPort: 443, HostPort: 8443, Protocol: "TCP"},
+				{ContainerPort: 53, HostPort: 5353, Protocol: "UDP"},
+			},
+			Registry: config.RegistryConfig{Enabled: true, Port: 5002},
+			TrustedCAs: config.TrustedCAsConfig{
+				Workloads: []config.WorkloadCA{{Name: "corp", CAFile: "/etc/corp-ca.pem"}},
+			},
+		},
+	}
+}
+
+func TestNewValuesContext(t *testing.T) {
+	vc := NewValuesContext(testValuesConfig())
+
+	if vc.Cluster.Name != "dev" || vc.Cluster.KubeContext != "kind-dev" {
+		t.Errorf("Cluster = %+v", vc.Cluster)
+	}
+	if want := (IngressFacts{Enabled: true, HTTPPort: 8080, HTTPSPort: 8443}); vc.Cluster.Ingress != want {
+		t.Errorf("Ingress = %+v, want %+v", vc.Cluster.Ingress, want)
+	}
+	if want := (RegistryFacts{Enabled: true, Host: "localhost:5002", InternalHost: "kind-registry:5000"}); vc.Registry != want {
+		t.Errorf("Registry = %+v, want %+v", vc.Registry, want)
+	}
+	want := WorkloadCAFacts{File: "/etc/corp-ca.pem", NodePath: "/usr/local/share/ca-certificates/corp.crt"}
+	if got := vc.Cluster.WorkloadCAs["corp"]; got != want {
+		t.Errorf("WorkloadCAs[corp] = %+v, want %+v", got, want)
+	}
+
+	if vc := NewValuesContext(&config.Config{}); vc.Registry.InternalHost != "" {
+		t.Errorf("InternalHost without a registry = %q, want empty", vc.Registry.InternalHost)
+	}
+}
+
+func TestMergeValues_Templates(t *testing.T) {
+	dir := t.TempDir()
+	caFile := filepath.Join(dir, "ca.pem")
+	if err := os.WriteFile(caFile, []byte("-----BEGIN CERTIFICATE-----\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	valuesFile := filepath.Join(dir, "values.yaml")
+	if err := os.WriteFile(valuesFile, []byte(`ingress:
+  port: {{ .Cluster.Ingress.HTTPSPort }}
+  hosts:
+    - {{ .Cluster.Name }}.localtest.me
+ca: |
+{{ file "`+filepath.ToSlash(caFile)+`" | indent 2 }}
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("KINDPLANE_TEST_TOKEN", "s3cret")
+
+	inline := map[string]interface{}{
+		"image":  map[string]interface{}{"registry": "{{ .Registry.InternalHost }}"},
+		"token":  `{{ env "KINDPLANE_TEST_TOKEN" }}`,
+		"args":   []interface{}{"--cluster={{ .Cluster.Name }}", 3},
+		"plain":  "no templates",
+		"helmed": `{{ "{{ .Release.Name }}" }}`,
+	}
+	got, err := MergeValues([]string{valuesFile}, inline, NewValuesContext(testValuesConfig()))
+	if err != nil {
+		t.Fatalf("MergeValues() error = %v", err)
+	}
+
+	want := map[string]interface{}{
+		"ingress": map[string]interface{}{"port": 8443, "hosts": []interface{}{"dev.localtest.me"}},
+		"ca":      "-----BEGIN CERTIFICATE-----\n",
+		"image":   map[string]interface{}{"registry": "kind-registry:5000"},
+		"token":   "s3cret",
+		"args":    []interface{}{"--cluster=dev", 3},
+		"plain":   "no templates",
+		"helmed":  "{{ .Release.Name }}",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MergeValues() = %#v, want %#v", got, want)
+	}
+
+	if inline["token"] != `{{ env "KINDPLANE_TEST_TOKEN" }}` {
+		t.Error("MergeValues() modified the inline values")
+	}
+}
+
+func TestMergeValues_TemplateErrors(t *testing.T) {
+	vc := NewValuesContext(testValuesConfig())
+
+	tests := []struct {
+		name   string
+		values map[string]interface{}
+		want   string
+	}{
+		{"unknown field", map[string]interface{}{"a": "{{ .Cluster.Nmae }}"}, "can't evaluate field Nmae"},
+		{"missing key", map[string]interface{}{"a": map[string]interface{}{"b": "{{ .Cluster.WorkloadCAs.other.File }}"}}, `template: a.b:1:11: executing "a.b" at <.Cluster.WorkloadCAs.other.File>: map has no entry for key "other"`},
+		{"unset env", map[string]interface{}{"a": `{{ env "KINDPLANE_TEST_UNSET" }}`}, "environment variable KINDPLANE_TEST_UNSET is not set"},
+		{"missing file", map[string]interface{}{"a": `{{ file "does-not-exist.pem" }}`}, "does-not-exist.pem"},
+		{"parse error", map[string]interface{}{"a": "{{ .Cluster.Name"}, "unclosed action"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := MergeValues(nil, tt.values, vc)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("MergeValues() error = %v, want containing %q", err, tt.want)
+			}
+			if err != nil && !strings.Contains(err.Error(), `{{ "{{ .Release.Name }}" }}`) {
+				t.Errorf("MergeValues() error = %v, want the escape syntax", err)
+			}
+		})
+	}
+
+	// Without a context values are not rendered
+	values := map[string]interface{}{"a": "{{ .Cluster.Name }}"}
+	got, err := MergeValues(nil, values, nil)
+	if err != nil || got["a"] != "{{ .Cluster.Name }}" {
+		t.Errorf("MergeValues(nil context) = %v, %v", got, err)
+	}
+}
+
+func TestChartValues_TemplateValues(t *testing.T) {
+	vc := NewValuesContext(testValuesConfig())
+	values := map[string]interface{}{"a": "{{ .Cluster.Name }}", "b": `{{ tpl .Values.x . }}`}
+
+	got, err := chartValues(config.ChartConfig{Values: values}, vc)
+	if err != nil || !reflect.DeepEqual(got, values) {
+		t.Errorf("chartValues() = %v, %v, want the values as written", got, err)
+	}
+
+	got, err = chartValues(config.ChartConfig{Values: map[string]interface{}{"a": "{{ .Cluster.Name }}"}, TemplateValues: true}, vc)
+	if err != nil || got["a"] != "dev" {
+		t.Errorf("chartValues(templateValues) = %v, %v, want a rendered", got, err)
+	}
+}
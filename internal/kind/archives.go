@@ -0,0 +1,142 @@
+package kind
+
+import (
+	"context"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+
+	"github.com/kanzi/kindplane/internal/cache"
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+// archiveCache keeps saved image tarballs on disk so repeated runs skip
+// the slow image save. Entries are keyed by image ID and platform, which
+// identify the content; the reference is part of the key as well because
+// the archive records the name it was saved under.
+type archiveCache struct {
+	dir string
+}
+
+// newArchiveCache returns the archive cache under the kindplane cache directory
+func newArchiveCache() (*archiveCache, error) {
+	dir, err := cache.Dir(cache.Images)
+	if err != nil {
+		return nil, err
+	}
+	return &archiveCache{dir: dir}, nil
+}
+
+// refKey is the part of an entry name identifying the image reference
+func refKey(image string) string {
+	return fmt.Sprintf("%x", sha256.Sum256([]byte(image)))[:12]
+}
+
+// path returns the entry for an image ID, platform and reference
+func (c *archiveCache) path(id string, p container.Platform, image string) string {
+	platform := p.OS + "-" + p.Architecture
+	if p.Variant != "" {
+		platform += "-" + p.Variant
+	}
+	return filepath.Join(c.dir, fmt.Sprintf("%s-%s-%s.tar", id, platform, refKey(image)))
+}
+
+// archive returns the path of a saved tarball for a local image, saving it
+// first on a cache miss. cached reports whether the save was skipped.
+// Older entries for the same reference are removed when a new one is saved,
+// so a moving tag such as latest does not accumulate archives.
+func (c *archiveCache) archive(ctx context.Context, rt container.Runtime, image, nodeArch string) (path string, cached bool, err error) {
+	id, err := rt.ImageID(ctx, image)
+	if err != nil {
+		return "", false, err
+	}
+	platform, err := rt.ImagePlatform(ctx, image)
+	if err != nil {
+		return "", false, err
+	}
+
+	path = c.path(id, platform, image)
+	cached, err = c.fill(path, image, func(tmp string) error {
+		if err := rt.SaveImages(ctx, tmp, image); err != nil {
+			return fmt.Errorf("failed to save image: %w", err)
+		}
+		if nodeArch == "arm64" {
+			stripOCIIndex(ctx, tmp)
+		}
+		return nil
+	})
+	return path, cached, err
+}
+
+// fill makes sure the entry at path for image exists, calling write to
+// produce it on a miss, and reports whether it was already cached
+func (c *archiveCache) fill(path, image string, write func(tmp string) error) (cached bool, err error) {
+	if _, err := os.Stat(path); err == nil {
+		cache.Touch(path)
+		return true, nil
+	}
+
+	if err := os.MkdirAll(c.dir, 0755); err != nil {
+		return false, fmt.Errorf("failed to create image cache: %w", err)
+	}
+
+	// Write next to the entry and rename, so concurrent runs never see a
+	// partial archive
+	tmp, err := os.CreateTemp(c.dir, "save-*.tar")
+	if err != nil {
+		return false, fmt.Errorf("failed to create temp file: %w", err)
+	}
+	tmpName := tmp.Name()
+	_ = tmp.Close()
+	defer func() { _ = os.Remove(tmpName) }()
+
+	if err := write(tmpName); err != nil {
+		return false, err
+	}
+
+	stale, _ := filepath.Glob(filepath.Join(c.dir, "*-"+refKey(image)+".tar"))
+	if err := os.Rename(tmpName, path); err != nil {
+		return false, fmt.Errorf("failed to store image archive: %w", err)
+	}
+	for _, old := range stale {
+		if old != path {
+			_ = os.Remove(old)
+		}
+	}
+	return false, nil
+}
+
+// stripOCIIndex removes OCI index files from a saved archive, best effort.
+//
+// Docker on macOS (ARM64) sometimes exports a tarball containing both OCI layout/index.json AND
+// a Docker v2 manifest.json. However, the 'index.json' often references a multi-arch manifest
+// digest (e.g., from the remote registry) that does not exist in the local blob store because
+// we only pulled the single architecture.
+//
+// When 'ctr' sees 'index.json', it tries to validate the entire index (all architectures),
+// fails to find the other architecture blobs, and errors with "content digest ... not found".
+//
+// By removing 'index.json' and 'oci-layout', we force 'ctr' to fall back to using
+// 'manifest.json' (Docker V2 format), which correctly points to the single-arch
+// blobs that are actually present in the tarball.
+func stripOCIIndex(ctx context.Context, archive string) {
+	repackDir, err := os.MkdirTemp("", "kind-repack-*")
+	if err != nil {
+		return
+	}
+	defer func() { _ = os.RemoveAll(repackDir) }()
+
+	if err := exec.CommandContext(ctx, "tar", "-xf", archive, "-C", repackDir).Run(); err != nil {
+		return
+	}
+	if _, err := os.Stat(filepath.Join(repackDir, "index.json")); err != nil {
+		return
+	}
+	_ = os.Remove(filepath.Join(repackDir, "index.json"))
+	_ = os.Remove(filepath.Join(repackDir, "oci-layout"))
+
+	// Repack over the original archive
+	_ = exec.CommandContext(ctx, "tar", "-C", repackDir, "-cf", archive, ".").Run()
+}
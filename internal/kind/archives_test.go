@@ -0,0 +1,75 @@
+package kind
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+func TestArchiveCache(t *testing.T) {
+	ctx := context.Background()
+	cache := &archiveCache{dir: t.TempDir()}
+	rt := container.NewFake()
+	img := "crossplane/crossplane:v2.1.0"
+	rt.Images[img] = container.Platform{OS: "linux", Architecture: "amd64"}
+	rt.ImageIDs[img] = "aaaa"
+
+	path, cached, err := cache.archive(ctx, rt, img, "amd64")
+	if err != nil {
+		t.Fatalf("archive() error = %v", err)
+	}
+	if cached {
+		t.Error("expected a miss on an empty cache")
+	}
+	if !strings.HasPrefix(filepath.Base(path), "aaaa-linux-amd64-") {
+		t.Errorf("entry name = %s, want it keyed by ID and platform", filepath.Base(path))
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("expected archive at %s: %v", path, err)
+	}
+
+	// Same content: served from the cache without saving again
+	rt.Calls = nil
+	again, cached, err := cache.archive(ctx, rt, img, "amd64")
+	if err != nil {
+		t.Fatalf("archive() error = %v", err)
+	}
+	if !cached || again != path {
+		t.Errorf("archive() = %s, cached %v; want %s from the cache", again, cached, path)
+	}
+	if rt.Called("SaveImages") {
+		t.Error("expected no save on a cache hit")
+	}
+
+	// The tag moved: the new content is saved and the old entry evicted
+	rt.ImageIDs[img] = "bbbb"
+	moved, cached, err := cache.archive(ctx, rt, img, "amd64")
+	if err != nil {
+		t.Fatalf("archive() error = %v", err)
+	}
+	if cached || moved == path {
+		t.Errorf("expected a new entry after the image changed, got %s (cached %v)", moved, cached)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected the stale entry to be removed")
+	}
+
+	entries, err := os.ReadDir(cache.dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 1 {
+		t.Errorf("cache holds %d entries, want 1", len(entries))
+	}
+}
+
+func TestArchiveCache_MissingImage(t *testing.T) {
+	cache := &archiveCache{dir: t.TempDir()}
+	if _, _, err := cache.archive(context.Background(), container.NewFake(), "missing:latest", "amd64"); err == nil {
+		t.Error("expected error for an image that is not present locally")
+	}
+}
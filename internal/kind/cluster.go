@@ -4,21 +4,30 @@ import (
 	"context"
 	"fmt"
 	"os"
-	"os/exec"
-	"strings"
+	"path/filepath"
 
 	"k8s.io/client-go/kubernetes"
 	"k8s.io/client-go/rest"
-	"k8s.io/client-go/tools/clientcmd"
 	"sigs.k8s.io/kind/pkg/cluster"
 	"sigs.k8s.io/kind/pkg/log"
 
 	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
 )
 
+// NewProvider returns a Kind provider bound to the configured container runtime
+func NewProvider(opts ...cluster.ProviderOption) *cluster.Provider {
+	if container.Default().Name() == container.RuntimePodman {
+		opts = append(opts, cluster.ProviderWithPodman())
+	} else {
+		opts = append(opts, cluster.ProviderWithDocker())
+	}
+	return cluster.NewProvider(opts...)
+}
+
 // ClusterExists checks if a Kind cluster with the given name exists
 func ClusterExists(name string) (bool, error) {
-	provider := cluster.NewProvider()
+	provider := NewProvider()
 	clusters, err := provider.List()
 	if err != nil {
 		return false, fmt.Errorf("failed to list clusters: %w", err)
@@ -45,7 +54,7 @@ func CreateClusterWithProgress(ctx context.Context, cfg *config.Config, logger l
 	if logger != nil {
 		providerOpts = append(providerOpts, cluster.ProviderWithLogger(logger))
 	}
-	provider := cluster.NewProvider(providerOpts...)
+	provider := NewProvider(providerOpts...)
 
 	// Build Kind config
 	kindConfig, err := BuildKindConfig(cfg)
@@ -53,56 +62,47 @@ func CreateClusterWithProgress(ctx context.Context, cfg *config.Config, logger l
 		return fmt.Errorf("failed to build kind config: %w", err)
 	}
 
+	kubeconfigPath := cfg.GetKubeconfigPath()
+	if err := os.MkdirAll(filepath.Dir(kubeconfigPath), 0755); err != nil {
+		return fmt.Errorf("failed to create kubeconfig directory: %w", err)
+	}
+
 	// Create cluster with config (node image is embedded in the config)
 	opts := []cluster.CreateOption{
 		cluster.CreateWithRawConfig([]byte(kindConfig)),
+		cluster.CreateWithKubeconfigPath(kubeconfigPath),
 	}
 
 	if err := provider.Create(cfg.Cluster.Name, opts...); err != nil {
 		return fmt.Errorf("failed to create cluster: %w", err)
 	}
 
+	// Kind always exports the host address; rewrite it for in-network consumers
+	if cfg.Cluster.Kubeconfig.Internal {
+		return ExportKubeConfig(cfg.Cluster.Name, kubeconfigPath, true)
+	}
+
 	return nil
 }
 
-// DeleteCluster deletes a Kind cluster
-func DeleteCluster(ctx context.Context, name string) error {
-	provider := cluster.NewProvider()
-	if err := provider.Delete(name, ""); err != nil {
+// DeleteCluster deletes a Kind cluster and removes its context from the
+// given kubeconfig file (the default kubeconfig when empty)
+func DeleteCluster(ctx context.Context, name, kubeconfigPath string) error {
+	provider := NewProvider()
+	if err := provider.Delete(name, kubeconfigPath); err != nil {
 		return fmt.Errorf("failed to delete cluster: %w", err)
 	}
 	return nil
 }
 
-// GetKubeClient returns a Kubernetes client for the specified Kind cluster
+// GetKubeClient returns a Kubernetes client for the specified Kind cluster,
+// built by Connect
 func GetKubeClient(clusterName string) (*kubernetes.Clientset, error) {
-	// Get kubeconfig path
-	kubeconfigPath := os.Getenv("KUBECONFIG")
-	if kubeconfigPath == "" {
-		home, err := os.UserHomeDir()
-		if err != nil {
-			return nil, fmt.Errorf("failed to get home directory: %w", err)
-		}
-		kubeconfigPath = fmt.Sprintf("%s/.kube/config", home)
-	}
-
-	// Build config with context
-	contextName := fmt.Sprintf("kind-%s", clusterName)
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
-		&clientcmd.ConfigOverrides{CurrentContext: contextName},
-	).ClientConfig()
-	if err != nil {
-		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
-	}
-
-	// Create clientset
-	clientset, err := kubernetes.NewForConfig(config)
+	conn, err := Connect(clusterName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+		return nil, err
 	}
-
-	return clientset, nil
+	return conn.Client, nil
 }
 
 // GetKubeConfigPath returns the path to the kubeconfig file for the cluster
@@ -120,38 +120,24 @@ func GetContextName(clusterName string) string {
 	return fmt.Sprintf("kind-%s", clusterName)
 }
 
-// GetRESTConfig returns a REST config for the specified Kind cluster
+// GetRESTConfig returns a REST config for the specified Kind cluster,
+// built by Connect. The kubeconfig is read from the cluster itself, so this
+// works regardless of where (or whether) the kubeconfig was exported.
 func GetRESTConfig(clusterName string) (*rest.Config, error) {
-	kubeconfigPath := GetKubeConfigPath(clusterName)
-	contextName := GetContextName(clusterName)
-
-	config, err := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(
-		&clientcmd.ClientConfigLoadingRules{ExplicitPath: kubeconfigPath},
-		&clientcmd.ConfigOverrides{CurrentContext: contextName},
-	).ClientConfig()
+	conn, err := Connect(clusterName)
 	if err != nil {
-		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
+		return nil, err
 	}
-
-	return config, nil
+	return conn.Config, nil
 }
 
-// GetNodeContainers returns the Docker container names for all nodes in a Kind cluster
+// GetNodeContainers returns the container names for all nodes in a Kind cluster
 func GetNodeContainers(clusterName string) ([]string, error) {
-	// Kind uses the naming convention: <cluster-name>-control-plane, <cluster-name>-worker, etc.
-	cmd := exec.Command("docker", "ps", "--filter", fmt.Sprintf("label=io.x-k8s.kind.cluster=%s", clusterName), "--format", "{{.Names}}")
-	output, err := cmd.Output()
+	// Kind labels every node container with the cluster name
+	nodes, err := container.Default().ListContainers(context.Background(), fmt.Sprintf("io.x-k8s.kind.cluster=%s", clusterName))
 	if err != nil {
 		return nil, fmt.Errorf("failed to list cluster containers: %w", err)
 	}
-
-	nodes := []string{}
-	for _, name := range strings.Split(strings.TrimSpace(string(output)), "\n") {
-		if name != "" {
-			nodes = append(nodes, name)
-		}
-	}
-
 	return nodes, nil
 }
 
@@ -164,9 +150,9 @@ func UpdateCACertificates(ctx context.Context, clusterName string) error {
 		return fmt.Errorf("failed to get cluster nodes: %w", err)
 	}
 
+	rt := container.Default()
 	for _, node := range nodes {
-		cmd := exec.CommandContext(ctx, "docker", "exec", node, "update-ca-certificates")
-		if output, err := cmd.CombinedOutput(); err != nil {
+		if output, err := rt.Exec(ctx, node, nil, "update-ca-certificates"); err != nil {
 			return fmt.Errorf("failed to update CA certificates on node %s: %w\nOutput: %s", node, err, string(output))
 		}
 	}
@@ -5,22 +5,6 @@ import (
 	"testing"
 )
 
-// TestExportKubeConfig_Removed verifies that ExportKubeConfig function has been removed
-func TestExportKubeConfig_Removed(t *testing.T) {
-	// ExportKubeConfig has been removed as it was using the kind CLI
-	// Kubeconfig access is now handled through GetKubeClient() and GetRESTConfig()
-	// which use the Kind Go library and don't require the CLI binary
-
-	// Verify that GetKubeClient and GetRESTConfig are available as alternatives
-	// These functions provide the same functionality without CLI dependency
-	_ = GetKubeClient
-	_ = GetRESTConfig
-
-	// If ExportKubeConfig was needed, it should be reimplemented using
-	// sigs.k8s.io/kind/pkg/cluster/internal/kubeconfig.Export()
-	// but since it wasn't used anywhere, it was safe to remove
-}
-
 // TestClusterExists tests the ClusterExists function
 func TestClusterExists(t *testing.T) {
 	if _, ok := os.LookupEnv("KIND_E2E"); !ok {
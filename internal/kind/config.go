@@ -2,6 +2,7 @@ package kind
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -46,8 +47,30 @@ type KindMount struct {
 	ReadOnly      bool   `yaml:"readOnly,omitempty"`
 }
 
-// BuildKindConfig creates a Kind configuration from kindplane config
+// redacted replaces registry credentials in Kind configs shown to users
+const redacted = "<redacted>"
+
+// BuildKindConfig creates a Kind configuration from kindplane config, with
+// the credentials of cluster.registryAuth read from their sources
 func BuildKindConfig(cfg *config.Config) (string, error) {
+	creds, err := ResolveRegistryAuth(context.Background(), cfg)
+	if err != nil {
+		return "", err
+	}
+	return buildKindConfig(cfg, creds)
+}
+
+// BuildRedactedKindConfig creates the Kind configuration with registry
+// credentials left out, for printing or saving in dumps
+func BuildRedactedKindConfig(cfg *config.Config) (string, error) {
+	var creds []RegistryCredential
+	for _, a := range cfg.Cluster.RegistryAuth {
+		creds = append(creds, RegistryCredential{Host: a.Host, Username: redacted, Password: redacted})
+	}
+	return buildKindConfig(cfg, creds)
+}
+
+func buildKindConfig(cfg *config.Config, creds []RegistryCredential) (string, error) {
 	kindConfig := &KindConfig{
 		Kind:       "Cluster",
 		APIVersion: "kind.x-k8s.io/v1alpha4",
@@ -63,8 +86,8 @@ func BuildKindConfig(cfg *config.Config) (string, error) {
 		kindConfig = rawConfig
 	}
 
-	// Add containerd config patches for local registry
-	if cfg.Cluster.Registry.Enabled {
+	// Add containerd config patches for the local registry, pull-through caches and mirrors
+	if usesHostsDir(cfg) {
 		kindConfig.ContainerdConfigPatches = append(kindConfig.ContainerdConfigPatches,
 			registryContainerdPatch(),
 		)
@@ -73,8 +96,8 @@ func BuildKindConfig(cfg *config.Config) (string, error) {
 	// Build node list (kindplane settings win)
 	kindConfig.Nodes = buildNodes(cfg, kindConfig.Nodes)
 
-	// Add containerd config patches for trusted registry CAs
-	kindConfig.ContainerdConfigPatches = append(kindConfig.ContainerdConfigPatches, buildContainerdPatches(cfg)...)
+	// Add containerd config patches for trusted registry CAs and credentials
+	kindConfig.ContainerdConfigPatches = append(kindConfig.ContainerdConfigPatches, buildContainerdPatches(cfg, creds)...)
 
 	// Marshal to YAML
 	var buf bytes.Buffer
@@ -231,7 +254,9 @@ nodeRegistration:
     node-labels: "ingress-ready=true,kindplane.io/managed-by=kindplane"`
 }
 
-// registryContainerdPatch returns the containerd config patch for local registry support
+// registryContainerdPatch returns the containerd config patch that reads
+// registry hosts from /etc/containerd/certs.d, for the local registry,
+// pull-through caches and registry mirrors
 func registryContainerdPatch() string {
 	return `[plugins."io.containerd.grpc.v1.cri".registry]
   config_path = "/etc/containerd/certs.d"`
@@ -268,10 +293,9 @@ func buildCAMounts(cfg *config.Config) []KindMount {
 
 		// Mount to system CA certificates directory for automatic trust
 		// After cluster creation, update-ca-certificates must be run on each node
-		containerPath := fmt.Sprintf("/usr/local/share/ca-certificates/%s.crt", wl.Name)
 		mounts = append(mounts, KindMount{
 			HostPath:      absPath,
-			ContainerPath: containerPath,
+			ContainerPath: wl.NodePath(),
 			ReadOnly:      true,
 		})
 	}
@@ -279,48 +303,37 @@ func buildCAMounts(cfg *config.Config) []KindMount {
 	return mounts
 }
 
-// buildContainerdPatches creates containerd config patches for trusted registry CAs
-func buildContainerdPatches(cfg *config.Config) []string {
-	if len(cfg.Cluster.TrustedCAs.Registries) == 0 {
-		return nil
-	}
-
+// buildContainerdPatches creates containerd config patches for trusted
+// registry CAs and registry credentials. When nodes use the hosts directory,
+// containerd finds the mounted CAs there instead.
+func buildContainerdPatches(cfg *config.Config, creds []RegistryCredential) []string {
 	var patches []string
 
 	// Build containerd config patch for each registry
-	for _, reg := range cfg.Cluster.TrustedCAs.Registries {
-		patch := fmt.Sprintf(`[plugins."io.containerd.grpc.v1.cri".registry.configs."%s".tls]
+	if !usesHostsDir(cfg) {
+		for _, reg := range cfg.Cluster.TrustedCAs.Registries {
+			patch := fmt.Sprintf(`[plugins."io.containerd.grpc.v1.cri".registry.configs."%s".tls]
   ca_file = "/etc/containerd/certs.d/%s/ca.crt"`, reg.Host, reg.Host)
-		patches = append(patches, patch)
-	}
-
-	// Combine all patches into a single patch string
-	if len(patches) > 0 {
-		var combined string
-		for i, patch := range patches {
-			if i > 0 {
-				combined += "\n"
-			}
-			combined += patch
+			patches = append(patches, patch)
 		}
-		return []string{combined}
 	}
 
-	return nil
-}
-
-// GenerateKindConfigFile creates a Kind config file from template
-func GenerateKindConfigFile(cfg *config.Config, outputPath string) error {
-	kindConfigYAML, err := BuildKindConfig(cfg)
-	if err != nil {
-		return err
+	for _, cred := range creds {
+		patch := fmt.Sprintf(`[plugins."io.containerd.grpc.v1.cri".registry.configs."%s".auth]`, registryServer(cred.Host))
+		if cred.Username != "" {
+			patch += fmt.Sprintf("\n  username = %q\n  password = %q", cred.Username, cred.Password)
+		}
+		if cred.IdentityToken != "" {
+			patch += fmt.Sprintf("\n  identitytoken = %q", cred.IdentityToken)
+		}
+		patches = append(patches, patch)
 	}
 
-	if err := os.WriteFile(outputPath, []byte(kindConfigYAML), 0644); err != nil {
-		return fmt.Errorf("failed to write kind config file: %w", err)
+	// Combine all patches into a single patch string
+	if len(patches) == 0 {
+		return nil
 	}
-
-	return nil
+	return []string{strings.Join(patches, "\n")}
 }
 
 // Note: kindConfigTemplate is not currently used since we use YAML marshaling directly
@@ -130,3 +130,38 @@ func TestBuildKindConfig_NodeImage(t *testing.T) {
 		})
 	}
 }
+
+func TestBuildKindConfig_RegistryHostsDir(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry config.RegistryConfig
+		mirrors  []config.RegistryMirror
+		want     bool
+	}{
+		{name: "no registry", want: false},
+		{name: "local registry", registry: config.RegistryConfig{Enabled: true}, want: true},
+		{name: "pull-through caches only", registry: config.RegistryConfig{PullThrough: config.PullThroughConfig{Enabled: true}}, want: true},
+		{name: "registry mirrors", mirrors: []config.RegistryMirror{{Host: "docker.io", Endpoints: []string{"https://mirror.example.com"}}}, want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := &config.Config{
+				Cluster: config.ClusterConfig{
+					Name:              "test-cluster",
+					KubernetesVersion: "1.34.0",
+					Nodes:             config.NodesConfig{ControlPlane: 1},
+					Registry:          tt.registry,
+					RegistryMirrors:   tt.mirrors,
+				},
+			}
+			kindConfig, err := BuildKindConfig(cfg)
+			if err != nil {
+				t.Fatalf("BuildKindConfig failed: %v", err)
+			}
+			if got := strings.Contains(kindConfig, `config_path = "/etc/containerd/certs.d"`); got != tt.want {
+				t.Errorf("config_path patch present = %v, want %v. YAML:\n%s", got, tt.want, kindConfig)
+			}
+		})
+	}
+}
@@ -0,0 +1,139 @@
+package kind
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+
+	"k8s.io/client-go/tools/clientcmd"
+
+	"github.com/kanzi/kindplane/internal/kube"
+)
+
+// kubeconfigOverride and contextOverride are set from the --kubeconfig and
+// --context flags
+var (
+	kubeconfigOverride string
+	contextOverride    string
+)
+
+// SetKubeconfigOverride makes Connect use a context of a kubeconfig file
+// instead of the Kind cluster's own kubeconfig. An empty path means the
+// default kubeconfig files and an empty context their current context;
+// when both are empty the override is cleared.
+func SetKubeconfigOverride(path, context string) {
+	kubeconfigOverride = path
+	contextOverride = context
+}
+
+// HasKubeconfigOverride reports whether --kubeconfig or --context was given
+func HasKubeconfigOverride() bool {
+	return kubeconfigOverride != "" || contextOverride != ""
+}
+
+// ClusterAvailable reports whether commands can connect to the cluster:
+// the Kind cluster exists, or --kubeconfig or --context names a cluster
+// explicitly (which need not be a Kind cluster)
+func ClusterAvailable(name string) (bool, error) {
+	if HasKubeconfigOverride() {
+		return true, nil
+	}
+	return ClusterExists(name)
+}
+
+// Connect returns the clients for the cluster. They are built from the Kind
+// cluster's kubeconfig, read from the cluster itself, never from the
+// current kubectl context. With --kubeconfig or --context the given context
+// is used instead; if a Kind cluster of that name exists, the context must
+// point at its API server.
+func Connect(clusterName string) (*kube.Connection, error) {
+	if !HasKubeconfigOverride() {
+		kubeconfig, err := KubeConfig(clusterName, false)
+		if err != nil {
+			return nil, err
+		}
+		return kube.FromKubeconfig([]byte(kubeconfig))
+	}
+
+	rules := clientcmd.NewDefaultClientConfigLoadingRules()
+	rules.ExplicitPath = kubeconfigOverride
+	clientConfig := clientcmd.NewNonInteractiveDeferredLoadingClientConfig(rules, &clientcmd.ConfigOverrides{
+		CurrentContext: contextOverride,
+	})
+	conn, err := kube.NewConnection(clientConfig)
+	if err != nil {
+		return nil, err
+	}
+
+	exists, err := ClusterExists(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return conn, nil
+	}
+	endpoints, err := kindEndpoints(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkEndpoint(conn.Server(), clusterName, endpoints); err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// kindEndpoints returns the API server URLs of a Kind cluster, as seen from
+// the host and from the kind network
+func kindEndpoints(clusterName string) ([]string, error) {
+	var endpoints []string
+	for _, internal := range []bool{false, true} {
+		kubeconfig, err := KubeConfig(clusterName, internal)
+		if err != nil {
+			return nil, err
+		}
+		config, err := clientcmd.RESTConfigFromKubeConfig([]byte(kubeconfig))
+		if err != nil {
+			return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
+		}
+		endpoints = append(endpoints, config.Host)
+	}
+	return endpoints, nil
+}
+
+// checkEndpoint refuses a server that is not one of the Kind cluster's API
+// endpoints, so that a stray context cannot send changes to another cluster
+func checkEndpoint(server, clusterName string, endpoints []string) error {
+	for _, endpoint := range endpoints {
+		if sameServer(server, endpoint) {
+			return nil
+		}
+	}
+	return fmt.Errorf("the selected context points at %s, but Kind cluster %q is served at %s; refusing to continue",
+		server, clusterName, strings.Join(endpoints, " or "))
+}
+
+// sameServer compares API server URLs, ignoring case in the scheme and
+// host, default ports and a trailing slash
+func sameServer(a, b string) bool {
+	return normalizeServer(a) == normalizeServer(b)
+}
+
+func normalizeServer(server string) string {
+	u, err := url.Parse(server)
+	if err != nil || u.Host == "" {
+		return strings.TrimSuffix(server, "/")
+	}
+	scheme := strings.ToLower(u.Scheme)
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = "443"
+		if scheme == "http" {
+			port = "80"
+		}
+	}
+	if strings.Contains(host, ":") {
+		host = "[" + host + "]"
+	}
+	return scheme + "://" + host + ":" + port + strings.TrimSuffix(u.Path, "/")
+}
@@ -0,0 +1,71 @@
+package kind
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCheckEndpoint(t *testing.T) {
+	endpoints := []string{"https://127.0.0.1:45123", "https://dev-control-plane:6443"}
+
+	tests := []struct {
+		name    string
+		server  string
+		wantErr bool
+	}{
+		{name: "host endpoint", server: "https://127.0.0.1:45123"},
+		{name: "internal endpoint", server: "https://dev-control-plane:6443"},
+		{name: "trailing slash", server: "https://127.0.0.1:45123/"},
+		{name: "host case", server: "https://DEV-control-plane:6443"},
+		{name: "other port", server: "https://127.0.0.1:6443", wantErr: true},
+		{name: "other cluster", server: "https://prod.example.com", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkEndpoint(tt.server, "dev", endpoints)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("checkEndpoint(%q) error = %v, wantErr %v", tt.server, err, tt.wantErr)
+			}
+			if err != nil && !strings.Contains(err.Error(), tt.server) {
+				t.Errorf("error %q does not name the server", err)
+			}
+		})
+	}
+}
+
+func TestSameServer(t *testing.T) {
+	tests := []struct {
+		a, b string
+		want bool
+	}{
+		{"https://example.com", "https://example.com:443", true},
+		{"http://example.com", "http://example.com:80", true},
+		{"https://example.com", "http://example.com", false},
+		{"https://[::1]:6443", "https://[::1]:6443/", true},
+		{"https://example.com/k8s/", "https://example.com/k8s", true},
+		{"https://example.com/k8s", "https://example.com", false},
+	}
+
+	for _, tt := range tests {
+		if got := sameServer(tt.a, tt.b); got != tt.want {
+			t.Errorf("sameServer(%q, %q) = %v, want %v", tt.a, tt.b, got, tt.want)
+		}
+	}
+}
+
+func TestClusterAvailable_Override(t *testing.T) {
+	SetKubeconfigOverride("", "prod")
+	t.Cleanup(func() { SetKubeconfigOverride("", "") })
+
+	if !HasKubeconfigOverride() {
+		t.Fatal("expected override to be set")
+	}
+	available, err := ClusterAvailable("does-not-exist")
+	if err != nil {
+		t.Fatalf("ClusterAvailable failed: %v", err)
+	}
+	if !available {
+		t.Error("expected a cluster named by --context to be available")
+	}
+}
@@ -2,19 +2,15 @@ package kind
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"io"
-	"os"
-	"os/exec"
 	"runtime"
+	"slices"
 	"strings"
-	"time"
-
-	"sigs.k8s.io/kind/pkg/cluster"
-	"sigs.k8s.io/kind/pkg/cluster/nodeutils"
 
 	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/helm"
+	"github.com/kanzi/kindplane/internal/xpkg"
 )
 
 // PreloadResult contains the result of image pre-loading
@@ -24,16 +20,9 @@ type PreloadResult struct {
 	RePulledCount int      // Number of images re-pulled for correct architecture
 }
 
-// imageArchInfo holds architecture information for an image
-type imageArchInfo struct {
-	Architecture string
-	OS           string
-	Variant      string // e.g., "v8" for arm64
-}
-
 // getNodeArchitecture returns the architecture of the Kind node
-func getNodeArchitecture(ctx context.Context, clusterName string) (string, error) {
-	provider := cluster.NewProvider()
+func getNodeArchitecture(ctx context.Context, rt container.Runtime, clusterName string) (string, error) {
+	provider := NewProvider()
 	nodes, err := provider.ListNodes(clusterName)
 	if err != nil {
 		return "", fmt.Errorf("failed to list nodes: %w", err)
@@ -43,8 +32,7 @@ func getNodeArchitecture(ctx context.Context, clusterName string) (string, error
 	}
 
 	// Get architecture from first node using uname -m
-	cmd := exec.CommandContext(ctx, "docker", "exec", nodes[0].String(), "uname", "-m")
-	output, err := cmd.Output()
+	output, err := rt.Exec(ctx, nodes[0].String(), nil, "uname", "-m")
 	if err != nil {
 		return "", fmt.Errorf("failed to get node architecture: %w", err)
 	}
@@ -66,35 +54,9 @@ func normalizeArch(arch string) string {
 	}
 }
 
-// getImageArchitecture returns the architecture of a local Docker image
-func getImageArchitecture(ctx context.Context, imageName string) (*imageArchInfo, error) {
-	cmd := exec.CommandContext(ctx, "docker", "inspect", imageName, "--format", "{{json .}}")
-	output, err := cmd.Output()
-	if err != nil {
-		return nil, fmt.Errorf("failed to inspect image: %w", err)
-	}
-
-	var inspectResult struct {
-		Architecture string `json:"Architecture"`
-		Os           string `json:"Os"`
-		Variant      string `json:"Variant"`
-	}
-
-	if err := json.Unmarshal(output, &inspectResult); err != nil {
-		return nil, fmt.Errorf("failed to parse image inspect output: %w", err)
-	}
-
-	return &imageArchInfo{
-		Architecture: inspectResult.Architecture,
-		OS:           inspectResult.Os,
-		Variant:      inspectResult.Variant,
-	}, nil
-}
-
 // pullImageForPlatform pulls an image for a specific platform
-func pullImageForPlatform(ctx context.Context, imageName, platform string) error {
-	cmd := exec.CommandContext(ctx, "docker", "pull", "--platform", platform, imageName)
-	if err := cmd.Run(); err != nil {
+func pullImageForPlatform(ctx context.Context, rt container.Runtime, imageName, platform string) error {
+	if err := rt.PullImage(ctx, imageName, platform); err != nil {
 		return fmt.Errorf("pulling image %s for platform %s: %w", imageName, platform, err)
 	}
 	return nil
@@ -118,36 +80,14 @@ func getHostArchitecture() string {
 	return normalizeArch(runtime.GOARCH)
 }
 
-// cmdReader wraps a ReadCloser and ensures the command is properly closed.
-// Used by saveDockerImage for streaming load path (currently unused; kept for future use).
-//
-//nolint:unused
-type cmdReader struct {
-	io.ReadCloser
-	cmd *exec.Cmd
-}
-
-//nolint:unused
-func (r *cmdReader) Close() error {
-	errClose := r.ReadCloser.Close()
-	errWait := r.cmd.Wait()
-	if errClose != nil {
-		return fmt.Errorf("closing ReadCloser: %w", errClose)
-	}
-	if errWait != nil {
-		return fmt.Errorf("waiting for cmd: %w", errWait)
-	}
-	return nil
-}
-
-// PullImages pulls Docker images from remote registries
+// PullImages pulls images from remote registries into the local container runtime
 func PullImages(ctx context.Context, images []string, logFn func(string)) (int, error) {
+	rt := container.Default()
 	successCount := 0
 	for i, img := range images {
 		logFn(fmt.Sprintf("Pulling %s (%d/%d)...", getShortImageName(img), i+1, len(images)))
 
-		cmd := exec.CommandContext(ctx, "docker", "pull", img)
-		if err := cmd.Run(); err != nil {
+		if err := rt.PullImage(ctx, img, ""); err != nil {
 			logFn(fmt.Sprintf("Warning: Failed to pull %s: %v", getShortImageName(img), err))
 			continue
 		}
@@ -158,11 +98,21 @@ func PullImages(ctx context.Context, images []string, logFn func(string)) (int,
 	return successCount, nil
 }
 
-// PreloadImages pre-loads images from local Docker into Kind cluster
+// PreloadImages pre-loads images from the local container runtime into Kind cluster,
+// or, with crossplane.imageCache.source registry or layout, from their
+// registries or an OCI image layout without using the runtime's image store.
 // Supports two modes:
 //   - Registry mode: Push images to local registry (if cfg.Cluster.Registry.Enabled)
 //   - Direct mode: Load images directly into Kind nodes
 func PreloadImages(ctx context.Context, clusterName string, cfg *config.Config, logFn func(string)) (*PreloadResult, error) {
+	return PreloadImagesWithOptions(ctx, clusterName, cfg, PreloadOptions{Log: logFn})
+}
+
+// PreloadImagesWithOptions is PreloadImages with control over concurrency
+// and per-image progress reporting
+func PreloadImagesWithOptions(ctx context.Context, clusterName string, cfg *config.Config, opts PreloadOptions) (*PreloadResult, error) {
+	opts = opts.withDefaults()
+	logFn := opts.Log
 	result := &PreloadResult{
 		LoadedCount:   0,
 		MissingImages: []string{},
@@ -175,13 +125,19 @@ func PreloadImages(ctx context.Context, clusterName string, cfg *config.Config,
 	}
 
 	// Collect all images to preload
-	images := collectImagesToPreload(cfg)
+	sources := CollectImages(ctx, cfg, logFn)
+	images := make([]string, 0, len(sources))
+	for _, s := range sources {
+		images = append(images, s.Image)
+	}
 	if len(images) == 0 {
 		return result, nil
 	}
 
+	rt := container.Default()
+
 	// Detect Kind node architecture
-	nodeArch, err := getNodeArchitecture(ctx, clusterName)
+	nodeArch, err := getNodeArchitecture(ctx, rt, clusterName)
 	if err != nil {
 		logFn(fmt.Sprintf("Warning: Could not detect node architecture: %v", err))
 		// Fall back to host architecture
@@ -189,8 +145,12 @@ func PreloadImages(ctx context.Context, clusterName string, cfg *config.Config,
 	}
 	logFn(fmt.Sprintf("Target architecture: %s", nodeArch))
 
+	if cfg.Crossplane.ImageCache.GetSource() != config.ImageSourceDaemon {
+		return preloadWithoutDaemon(ctx, rt, clusterName, cfg, images, nodeArch, opts, result)
+	}
+
 	// Filter to only locally available images and check architecture
-	localImages, rePulledCount, err := filterAndFixLocalImages(ctx, images, nodeArch, logFn)
+	localImages, rePulledCount, err := filterAndFixLocalImages(ctx, rt, images, nodeArch, logFn)
 	if err != nil {
 		return result, err
 	}
@@ -217,67 +177,161 @@ func PreloadImages(ctx context.Context, clusterName string, cfg *config.Config,
 	// Choose mode based on registry configuration
 	var loadErr error
 	var loaded int
-	if cfg.Cluster.Registry.Enabled {
+	if cfg.Cluster.Registry.Enabled && !opts.ImportToNodes {
 		// Registry mode: Push to local registry
 		registryHost := fmt.Sprintf("localhost:%d", cfg.Cluster.Registry.GetPort())
-		loaded, loadErr = pushImagesToRegistry(ctx, localImages, registryHost, logFn)
+		loaded, loadErr = pushImagesToRegistry(ctx, rt, localImages, registryHost, logFn)
 	} else {
 		// Direct mode: Load into Kind nodes
-		loaded, loadErr = loadImagesIntoNodes(ctx, clusterName, nodeArch, localImages, logFn)
+		nodes, err := GetNodeContainers(clusterName)
+		if err != nil {
+			return result, fmt.Errorf("failed to list nodes: %w", err)
+		}
+		cache, err := newArchiveCache()
+		if err != nil {
+			return result, err
+		}
+		loaded, loadErr = loadImagesIntoNodes(ctx, rt, daemonSource(rt, cache, nodeArch), nodes, localImages, opts)
 	}
 
 	result.LoadedCount = loaded
 	return result, loadErr
 }
 
+// ImageSource is an image to preload and the configuration entries that need it
+type ImageSource struct {
+	Image   string   `json:"image"`
+	Sources []string `json:"sources"`
+}
+
+// imageResolvers map configuration entries to images. A nil providerImages
+// derives images from the package name; a nil chartImages skips charts.
+type imageResolvers struct {
+	providerImages func(pkg string) []string
+	chartImages    func(chart config.ChartConfig) []string
+}
+
+// CollectImages resolves every image to preload for cfg: Crossplane core,
+// providers (from their package metadata), the images of configured Helm
+// charts and additional images. Problems resolving an entry are reported
+// through logFn and do not stop collection.
+func CollectImages(ctx context.Context, cfg *config.Config, logFn func(string)) []ImageSource {
+	return collectImageSources(cfg, imageResolvers{
+		providerImages: func(pkg string) []string {
+			images, err := ProviderImages(ctx, pkg)
+			if err != nil {
+				logFn(fmt.Sprintf("Warning: Could not read package metadata for %s, guessing its images: %v", getShortImageName(pkg), err))
+			}
+			return images
+		},
+		chartImages: func(chart config.ChartConfig) []string {
+			images, err := ChartImages(ctx, cfg, chart)
+			if err != nil {
+				logFn(fmt.Sprintf("Warning: Could not render chart %s to find its images: %v", chart.Name, err))
+			}
+			return images
+		},
+	})
+}
+
+// ChartImages renders a configured chart with its merged values and returns
+// the images of every container, init container and hook it deploys
+func ChartImages(ctx context.Context, cfg *config.Config, chart config.ChartConfig) ([]string, error) {
+	manifest, err := helm.RenderChart(ctx, chart, cfg.Cluster.KubernetesVersion, helm.NewValuesContext(cfg))
+	if err != nil {
+		return nil, err
+	}
+	return helm.ExtractImages(manifest)
+}
+
 // collectImagesToPreload gathers all images that should be pre-loaded
-func collectImagesToPreload(cfg *config.Config) []string {
-	images := make(map[string]bool) // Use map to deduplicate
+func collectImagesToPreload(cfg *config.Config, resolvers imageResolvers) []string {
+	sources := collectImageSources(cfg, resolvers)
+	images := make([]string, 0, len(sources))
+	for _, s := range sources {
+		images = append(images, s.Image)
+	}
+	return images
+}
 
-	// 1. Crossplane core images
-	if cfg.Crossplane.ImageCache == nil || cfg.Crossplane.ImageCache.ShouldPreloadCrossplane() {
-		for _, img := range deriveCrossplaneImages(cfg.Crossplane.Version) {
-			images[img] = true
+// collectImageSources gathers all images that should be pre-loaded, in
+// order of first appearance, with the configuration entries needing each
+func collectImageSources(cfg *config.Config, resolvers imageResolvers) []ImageSource {
+	providerImages := resolvers.providerImages
+	if providerImages == nil {
+		providerImages = deriveProviderImages
+	}
+
+	var result []ImageSource
+	index := make(map[string]int) // Deduplicate, keeping every source
+	add := func(source string, images ...string) {
+		for _, img := range images {
+			if i, ok := index[img]; ok {
+				if !slices.Contains(result[i].Sources, source) {
+					result[i].Sources = append(result[i].Sources, source)
+				}
+				continue
+			}
+			index[img] = len(result)
+			result = append(result, ImageSource{Image: img, Sources: []string{source}})
 		}
 	}
 
+	imageCache := cfg.Crossplane.ImageCache
+
+	// 1. Crossplane core images
+	if imageCache == nil || imageCache.ShouldPreloadCrossplane() {
+		add("crossplane", deriveCrossplaneImages(cfg.Crossplane.Version)...)
+	}
+
 	// 2. Provider images
-	if cfg.Crossplane.ImageCache == nil || cfg.Crossplane.ImageCache.ShouldPreloadProviders() {
+	if imageCache == nil || imageCache.ShouldPreloadProviders() {
 		for _, provider := range cfg.Crossplane.Providers {
+			source := "provider " + provider.Name
 			// Check for overrides first
-			if cfg.Crossplane.ImageCache != nil {
-				if overrides, ok := cfg.Crossplane.ImageCache.ImageOverrides[provider.Package]; ok {
-					for _, img := range overrides {
-						images[img] = true
-					}
+			if imageCache != nil {
+				if overrides, ok := imageCache.ImageOverrides[provider.Package]; ok {
+					add(source, overrides...)
 					continue
 				}
 			}
-
-			// Use convention-based derivation
-			for _, img := range deriveProviderImages(provider.Package) {
-				images[img] = true
-			}
+			add(source, providerImages(provider.Package)...)
 		}
 	}
 
-	// 3. Additional images
-	if cfg.Crossplane.ImageCache != nil {
-		for _, img := range cfg.Crossplane.ImageCache.AdditionalImages {
-			images[img] = true
+	// 3. Chart images
+	if resolvers.chartImages != nil && (imageCache == nil || imageCache.ShouldPreloadCharts()) {
+		for _, chart := range cfg.Charts {
+			add("chart "+chart.Name, resolvers.chartImages(chart)...)
 		}
 	}
 
-	// Convert to slice
-	result := make([]string, 0, len(images))
-	for img := range images {
-		result = append(result, img)
+	// 4. Additional images
+	if imageCache != nil {
+		add("additionalImages", imageCache.AdditionalImages...)
 	}
 
 	return result
 }
 
-// deriveProviderImages derives controller image names from provider packages
+// ProviderImages returns the images needed to run a provider package, read
+// from the package metadata in its registry and including dependencies
+// pinned to exact versions. If the metadata cannot be read the images are
+// derived from the package name, and the error is returned alongside them.
+func ProviderImages(ctx context.Context, pkg string) ([]string, error) {
+	resolver, err := xpkg.NewResolver()
+	if err != nil {
+		return deriveProviderImages(pkg), err
+	}
+	images, err := resolver.Images(ctx, pkg)
+	if err != nil {
+		return deriveProviderImages(pkg), err
+	}
+	return images, nil
+}
+
+// deriveProviderImages derives controller image names from provider packages.
+// It is the fallback when a package's metadata cannot be read.
 // Convention: xpkg.upbound.io/publisher/provider:version
 //
 //	-> xpkg.upbound.io/publisher/provider:version (the xpkg package)
@@ -329,13 +383,13 @@ func deriveCrossplaneImages(version string) []string {
 // filterAndFixLocalImages checks which images exist locally and have correct architecture.
 // If an image exists but has wrong architecture, it attempts to re-pull with correct platform.
 // Returns: list of valid local images, count of re-pulled images, error
-func filterAndFixLocalImages(ctx context.Context, images []string, targetArch string, logFn func(string)) ([]string, int, error) {
+func filterAndFixLocalImages(ctx context.Context, rt container.Runtime, images []string, targetArch string, logFn func(string)) ([]string, int, error) {
 	var localImages []string
 	rePulledCount := 0
 	targetPlatform := getTargetPlatform(targetArch)
 
 	for _, img := range images {
-		exists, err := imageExistsLocally(ctx, img)
+		exists, err := rt.ImageExists(ctx, img)
 		if err != nil {
 			// Don't fail on individual image checks, just skip
 			continue
@@ -347,7 +401,7 @@ func filterAndFixLocalImages(ctx context.Context, images []string, targetArch st
 		}
 
 		// Check image architecture
-		archInfo, err := getImageArchitecture(ctx, img)
+		platform, err := rt.ImagePlatform(ctx, img)
 		if err != nil {
 			logFn(fmt.Sprintf("Warning: Could not check architecture for %s: %v", getShortImageName(img), err))
 			// Include it anyway and let the load fail if there's an issue
@@ -355,12 +409,12 @@ func filterAndFixLocalImages(ctx context.Context, images []string, targetArch st
 			continue
 		}
 
-		if isArchitectureMismatch(archInfo.Architecture, targetArch) {
+		if isArchitectureMismatch(platform.Architecture, targetArch) {
 			logFn(fmt.Sprintf("Image %s has wrong architecture (%s, need %s), re-pulling...",
-				getShortImageName(img), archInfo.Architecture, targetArch))
+				getShortImageName(img), platform.Architecture, targetArch))
 
 			// Try to re-pull with correct platform
-			if err := pullImageForPlatform(ctx, img, targetPlatform); err != nil {
+			if err := pullImageForPlatform(ctx, rt, img, targetPlatform); err != nil {
 				logFn(fmt.Sprintf("Warning: Failed to re-pull %s for %s: %v",
 					getShortImageName(img), targetPlatform, err))
 				continue
@@ -376,35 +430,22 @@ func filterAndFixLocalImages(ctx context.Context, images []string, targetArch st
 	return localImages, rePulledCount, nil
 }
 
-// imageExistsLocally checks if an image exists in local Docker daemon
-func imageExistsLocally(ctx context.Context, imageName string) (bool, error) {
-	cmd := exec.CommandContext(ctx, "docker", "images", "-q", imageName)
-	output, err := cmd.Output()
-	if err != nil {
-		return false, err
-	}
-	return len(strings.TrimSpace(string(output))) > 0, nil
-}
-
 // pushImagesToRegistry pushes local images to the local registry
-func pushImagesToRegistry(ctx context.Context, images []string, registryHost string, logFn func(string)) (int, error) {
+func pushImagesToRegistry(ctx context.Context, rt container.Runtime, images []string, registryHost string, logFn func(string)) (int, error) {
 	successCount := 0
 
 	for _, img := range images {
 		// Tag image for local registry
-		registryImage := retagForRegistry(img, registryHost)
+		registryImage := RetagForRegistry(img, registryHost)
 
 		// Tag command
-		tagCmd := exec.CommandContext(ctx, "docker", "tag", img, registryImage)
-		if err := tagCmd.Run(); err != nil {
+		if err := rt.TagImage(ctx, img, registryImage); err != nil {
 			logFn(fmt.Sprintf("Warning: Failed to tag %s: %v", img, err))
 			continue
 		}
 
 		// Push to registry
-		pushCmd := exec.CommandContext(ctx, "docker", "push", registryImage)
-		// Suppress output to avoid clutter
-		if err := pushCmd.Run(); err != nil {
+		if err := rt.PushImage(ctx, registryImage); err != nil {
 			logFn(fmt.Sprintf("Warning: Failed to push %s: %v", registryImage, err))
 			continue
 		}
@@ -420,8 +461,8 @@ func pushImagesToRegistry(ctx context.Context, images []string, registryHost str
 	return 0, fmt.Errorf("failed to push any images to registry")
 }
 
-// retagForRegistry retags an image for the local registry
-func retagForRegistry(imageName, registryHost string) string {
+// RetagForRegistry retags an image for the local registry
+func RetagForRegistry(imageName, registryHost string) string {
 	// Remove any existing registry prefix and add local registry
 	// e.g., xpkg.upbound.io/upbound/provider-aws:v1 -> localhost:5001/upbound/provider-aws:v1
 	// But keep Docker Hub images intact: crossplane/crossplane:v1 -> localhost:5001/crossplane/crossplane:v1
@@ -438,207 +479,6 @@ func retagForRegistry(imageName, registryHost string) string {
 	return fmt.Sprintf("%s/%s", registryHost, imageName)
 }
 
-// loadImagesIntoNodes loads images directly into Kind nodes using Kind SDK
-func loadImagesIntoNodes(ctx context.Context, clusterName string, nodeArch string, images []string, logFn func(string)) (int, error) {
-	// Get Kind provider
-	provider := cluster.NewProvider()
-
-	// List all nodes in the cluster
-	nodes, err := provider.ListNodes(clusterName)
-	if err != nil {
-		return 0, fmt.Errorf("failed to list nodes: %w", err)
-	}
-
-	if len(nodes) == 0 {
-		return 0, fmt.Errorf("no nodes found in cluster %s", clusterName)
-	}
-
-	logFn(fmt.Sprintf("Loading images into %d node(s)", len(nodes)))
-
-	successCount := 0
-
-	// Load each image
-	for _, img := range images {
-		logFn(fmt.Sprintf("Processing %s...", getShortImageName(img)))
-
-		// Check if image is already loaded in the first node
-		if _, err := nodeutils.ImageID(nodes[0], img); err == nil {
-			logFn("  ✓ Already present")
-			successCount++
-			continue
-		}
-
-		// For macOS with Docker Desktop, we need to handle each node separately
-		// and create a fresh stream for each node
-		var failedNodes map[string]error // populated only in legacy import fallback
-
-		// Use a temporary file to transfer the image to avoid pipe issues
-		// This is a workaround for some environments where piping directly to ctr fails
-
-		// 1. Create a temporary file
-		tmpFile, err := os.CreateTemp("", "kind-image-*.tar")
-		if err != nil {
-			logFn(fmt.Sprintf("  ✗ Failed to create temp file: %v", err))
-			continue
-		}
-		tmpFileName := tmpFile.Name()
-
-		// 2. Save image to the temporary file
-		logFn("  Exporting image to temp file...")
-		saveCmd := exec.CommandContext(ctx, "docker", "save", "-o", tmpFileName, img)
-		if out, err := saveCmd.CombinedOutput(); err != nil {
-			logFn(fmt.Sprintf("  ✗ Failed to save image: %v (%s)", err, strings.TrimSpace(string(out))))
-			_ = tmpFile.Close()
-			_ = os.Remove(tmpFileName)
-			continue
-		}
-		_ = tmpFile.Close() // Close so we can read it again
-
-		// WORKAROUND: Remove OCI index files from the tarball if present.
-		// Docker on macOS (ARM64) sometimes exports a tarball containing both OCI layout/index.json AND
-		// a Docker v2 manifest.json. However, the 'index.json' often references a multi-arch manifest
-		// digest (e.g., from the remote registry) that does not exist in the local blob store because
-		// we only pulled the single architecture.
-		//
-		// When 'ctr' sees 'index.json', it tries to validate the entire index (all architectures),
-		// fails to find the other architecture blobs, and errors with "content digest ... not found".
-		//
-		// By removing 'index.json' and 'oci-layout', we force 'ctr' (and 'kind load') to fall back
-		// to using 'manifest.json' (Docker V2 format), which correctly points to the single-arch
-		// blobs that are actually present in the tarball.
-		if nodeArch == "arm64" {
-			// Create a temporary directory for repackaging
-			repackDir, err := os.MkdirTemp("", "kind-repack-*")
-			if err == nil {
-				// Unpack tarball
-				unpackCmd := exec.CommandContext(ctx, "tar", "-xf", tmpFileName, "-C", repackDir)
-				if err := unpackCmd.Run(); err == nil {
-					// Check if index.json exists
-					if _, err := os.Stat(fmt.Sprintf("%s/index.json", repackDir)); err == nil {
-						// Remove OCI files
-						_ = os.Remove(fmt.Sprintf("%s/index.json", repackDir))
-						_ = os.Remove(fmt.Sprintf("%s/oci-layout", repackDir))
-
-						// Repack into the temp file (overwriting it)
-						// Note: We need to recreate the file
-						tmpFileForRepack, err := os.Create(tmpFileName)
-						if err == nil {
-							// Tar command to repack relative to repackDir
-							repackCmd := exec.CommandContext(ctx, "tar", "-C", repackDir, "-cf", tmpFileForRepack.Name(), ".")
-							if err := repackCmd.Run(); err != nil {
-								logFn(fmt.Sprintf("  Warning: Failed to repack image (continuing with original): %v", err))
-							}
-							_ = tmpFileForRepack.Close()
-						}
-					}
-				}
-				_ = os.RemoveAll(repackDir)
-			}
-		}
-
-		// 3. Load via `kind load image-archive` command directly instead of using the library
-		// The library implementation seems to have issues with pipes on some environments
-		// and we are already shelling out for docker commands anyway.
-
-		logFn("  Loading archive into cluster nodes...")
-		loadCmd := exec.CommandContext(ctx, "kind", "load", "image-archive", tmpFileName, "--name", clusterName)
-		out, err := loadCmd.CombinedOutput()
-		_ = out // Prevent unused variable error if we don't use it in success path
-		if err != nil {
-			logFn(fmt.Sprintf("  Warning: Standard load failed, trying legacy import: %v", err))
-
-			// Fallback: Manually copy and import into each node
-			// This works around issues where `kind load` or streaming fails
-			failedNodes = make(map[string]error)
-
-			for _, n := range nodes {
-				// 1. Copy file to node container
-				containerName := n.String()
-				// Use a simpler filename to avoid potential character issues in shell
-				// Copy to root instead of /tmp because /tmp behaves weirdly in some Kind nodes/Docker Desktop setups
-				simpleName := fmt.Sprintf("image-%d.tar", time.Now().UnixNano())
-				targetPath := fmt.Sprintf("/%s", simpleName)
-
-				logFn(fmt.Sprintf("  Copying to %s:%s...", containerName, targetPath))
-				cpCmd := exec.CommandContext(ctx, "docker", "cp", tmpFileName, fmt.Sprintf("%s:%s", containerName, targetPath))
-				if out, err := cpCmd.CombinedOutput(); err != nil {
-					nodeErr := fmt.Errorf("copy failed: %w (%s)", err, strings.TrimSpace(string(out)))
-					failedNodes[containerName] = nodeErr
-					logFn(fmt.Sprintf("  ✗ Failed to copy to node %s: %v", containerName, nodeErr))
-					continue
-				}
-
-				// 2. Import using ctr inside the node
-				logFn(fmt.Sprintf("  Importing in %s...", containerName))
-				// Try with --no-unpack first as it's often more robust
-				// NOTE: We need to use the path relative to the container filesystem.
-				// The previous copy put it at /tmp/... inside the container.
-				importCmd := exec.CommandContext(ctx, "docker", "exec", containerName, "ctr", "-n", "k8s.io", "images", "import", "--no-unpack", targetPath)
-				out, err := importCmd.CombinedOutput()
-				_ = out // Prevent unused variable error
-
-				if err != nil {
-					// Retry without --no-unpack
-					importCmd = exec.CommandContext(ctx, "docker", "exec", containerName, "ctr", "-n", "k8s.io", "images", "import", targetPath)
-					if out2, err2 := importCmd.CombinedOutput(); err2 != nil {
-						nodeErr := fmt.Errorf("import failed: %w (output1: %s; output2: %s)",
-							err2, strings.TrimSpace(string(out)), strings.TrimSpace(string(out2)))
-						failedNodes[containerName] = nodeErr
-						logFn(fmt.Sprintf("  ✗ Failed to import in node %s: %v", containerName, nodeErr))
-					}
-				}
-
-				// Cleanup temp file in node
-				if rmErr := exec.CommandContext(ctx, "docker", "exec", containerName, "rm", targetPath).Run(); rmErr != nil {
-					logFn(fmt.Sprintf("  Warning: failed to remove temp file %s in %s: %v", targetPath, containerName, rmErr))
-				}
-			}
-
-			if len(failedNodes) > 0 {
-				logFn(fmt.Sprintf("  ✗ Image not loaded on %d node(s):", len(failedNodes)))
-				for node, nodeErr := range failedNodes {
-					logFn(fmt.Sprintf("    - %s: %v", node, nodeErr))
-				}
-			}
-		}
-
-		_ = os.Remove(tmpFileName)
-
-		// Success when standard load worked, or when legacy fallback ran with no node failures
-		legacyFailed := len(failedNodes) > 0
-		if !legacyFailed {
-			logFn("  ✓ Loaded successfully")
-			successCount++
-		}
-	}
-
-	if successCount > 0 {
-		logFn(fmt.Sprintf("Pre-loaded %d/%d images into Kind nodes", successCount, len(images)))
-		return successCount, nil
-	}
-	logFn("No images were successfully loaded")
-	return 0, fmt.Errorf("failed to load any images into Kind nodes")
-}
-
-// saveDockerImage exports a Docker image as a tar stream.
-// Reserved for streaming load path (currently unused).
-//
-//nolint:unused
-func saveDockerImage(ctx context.Context, imageName string) (io.ReadCloser, error) {
-	cmd := exec.CommandContext(ctx, "docker", "save", imageName)
-	stdout, err := cmd.StdoutPipe()
-	if err != nil {
-		return nil, fmt.Errorf("failed to create stdout pipe: %w", err)
-	}
-
-	if err := cmd.Start(); err != nil {
-		return nil, fmt.Errorf("failed to start docker save: %w", err)
-	}
-
-	// Return reader that closes the command when done
-	return &cmdReader{ReadCloser: stdout, cmd: cmd}, nil
-}
-
 // getShortImageName returns a shortened version of the image name for display
 func getShortImageName(imageName string) string {
 	// For xpkg images, show just publisher/package:tag
@@ -1,10 +1,15 @@
 package kind
 
 import (
+	"context"
+	"fmt"
 	"reflect"
+	"slices"
+	"sort"
 	"testing"
 
 	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
 )
 
 func TestPreloadResult(t *testing.T) {
@@ -219,9 +224,9 @@ func TestRetagForRegistry(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := retagForRegistry(tt.imageName, tt.registryHost)
+			result := RetagForRegistry(tt.imageName, tt.registryHost)
 			if result != tt.expected {
-				t.Errorf("retagForRegistry(%q, %q) = %q, want %q", tt.imageName, tt.registryHost, result, tt.expected)
+				t.Errorf("RetagForRegistry(%q, %q) = %q, want %q", tt.imageName, tt.registryHost, result, tt.expected)
 			}
 		})
 	}
@@ -400,7 +405,7 @@ func TestCollectImagesToPreload(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			result := collectImagesToPreload(tt.cfg)
+			result := collectImagesToPreload(tt.cfg, imageResolvers{})
 
 			// Sort both slices for comparison (order doesn't matter)
 			if len(result) != len(tt.expected) {
@@ -422,6 +427,77 @@ func TestCollectImagesToPreload(t *testing.T) {
 	}
 }
 
+func TestCollectImagesToPreload_ProviderResolver(t *testing.T) {
+	falseVal := false
+	cfg := &config.Config{
+		Crossplane: config.CrossplaneConfig{
+			Providers: []config.ProviderConfig{
+				{Name: "provider-example", Package: "ghcr.io/example/provider-example:v0.2.0"},
+			},
+			ImageCache: &config.ImageCacheConfig{PreloadCrossplane: &falseVal},
+		},
+	}
+
+	result := collectImagesToPreload(cfg, imageResolvers{
+		providerImages: func(pkg string) []string {
+			return []string{pkg, "ghcr.io/example/controller:v0.2.0"}
+		},
+	})
+	sort.Strings(result)
+	want := []string{"ghcr.io/example/controller:v0.2.0", "ghcr.io/example/provider-example:v0.2.0"}
+	if !reflect.DeepEqual(result, want) {
+		t.Errorf("collectImagesToPreload() = %v, want %v", result, want)
+	}
+}
+
+func TestCollectImageSources(t *testing.T) {
+	falseVal := false
+	cfg := &config.Config{
+		Crossplane: config.CrossplaneConfig{
+			Version: "1.15.0",
+			Providers: []config.ProviderConfig{
+				{Name: "provider-kubernetes", Package: "xpkg.upbound.io/crossplane-contrib/provider-kubernetes:v0.12.0"},
+			},
+			ImageCache: &config.ImageCacheConfig{
+				AdditionalImages: []string{"busybox:1.36"},
+			},
+		},
+		Charts: []config.ChartConfig{
+			{Name: "cert-manager", Chart: "cert-manager"},
+			{Name: "ingress-nginx", Chart: "ingress-nginx"},
+		},
+	}
+	chartImages := map[string][]string{
+		"cert-manager":  {"quay.io/jetstack/cert-manager-controller:v1.14.0", "busybox:1.36"},
+		"ingress-nginx": {"registry.k8s.io/ingress-nginx/controller:v1.10.0"},
+	}
+	resolvers := imageResolvers{
+		chartImages: func(chart config.ChartConfig) []string { return chartImages[chart.Name] },
+	}
+
+	got := collectImageSources(cfg, resolvers)
+	want := []ImageSource{
+		{Image: "crossplane/crossplane:v1.15.0", Sources: []string{"crossplane"}},
+		{Image: "crossplane/crossplane-rbac-manager:v1.15.0", Sources: []string{"crossplane"}},
+		{Image: "xpkg.upbound.io/crossplane-contrib/provider-kubernetes:v0.12.0", Sources: []string{"provider provider-kubernetes"}},
+		{Image: "xpkg.upbound.io/crossplane-contrib/provider-kubernetes-controller:v0.12.0", Sources: []string{"provider provider-kubernetes"}},
+		{Image: "quay.io/jetstack/cert-manager-controller:v1.14.0", Sources: []string{"chart cert-manager"}},
+		{Image: "busybox:1.36", Sources: []string{"chart cert-manager", "additionalImages"}},
+		{Image: "registry.k8s.io/ingress-nginx/controller:v1.10.0", Sources: []string{"chart ingress-nginx"}},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("collectImageSources() = %+v, want %+v", got, want)
+	}
+
+	// Chart images can be turned off like the other categories
+	cfg.Crossplane.ImageCache.PreloadCharts = &falseVal
+	for _, s := range collectImageSources(cfg, resolvers) {
+		if slices.Contains(s.Sources, "chart cert-manager") || slices.Contains(s.Sources, "chart ingress-nginx") {
+			t.Errorf("chart image %s collected with preloadCharts: false", s.Image)
+		}
+	}
+}
+
 func TestImageCacheConfig_IsEnabled(t *testing.T) {
 	trueVal := true
 	falseVal := false
@@ -556,3 +632,59 @@ func TestImageCacheConfig_ShouldPreloadCrossplane(t *testing.T) {
 		})
 	}
 }
+
+func TestFilterAndFixLocalImages(t *testing.T) {
+	rt := container.NewFake()
+	rt.Images["crossplane/crossplane:v1.20.0"] = container.Platform{OS: "linux", Architecture: "amd64"}
+	rt.Images["crossplane/crossplane-rbac-manager:v1.20.0"] = container.Platform{OS: "linux", Architecture: "arm64"}
+
+	images := []string{
+		"crossplane/crossplane:v1.20.0",
+		"crossplane/crossplane-rbac-manager:v1.20.0",
+		"xpkg.upbound.io/upbound/provider-aws:v1",
+	}
+
+	local, rePulled, err := filterAndFixLocalImages(context.Background(), rt, images, "amd64", func(string) {})
+	if err != nil {
+		t.Fatalf("filterAndFixLocalImages() error = %v", err)
+	}
+
+	want := []string{"crossplane/crossplane:v1.20.0", "crossplane/crossplane-rbac-manager:v1.20.0"}
+	if !reflect.DeepEqual(local, want) {
+		t.Errorf("local images = %v, want %v", local, want)
+	}
+	if rePulled != 1 {
+		t.Errorf("rePulled = %d, want 1", rePulled)
+	}
+	if !rt.Called("PullImage crossplane/crossplane-rbac-manager:v1.20.0 linux/amd64") {
+		t.Errorf("expected mismatched image to be re-pulled, calls: %v", rt.Calls)
+	}
+}
+
+func TestPushImagesToRegistry(t *testing.T) {
+	rt := container.NewFake()
+	rt.Images["crossplane/crossplane:v1.20.0"] = container.Platform{OS: "linux", Architecture: "amd64"}
+	rt.Images["xpkg.upbound.io/upbound/provider-aws:v1"] = container.Platform{OS: "linux", Architecture: "amd64"}
+
+	images := []string{"crossplane/crossplane:v1.20.0", "xpkg.upbound.io/upbound/provider-aws:v1"}
+	n, err := pushImagesToRegistry(context.Background(), rt, images, "localhost:5001", func(string) {})
+	if err != nil {
+		t.Fatalf("pushImagesToRegistry() error = %v", err)
+	}
+	if n != 2 {
+		t.Errorf("pushed = %d, want 2", n)
+	}
+	for _, want := range []string{
+		"PushImage localhost:5001/crossplane/crossplane:v1.20.0",
+		"PushImage localhost:5001/upbound/provider-aws:v1",
+	} {
+		if !rt.Called(want) {
+			t.Errorf("expected %q, calls: %v", want, rt.Calls)
+		}
+	}
+
+	rt.Errors["PushImage"] = fmt.Errorf("connection refused")
+	if _, err := pushImagesToRegistry(context.Background(), rt, images, "localhost:5001", func(string) {}); err == nil {
+		t.Error("expected error when no image could be pushed")
+	}
+}
@@ -0,0 +1,70 @@
+package kind
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"k8s.io/client-go/tools/clientcmd"
+)
+
+// KubeConfig returns the kubeconfig for a Kind cluster. When internal is true
+// the server address is the control plane's address on the Docker network,
+// for consumers running in sibling containers.
+func KubeConfig(clusterName string, internal bool) (string, error) {
+	provider := NewProvider()
+	kubeconfig, err := provider.KubeConfig(clusterName, internal)
+	if err != nil {
+		return "", fmt.Errorf("failed to get kubeconfig for cluster %s: %w", clusterName, err)
+	}
+	return kubeconfig, nil
+}
+
+// ExportKubeConfig merges the cluster's context into the kubeconfig file at
+// path (the default kubeconfig when empty) and makes it the current context
+func ExportKubeConfig(clusterName, path string, internal bool) error {
+	if path != "" {
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			return fmt.Errorf("failed to create kubeconfig directory: %w", err)
+		}
+	}
+
+	provider := NewProvider()
+	if err := provider.ExportKubeConfig(clusterName, path, internal); err != nil {
+		return fmt.Errorf("failed to export kubeconfig: %w", err)
+	}
+	return nil
+}
+
+// RemoveKubeConfig removes the cluster's context, cluster and user entries
+// from the kubeconfig file at path. A missing file is not an error.
+// It returns true if anything was removed.
+func RemoveKubeConfig(clusterName, path string) (bool, error) {
+	kubeconfig, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return false, nil
+		}
+		return false, fmt.Errorf("failed to load kubeconfig %s: %w", path, err)
+	}
+
+	name := GetContextName(clusterName)
+	_, hasContext := kubeconfig.Contexts[name]
+	_, hasCluster := kubeconfig.Clusters[name]
+	_, hasUser := kubeconfig.AuthInfos[name]
+	if !hasContext && !hasCluster && !hasUser {
+		return false, nil
+	}
+
+	delete(kubeconfig.Contexts, name)
+	delete(kubeconfig.Clusters, name)
+	delete(kubeconfig.AuthInfos, name)
+	if kubeconfig.CurrentContext == name {
+		kubeconfig.CurrentContext = ""
+	}
+
+	if err := clientcmd.WriteToFile(*kubeconfig, path); err != nil {
+		return false, fmt.Errorf("failed to write kubeconfig %s: %w", path, err)
+	}
+	return true, nil
+}
@@ -0,0 +1,74 @@
+package kind
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+func TestRemoveKubeConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config")
+
+	kubeconfig := clientcmdapi.NewConfig()
+	for _, name := range []string{"kind-dev", "other"} {
+		kubeconfig.Clusters[name] = &clientcmdapi.Cluster{Server: "https://127.0.0.1:6443"}
+		kubeconfig.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: "token"}
+		kubeconfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	}
+	kubeconfig.CurrentContext = "kind-dev"
+	if err := clientcmd.WriteToFile(*kubeconfig, path); err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+
+	removed, err := RemoveKubeConfig("dev", path)
+	if err != nil {
+		t.Fatalf("RemoveKubeConfig failed: %v", err)
+	}
+	if !removed {
+		t.Fatal("expected context to be removed")
+	}
+
+	result, err := clientcmd.LoadFromFile(path)
+	if err != nil {
+		t.Fatalf("failed to reload kubeconfig: %v", err)
+	}
+	if _, ok := result.Contexts["kind-dev"]; ok {
+		t.Error("expected kind-dev context to be removed")
+	}
+	if _, ok := result.Clusters["kind-dev"]; ok {
+		t.Error("expected kind-dev cluster to be removed")
+	}
+	if _, ok := result.AuthInfos["kind-dev"]; ok {
+		t.Error("expected kind-dev user to be removed")
+	}
+	if _, ok := result.Contexts["other"]; !ok {
+		t.Error("expected unrelated context to be kept")
+	}
+	if result.CurrentContext != "" {
+		t.Errorf("expected current context to be cleared, got %q", result.CurrentContext)
+	}
+
+	// Removing again is a no-op
+	removed, err = RemoveKubeConfig("dev", path)
+	if err != nil || removed {
+		t.Errorf("expected no-op on second removal, got removed=%v err=%v", removed, err)
+	}
+}
+
+func TestRemoveKubeConfig_MissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "missing")
+
+	removed, err := RemoveKubeConfig("dev", path)
+	if err != nil {
+		t.Fatalf("expected no error for missing file, got %v", err)
+	}
+	if removed {
+		t.Error("expected nothing to be removed")
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Error("expected file not to be created")
+	}
+}
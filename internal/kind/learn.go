@@ -0,0 +1,124 @@
+package kind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/oci"
+)
+
+// kindSystemImages are prefixes of the images bundled in Kind node images,
+// which are never worth preloading
+var kindSystemImages = []string{
+	"docker.io/kindest/",
+	"registry.k8s.io/coredns/",
+	"registry.k8s.io/etcd:",
+	"registry.k8s.io/kube-apiserver:",
+	"registry.k8s.io/kube-controller-manager:",
+	"registry.k8s.io/kube-proxy:",
+	"registry.k8s.io/kube-scheduler:",
+	"registry.k8s.io/pause:",
+}
+
+// NodeImages returns the tagged images in the containerd stores of a
+// cluster's nodes, fully qualified and sorted, without Kind's own images
+func NodeImages(ctx context.Context, clusterName string) ([]string, error) {
+	nodes, err := GetNodeContainers(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(nodes) == 0 {
+		return nil, fmt.Errorf("no nodes found in cluster %s", clusterName)
+	}
+	return nodeImages(ctx, container.Default(), nodes)
+}
+
+// nodeImages lists the images on each node with crictl
+func nodeImages(ctx context.Context, rt container.Runtime, nodes []string) ([]string, error) {
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		out, err := rt.Exec(ctx, node, nil, "crictl", "images", "-o", "json")
+		if err != nil {
+			return nil, fmt.Errorf("failed to list images on node %s: %w", node, err)
+		}
+		images, err := parseCrictlImages(out)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse images on node %s: %w", node, err)
+		}
+		for _, img := range images {
+			seen[oci.Normalize(img)] = true
+		}
+	}
+
+	var images []string
+	for img := range seen {
+		if !isKindSystemImage(img) {
+			images = append(images, img)
+		}
+	}
+	sort.Strings(images)
+	return images, nil
+}
+
+// parseCrictlImages returns the tags in `crictl images -o json` output;
+// images without a tag are left out as they can't be listed by name
+func parseCrictlImages(out []byte) ([]string, error) {
+	var list struct {
+		Images []struct {
+			RepoTags []string `json:"repoTags"`
+		} `json:"images"`
+	}
+	if err := json.Unmarshal(out, &list); err != nil {
+		return nil, err
+	}
+	var images []string
+	for _, img := range list.Images {
+		images = append(images, img.RepoTags...)
+	}
+	return images, nil
+}
+
+// isKindSystemImage reports whether a fully qualified image comes with the
+// Kind node image
+func isKindSystemImage(image string) bool {
+	for _, prefix := range kindSystemImages {
+		if strings.HasPrefix(image, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// UnpreloadedImages returns the images in nodeImages that the configuration
+// does not preload, in their familiar form (e.g. "busybox:1.36" rather than
+// "docker.io/library/busybox:1.36"), so they can be added to
+// crossplane.imageCache.additionalImages
+func UnpreloadedImages(nodeImages []string, preloaded []ImageSource) []string {
+	known := make(map[string]bool, len(preloaded))
+	for _, s := range preloaded {
+		known[oci.Normalize(s.Image)] = true
+	}
+
+	var missing []string
+	for _, img := range nodeImages {
+		if !known[oci.Normalize(img)] {
+			missing = append(missing, oci.Familiar(img))
+		}
+	}
+	return missing
+}
+
+// LearnImages returns the images a bootstrapped cluster's nodes hold that
+// cfg does not preload; see UnpreloadedImages
+func LearnImages(ctx context.Context, clusterName string, cfg *config.Config, logFn func(string)) ([]string, error) {
+	images, err := NodeImages(ctx, clusterName)
+	if err != nil {
+		return nil, err
+	}
+	return UnpreloadedImages(images, CollectImages(ctx, cfg, logFn)), nil
+}
@@ -0,0 +1,67 @@
+package kind
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+const crictlImagesJSON = `{
+  "images": [
+    {"id": "sha256:1", "repoTags": ["docker.io/crossplane/crossplane:v2.1.0"], "repoDigests": []},
+    {"id": "sha256:2", "repoTags": ["registry.k8s.io/kube-apiserver:v1.34.0"], "repoDigests": []},
+    {"id": "sha256:3", "repoTags": ["docker.io/kindest/kindnetd:v20250512"], "repoDigests": []},
+    {"id": "sha256:4", "repoTags": [], "repoDigests": ["ghcr.io/org/untagged@sha256:abc"]},
+    {"id": "sha256:5", "repoTags": ["docker.io/library/busybox:1.36"], "repoDigests": []}
+  ]
+}`
+
+func TestNodeImages(t *testing.T) {
+	rt := container.NewFake()
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		if node == "test-worker" {
+			return []byte(`{"images": [{"repoTags": ["quay.io/jetstack/cert-manager-controller:v1.16.0"]}]}`), nil
+		}
+		return []byte(crictlImagesJSON), nil
+	}
+
+	images, err := nodeImages(context.Background(), rt, []string{"test-control-plane", "test-worker"})
+	if err != nil {
+		t.Fatalf("nodeImages() error = %v", err)
+	}
+	want := []string{
+		"docker.io/crossplane/crossplane:v2.1.0",
+		"docker.io/library/busybox:1.36",
+		"quay.io/jetstack/cert-manager-controller:v1.16.0",
+	}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("nodeImages() = %v, want %v", images, want)
+	}
+}
+
+func TestUnpreloadedImages(t *testing.T) {
+	nodeImages := []string{
+		"docker.io/crossplane/crossplane:v2.1.0",
+		"docker.io/library/busybox:1.36",
+		"quay.io/jetstack/cert-manager-controller:v1.16.0",
+	}
+	preloaded := []ImageSource{
+		{Image: "crossplane/crossplane:v2.1.0", Sources: []string{"crossplane"}},
+		{Image: "busybox:1.36", Sources: []string{"additionalImages"}},
+	}
+
+	got := UnpreloadedImages(nodeImages, preloaded)
+	want := []string{"quay.io/jetstack/cert-manager-controller:v1.16.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnpreloadedImages() = %v, want %v", got, want)
+	}
+
+	// Docker Hub images are written the way people write them
+	got = UnpreloadedImages(nodeImages, nil)
+	want = []string{"crossplane/crossplane:v2.1.0", "busybox:1.36", "quay.io/jetstack/cert-manager-controller:v1.16.0"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("UnpreloadedImages() = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,298 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/pelletier/go-toml"
+
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+// DefaultLoadConcurrency is how many images are loaded at once, and how many
+// node imports may run at once, when PreloadOptions.Concurrency is not set
+const DefaultLoadConcurrency = 4
+
+// ImageProgress reports the loading state of one image
+type ImageProgress struct {
+	Image    string
+	Status   string
+	Progress float64 // 0.0 to 1.0
+	Done     bool
+	Failed   bool
+}
+
+// PreloadOptions configures PreloadImagesWithOptions
+type PreloadOptions struct {
+	// Log receives progress messages; it may be called concurrently
+	Log func(string)
+	// Progress, when set, receives per-image progress while images are
+	// loaded into nodes; it may be called concurrently
+	Progress func(ImageProgress)
+	// Concurrency bounds parallel image loads and node imports
+	// (default DefaultLoadConcurrency)
+	Concurrency int
+	// ImportToNodes imports images into the nodes even when the local
+	// registry is enabled, so that pods find them under their own names
+	ImportToNodes bool
+}
+
+// withDefaults fills in unset options and serialises the log callback
+func (o PreloadOptions) withDefaults() PreloadOptions {
+	if o.Concurrency <= 0 {
+		o.Concurrency = DefaultLoadConcurrency
+	}
+	if o.Log == nil {
+		o.Log = func(string) {}
+	} else {
+		var mu sync.Mutex
+		log := o.Log
+		o.Log = func(msg string) {
+			mu.Lock()
+			defer mu.Unlock()
+			log(msg)
+		}
+	}
+	if o.Progress == nil {
+		o.Progress = func(ImageProgress) {}
+	}
+	return o
+}
+
+// imageSource produces the archives loaded into nodes
+type imageSource struct {
+	// verb describes producing an archive in progress updates, e.g. "saving"
+	verb string
+	// archive returns the path of an archive holding image and whether it
+	// was taken from the cache
+	archive func(ctx context.Context, image string) (path string, cached bool, err error)
+}
+
+// daemonSource saves images from the container daemon into the archive cache
+func daemonSource(rt container.Runtime, cache *archiveCache, nodeArch string) imageSource {
+	return imageSource{
+		verb: "saving",
+		archive: func(ctx context.Context, image string) (string, bool, error) {
+			return cache.archive(ctx, rt, image, nodeArch)
+		},
+	}
+}
+
+// loadImagesIntoNodes loads images into every node that lacks them. Images
+// are loaded concurrently; each archive is produced once, by src, and
+// streamed into all nodes.
+func loadImagesIntoNodes(ctx context.Context, rt container.Runtime, src imageSource, nodes []string, images []string, opts PreloadOptions) (int, error) {
+	if len(nodes) == 0 {
+		return 0, fmt.Errorf("no nodes found in cluster")
+	}
+	logFn := opts.Log
+	logFn(fmt.Sprintf("Loading %d image(s) into %d node(s), %d at a time", len(images), len(nodes), opts.Concurrency))
+
+	snapshotters := make(map[string]string, len(nodes))
+	for _, node := range nodes {
+		snapshotters[node] = nodeSnapshotter(ctx, rt, node)
+	}
+
+	loader := &imageLoader{
+		rt:           rt,
+		src:          src,
+		snapshotters: snapshotters,
+		nodeSlots:    make(chan struct{}, opts.Concurrency),
+		opts:         opts,
+	}
+
+	imageSlots := make(chan struct{}, opts.Concurrency)
+	var loaded atomic.Int32
+	var wg sync.WaitGroup
+	for _, img := range images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			imageSlots <- struct{}{}
+			defer func() { <-imageSlots }()
+			if loader.load(ctx, nodes, img) {
+				loaded.Add(1)
+			}
+		}()
+	}
+	wg.Wait()
+
+	successCount := int(loaded.Load())
+	if successCount > 0 {
+		logFn(fmt.Sprintf("Pre-loaded %d/%d images into Kind nodes", successCount, len(images)))
+		return successCount, nil
+	}
+	logFn("No images were successfully loaded")
+	return 0, fmt.Errorf("failed to load any images into Kind nodes")
+}
+
+// imageLoader holds what concurrent image loads share
+type imageLoader struct {
+	rt           container.Runtime
+	src          imageSource
+	snapshotters map[string]string
+	nodeSlots    chan struct{} // bounds node imports across all images
+	opts         PreloadOptions
+}
+
+// load loads one image into the nodes missing it and reports whether it
+// ended up on every node
+func (l *imageLoader) load(ctx context.Context, nodes []string, img string) bool {
+	short := getShortImageName(img)
+	report := func(p ImageProgress) {
+		p.Image = img
+		l.opts.Progress(p)
+	}
+
+	var targets []string
+	for _, node := range nodes {
+		if !nodeHasImage(ctx, l.rt, node, img) {
+			targets = append(targets, node)
+		}
+	}
+	if len(targets) == 0 {
+		l.opts.Log(fmt.Sprintf("✓ %s already present", short))
+		report(ImageProgress{Status: "already present", Progress: 1, Done: true})
+		return true
+	}
+
+	report(ImageProgress{Status: l.src.verb, Progress: 0.1})
+	archive, cached, err := l.src.archive(ctx, img)
+	if err != nil {
+		l.opts.Log(fmt.Sprintf("✗ %s: %v", short, err))
+		report(ImageProgress{Status: "failed", Progress: 1, Done: true, Failed: true})
+		return false
+	}
+	if cached {
+		l.opts.Log(fmt.Sprintf("Using cached archive for %s", short))
+	}
+
+	var mu sync.Mutex
+	done := 0
+	failedNodes := map[string]error{}
+	report(ImageProgress{Status: fmt.Sprintf("loading 0/%d nodes", len(targets)), Progress: 0.3})
+
+	var wg sync.WaitGroup
+	for _, node := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.nodeSlots <- struct{}{}
+			err := importArchive(ctx, l.rt, node, l.snapshotters[node], archive)
+			<-l.nodeSlots
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failedNodes[node] = err
+			}
+			done++
+			report(ImageProgress{
+				Status:   fmt.Sprintf("loading %d/%d nodes", done, len(targets)),
+				Progress: 0.3 + 0.7*float64(done)/float64(len(targets)),
+			})
+		}()
+	}
+	wg.Wait()
+
+	if len(failedNodes) > 0 {
+		l.opts.Log(fmt.Sprintf("✗ %s not loaded on %d node(s):", short, len(failedNodes)))
+		for node, nodeErr := range failedNodes {
+			l.opts.Log(fmt.Sprintf("    - %s: %v", node, nodeErr))
+		}
+		report(ImageProgress{Status: "failed", Progress: 1, Done: true, Failed: true})
+		return false
+	}
+
+	l.opts.Log(fmt.Sprintf("✓ Loaded %s", short))
+	report(ImageProgress{Status: "loaded", Progress: 1, Done: true})
+	return true
+}
+
+// nodeHasImage reports whether a node's containerd already has an image
+func nodeHasImage(ctx context.Context, rt container.Runtime, node, img string) bool {
+	_, err := rt.Exec(ctx, node, nil, "crictl", "inspecti", img)
+	return err == nil
+}
+
+// importArchive streams an image archive into a node's containerd, falling
+// back to copying the archive into the node when streaming fails
+func importArchive(ctx context.Context, rt container.Runtime, node, snapshotter, archive string) error {
+	f, err := os.Open(archive)
+	if err != nil {
+		return err
+	}
+	defer func() { _ = f.Close() }()
+
+	args := []string{"ctr", "--namespace=k8s.io", "images", "import", "--all-platforms", "--digests"}
+	if snapshotter != "" {
+		args = append(args, "--snapshotter="+snapshotter)
+	}
+	args = append(args, "-")
+
+	out, err := rt.Exec(ctx, node, f, args...)
+	if err == nil {
+		return nil
+	}
+
+	// Streaming over exec fails in some Docker Desktop setups
+	if legacyErr := importArchiveFromFile(ctx, rt, node, archive); legacyErr != nil {
+		return fmt.Errorf("stream import failed: %w (output: %s); copy import failed: %v",
+			err, strings.TrimSpace(string(out)), legacyErr)
+	}
+	return nil
+}
+
+// importArchiveFromFile copies an archive into a node and imports it there
+func importArchiveFromFile(ctx context.Context, rt container.Runtime, node, archive string) error {
+	// Copy to root instead of /tmp because /tmp behaves weirdly in some Kind nodes/Docker Desktop setups
+	targetPath := fmt.Sprintf("/image-%d.tar", time.Now().UnixNano())
+	if err := rt.CopyToContainer(ctx, archive, node, targetPath); err != nil {
+		return fmt.Errorf("copy failed: %w", err)
+	}
+	defer func() { _, _ = rt.Exec(ctx, node, nil, "rm", "-f", targetPath) }()
+
+	// Try with --no-unpack first as it's often more robust
+	out, err := rt.Exec(ctx, node, nil, "ctr", "-n", "k8s.io", "images", "import", "--no-unpack", targetPath)
+	if err != nil {
+		if out2, err2 := rt.Exec(ctx, node, nil, "ctr", "-n", "k8s.io", "images", "import", targetPath); err2 != nil {
+			return fmt.Errorf("import failed: %w (output1: %s; output2: %s)",
+				err2, strings.TrimSpace(string(out)), strings.TrimSpace(string(out2)))
+		}
+	}
+	return nil
+}
+
+// nodeSnapshotter returns the snapshotter the node's CRI plugin uses, as
+// images must be unpacked into it, or "" to use the ctr default
+func nodeSnapshotter(ctx context.Context, rt container.Runtime, node string) string {
+	out, err := rt.Exec(ctx, node, nil, "containerd", "config", "dump")
+	if err != nil {
+		return ""
+	}
+	return parseSnapshotter(string(out))
+}
+
+// parseSnapshotter reads the CRI snapshotter from a containerd config dump
+func parseSnapshotter(config string) string {
+	parsed, err := toml.Load(config)
+	if err != nil {
+		return ""
+	}
+	var path []string
+	switch parsed.Get("version") {
+	case int64(2):
+		path = []string{"plugins", "io.containerd.grpc.v1.cri", "containerd", "snapshotter"}
+	case int64(3):
+		path = []string{"plugins", "io.containerd.cri.v1.images", "snapshotter"}
+	default:
+		return ""
+	}
+	snapshotter, _ := parsed.GetPath(path).(string)
+	return snapshotter
+}
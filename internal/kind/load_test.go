@@ -0,0 +1,173 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+func TestParseSnapshotter(t *testing.T) {
+	tests := []struct {
+		name   string
+		config string
+		want   string
+	}{
+		{
+			name: "version 2",
+			config: `version = 2
+[plugins."io.containerd.grpc.v1.cri".containerd]
+  snapshotter = "overlayfs"
+`,
+			want: "overlayfs",
+		},
+		{
+			name: "version 3",
+			config: `version = 3
+[plugins."io.containerd.cri.v1.images"]
+  snapshotter = "native"
+`,
+			want: "native",
+		},
+		{
+			name:   "unknown version",
+			config: "version = 1\n",
+			want:   "",
+		},
+		{
+			name:   "invalid",
+			config: "not toml [",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseSnapshotter(tt.config); got != tt.want {
+				t.Errorf("parseSnapshotter() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoadImagesIntoNodes(t *testing.T) {
+	rt := container.NewFake()
+	images := []string{"crossplane/crossplane:v2.1.0", "xpkg.upbound.io/upbound/provider-aws-s3:v1", "busybox:latest"}
+	for _, img := range images {
+		rt.Images[img] = container.Platform{OS: "linux", Architecture: "amd64"}
+	}
+	nodes := []string{"test-control-plane", "test-worker"}
+
+	var mu sync.Mutex
+	imported := map[string][]string{}
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		switch strings.Join(cmd[:2], " ") {
+		case "containerd config":
+			return []byte("version = 2\n[plugins.\"io.containerd.grpc.v1.cri\".containerd]\nsnapshotter = \"overlayfs\"\n"), nil
+		case "crictl inspecti":
+			// busybox is already on the control plane
+			if node == "test-control-plane" && cmd[2] == "busybox:latest" {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("no such image")
+		case "ctr --namespace=k8s.io":
+			if !strings.Contains(strings.Join(cmd, " "), "--snapshotter=overlayfs") {
+				return nil, fmt.Errorf("missing snapshotter: %v", cmd)
+			}
+			mu.Lock()
+			defer mu.Unlock()
+			// The fake archive lists the image names it was saved from
+			imported[node] = append(imported[node], string(stdin))
+			return nil, nil
+		}
+		return nil, nil
+	}
+
+	var progressMu sync.Mutex
+	final := map[string]ImageProgress{}
+	opts := PreloadOptions{
+		Concurrency: 2,
+		Progress: func(p ImageProgress) {
+			progressMu.Lock()
+			defer progressMu.Unlock()
+			final[p.Image] = p
+		},
+	}.withDefaults()
+
+	cache := &archiveCache{dir: t.TempDir()}
+	n, err := loadImagesIntoNodes(context.Background(), rt, daemonSource(rt, cache, "amd64"), nodes, images, opts)
+	if err != nil {
+		t.Fatalf("loadImagesIntoNodes() error = %v", err)
+	}
+	if n != len(images) {
+		t.Errorf("loaded = %d, want %d", n, len(images))
+	}
+
+	if got := len(imported["test-control-plane"]); got != 2 {
+		t.Errorf("control plane imports = %d, want 2 (busybox already present)", got)
+	}
+	if got := len(imported["test-worker"]); got != 3 {
+		t.Errorf("worker imports = %d, want 3", got)
+	}
+
+	// Each image is saved once and shared by all nodes
+	saves := 0
+	for _, call := range rt.Calls {
+		if strings.HasPrefix(call, "SaveImages") {
+			saves++
+		}
+	}
+	if saves != len(images) {
+		t.Errorf("saves = %d, want %d", saves, len(images))
+	}
+
+	for _, img := range images {
+		p := final[img]
+		if !p.Done || p.Failed || p.Progress != 1 {
+			t.Errorf("final progress for %s = %+v, want done", img, p)
+		}
+	}
+}
+
+func TestLoadImagesIntoNodes_FallsBackToCopy(t *testing.T) {
+	rt := container.NewFake()
+	img := "crossplane/crossplane:v2.1.0"
+	rt.Images[img] = container.Platform{OS: "linux", Architecture: "amd64"}
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		if cmd[0] == "crictl" || (cmd[0] == "ctr" && cmd[len(cmd)-1] == "-") {
+			return nil, fmt.Errorf("failed")
+		}
+		return nil, nil
+	}
+
+	cache := &archiveCache{dir: t.TempDir()}
+	n, err := loadImagesIntoNodes(context.Background(), rt, daemonSource(rt, cache, "amd64"), []string{"node"}, []string{img}, PreloadOptions{}.withDefaults())
+	if err != nil || n != 1 {
+		t.Fatalf("loadImagesIntoNodes() = %d, %v; want 1 image via the copy fallback", n, err)
+	}
+	if !rt.Called("CopyToContainer") {
+		t.Errorf("expected the archive to be copied into the node, calls: %v", rt.Calls)
+	}
+}
+
+func TestLoadImagesIntoNodes_AllFail(t *testing.T) {
+	rt := container.NewFake()
+	img := "crossplane/crossplane:v2.1.0"
+	rt.Images[img] = container.Platform{OS: "linux", Architecture: "amd64"}
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		return nil, fmt.Errorf("failed")
+	}
+
+	var failed bool
+	opts := PreloadOptions{Progress: func(p ImageProgress) { failed = failed || p.Failed }}.withDefaults()
+	cache := &archiveCache{dir: t.TempDir()}
+	if _, err := loadImagesIntoNodes(context.Background(), rt, daemonSource(rt, cache, "amd64"), []string{"node"}, []string{img}, opts); err == nil {
+		t.Error("expected error when no image could be loaded")
+	}
+	if !failed {
+		t.Error("expected the image to be reported as failed")
+	}
+}
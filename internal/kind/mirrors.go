@@ -0,0 +1,192 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"os"
+	"path"
+	"strings"
+
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+const (
+	// containerdHostsDir is where containerd reads per-registry host configs
+	containerdHostsDir = "/etc/containerd/certs.d"
+	// dockerHubServer serves the docker.io registry API
+	dockerHubServer = "registry-1.docker.io"
+)
+
+// RegistryCredential is a username and password, or an identity token, that
+// nodes send to a registry host
+type RegistryCredential struct {
+	Host          string
+	Username      string
+	Password      string
+	IdentityToken string
+}
+
+// HasRegistryMirrors returns true if any registry mirrors are configured
+func HasRegistryMirrors(cfg *config.Config) bool {
+	return len(cfg.Cluster.RegistryMirrors) > 0
+}
+
+// usesHostsDir reports whether nodes read registry hosts from containerd's
+// hosts directory. containerd then refuses the older mirrors and configs.tls
+// settings, so mirrors and CAs are configured in the directory as well.
+func usesHostsDir(cfg *config.Config) bool {
+	return cfg.Cluster.Registry.UsesHostsDir() || HasRegistryMirrors(cfg)
+}
+
+// registryServer returns the host nodes contact for a registry
+func registryServer(host string) string {
+	if host == "docker.io" {
+		return dockerHubServer
+	}
+	return host
+}
+
+// trustedCAPath returns where the CA of a registry host is mounted on nodes,
+// or "" when trustedCAs has none for it
+func trustedCAPath(cfg *config.Config, host string) string {
+	for _, reg := range cfg.Cluster.TrustedCAs.Registries {
+		if reg.Host == host {
+			return fmt.Sprintf("%s/%s/ca.crt", containerdHostsDir, host)
+		}
+	}
+	return ""
+}
+
+// mirrorHostsToml returns the containerd hosts.toml that sends pulls for a
+// registry to its mirrors, falling back to the registry itself
+func mirrorHostsToml(cfg *config.Config, mirror config.RegistryMirror) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "server = %q\n", "https://"+registryServer(mirror.Host))
+	if ca := trustedCAPath(cfg, mirror.Host); ca != "" {
+		fmt.Fprintf(&b, "ca = %q\n", ca)
+	}
+	for _, endpoint := range mirror.Endpoints {
+		fmt.Fprintf(&b, "\n[host.%q]\n  capabilities = [\"pull\", \"resolve\"]\n", endpoint)
+		if u, err := url.Parse(endpoint); err == nil {
+			if ca := trustedCAPath(cfg, u.Host); ca != "" {
+				fmt.Fprintf(&b, "  ca = %q\n", ca)
+			}
+		}
+	}
+	return b.String()
+}
+
+// MirrorAPIURL returns the registry API root containerd uses for a mirror
+// endpoint: the endpoint path with /v2 appended unless it already ends in it
+func MirrorAPIURL(endpoint string) (string, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return "", err
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = "/v2"
+	} else if u.Path = path.Clean(u.Path); !strings.HasSuffix(u.Path, "/v2") {
+		u.Path += "/v2"
+	}
+	return u.String() + "/", nil
+}
+
+// ConfigureRegistryMirrors writes the hosts.toml of every registry mirror on
+// all nodes in the cluster
+func ConfigureRegistryMirrors(ctx context.Context, cfg *config.Config) error {
+	nodes, err := GetNodeContainers(cfg.Cluster.Name)
+	if err != nil {
+		return fmt.Errorf("failed to get cluster nodes: %w", err)
+	}
+	return configureRegistryMirrors(ctx, container.Default(), nodes, cfg)
+}
+
+func configureRegistryMirrors(ctx context.Context, rt container.Runtime, nodes []string, cfg *config.Config) error {
+	for _, node := range nodes {
+		for _, mirror := range cfg.Cluster.RegistryMirrors {
+			if err := WriteHostsToml(ctx, rt, node, mirror.Host, mirrorHostsToml(cfg, mirror)); err != nil {
+				return fmt.Errorf("failed to configure mirrors for %s on node %s: %w", mirror.Host, node, err)
+			}
+		}
+	}
+	return nil
+}
+
+// WriteHostsToml writes the containerd hosts.toml for a registry host on a node
+func WriteHostsToml(ctx context.Context, rt container.Runtime, nodeName, host, hostsToml string) error {
+	registryDir := containerdHostsDir + "/" + host
+
+	// Create registry config directory
+	if out, err := rt.Exec(ctx, nodeName, nil, "mkdir", "-p", registryDir); err != nil {
+		return fmt.Errorf("failed to create %s: %w\nOutput: %s", registryDir, err, string(out))
+	}
+
+	// Write hosts.toml to node
+	out, err := rt.Exec(ctx, nodeName, strings.NewReader(hostsToml),
+		"sh", "-c", fmt.Sprintf("cat > %s/hosts.toml", registryDir))
+	if err != nil {
+		return fmt.Errorf("failed to write %s/hosts.toml: %w\nOutput: %s", registryDir, err, string(out))
+	}
+	return nil
+}
+
+// ResolveRegistryAuth reads the credentials of every cluster.registryAuth entry
+func ResolveRegistryAuth(ctx context.Context, cfg *config.Config) ([]RegistryCredential, error) {
+	var creds []RegistryCredential
+	for _, a := range cfg.Cluster.RegistryAuth {
+		cred, err := ResolveRegistryCredential(ctx, a)
+		if err != nil {
+			return nil, err
+		}
+		creds = append(creds, cred)
+	}
+	return creds, nil
+}
+
+// ResolveRegistryCredential reads the credentials of a registryAuth entry
+// from its environment variables, or else from a Docker config file,
+// including any credential helper it names
+func ResolveRegistryCredential(ctx context.Context, a config.RegistryAuth) (RegistryCredential, error) {
+	cred := RegistryCredential{Host: a.Host}
+	if a.UsernameEnv != "" {
+		cred.Username, cred.Password = os.Getenv(a.UsernameEnv), os.Getenv(a.PasswordEnv)
+		if cred.Username == "" || cred.Password == "" {
+			return cred, fmt.Errorf("registry auth for %s: environment variables %s and %s must be set", a.Host, a.UsernameEnv, a.PasswordEnv)
+		}
+		return cred, nil
+	}
+
+	source := "the Docker config"
+	var store credentials.Store
+	var err error
+	if file := a.GetDockerConfig(); file != "" {
+		source = file
+		if _, statErr := os.Stat(file); statErr != nil {
+			return cred, fmt.Errorf("registry auth for %s: %w", a.Host, statErr)
+		}
+		store, err = credentials.NewStore(file, credentials.StoreOptions{})
+	} else {
+		store, err = credentials.NewStoreFromDocker(credentials.StoreOptions{})
+	}
+	if err != nil {
+		return cred, fmt.Errorf("registry auth for %s: failed to read %s: %w", a.Host, source, err)
+	}
+
+	c, err := store.Get(ctx, credentials.ServerAddressFromRegistry(a.Host))
+	if err != nil {
+		return cred, fmt.Errorf("registry auth for %s: failed to read credentials from %s: %w", a.Host, source, err)
+	}
+	if c == auth.EmptyCredential {
+		return cred, fmt.Errorf("registry auth for %s: no credentials in %s", a.Host, source)
+	}
+	cred.Username, cred.Password, cred.IdentityToken = c.Username, c.Password, c.RefreshToken
+	if cred.Password == "" && cred.IdentityToken == "" {
+		return cred, fmt.Errorf("registry auth for %s: credentials have no password or token", a.Host)
+	}
+	return cred, nil
+}
@@ -0,0 +1,185 @@
+package kind
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+func TestBuildContainerdPatches(t *testing.T) {
+	cas := config.TrustedCAsConfig{Registries: []config.RegistryCA{{Host: "registry.example.com", CAFile: "/tmp/ca.crt"}}}
+	creds := []RegistryCredential{
+		{Host: "docker.io", Username: "user", Password: `pa"ss`},
+		{Host: "artifactory.example.com", IdentityToken: "token"},
+	}
+
+	cfg := &config.Config{Cluster: config.ClusterConfig{TrustedCAs: cas}}
+	patches := buildContainerdPatches(cfg, creds)
+	if len(patches) != 1 {
+		t.Fatalf("expected a single combined patch, got %d", len(patches))
+	}
+	for _, want := range []string{
+		`[plugins."io.containerd.grpc.v1.cri".registry.configs."registry.example.com".tls]`,
+		`ca_file = "/etc/containerd/certs.d/registry.example.com/ca.crt"`,
+		`[plugins."io.containerd.grpc.v1.cri".registry.configs."registry-1.docker.io".auth]`,
+		`username = "user"`,
+		`password = "pa\"ss"`,
+		`[plugins."io.containerd.grpc.v1.cri".registry.configs."artifactory.example.com".auth]`,
+		`identitytoken = "token"`,
+	} {
+		if !strings.Contains(patches[0], want) {
+			t.Errorf("patch missing %q:\n%s", want, patches[0])
+		}
+	}
+
+	// containerd refuses configs.tls alongside the hosts directory
+	cfg.Cluster.Registry.Enabled = true
+	patches = buildContainerdPatches(cfg, creds)
+	if len(patches) != 1 || strings.Contains(patches[0], ".tls]") {
+		t.Errorf("expected only auth patches with the hosts directory, got %v", patches)
+	}
+
+	if patches := buildContainerdPatches(cfg, nil); patches != nil {
+		t.Errorf("expected no patches, got %v", patches)
+	}
+}
+
+func TestMirrorHostsToml(t *testing.T) {
+	cfg := &config.Config{Cluster: config.ClusterConfig{
+		TrustedCAs: config.TrustedCAsConfig{Registries: []config.RegistryCA{{Host: "artifactory.example.com", CAFile: "/tmp/ca.crt"}}},
+	}}
+	mirror := config.RegistryMirror{
+		Host:      "docker.io",
+		Endpoints: []string{"https://artifactory.example.com/v2/docker-remote", "http://cache.local:5000"},
+	}
+
+	want := `server = "https://registry-1.docker.io"
+
+[host."https://artifactory.example.com/v2/docker-remote"]
+  capabilities = ["pull", "resolve"]
+  ca = "/etc/containerd/certs.d/artifactory.example.com/ca.crt"
+
+[host."http://cache.local:5000"]
+  capabilities = ["pull", "resolve"]
+`
+	if got := mirrorHostsToml(cfg, mirror); got != want {
+		t.Errorf("mirrorHostsToml() =\n%s\nwant:\n%s", got, want)
+	}
+}
+
+func TestMirrorAPIURL(t *testing.T) {
+	tests := []struct {
+		endpoint string
+		want     string
+	}{
+		{"https://mirror.example.com", "https://mirror.example.com/v2/"},
+		{"https://mirror.example.com/", "https://mirror.example.com/v2/"},
+		{"https://artifactory.example.com/artifactory/api/docker/remote", "https://artifactory.example.com/artifactory/api/docker/remote/v2/"},
+		{"https://artifactory.example.com/docker-remote/v2/", "https://artifactory.example.com/docker-remote/v2/"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.endpoint, func(t *testing.T) {
+			got, err := MirrorAPIURL(tt.endpoint)
+			if err != nil {
+				t.Fatalf("MirrorAPIURL() error = %v", err)
+			}
+			if got != tt.want {
+				t.Errorf("MirrorAPIURL(%q) = %q, want %q", tt.endpoint, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigureRegistryMirrors(t *testing.T) {
+	rt := container.NewFake()
+	written := map[string]string{}
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		if len(stdin) > 0 {
+			written[node+" "+cmd[len(cmd)-1]] = string(stdin)
+		}
+		return nil, nil
+	}
+	cfg := &config.Config{Cluster: config.ClusterConfig{RegistryMirrors: []config.RegistryMirror{
+		{Host: "docker.io", Endpoints: []string{"https://mirror.example.com"}},
+		{Host: "ghcr.io", Endpoints: []string{"https://mirror.example.com"}},
+	}}}
+
+	if err := configureRegistryMirrors(context.Background(), rt, []string{"cp", "worker"}, cfg); err != nil {
+		t.Fatalf("configureRegistryMirrors() error = %v", err)
+	}
+	if len(written) != 4 {
+		t.Errorf("expected 4 hosts.toml files, got %v", written)
+	}
+	if !strings.Contains(written["worker cat > /etc/containerd/certs.d/ghcr.io/hosts.toml"], `server = "https://ghcr.io"`) {
+		t.Errorf("unexpected ghcr.io hosts.toml on worker: %v", written)
+	}
+}
+
+func TestResolveRegistryCredential(t *testing.T) {
+	ctx := context.Background()
+
+	t.Setenv("TEST_REGISTRY_USER", "user")
+	t.Setenv("TEST_REGISTRY_PASSWORD", "secret")
+	cred, err := ResolveRegistryCredential(ctx, config.RegistryAuth{Host: "artifactory.example.com", UsernameEnv: "TEST_REGISTRY_USER", PasswordEnv: "TEST_REGISTRY_PASSWORD"})
+	if err != nil {
+		t.Fatalf("ResolveRegistryCredential() from env error = %v", err)
+	}
+	if cred.Username != "user" || cred.Password != "secret" {
+		t.Errorf("credential from env = %+v", cred)
+	}
+
+	_, err = ResolveRegistryCredential(ctx, config.RegistryAuth{Host: "artifactory.example.com", UsernameEnv: "TEST_REGISTRY_USER", PasswordEnv: "TEST_REGISTRY_UNSET"})
+	if err == nil || !strings.Contains(err.Error(), "TEST_REGISTRY_UNSET") {
+		t.Errorf("expected an error naming the unset variable, got %v", err)
+	}
+
+	dockerConfig := filepath.Join(t.TempDir(), "config.json")
+	// "dXNlcjpodW50ZXIy" is "user:hunter2"
+	data := `{"auths":{"artifactory.example.com":{"auth":"dXNlcjpodW50ZXIy"}}}`
+	if err := os.WriteFile(dockerConfig, []byte(data), 0600); err != nil {
+		t.Fatal(err)
+	}
+	cred, err = ResolveRegistryCredential(ctx, config.RegistryAuth{Host: "artifactory.example.com", DockerConfig: dockerConfig})
+	if err != nil {
+		t.Fatalf("ResolveRegistryCredential() from docker config error = %v", err)
+	}
+	if cred.Username != "user" || cred.Password != "hunter2" {
+		t.Errorf("credential from docker config = %+v", cred)
+	}
+
+	if _, err := ResolveRegistryCredential(ctx, config.RegistryAuth{Host: "other.example.com", DockerConfig: dockerConfig}); err == nil {
+		t.Error("expected an error for a host without credentials")
+	}
+}
+
+func TestBuildRedactedKindConfig(t *testing.T) {
+	t.Setenv("TEST_REGISTRY_USER", "user")
+	t.Setenv("TEST_REGISTRY_PASSWORD", "secret")
+	cfg := &config.Config{Cluster: config.ClusterConfig{
+		Name:         "test-cluster",
+		Nodes:        config.NodesConfig{ControlPlane: 1},
+		RegistryAuth: []config.RegistryAuth{{Host: "artifactory.example.com", UsernameEnv: "TEST_REGISTRY_USER", PasswordEnv: "TEST_REGISTRY_PASSWORD"}},
+	}}
+
+	kindConfig, err := BuildKindConfig(cfg)
+	if err != nil {
+		t.Fatalf("BuildKindConfig() error = %v", err)
+	}
+	if !strings.Contains(kindConfig, `password = "secret"`) {
+		t.Errorf("expected the password in the Kind config:\n%s", kindConfig)
+	}
+
+	redactedConfig, err := BuildRedactedKindConfig(cfg)
+	if err != nil {
+		t.Fatalf("BuildRedactedKindConfig() error = %v", err)
+	}
+	if strings.Contains(redactedConfig, "secret") || !strings.Contains(redactedConfig, redacted) {
+		t.Errorf("expected credentials to be redacted:\n%s", redactedConfig)
+	}
+}
@@ -0,0 +1,220 @@
+package kind
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+const (
+	// PortMapConfigMap is the ConfigMap recording the host ports a cluster was created with
+	PortMapConfigMap = "kindplane-ports"
+	// PortMapNamespace is the namespace of the port map ConfigMap; kube-public
+	// is readable by anyone with cluster access, like local-registry-hosting
+	PortMapNamespace = "kube-public"
+
+	portMapKey = "ports.json"
+)
+
+// PortAssignment records the host port chosen for one configured port mapping
+type PortAssignment struct {
+	ContainerPort int32  `json:"containerPort"`
+	RequestedPort int32  `json:"requestedPort"`
+	HostPort      int32  `json:"hostPort"`
+	Protocol      string `json:"protocol"`
+}
+
+// PortMap describes the host ports a cluster actually uses
+type PortMap struct {
+	Strategy     string           `json:"strategy"`
+	Mappings     []PortAssignment `json:"mappings,omitempty"`
+	RegistryPort int              `json:"registryPort,omitempty"`
+}
+
+// ResolvePortMappings decides the host port of every configured port mapping.
+// With the fixed strategy the configured ports are used unchanged; with auto,
+// ports that are already in use are replaced by free ones.
+func ResolvePortMappings(cfg *config.Config) (*PortMap, error) {
+	return resolvePortMappings(cfg, HostPortFree, FreeHostPort)
+}
+
+// ConfiguredPortMap describes the configured host ports without probing them,
+// for clusters whose ports were never resolved
+func ConfiguredPortMap(cfg *config.Config) *PortMap {
+	pm := &PortMap{Strategy: config.PortStrategyFixed}
+	if cfg.Cluster.AutoPorts() {
+		pm.Strategy = config.PortStrategyAuto
+	}
+	for _, m := range cfg.Cluster.PortMappings {
+		pm.Mappings = append(pm.Mappings, PortAssignment{
+			ContainerPort: m.ContainerPort,
+			RequestedPort: m.HostPort,
+			HostPort:      m.HostPort,
+			Protocol:      protocolOrTCP(m.Protocol),
+		})
+	}
+	if cfg.Cluster.Registry.Enabled {
+		pm.RegistryPort = cfg.Cluster.Registry.GetPort()
+	}
+	return pm
+}
+
+// protocolOrTCP defaults an empty port mapping protocol to TCP, as Kind does
+func protocolOrTCP(protocol string) string {
+	if protocol == "" {
+		return "TCP"
+	}
+	return protocol
+}
+
+// resolvePortMappings implements ResolvePortMappings with injectable probes
+func resolvePortMappings(cfg *config.Config, free func(port int, protocol string) bool, alloc func(protocol string) (int, error)) (*PortMap, error) {
+	pm := &PortMap{Strategy: config.PortStrategyFixed}
+	if cfg.Cluster.AutoPorts() {
+		pm.Strategy = config.PortStrategyAuto
+	}
+
+	taken := map[string]bool{}
+	for _, m := range cfg.Cluster.PortMappings {
+		protocol := protocolOrTCP(m.Protocol)
+		hostPort := m.HostPort
+		key := func(p int32) string { return fmt.Sprintf("%d/%s", p, protocol) }
+		if pm.Strategy == config.PortStrategyAuto && (taken[key(hostPort)] || !free(int(hostPort), protocol)) {
+			port, err := alloc(protocol)
+			if err != nil {
+				return nil, fmt.Errorf("failed to find a free host port for container port %d: %w", m.ContainerPort, err)
+			}
+			hostPort = int32(port)
+		}
+		taken[key(hostPort)] = true
+
+		pm.Mappings = append(pm.Mappings, PortAssignment{
+			ContainerPort: m.ContainerPort,
+			RequestedPort: m.HostPort,
+			HostPort:      hostPort,
+			Protocol:      protocol,
+		})
+	}
+
+	return pm, nil
+}
+
+// Apply writes the resolved host ports back into the configuration so the
+// Kind config and registry use them
+func (pm *PortMap) Apply(cfg *config.Config) {
+	for _, a := range pm.Mappings {
+		for i := range cfg.Cluster.PortMappings {
+			m := &cfg.Cluster.PortMappings[i]
+			if m.ContainerPort == a.ContainerPort && m.HostPort == a.RequestedPort && strings.EqualFold(protocolOrTCP(m.Protocol), a.Protocol) {
+				m.HostPort = a.HostPort
+				break
+			}
+		}
+	}
+	if pm.RegistryPort != 0 {
+		cfg.Cluster.Registry.Port = pm.RegistryPort
+	}
+}
+
+// HostPortFree reports whether a host port can be bound on all interfaces,
+// which is where Kind publishes port mappings by default
+func HostPortFree(port int, protocol string) bool {
+	addr := fmt.Sprintf(":%d", port)
+	switch strings.ToUpper(protocol) {
+	case "UDP":
+		conn, err := net.ListenPacket("udp", addr)
+		if err != nil {
+			return false
+		}
+		_ = conn.Close()
+		return true
+	case "SCTP":
+		// No portable probe; assume free and let the runtime report conflicts
+		return true
+	default:
+		l, err := net.Listen("tcp", addr)
+		if err != nil {
+			return false
+		}
+		_ = l.Close()
+		return true
+	}
+}
+
+// FreeHostPort asks the kernel for a currently unused host port
+func FreeHostPort(protocol string) (int, error) {
+	if strings.EqualFold(protocol, "UDP") {
+		conn, err := net.ListenPacket("udp", ":0")
+		if err != nil {
+			return 0, err
+		}
+		defer func() { _ = conn.Close() }()
+		return conn.LocalAddr().(*net.UDPAddr).Port, nil
+	}
+
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		return 0, err
+	}
+	defer func() { _ = l.Close() }()
+	return l.Addr().(*net.TCPAddr).Port, nil
+}
+
+// SavePortMap records the port map on the cluster
+func SavePortMap(ctx context.Context, client kubernetes.Interface, pm *PortMap) error {
+	data, err := json.MarshalIndent(pm, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to encode port map: %w", err)
+	}
+
+	cm := &corev1.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PortMapConfigMap,
+			Namespace: PortMapNamespace,
+			Labels: map[string]string{
+				"app.kubernetes.io/managed-by": "kindplane",
+			},
+		},
+		Data: map[string]string{portMapKey: string(data)},
+	}
+
+	cmClient := client.CoreV1().ConfigMaps(PortMapNamespace)
+	existing, err := cmClient.Get(ctx, PortMapConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		_, err = cmClient.Create(ctx, cm, metav1.CreateOptions{})
+	} else if err == nil {
+		cm.ResourceVersion = existing.ResourceVersion
+		_, err = cmClient.Update(ctx, cm, metav1.UpdateOptions{})
+	}
+	if err != nil {
+		return fmt.Errorf("failed to save port map: %w", err)
+	}
+	return nil
+}
+
+// LoadPortMap reads the port map recorded on the cluster. It returns nil
+// without error for clusters created before port maps were recorded.
+func LoadPortMap(ctx context.Context, client kubernetes.Interface) (*PortMap, error) {
+	cm, err := client.CoreV1().ConfigMaps(PortMapNamespace).Get(ctx, PortMapConfigMap, metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read port map: %w", err)
+	}
+
+	var pm PortMap
+	if err := json.Unmarshal([]byte(cm.Data[portMapKey]), &pm); err != nil {
+		return nil, fmt.Errorf("failed to parse port map: %w", err)
+	}
+	return &pm, nil
+}
@@ -0,0 +1,154 @@
+//nolint:staticcheck // Using fake.NewSimpleClientset which is deprecated but still works
+package kind
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"k8s.io/client-go/kubernetes/fake"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func portsConfig(strategy string) *config.Config {
+	return &config.Config{
+		Cluster: config.ClusterConfig{
+			Name:         "test",
+			PortStrategy: strategy,
+			PortMappings: []config.PortMapping{
+				{ContainerPort: 80, HostPort: 8080},
+				{ContainerPort: 443, HostPort: 8443, Protocol: "TCP"},
+				{ContainerPort: 53, HostPort: 5353, Protocol: "UDP"},
+			},
+		},
+	}
+}
+
+func TestResolvePortMappings(t *testing.T) {
+	busy := map[int]bool{8080: true}
+	free := func(port int, protocol string) bool { return !busy[port] }
+	next := 40000
+	alloc := func(protocol string) (int, error) {
+		next++
+		return next, nil
+	}
+
+	tests := []struct {
+		name     string
+		strategy string
+		want     []int32
+	}{
+		{name: "fixed keeps configured ports", strategy: "", want: []int32{8080, 8443, 5353}},
+		{name: "auto replaces busy ports", strategy: config.PortStrategyAuto, want: []int32{40001, 8443, 5353}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			next = 40000
+			cfg := portsConfig(tt.strategy)
+			pm, err := resolvePortMappings(cfg, free, alloc)
+			if err != nil {
+				t.Fatalf("resolvePortMappings() error = %v", err)
+			}
+
+			var got []int32
+			for _, a := range pm.Mappings {
+				got = append(got, a.HostPort)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("host ports = %v, want %v", got, tt.want)
+			}
+			if pm.Mappings[0].RequestedPort != 8080 || pm.Mappings[2].Protocol != "UDP" {
+				t.Errorf("unexpected assignment: %+v", pm.Mappings)
+			}
+
+			pm.Apply(cfg)
+			for i, m := range cfg.Cluster.PortMappings {
+				if m.HostPort != tt.want[i] {
+					t.Errorf("applied host port [%d] = %d, want %d", i, m.HostPort, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestResolvePortMappings_DuplicateHostPorts(t *testing.T) {
+	cfg := &config.Config{Cluster: config.ClusterConfig{
+		PortStrategy: config.PortStrategyAuto,
+		PortMappings: []config.PortMapping{
+			{ContainerPort: 80, HostPort: 8080},
+			{ContainerPort: 81, HostPort: 8080},
+		},
+	}}
+	pm, err := resolvePortMappings(cfg, func(int, string) bool { return true }, func(string) (int, error) { return 41000, nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pm.Mappings[0].HostPort != 8080 || pm.Mappings[1].HostPort != 41000 {
+		t.Errorf("expected second mapping to be moved, got %+v", pm.Mappings)
+	}
+}
+
+func TestConfiguredPortMap(t *testing.T) {
+	cfg := portsConfig(config.PortStrategyAuto)
+	cfg.Cluster.Registry = config.RegistryConfig{Enabled: true}
+
+	pm := ConfiguredPortMap(cfg)
+	if pm.Strategy != config.PortStrategyAuto {
+		t.Errorf("strategy = %s", pm.Strategy)
+	}
+	if pm.RegistryPort != 5001 {
+		t.Errorf("registry port = %d, want 5001", pm.RegistryPort)
+	}
+	if pm.Mappings[0].Protocol != "TCP" || pm.Mappings[0].HostPort != 8080 {
+		t.Errorf("unexpected mapping: %+v", pm.Mappings[0])
+	}
+}
+
+func TestSaveAndLoadPortMap(t *testing.T) {
+	ctx := context.Background()
+	client := fake.NewSimpleClientset()
+
+	pm, err := LoadPortMap(ctx, client)
+	if err != nil || pm != nil {
+		t.Fatalf("LoadPortMap() on empty cluster = %v, %v; want nil, nil", pm, err)
+	}
+
+	want := &PortMap{
+		Strategy:     config.PortStrategyAuto,
+		Mappings:     []PortAssignment{{ContainerPort: 80, RequestedPort: 8080, HostPort: 40001, Protocol: "TCP"}},
+		RegistryPort: 5002,
+	}
+	if err := SavePortMap(ctx, client, want); err != nil {
+		t.Fatalf("SavePortMap() error = %v", err)
+	}
+	// Saving twice updates in place
+	want.RegistryPort = 5003
+	if err := SavePortMap(ctx, client, want); err != nil {
+		t.Fatalf("SavePortMap() update error = %v", err)
+	}
+
+	got, err := LoadPortMap(ctx, client)
+	if err != nil {
+		t.Fatalf("LoadPortMap() error = %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("LoadPortMap() = %+v, want %+v", got, want)
+	}
+}
+
+func TestFreeHostPort(t *testing.T) {
+	for _, protocol := range []string{"TCP", "UDP"} {
+		port, err := FreeHostPort(protocol)
+		if err != nil {
+			t.Fatalf("FreeHostPort(%s) error = %v", protocol, err)
+		}
+		if port <= 0 {
+			t.Errorf("FreeHostPort(%s) = %d", protocol, port)
+		}
+		if !HostPortFree(port, protocol) {
+			t.Errorf("port %d/%s reported busy right after allocation", port, protocol)
+		}
+	}
+}
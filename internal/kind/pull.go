@@ -0,0 +1,153 @@
+package kind
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"sync/atomic"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/oci"
+)
+
+// preloadWithoutDaemon preloads images without the container daemon's image
+// store, for crossplane.imageCache.source registry and layout. Each image is
+// resolved in the OCI layout or its registry, then copied to the local
+// registry or imported into the nodes from an OCI archive.
+func preloadWithoutDaemon(ctx context.Context, rt container.Runtime, clusterName string, cfg *config.Config, images []string, nodeArch string, opts PreloadOptions, result *PreloadResult) (*PreloadResult, error) {
+	logFn := opts.Log
+	ic := cfg.Crossplane.ImageCache
+
+	puller, err := oci.NewPuller(ctx, oci.Options{
+		LayoutDir:  ic.LayoutDir,
+		LayoutOnly: ic.GetSource() == config.ImageSourceLayout,
+		TrustedCAs: cfg.Cluster.TrustedCAs.Registries,
+		Platform:   ocispec.Platform{OS: "linux", Architecture: nodeArch},
+	})
+	if err != nil {
+		return result, err
+	}
+
+	resolved := resolveImages(ctx, puller, images, opts)
+	var found []string
+	for _, img := range images {
+		if resolved[img] != nil {
+			found = append(found, img)
+		} else {
+			result.MissingImages = append(result.MissingImages, img)
+		}
+	}
+	if len(found) == 0 {
+		logFn("No images could be resolved (nodes will pull them)")
+		return result, nil
+	}
+	logFn(fmt.Sprintf("Resolved %d/%d images", len(found), len(images)))
+
+	if cfg.Cluster.Registry.Enabled && !opts.ImportToNodes {
+		registryHost := fmt.Sprintf("localhost:%d", cfg.Cluster.Registry.GetPort())
+		result.LoadedCount, err = pushResolvedImages(ctx, puller, resolved, found, registryHost, opts)
+		return result, err
+	}
+
+	nodes, err := GetNodeContainers(clusterName)
+	if err != nil {
+		return result, fmt.Errorf("failed to list nodes: %w", err)
+	}
+	cache, err := newArchiveCache()
+	if err != nil {
+		return result, err
+	}
+	result.LoadedCount, err = loadImagesIntoNodes(ctx, rt, pullSource(puller, cache, resolved, nodeArch), nodes, found, opts)
+	return result, err
+}
+
+// resolveImages resolves images concurrently. Images that can't be resolved
+// are logged and left out of the returned map.
+func resolveImages(ctx context.Context, puller *oci.Puller, images []string, opts PreloadOptions) map[string]*oci.Image {
+	var mu sync.Mutex
+	resolved := make(map[string]*oci.Image, len(images))
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for _, image := range images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			img, err := puller.Resolve(ctx, image)
+			if err != nil {
+				opts.Log(fmt.Sprintf("✗ %s: %v", getShortImageName(image), err))
+				return
+			}
+			mu.Lock()
+			resolved[image] = img
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return resolved
+}
+
+// pullSource writes resolved images to OCI archives in the archive cache,
+// keyed by the digest they resolved to
+func pullSource(puller *oci.Puller, cache *archiveCache, resolved map[string]*oci.Image, nodeArch string) imageSource {
+	platform := container.Platform{OS: "linux", Architecture: nodeArch}
+	return imageSource{
+		verb: "pulling",
+		archive: func(ctx context.Context, image string) (string, bool, error) {
+			img := resolved[image]
+			path := cache.path(img.Descriptor.Digest.Encoded(), platform, image)
+			cached, err := cache.fill(path, image, func(tmp string) error {
+				f, err := os.Create(tmp)
+				if err != nil {
+					return err
+				}
+				if err := puller.WriteArchive(ctx, img, f); err != nil {
+					_ = f.Close()
+					return err
+				}
+				return f.Close()
+			})
+			return path, cached, err
+		},
+	}
+}
+
+// pushResolvedImages copies resolved images into the local registry
+func pushResolvedImages(ctx context.Context, puller *oci.Puller, resolved map[string]*oci.Image, images []string, registryHost string, opts PreloadOptions) (int, error) {
+	opts.Log(fmt.Sprintf("Copying %d image(s) to local registry %s", len(images), registryHost))
+
+	var pushed atomic.Int32
+	slots := make(chan struct{}, opts.Concurrency)
+	var wg sync.WaitGroup
+	for _, image := range images {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			slots <- struct{}{}
+			defer func() { <-slots }()
+
+			opts.Progress(ImageProgress{Image: image, Status: "copying", Progress: 0.1})
+			if err := puller.Push(ctx, resolved[image], RetagForRegistry(image, registryHost)); err != nil {
+				opts.Log(fmt.Sprintf("✗ %s: %v", getShortImageName(image), err))
+				opts.Progress(ImageProgress{Image: image, Status: "failed", Progress: 1, Done: true, Failed: true})
+				return
+			}
+			opts.Log(fmt.Sprintf("✓ Copied %s", getShortImageName(image)))
+			opts.Progress(ImageProgress{Image: image, Status: "copied", Progress: 1, Done: true})
+			pushed.Add(1)
+		}()
+	}
+	wg.Wait()
+
+	if n := int(pushed.Load()); n > 0 {
+		opts.Log(fmt.Sprintf("Copied %d/%d images to local registry", n, len(images)))
+		return n, nil
+	}
+	return 0, fmt.Errorf("failed to copy any images to local registry")
+}
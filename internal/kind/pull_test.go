@@ -0,0 +1,128 @@
+package kind
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"strings"
+	"sync"
+	"testing"
+
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	orasoci "oras.land/oras-go/v2/content/oci"
+
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/oci"
+)
+
+// writeLayoutImage stores a single-layer linux/amd64 image in an OCI layout
+func writeLayoutImage(t *testing.T, dir, tag string) {
+	t.Helper()
+	ctx := context.Background()
+	store, err := orasoci.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, _ := json.Marshal(ocispec.Image{Platform: ocispec.Platform{OS: "linux", Architecture: "amd64"}})
+	cfgDesc, err := oras.PushBytes(ctx, store, ocispec.MediaTypeImageConfig, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDesc, err := oras.PushBytes(ctx, store, ocispec.MediaTypeImageLayer, []byte(tag))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, _ := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    cfgDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	if _, err := oras.TagBytes(ctx, store, ocispec.MediaTypeImageManifest, manifest, tag); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// archiveImageName returns the containerd image name recorded in an OCI archive
+func archiveImageName(t *testing.T, data []byte) string {
+	t.Helper()
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if err != nil {
+			t.Fatalf("no index.json in archive: %v", err)
+		}
+		if hdr.Name != "index.json" {
+			continue
+		}
+		var index ocispec.Index
+		if err := json.NewDecoder(tr).Decode(&index); err != nil {
+			t.Fatal(err)
+		}
+		return index.Manifests[0].Annotations["io.containerd.image.name"]
+	}
+}
+
+func TestPullSource_LoadsFromLayout(t *testing.T) {
+	ctx := context.Background()
+	layoutDir := t.TempDir()
+	writeLayoutImage(t, layoutDir, "crossplane/crossplane:v2.1.0")
+
+	puller, err := oci.NewPuller(ctx, oci.Options{
+		LayoutDir:  layoutDir,
+		LayoutOnly: true,
+		Platform:   ocispec.Platform{OS: "linux", Architecture: "amd64"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var logMu sync.Mutex
+	var logs []string
+	opts := PreloadOptions{Log: func(msg string) {
+		logMu.Lock()
+		defer logMu.Unlock()
+		logs = append(logs, msg)
+	}}.withDefaults()
+
+	images := []string{"crossplane/crossplane:v2.1.0", "crossplane/crossplane-rbac-manager:v2.1.0"}
+	resolved := resolveImages(ctx, puller, images, opts)
+	if len(resolved) != 1 || resolved[images[0]] == nil {
+		t.Fatalf("resolved = %v, want only %s", resolved, images[0])
+	}
+
+	// No image store is involved: the daemon has no images at all
+	rt := container.NewFake()
+	var imported []string
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		if cmd[0] == "crictl" {
+			return nil, context.Canceled // not present
+		}
+		if cmd[0] == "ctr" {
+			imported = append(imported, archiveImageName(t, stdin))
+		}
+		return nil, nil
+	}
+
+	cache := &archiveCache{dir: t.TempDir()}
+	src := pullSource(puller, cache, resolved, "amd64")
+	for run := 0; run < 2; run++ {
+		n, err := loadImagesIntoNodes(ctx, rt, src, []string{"node"}, []string{images[0]}, opts)
+		if err != nil || n != 1 {
+			t.Fatalf("loadImagesIntoNodes() = %d, %v", n, err)
+		}
+	}
+
+	if len(imported) != 2 || imported[0] != "docker.io/crossplane/crossplane:v2.1.0" {
+		t.Errorf("imported = %v, want the normalised image name twice", imported)
+	}
+	if !strings.Contains(strings.Join(logs, "\n"), "Using cached archive") {
+		t.Errorf("expected the second run to use the cached archive, logs: %v", logs)
+	}
+	if rt.Called("SaveImages") {
+		t.Error("expected no daemon save")
+	}
+}
@@ -0,0 +1,144 @@
+// Package kube builds the Kubernetes clients kindplane uses to talk to a
+// cluster. Every client of a Connection comes from the same kubeconfig, so
+// none of them falls back to the current kubectl context.
+package kube
+
+import (
+	"fmt"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/cli-runtime/pkg/genericclioptions"
+	"k8s.io/client-go/discovery"
+	"k8s.io/client-go/discovery/cached/memory"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/rest"
+	"k8s.io/client-go/restmapper"
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// helmBurst matches Helm's default client burst limit; the client-go
+// default of 10 throttles installs of charts with many resources
+const helmBurst = 100
+
+// Connection holds the clients for one cluster
+type Connection struct {
+	// Config is the REST config every client is built from
+	Config *rest.Config
+	// Client is the typed clientset
+	Client *kubernetes.Clientset
+
+	clientConfig clientcmd.ClientConfig
+	dynamicOnce  sync.Once
+	dynamic      dynamic.Interface
+	dynamicErr   error
+}
+
+// NewConnection builds the clients for the current context of clientConfig
+func NewConnection(clientConfig clientcmd.ClientConfig) (*Connection, error) {
+	config, err := clientConfig.ClientConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to build kubeconfig: %w", err)
+	}
+	client, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create kubernetes client: %w", err)
+	}
+	return &Connection{
+		Config:       config,
+		Client:       client,
+		clientConfig: clientConfig,
+	}, nil
+}
+
+// FromKubeconfig builds the clients for the current context of a kubeconfig
+func FromKubeconfig(data []byte) (*Connection, error) {
+	clientConfig, err := clientcmd.NewClientConfigFromBytes(data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse kubeconfig: %w", err)
+	}
+	return NewConnection(clientConfig)
+}
+
+// Server returns the API server URL the connection talks to
+func (c *Connection) Server() string {
+	return c.Config.Host
+}
+
+// Dynamic returns a dynamic client, created on first use
+func (c *Connection) Dynamic() (dynamic.Interface, error) {
+	c.dynamicOnce.Do(func() {
+		c.dynamic, c.dynamicErr = dynamic.NewForConfig(c.Config)
+		if c.dynamicErr != nil {
+			c.dynamicErr = fmt.Errorf("failed to create dynamic client: %w", c.dynamicErr)
+		}
+	})
+	return c.dynamic, c.dynamicErr
+}
+
+// Kubeconfig returns a kubeconfig holding only the connection's context
+func (c *Connection) Kubeconfig() ([]byte, error) {
+	raw, err := c.clientConfig.RawConfig()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	if err := clientcmdapi.MinifyConfig(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	if err := clientcmdapi.FlattenConfig(&raw); err != nil {
+		return nil, fmt.Errorf("failed to read kubeconfig: %w", err)
+	}
+	return clientcmd.Write(raw)
+}
+
+// RESTClientGetter returns a getter for Helm's action configuration that
+// uses the connection's REST config
+func (c *Connection) RESTClientGetter() genericclioptions.RESTClientGetter {
+	return &restClientGetter{conn: c}
+}
+
+// restClientGetter implements genericclioptions.RESTClientGetter for a
+// Connection
+type restClientGetter struct {
+	conn *Connection
+
+	discoveryOnce sync.Once
+	discovery     discovery.CachedDiscoveryInterface
+	discoveryErr  error
+}
+
+func (g *restClientGetter) ToRESTConfig() (*rest.Config, error) {
+	config := rest.CopyConfig(g.conn.Config)
+	if config.Burst < helmBurst {
+		config.Burst = helmBurst
+	}
+	return config, nil
+}
+
+func (g *restClientGetter) ToDiscoveryClient() (discovery.CachedDiscoveryInterface, error) {
+	g.discoveryOnce.Do(func() {
+		config, _ := g.ToRESTConfig()
+		client, err := discovery.NewDiscoveryClientForConfig(config)
+		if err != nil {
+			g.discoveryErr = fmt.Errorf("failed to create discovery client: %w", err)
+			return
+		}
+		g.discovery = memory.NewMemCacheClient(client)
+	})
+	return g.discovery, g.discoveryErr
+}
+
+func (g *restClientGetter) ToRESTMapper() (meta.RESTMapper, error) {
+	client, err := g.ToDiscoveryClient()
+	if err != nil {
+		return nil, err
+	}
+	mapper := restmapper.NewDeferredDiscoveryRESTMapper(client)
+	return restmapper.NewShortcutExpander(mapper, client, nil), nil
+}
+
+func (g *restClientGetter) ToRawKubeConfigLoader() clientcmd.ClientConfig {
+	return g.conn.clientConfig
+}
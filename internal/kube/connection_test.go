@@ -0,0 +1,102 @@
+package kube
+
+import (
+	"testing"
+
+	"k8s.io/client-go/tools/clientcmd"
+	clientcmdapi "k8s.io/client-go/tools/clientcmd/api"
+)
+
+// testKubeconfig returns a kubeconfig with a kind-dev and an other context,
+// kind-dev being current
+func testKubeconfig(t *testing.T) []byte {
+	t.Helper()
+	kubeconfig := clientcmdapi.NewConfig()
+	for name, server := range map[string]string{"kind-dev": "https://127.0.0.1:45123", "other": "https://prod.example.com"} {
+		kubeconfig.Clusters[name] = &clientcmdapi.Cluster{Server: server}
+		kubeconfig.AuthInfos[name] = &clientcmdapi.AuthInfo{Token: name + "-token"}
+		kubeconfig.Contexts[name] = &clientcmdapi.Context{Cluster: name, AuthInfo: name}
+	}
+	kubeconfig.CurrentContext = "kind-dev"
+	data, err := clientcmd.Write(*kubeconfig)
+	if err != nil {
+		t.Fatalf("failed to write kubeconfig: %v", err)
+	}
+	return data
+}
+
+func TestFromKubeconfig(t *testing.T) {
+	conn, err := FromKubeconfig(testKubeconfig(t))
+	if err != nil {
+		t.Fatalf("FromKubeconfig failed: %v", err)
+	}
+	if got := conn.Server(); got != "https://127.0.0.1:45123" {
+		t.Errorf("Server() = %q, want the current context's server", got)
+	}
+	if conn.Client == nil {
+		t.Error("expected a clientset")
+	}
+	if _, err := conn.Dynamic(); err != nil {
+		t.Errorf("Dynamic failed: %v", err)
+	}
+}
+
+func TestFromKubeconfig_Invalid(t *testing.T) {
+	if _, err := FromKubeconfig([]byte("not: [a kubeconfig")); err == nil {
+		t.Fatal("expected an error for an invalid kubeconfig")
+	}
+}
+
+func TestConnection_Kubeconfig(t *testing.T) {
+	conn, err := FromKubeconfig(testKubeconfig(t))
+	if err != nil {
+		t.Fatalf("FromKubeconfig failed: %v", err)
+	}
+	data, err := conn.Kubeconfig()
+	if err != nil {
+		t.Fatalf("Kubeconfig failed: %v", err)
+	}
+	kubeconfig, err := clientcmd.Load(data)
+	if err != nil {
+		t.Fatalf("failed to load kubeconfig: %v", err)
+	}
+	if len(kubeconfig.Contexts) != 1 || kubeconfig.Contexts["kind-dev"] == nil {
+		t.Errorf("expected only the kind-dev context, got %v", kubeconfig.Contexts)
+	}
+	if _, ok := kubeconfig.AuthInfos["other"]; ok {
+		t.Error("expected credentials of other contexts to be dropped")
+	}
+}
+
+func TestRESTClientGetter(t *testing.T) {
+	conn, err := FromKubeconfig(testKubeconfig(t))
+	if err != nil {
+		t.Fatalf("FromKubeconfig failed: %v", err)
+	}
+	getter := conn.RESTClientGetter()
+
+	config, err := getter.ToRESTConfig()
+	if err != nil {
+		t.Fatalf("ToRESTConfig failed: %v", err)
+	}
+	if config.Host != conn.Server() {
+		t.Errorf("ToRESTConfig host = %q, want %q", config.Host, conn.Server())
+	}
+	if config.Burst != helmBurst {
+		t.Errorf("ToRESTConfig burst = %d, want %d", config.Burst, helmBurst)
+	}
+	if conn.Config.Burst == helmBurst {
+		t.Error("expected the connection's config not to be modified")
+	}
+
+	raw, err := getter.ToRawKubeConfigLoader().RawConfig()
+	if err != nil {
+		t.Fatalf("RawConfig failed: %v", err)
+	}
+	if raw.CurrentContext != "kind-dev" {
+		t.Errorf("loader context = %q, want kind-dev", raw.CurrentContext)
+	}
+	if _, err := getter.ToRESTMapper(); err != nil {
+		t.Errorf("ToRESTMapper failed: %v", err)
+	}
+}
@@ -0,0 +1,37 @@
+package lock
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/kanzi/kindplane/internal/ui"
+)
+
+// WaitFlag is the persistent flag mutating commands read their lock wait from
+const WaitFlag = "wait-for-lock"
+
+// ForCommand acquires the cluster lock on behalf of a CLI command, honouring
+// --wait-for-lock and recording the command path as the holder
+func ForCommand(cmd *cobra.Command, cluster string) (*Lock, error) {
+	var wait time.Duration
+	if f := cmd.Flags().Lookup(WaitFlag); f != nil {
+		wait, _ = cmd.Flags().GetDuration(WaitFlag)
+	}
+
+	l, err := Acquire(cluster, Options{
+		Command: cmd.CommandPath(),
+		Wait:    wait,
+		OnWait: func(holder Info) {
+			fmt.Println(ui.Info("Cluster is locked by %s, waiting up to %s...", holder, wait))
+		},
+	})
+
+	var locked *LockedError
+	if errors.As(err, &locked) && wait == 0 {
+		return nil, fmt.Errorf("%w; use --%s to wait for it", err, WaitFlag)
+	}
+	return l, err
+}
@@ -0,0 +1,298 @@
+package lock
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/kanzi/kindplane/internal/state"
+)
+
+const (
+	// lockDir is the state subdirectory holding one lock file per cluster
+	lockDir = "locks"
+
+	// pollInterval is how often a waiting caller retries the lock
+	pollInterval = 500 * time.Millisecond
+
+	// partialWriteGrace is how long an unreadable lock file is assumed to be
+	// mid-write by its owner before it is treated as stale
+	partialWriteGrace = 10 * time.Second
+)
+
+// Info describes the holder of a cluster lock
+type Info struct {
+	Cluster    string    `json:"cluster"`
+	PID        int       `json:"pid"`
+	Host       string    `json:"host"`
+	Command    string    `json:"command"`
+	AcquiredAt time.Time `json:"acquiredAt"`
+}
+
+// String describes the holder for error messages
+func (i Info) String() string {
+	return fmt.Sprintf("'%s' (pid %d on %s, since %s)",
+		i.Command, i.PID, i.Host, i.AcquiredAt.Local().Format(time.Kitchen))
+}
+
+// LockedError is returned when another process holds the cluster lock
+type LockedError struct {
+	Holder Info
+}
+
+func (e *LockedError) Error() string {
+	return fmt.Sprintf("cluster %q is locked by %s", e.Holder.Cluster, e.Holder)
+}
+
+// Options controls how a lock is acquired
+type Options struct {
+	// Command is recorded in the lock file so other callers can see who holds it
+	Command string
+	// Wait is how long to wait for a held lock; zero fails immediately
+	Wait time.Duration
+	// OnWait is called once, with the current holder, when Acquire starts waiting
+	OnWait func(holder Info)
+}
+
+// Lock is a held cluster lock
+type Lock struct {
+	path string
+}
+
+// held tracks locks owned by this process so nested acquisitions (for example
+// a snapshot restore inside up) do not deadlock
+var (
+	heldMu sync.Mutex
+	held   = map[string]int{}
+)
+
+// Acquire takes the advisory lock for a cluster, waiting up to opts.Wait
+// when another live process holds it. Locks left behind by dead processes
+// on this host are removed automatically.
+func Acquire(cluster string, opts Options) (*Lock, error) {
+	path, err := state.Path(lockDir, cluster+".lock")
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve lock path: %w", err)
+	}
+	return acquireAt(path, cluster, opts)
+}
+
+// acquireAt implements Acquire for an explicit lock file path
+func acquireAt(path, cluster string, opts Options) (*Lock, error) {
+	heldMu.Lock()
+	if held[path] > 0 {
+		held[path]++
+		heldMu.Unlock()
+		return &Lock{path: path}, nil
+	}
+	heldMu.Unlock()
+
+	host, _ := os.Hostname()
+	info := Info{
+		Cluster:    cluster,
+		PID:        os.Getpid(),
+		Host:       host,
+		Command:    opts.Command,
+		AcquiredAt: time.Now().UTC(),
+	}
+
+	deadline := time.Now().Add(opts.Wait)
+	notified := false
+	for {
+		err := tryCreate(path, info)
+		if err == nil {
+			heldMu.Lock()
+			held[path]++
+			heldMu.Unlock()
+			return &Lock{path: path}, nil
+		}
+
+		var locked *LockedError
+		if !errors.As(err, &locked) {
+			return nil, err
+		}
+		if opts.Wait <= 0 || time.Now().After(deadline) {
+			return nil, err
+		}
+		if !notified && opts.OnWait != nil {
+			opts.OnWait(locked.Holder)
+			notified = true
+		}
+		time.Sleep(pollInterval)
+	}
+}
+
+// tryCreate attempts to create the lock file once, clearing a stale lock first
+func tryCreate(path string, info Info) error {
+	data, err := json.MarshalIndent(info, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = create(path, data)
+	if !errors.Is(err, fs.ErrExist) {
+		return err
+	}
+
+	stale, readErr := os.ReadFile(path)
+	holder, readErr := parseInfo(path, stale, readErr)
+	switch {
+	case readErr == nil && !isStale(holder):
+		if holder.Cluster == "" {
+			holder.Cluster = info.Cluster
+		}
+		return &LockedError{Holder: *holder}
+	case errors.Is(readErr, fs.ErrNotExist):
+		// Released between our create and read
+		return tryCreate(path, info)
+	case readErr != nil && modifiedWithin(path, partialWriteGrace):
+		// Probably still being written by its owner
+		return &LockedError{Holder: Info{Cluster: info.Cluster, Command: "unknown"}}
+	}
+
+	return takeOver(path, stale, data, info)
+}
+
+// takeOver replaces the stale lock file holding stale with a new one.
+// Racing callers that saw the same stale file serialise on a marker named
+// after its contents, and the lock is only removed when it still holds
+// them, so a lock another caller took over in the meantime is never
+// removed.
+func takeOver(path string, stale, data []byte, info Info) error {
+	busy := &LockedError{Holder: Info{Cluster: info.Cluster, Command: "unknown"}}
+	sum := sha256.Sum256(stale)
+	marker := path + ".takeover-" + hex.EncodeToString(sum[:8])
+
+	if err := create(marker, nil); errors.Is(err, fs.ErrExist) {
+		// Left behind by a caller that died mid-takeover; moving it aside
+		// lets only one of the callers that find it retry
+		if aside := fmt.Sprintf("%s.%d", marker, os.Getpid()); !modifiedWithin(marker, partialWriteGrace) && os.Rename(marker, aside) == nil {
+			_ = os.Remove(aside)
+		}
+		return busy
+	} else if err != nil {
+		return err
+	}
+	defer func() { _ = os.Remove(marker) }()
+
+	current, err := os.ReadFile(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+	case err != nil:
+		return err
+	case !bytes.Equal(current, stale):
+		if holder, err := parseInfo(path, current, nil); err == nil {
+			busy.Holder = *holder
+		}
+		return busy
+	default:
+		if err := os.Remove(path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return err
+		}
+	}
+
+	if err := create(path, data); errors.Is(err, fs.ErrExist) {
+		return busy
+	} else if err != nil {
+		return err
+	}
+	return nil
+}
+
+// create writes a new lock file, failing with fs.ErrExist if one is present
+func create(path string, data []byte) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		if errors.Is(err, fs.ErrExist) {
+			return err
+		}
+		return fmt.Errorf("failed to create lock file %s: %w", path, err)
+	}
+	_, werr := f.Write(data)
+	cerr := f.Close()
+	if werr != nil || cerr != nil {
+		_ = os.Remove(path)
+		return fmt.Errorf("failed to write lock file %s: %w", path, errors.Join(werr, cerr))
+	}
+	return nil
+}
+
+// modifiedWithin reports whether the file was modified in the last d
+func modifiedWithin(path string, d time.Duration) bool {
+	fi, err := os.Stat(path)
+	return err == nil && time.Since(fi.ModTime()) < d
+}
+
+// isStale reports whether the holder is a dead process on this host. Locks
+// held from other hosts (shared home directories) are never considered stale.
+func isStale(holder *Info) bool {
+	host, _ := os.Hostname()
+	if !strings.EqualFold(holder.Host, host) {
+		return false
+	}
+	if holder.PID <= 0 {
+		return true
+	}
+	return !processAlive(holder.PID)
+}
+
+// Read returns the holder recorded in a lock file
+func Read(path string) (*Info, error) {
+	data, err := os.ReadFile(path)
+	return parseInfo(path, data, err)
+}
+
+// parseInfo parses the contents of a lock file read with err
+func parseInfo(path string, data []byte, err error) (*Info, error) {
+	if err != nil {
+		return nil, err
+	}
+	var info Info
+	if err := json.Unmarshal(data, &info); err != nil {
+		return nil, fmt.Errorf("failed to parse lock file %s: %w", path, err)
+	}
+	return &info, nil
+}
+
+// Release drops the lock. Releasing a nested acquisition only decrements the
+// in-process count; the file is removed when the outermost holder releases.
+func (l *Lock) Release() error {
+	if l == nil {
+		return nil
+	}
+
+	heldMu.Lock()
+	defer heldMu.Unlock()
+	if held[l.path] == 0 {
+		return nil
+	}
+	held[l.path]--
+	if held[l.path] > 0 {
+		return nil
+	}
+	delete(held, l.path)
+
+	// Only remove the file if it is still ours
+	holder, err := Read(l.path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if holder.PID != os.Getpid() {
+		return nil
+	}
+	if err := os.Remove(l.path); err != nil && !os.IsNotExist(err) {
+		return fmt.Errorf("failed to remove lock file: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,306 @@
+package lock
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// writeHolder writes a lock file as if held by another process
+func writeHolder(t *testing.T, path string, info Info) {
+	t.Helper()
+	data, err := json.Marshal(info)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestAcquireAndRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+
+	l, err := acquireAt(path, "dev", Options{Command: "kindplane up"})
+	if err != nil {
+		t.Fatalf("acquireAt() error = %v", err)
+	}
+
+	info, err := Read(path)
+	if err != nil {
+		t.Fatalf("Read() error = %v", err)
+	}
+	if info.PID != os.Getpid() || info.Command != "kindplane up" || info.Cluster != "dev" {
+		t.Errorf("unexpected lock info: %+v", info)
+	}
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected lock file to be removed, stat err = %v", err)
+	}
+}
+
+func TestAcquire_Nested(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+
+	outer, err := acquireAt(path, "dev", Options{})
+	if err != nil {
+		t.Fatalf("outer acquire error = %v", err)
+	}
+	inner, err := acquireAt(path, "dev", Options{})
+	if err != nil {
+		t.Fatalf("nested acquire error = %v", err)
+	}
+
+	_ = inner.Release()
+	if _, err := os.Stat(path); err != nil {
+		t.Errorf("lock file should survive nested release: %v", err)
+	}
+	_ = outer.Release()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("expected lock file to be removed after outer release")
+	}
+}
+
+func TestAcquire_HeldByLiveProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+	host, _ := os.Hostname()
+	// The parent process is alive for the duration of the test
+	writeHolder(t, path, Info{Cluster: "dev", PID: os.Getppid(), Host: host, Command: "kindplane down"})
+
+	_, err := acquireAt(path, "dev", Options{})
+	var locked *LockedError
+	if !errors.As(err, &locked) {
+		t.Fatalf("expected LockedError, got %v", err)
+	}
+	if locked.Holder.Command != "kindplane down" {
+		t.Errorf("holder command = %q", locked.Holder.Command)
+	}
+}
+
+func TestAcquire_WaitTimesOut(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+	host, _ := os.Hostname()
+	writeHolder(t, path, Info{Cluster: "dev", PID: os.Getppid(), Host: host, Command: "kindplane up"})
+
+	waited := false
+	start := time.Now()
+	_, err := acquireAt(path, "dev", Options{
+		Wait:   time.Second,
+		OnWait: func(Info) { waited = true },
+	})
+	if err == nil {
+		t.Fatal("expected timeout error")
+	}
+	if !waited {
+		t.Error("expected OnWait to be called")
+	}
+	if time.Since(start) < time.Second {
+		t.Error("expected Acquire to wait before giving up")
+	}
+}
+
+func TestAcquire_WaitSucceedsAfterRelease(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+	host, _ := os.Hostname()
+	writeHolder(t, path, Info{Cluster: "dev", PID: os.Getppid(), Host: host})
+
+	go func() {
+		time.Sleep(700 * time.Millisecond)
+		_ = os.Remove(path)
+	}()
+
+	l, err := acquireAt(path, "dev", Options{Wait: 5 * time.Second})
+	if err != nil {
+		t.Fatalf("expected lock after release, got %v", err)
+	}
+	_ = l.Release()
+}
+
+func TestAcquire_StaleLocks(t *testing.T) {
+	host, _ := os.Hostname()
+	old := time.Now().Add(-time.Hour)
+
+	tests := []struct {
+		name    string
+		content string
+		info    *Info
+		old     bool
+	}{
+		{name: "dead process", info: &Info{Cluster: "dev", PID: 1 << 22, Host: host}},
+		{name: "missing pid", info: &Info{Cluster: "dev", Host: host}},
+		{name: "corrupt and old", content: "{not json", old: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dev.lock")
+			if tt.info != nil {
+				writeHolder(t, path, *tt.info)
+			} else if err := os.WriteFile(path, []byte(tt.content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if tt.old {
+				if err := os.Chtimes(path, old, old); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			l, err := acquireAt(path, "dev", Options{})
+			if err != nil {
+				t.Fatalf("expected stale lock to be taken over, got %v", err)
+			}
+			_ = l.Release()
+		})
+	}
+}
+
+// TestTakeOver_Concurrent starts callers that all saw the same stale lock
+// at once and checks that exactly one takes it over
+func TestTakeOver_Concurrent(t *testing.T) {
+	host, _ := os.Hostname()
+	for round := 0; round < 20; round++ {
+		path := filepath.Join(t.TempDir(), "dev.lock")
+		writeHolder(t, path, Info{Cluster: "dev", PID: 1 << 22, Host: host})
+		stale, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		const callers = 16
+		errs := make([]error, callers)
+		start := make(chan struct{})
+		var wg sync.WaitGroup
+		for i := range callers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				info := Info{Cluster: "dev", PID: os.Getpid(), Host: host, Command: strconv.Itoa(i)}
+				data, _ := json.Marshal(info)
+				<-start
+				errs[i] = takeOver(path, stale, data, info)
+			}()
+		}
+		close(start)
+		wg.Wait()
+
+		winner := -1
+		for i, err := range errs {
+			var locked *LockedError
+			switch {
+			case err == nil && winner >= 0:
+				t.Fatalf("round %d: callers %d and %d both took the lock over", round, winner, i)
+			case err == nil:
+				winner = i
+			case !errors.As(err, &locked):
+				t.Fatalf("round %d: takeOver() error = %v", round, err)
+			}
+		}
+		if winner < 0 {
+			t.Fatalf("round %d: no caller took the stale lock over", round)
+		}
+		holder, err := Read(path)
+		if err != nil || holder.Command != strconv.Itoa(winner) {
+			t.Fatalf("round %d: lock file = %+v, %v, want caller %d", round, holder, err, winner)
+		}
+	}
+}
+
+func TestTryCreate_LeftoverTakeoverMarker(t *testing.T) {
+	host, _ := os.Hostname()
+	path := filepath.Join(t.TempDir(), "dev.lock")
+	writeHolder(t, path, Info{Cluster: "dev", PID: 1 << 22, Host: host})
+	stale, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sum := sha256.Sum256(stale)
+	marker := path + ".takeover-" + hex.EncodeToString(sum[:8])
+	if err := os.WriteFile(marker, nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().Add(-time.Hour)
+	if err := os.Chtimes(marker, old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	info := Info{Cluster: "dev", PID: os.Getpid(), Host: host}
+	var locked *LockedError
+	if err := tryCreate(path, info); !errors.As(err, &locked) {
+		t.Fatalf("expected the takeover to wait for the marker, got %v", err)
+	}
+	if err := tryCreate(path, info); err != nil {
+		t.Fatalf("expected the leftover marker to be cleared, got %v", err)
+	}
+	if _, err := os.Stat(marker); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("expected the marker to be removed, got %v", err)
+	}
+}
+
+func TestAcquire_CorruptRecentIsHeld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+	if err := os.WriteFile(path, []byte(""), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var locked *LockedError
+	if _, err := acquireAt(path, "dev", Options{}); !errors.As(err, &locked) {
+		t.Fatalf("expected freshly written lock to be respected, got %v", err)
+	}
+}
+
+func TestAcquire_OtherHostNeverStale(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+	writeHolder(t, path, Info{Cluster: "dev", PID: 1 << 22, Host: "some-other-host.invalid"})
+
+	var locked *LockedError
+	if _, err := acquireAt(path, "dev", Options{}); !errors.As(err, &locked) {
+		t.Fatalf("expected lock from another host to be respected, got %v", err)
+	}
+}
+
+func TestRelease_KeepsForeignLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "dev.lock")
+
+	l, err := acquireAt(path, "dev", Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Simulate the lock being broken and re-taken by another process
+	host, _ := os.Hostname()
+	writeHolder(t, path, Info{Cluster: "dev", PID: os.Getppid(), Host: host})
+
+	if err := l.Release(); err != nil {
+		t.Fatalf("Release() error = %v", err)
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Error("expected foreign lock file to be kept")
+	}
+}
+
+func TestLockedError_Message(t *testing.T) {
+	err := &LockedError{Holder: Info{
+		Cluster:    "dev",
+		PID:        4242,
+		Host:       "laptop",
+		Command:    "kindplane up",
+		AcquiredAt: time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+	}}
+	msg := err.Error()
+	for _, want := range []string{`cluster "dev" is locked by`, "kindplane up", "pid 4242", "laptop"} {
+		if !strings.Contains(msg, want) {
+			t.Errorf("message %q missing %q", msg, want)
+		}
+	}
+}
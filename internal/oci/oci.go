@@ -0,0 +1,202 @@
+// Package oci pulls container images without a container daemon. Images
+// are read from an OCI image-layout directory or fetched from their
+// registries, and written either as OCI archives that containerd can import
+// or straight into another registry.
+package oci
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"strings"
+
+	"oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/credentials"
+	"oras.land/oras-go/v2/registry/remote/retry"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+const (
+	// dockerHub is the registry of image references without a host
+	dockerHub = "docker.io"
+	// dockerHubHost serves the Docker Hub registry API
+	dockerHubHost = "registry-1.docker.io"
+)
+
+// Normalize returns the fully qualified form of an image reference, the
+// name containerd and the kubelet use: Docker Hub images get docker.io (and
+// library/ for official images) and references without a tag or digest get
+// :latest. For example "busybox" becomes "docker.io/library/busybox:latest".
+func Normalize(image string) string {
+	host, repo := dockerHub, image
+	if first, rest, ok := strings.Cut(image, "/"); ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		host, repo = first, rest
+	}
+	if host == dockerHub && !strings.Contains(repo, "/") {
+		repo = "library/" + repo
+	}
+	name := host + "/" + repo
+
+	// A tag follows the last colon after the last slash
+	lastPart := name[strings.LastIndex(name, "/")+1:]
+	if !strings.Contains(name, "@") && !strings.Contains(lastPart, ":") {
+		name += ":latest"
+	}
+	return name
+}
+
+// Familiar shortens a fully qualified Docker Hub reference to the form
+// people write, the reverse of Normalize: "docker.io/library/busybox:1.36"
+// becomes "busybox:1.36". Other references are returned unchanged.
+func Familiar(name string) string {
+	if rest, ok := strings.CutPrefix(name, dockerHub+"/library/"); ok && !strings.Contains(rest, "/") {
+		return rest
+	}
+	if rest, ok := strings.CutPrefix(name, dockerHub+"/"); ok {
+		return rest
+	}
+	return name
+}
+
+// ParseReference parses an image reference after normalising it
+func ParseReference(image string) (registry.Reference, error) {
+	ref, err := registry.ParseReference(Normalize(image))
+	if err != nil {
+		return registry.Reference{}, fmt.Errorf("invalid image reference %q: %w", image, err)
+	}
+	return ref, nil
+}
+
+// IsLocalRegistry reports whether host is a loopback registry, which is
+// served over plain HTTP like the kindplane local registry
+func IsLocalRegistry(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
+
+// NewRepository returns an authenticated client for the repository of ref.
+// Credentials come from the Docker config (including credential helpers),
+// and CA files from trustedCAs whose host matches the registry are trusted
+// in addition to the system roots.
+func NewRepository(ref registry.Reference, trustedCAs []config.RegistryCA) (*remote.Repository, error) {
+	host := ref.Registry
+	if host == dockerHub {
+		host = dockerHubHost
+	}
+	repo, err := remote.NewRepository(host + "/" + ref.Repository)
+	if err != nil {
+		return nil, err
+	}
+	repo.PlainHTTP = usePlainHTTP(host, trustedCAs)
+	if repo.Client, err = newClient(host, trustedCAs); err != nil {
+		return nil, err
+	}
+	return repo, nil
+}
+
+// NewRegistry returns an authenticated client for a registry host, set up
+// like NewRepository
+func NewRegistry(host string, trustedCAs []config.RegistryCA) (*remote.Registry, error) {
+	reg, err := remote.NewRegistry(host)
+	if err != nil {
+		return nil, err
+	}
+	reg.PlainHTTP = usePlainHTTP(host, trustedCAs)
+	if reg.Client, err = newClient(host, trustedCAs); err != nil {
+		return nil, err
+	}
+	return reg, nil
+}
+
+// usePlainHTTP reports whether a registry is reached over plain HTTP: a
+// loopback registry is, unless a CA is configured for it
+func usePlainHTTP(host string, trustedCAs []config.RegistryCA) bool {
+	if !IsLocalRegistry(host) {
+		return false
+	}
+	for _, ca := range trustedCAs {
+		if hostMatches(ca.Host, host) {
+			return false
+		}
+	}
+	return true
+}
+
+// newClient returns an HTTP client for a registry host with retries, the
+// CAs configured for the host and Docker config credentials
+func newClient(host string, trustedCAs []config.RegistryCA) (*auth.Client, error) {
+	transport, err := registryTransport(host, trustedCAs)
+	if err != nil {
+		return nil, err
+	}
+	client := &auth.Client{
+		Client: &http.Client{Transport: retry.NewTransport(transport)},
+		Cache:  auth.NewCache(),
+	}
+	if store, err := credentials.NewStoreFromDocker(credentials.StoreOptions{}); err == nil {
+		client.Credential = credentials.Credential(store)
+	}
+	return client, nil
+}
+
+// Transport returns the HTTP transport for a registry host, trusting the CA
+// files in trustedCAs whose host matches it as well as the system roots
+func Transport(host string, trustedCAs []config.RegistryCA) (http.RoundTripper, error) {
+	return registryTransport(host, trustedCAs)
+}
+
+// registryTransport returns the HTTP transport for a registry host, trusting
+// the CA files configured for it
+func registryTransport(host string, trustedCAs []config.RegistryCA) (http.RoundTripper, error) {
+	var caFiles []string
+	for _, ca := range trustedCAs {
+		if hostMatches(ca.Host, host) {
+			caFiles = append(caFiles, ca.CAFile)
+		}
+	}
+	if len(caFiles) == 0 {
+		return http.DefaultTransport, nil
+	}
+
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	for _, file := range caFiles {
+		pem, err := os.ReadFile(file)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read CA for %s: %w", host, err)
+		}
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("no certificates found in CA file %s", file)
+		}
+	}
+
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = &tls.Config{RootCAs: pool, MinVersion: tls.VersionTLS12}
+	return transport, nil
+}
+
+// hostMatches reports whether a trustedCAs host pattern, which may start
+// with "*." to match subdomains, matches a registry host
+func hostMatches(pattern, host string) bool {
+	if pattern == host {
+		return true
+	}
+	if suffix, ok := strings.CutPrefix(pattern, "*"); ok && strings.HasPrefix(suffix, ".") {
+		return strings.HasSuffix(host, suffix) && len(host) > len(suffix)
+	}
+	return false
+}
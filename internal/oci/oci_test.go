@@ -0,0 +1,139 @@
+package oci
+
+import (
+	"crypto/tls"
+	"encoding/pem"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		image string
+		want  string
+	}{
+		{"busybox", "docker.io/library/busybox:latest"},
+		{"busybox:1.36", "docker.io/library/busybox:1.36"},
+		{"crossplane/crossplane:v2.1.0", "docker.io/crossplane/crossplane:v2.1.0"},
+		{"docker.io/busybox", "docker.io/library/busybox:latest"},
+		{"xpkg.upbound.io/upbound/provider-aws-s3:v1", "xpkg.upbound.io/upbound/provider-aws-s3:v1"},
+		{"localhost:5001/team/app", "localhost:5001/team/app:latest"},
+		{"ghcr.io/org/app@sha256:abc", "ghcr.io/org/app@sha256:abc"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.image, func(t *testing.T) {
+			if got := Normalize(tt.image); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.image, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFamiliar(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"docker.io/library/busybox:1.36", "busybox:1.36"},
+		{"docker.io/crossplane/crossplane:v2.1.0", "crossplane/crossplane:v2.1.0"},
+		{"ghcr.io/org/app:v1", "ghcr.io/org/app:v1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Familiar(tt.name); got != tt.want {
+				t.Errorf("Familiar(%q) = %q, want %q", tt.name, got, tt.want)
+			}
+			if Normalize(tt.want) != tt.name {
+				t.Errorf("Normalize(Familiar(%q)) = %q", tt.name, Normalize(tt.want))
+			}
+		})
+	}
+}
+
+func TestHostMatches(t *testing.T) {
+	tests := []struct {
+		pattern string
+		host    string
+		want    bool
+	}{
+		{"registry.example.com:5000", "registry.example.com:5000", true},
+		{"registry.example.com", "registry.example.com:5000", false},
+		{"*.internal.company.com", "harbor.internal.company.com", true},
+		{"*.internal.company.com", "internal.company.com", false},
+		{"*.internal.company.com", "evilinternal.company.com", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.pattern+" "+tt.host, func(t *testing.T) {
+			if got := hostMatches(tt.pattern, tt.host); got != tt.want {
+				t.Errorf("hostMatches(%q, %q) = %v, want %v", tt.pattern, tt.host, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRegistryTransport_TrustedCA(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer server.Close()
+
+	caFile := filepath.Join(t.TempDir(), "ca.crt")
+	cert := server.TLS.Certificates[0].Certificate[0]
+	if err := os.WriteFile(caFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}), 0644); err != nil {
+		t.Fatal(err)
+	}
+	host := server.Listener.Addr().String()
+
+	untrusted, err := registryTransport(host, nil)
+	if err != nil {
+		t.Fatalf("registryTransport() error = %v", err)
+	}
+	if _, err := (&http.Client{Transport: untrusted}).Get(server.URL); err == nil {
+		t.Error("expected the test server to be untrusted without its CA")
+	}
+
+	trusted, err := registryTransport(host, []config.RegistryCA{
+		{Host: "other.example.com", CAFile: "/does/not/exist"},
+		{Host: host, CAFile: caFile},
+	})
+	if err != nil {
+		t.Fatalf("registryTransport() error = %v", err)
+	}
+	resp, err := (&http.Client{Transport: trusted}).Get(server.URL)
+	if err != nil {
+		t.Fatalf("expected the configured CA to be trusted: %v", err)
+	}
+	_ = resp.Body.Close()
+	if tr, ok := trusted.(*http.Transport); !ok || tr.TLSClientConfig.MinVersion != tls.VersionTLS12 {
+		t.Error("expected a TLS 1.2+ transport")
+	}
+}
+
+func TestUsePlainHTTP(t *testing.T) {
+	ca := []config.RegistryCA{{Host: "localhost:5001", CAFile: "/tmp/ca.crt"}}
+	tests := []struct {
+		host       string
+		trustedCAs []config.RegistryCA
+		want       bool
+	}{
+		{"localhost:5001", nil, true},
+		{"127.0.0.1:5001", nil, true},
+		{"localhost:5001", ca, false},
+		{"localhost:5002", ca, true},
+		{"ghcr.io", nil, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.host, func(t *testing.T) {
+			if got := usePlainHTTP(tt.host, tt.trustedCAs); got != tt.want {
+				t.Errorf("usePlainHTTP(%q) = %v, want %v", tt.host, got, tt.want)
+			}
+		})
+	}
+}
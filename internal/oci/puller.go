@@ -0,0 +1,272 @@
+package oci
+
+import (
+	"archive/tar"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/errdef"
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+// SourceLayout is the Image.Source of images read from the OCI layout
+const SourceLayout = "layout"
+
+// annotationImageName is the image name containerd records on import
+const annotationImageName = "io.containerd.image.name"
+
+// ErrNotFound is returned for images that are neither in the layout nor,
+// when registries are used, in their registry
+var ErrNotFound = errors.New("image not found")
+
+// Options configures a Puller
+type Options struct {
+	// LayoutDir is an OCI image-layout directory searched before registries
+	LayoutDir string
+	// LayoutOnly disables registry pulls
+	LayoutOnly bool
+	// TrustedCAs are the CA files to trust for registry hosts
+	TrustedCAs []config.RegistryCA
+	// Platform selects one image from multi-platform indexes; all platforms
+	// are copied when it is empty
+	Platform ocispec.Platform
+}
+
+// Image is a resolved image that can be archived or pushed
+type Image struct {
+	// Name is the normalised image reference
+	Name string
+	// Descriptor is what the reference resolved to, possibly a
+	// multi-platform index
+	Descriptor ocispec.Descriptor
+	// Source is SourceLayout or the registry host the image came from
+	Source string
+
+	target oras.ReadOnlyTarget
+	ref    string // reference within target
+}
+
+// Puller copies images from an OCI image layout or their registries
+type Puller struct {
+	opts   Options
+	layout oras.ReadOnlyTarget
+
+	// newRepository connects to the repository of a reference
+	newRepository func(ref registry.Reference) (oras.Target, error)
+}
+
+// NewPuller returns a Puller for opts, opening the layout if one is set
+func NewPuller(ctx context.Context, opts Options) (*Puller, error) {
+	p := &Puller{
+		opts: opts,
+		newRepository: func(ref registry.Reference) (oras.Target, error) {
+			return NewRepository(ref, opts.TrustedCAs)
+		},
+	}
+	if opts.LayoutDir != "" {
+		store, err := oci.NewFromFS(ctx, os.DirFS(opts.LayoutDir))
+		if err != nil {
+			return nil, fmt.Errorf("failed to open OCI layout %s: %w", opts.LayoutDir, err)
+		}
+		p.layout = store
+	}
+	return p, nil
+}
+
+// Resolve finds an image, in the layout first and then in its registry.
+// Layout entries match on their org.opencontainers.image.ref.name
+// annotation, either as written in the config or fully qualified, or on
+// the digest of a pinned reference.
+func (p *Puller) Resolve(ctx context.Context, image string) (*Image, error) {
+	name := Normalize(image)
+	ref, err := ParseReference(image)
+	if err != nil {
+		return nil, err
+	}
+
+	if p.layout != nil {
+		candidates := []string{image, name}
+		if _, err := ref.Digest(); err == nil {
+			candidates = append(candidates, ref.Reference)
+		}
+		for _, candidate := range candidates {
+			if desc, err := p.layout.Resolve(ctx, candidate); err == nil {
+				return &Image{Name: name, Descriptor: desc, Source: SourceLayout, target: p.layout, ref: candidate}, nil
+			}
+		}
+	}
+	if p.opts.LayoutOnly {
+		return nil, fmt.Errorf("%w in %s: %s", ErrNotFound, p.opts.LayoutDir, image)
+	}
+
+	repo, err := p.newRepository(ref)
+	if err != nil {
+		return nil, err
+	}
+	desc, err := repo.Resolve(ctx, ref.Reference)
+	if err != nil {
+		if errors.Is(err, errdef.ErrNotFound) {
+			return nil, fmt.Errorf("%w: %s", ErrNotFound, image)
+		}
+		return nil, fmt.Errorf("failed to resolve %s: %w", image, err)
+	}
+	return &Image{Name: name, Descriptor: desc, Source: ref.Registry, target: repo, ref: ref.Reference}, nil
+}
+
+// WriteArchive writes an image as an OCI archive that `ctr images import`
+// accepts, named after the image
+func (p *Puller) WriteArchive(ctx context.Context, img *Image, w io.Writer) error {
+	dir, err := os.MkdirTemp("", "kindplane-oci-*")
+	if err != nil {
+		return fmt.Errorf("failed to create temp dir: %w", err)
+	}
+	defer func() { _ = os.RemoveAll(dir) }()
+
+	store, err := oci.New(dir)
+	if err != nil {
+		return err
+	}
+	desc, err := oras.Copy(ctx, img.target, img.ref, store, img.ref, p.copyOptions(true))
+	if err != nil {
+		return fmt.Errorf("failed to pull %s: %w", img.Name, err)
+	}
+
+	// Replace the store's index with one naming the image the way
+	// containerd expects
+	desc.Annotations = map[string]string{annotationImageName: img.Name}
+	if tag := tagOf(img.Name); tag != "" {
+		desc.Annotations[ocispec.AnnotationRefName] = tag
+	}
+	index, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{desc},
+	})
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, ocispec.ImageIndexFile), index, 0644); err != nil {
+		return err
+	}
+	return writeTar(dir, w)
+}
+
+// Push copies an image to a registry reference such as
+// "localhost:5001/crossplane/crossplane:v2.1.0"
+func (p *Puller) Push(ctx context.Context, img *Image, dst string) error {
+	ref, err := registry.ParseReference(dst)
+	if err != nil {
+		return fmt.Errorf("invalid target reference %q: %w", dst, err)
+	}
+	repo, err := p.newRepository(ref)
+	if err != nil {
+		return err
+	}
+	// A digest must keep naming the same content, so pinned images are
+	// copied with all their platforms
+	_, notPinned := ref.Digest()
+	if _, err := oras.Copy(ctx, img.target, img.ref, repo, ref.Reference, p.copyOptions(notPinned != nil)); err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", img.Name, dst, err)
+	}
+	return nil
+}
+
+// Layout is an OCI image-layout directory that images are saved into
+type Layout struct {
+	Dir   string
+	store *oci.Store
+}
+
+// CreateLayout opens the OCI image layout in dir, creating it if needed
+func CreateLayout(dir string) (*Layout, error) {
+	store, err := oci.New(dir)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create OCI layout %s: %w", dir, err)
+	}
+	return &Layout{Dir: dir, store: store}, nil
+}
+
+// Save copies an image into a layout, named by its normalised reference so
+// that a Puller reading the layout resolves it. Images pinned by digest keep
+// all their platforms; others are reduced to the configured platform.
+func (p *Puller) Save(ctx context.Context, img *Image, layout *Layout) error {
+	pinned := strings.Contains(img.Name, "@")
+	if _, err := oras.Copy(ctx, img.target, img.ref, layout.store, img.Name, p.copyOptions(!pinned)); err != nil {
+		return fmt.Errorf("failed to copy %s to %s: %w", img.Name, layout.Dir, err)
+	}
+	return nil
+}
+
+// copyOptions returns the options to copy an image with, selecting the
+// configured platform when selectPlatform is set
+func (p *Puller) copyOptions(selectPlatform bool) oras.CopyOptions {
+	opts := oras.DefaultCopyOptions
+	if selectPlatform && p.opts.Platform.OS != "" {
+		platform := p.opts.Platform
+		opts.WithTargetPlatform(&platform)
+	}
+	return opts
+}
+
+// tagOf returns the tag of a normalised reference, or "" when it is pinned
+// by digest
+func tagOf(name string) string {
+	if strings.Contains(name, "@") {
+		return ""
+	}
+	lastPart := name[strings.LastIndex(name, "/")+1:]
+	_, tag, _ := strings.Cut(lastPart, ":")
+	return tag
+}
+
+// writeTar writes the files under dir to w as a tar archive
+func writeTar(dir string, w io.Writer) error {
+	tw := tar.NewWriter(w)
+	err := filepath.WalkDir(dir, func(path string, d os.DirEntry, err error) error {
+		if err != nil || path == dir {
+			return err
+		}
+		rel, err := filepath.Rel(dir, path)
+		if err != nil {
+			return err
+		}
+		info, err := d.Info()
+		if err != nil {
+			return err
+		}
+		hdr, err := tar.FileInfoHeader(info, "")
+		if err != nil {
+			return err
+		}
+		hdr.Name = filepath.ToSlash(rel)
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return nil
+		}
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer func() { _ = f.Close() }()
+		_, err = io.Copy(tw, f)
+		return err
+	})
+	if err != nil {
+		return fmt.Errorf("failed to write archive: %w", err)
+	}
+	return tw.Close()
+}
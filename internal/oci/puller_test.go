@@ -0,0 +1,244 @@
+package oci
+
+import (
+	"archive/tar"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/content/oci"
+	"oras.land/oras-go/v2/registry"
+)
+
+// pushImage stores a single-layer image for a platform in target
+func pushImage(t *testing.T, target oras.Target, arch string) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+
+	cfg, err := json.Marshal(ocispec.Image{Platform: ocispec.Platform{OS: "linux", Architecture: arch}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfgDesc, err := oras.PushBytes(ctx, target, ocispec.MediaTypeImageConfig, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	layerDesc, err := oras.PushBytes(ctx, target, ocispec.MediaTypeImageLayer, []byte("layer-"+arch))
+	if err != nil {
+		t.Fatal(err)
+	}
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    cfgDesc,
+		Layers:    []ocispec.Descriptor{layerDesc},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := oras.PushBytes(ctx, target, ocispec.MediaTypeImageManifest, manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc.Platform = &ocispec.Platform{OS: "linux", Architecture: arch}
+	return desc
+}
+
+// pushIndex stores a multi-platform image in target and tags it
+func pushIndex(t *testing.T, target oras.Target, tag string, archs ...string) ocispec.Descriptor {
+	t.Helper()
+	var manifests []ocispec.Descriptor
+	for _, arch := range archs {
+		manifests = append(manifests, pushImage(t, target, arch))
+	}
+	index, err := json.Marshal(ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: manifests,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc, err := oras.TagBytes(context.Background(), target, ocispec.MediaTypeImageIndex, index, tag)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+// readArchive returns the files of a tar archive by name
+func readArchive(t *testing.T, data []byte) map[string][]byte {
+	t.Helper()
+	files := map[string][]byte{}
+	tr := tar.NewReader(bytes.NewReader(data))
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			return files
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		b, err := io.ReadAll(tr)
+		if err != nil {
+			t.Fatal(err)
+		}
+		files[hdr.Name] = b
+	}
+}
+
+func TestPuller_LayoutArchive(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	layout, err := oci.New(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pushIndex(t, layout, "crossplane/crossplane:v2.1.0", "amd64", "arm64")
+
+	p, err := NewPuller(ctx, Options{
+		LayoutDir:  dir,
+		LayoutOnly: true,
+		Platform:   ocispec.Platform{OS: "linux", Architecture: "arm64"},
+	})
+	if err != nil {
+		t.Fatalf("NewPuller() error = %v", err)
+	}
+
+	img, err := p.Resolve(ctx, "crossplane/crossplane:v2.1.0")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if img.Source != SourceLayout || img.Name != "docker.io/crossplane/crossplane:v2.1.0" {
+		t.Errorf("Resolve() = %s from %s", img.Name, img.Source)
+	}
+
+	if _, err := p.Resolve(ctx, "crossplane/crossplane:v9"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() of a missing image error = %v, want ErrNotFound", err)
+	}
+
+	var buf bytes.Buffer
+	if err := p.WriteArchive(ctx, img, &buf); err != nil {
+		t.Fatalf("WriteArchive() error = %v", err)
+	}
+	files := readArchive(t, buf.Bytes())
+	if _, ok := files["oci-layout"]; !ok {
+		t.Error("expected an oci-layout file")
+	}
+
+	var index ocispec.Index
+	if err := json.Unmarshal(files["index.json"], &index); err != nil {
+		t.Fatalf("invalid index.json: %v", err)
+	}
+	if len(index.Manifests) != 1 {
+		t.Fatalf("index has %d manifests, want 1", len(index.Manifests))
+	}
+	desc := index.Manifests[0]
+	if desc.MediaType != ocispec.MediaTypeImageManifest {
+		t.Errorf("expected the arm64 manifest to be selected, got %s", desc.MediaType)
+	}
+	if got := desc.Annotations[annotationImageName]; got != "docker.io/crossplane/crossplane:v2.1.0" {
+		t.Errorf("image name annotation = %q", got)
+	}
+	if got := desc.Annotations[ocispec.AnnotationRefName]; got != "v2.1.0" {
+		t.Errorf("ref name annotation = %q", got)
+	}
+	if _, ok := files["blobs/sha256/"+desc.Digest.Encoded()]; !ok {
+		t.Error("expected the manifest blob in the archive")
+	}
+	if _, ok := files["blobs/sha256/"+digest.FromString("layer-amd64").Encoded()]; ok {
+		t.Error("expected other platforms to be left out")
+	}
+	if _, ok := files["blobs/sha256/"+digest.FromString("layer-arm64").Encoded()]; !ok {
+		t.Error("expected the arm64 layer in the archive")
+	}
+}
+
+func TestPuller_RegistryFallbackAndPush(t *testing.T) {
+	ctx := context.Background()
+	repos := map[string]*memory.Store{}
+	repoFor := func(ref registry.Reference) (oras.Target, error) {
+		key := ref.Registry + "/" + ref.Repository
+		if repos[key] == nil {
+			repos[key] = memory.New()
+		}
+		return repos[key], nil
+	}
+
+	upstream, _ := repoFor(registry.Reference{Registry: "docker.io", Repository: "library/busybox"})
+	pushIndex(t, upstream, "1.36", "amd64", "arm64")
+
+	p, err := NewPuller(ctx, Options{Platform: ocispec.Platform{OS: "linux", Architecture: "amd64"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.newRepository = repoFor
+
+	img, err := p.Resolve(ctx, "busybox:1.36")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if img.Source != "docker.io" {
+		t.Errorf("Source = %q, want docker.io", img.Source)
+	}
+	if _, err := p.Resolve(ctx, "busybox:missing"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve() of a missing tag error = %v, want ErrNotFound", err)
+	}
+
+	if err := p.Push(ctx, img, "localhost:5001/library/busybox:1.36"); err != nil {
+		t.Fatalf("Push() error = %v", err)
+	}
+	pushed, err := repos["localhost:5001/library/busybox"].Resolve(ctx, "1.36")
+	if err != nil {
+		t.Fatalf("expected the image in the target registry: %v", err)
+	}
+	if pushed.MediaType != ocispec.MediaTypeImageManifest {
+		t.Errorf("expected the amd64 manifest to be pushed, got %s", pushed.MediaType)
+	}
+}
+
+func TestPuller_SaveToLayout(t *testing.T) {
+	ctx := context.Background()
+	upstream := memory.New()
+	pushIndex(t, upstream, "1.36", "amd64", "arm64")
+
+	p, err := NewPuller(ctx, Options{Platform: ocispec.Platform{OS: "linux", Architecture: "arm64"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	p.newRepository = func(registry.Reference) (oras.Target, error) { return upstream, nil }
+
+	img, err := p.Resolve(ctx, "busybox:1.36")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	dir := t.TempDir()
+	layout, err := CreateLayout(dir)
+	if err != nil {
+		t.Fatalf("CreateLayout() error = %v", err)
+	}
+	if err := p.Save(ctx, img, layout); err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	// The saved image resolves from the layout by the name used in the config
+	offline, err := NewPuller(ctx, Options{LayoutDir: dir, LayoutOnly: true})
+	if err != nil {
+		t.Fatalf("NewPuller() error = %v", err)
+	}
+	saved, err := offline.Resolve(ctx, "busybox:1.36")
+	if err != nil {
+		t.Fatalf("Resolve() from the layout error = %v", err)
+	}
+	if saved.Source != SourceLayout || saved.Descriptor.MediaType != ocispec.MediaTypeImageManifest {
+		t.Errorf("Resolve() = %s from %s, want the arm64 manifest from the layout", saved.Descriptor.MediaType, saved.Source)
+	}
+}
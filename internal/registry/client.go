@@ -0,0 +1,254 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/opencontainers/go-digest"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2/content"
+	orasregistry "oras.land/oras-go/v2/registry"
+	"oras.land/oras-go/v2/registry/remote"
+	"oras.land/oras-go/v2/registry/remote/auth"
+	"oras.land/oras-go/v2/registry/remote/errcode"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/oci"
+)
+
+// Docker manifest media types, which registry:2 serves for images pushed by Docker
+const (
+	mediaTypeDockerManifest     = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// ErrDeleteDisabled is returned when the registry was started without
+// REGISTRY_STORAGE_DELETE_ENABLED and refuses to delete manifests
+var ErrDeleteDisabled = errors.New("registry does not allow deletes")
+
+// RepositoryInfo describes a repository in the registry and its tags
+type RepositoryInfo struct {
+	Name string    `json:"name"`
+	Tags []TagInfo `json:"tags"`
+}
+
+// TagInfo describes a tag and the manifest it points to
+type TagInfo struct {
+	Tag       string `json:"tag"`
+	Digest    string `json:"digest"`
+	MediaType string `json:"mediaType"`
+	// Size is the total size of the manifest and the blobs it references,
+	// across all platforms of a multi-platform image
+	Size int64 `json:"size"`
+}
+
+// Client talks to a registry over the Docker Registry HTTP API v2
+type Client struct {
+	host string
+	reg  *remote.Registry
+}
+
+// NewClient returns a client for the registry at host, such as the value of
+// Manager.GetRegistryHost. Loopback registries are reached over plain HTTP
+// unless trustedCAs has a CA for them.
+func NewClient(host string, trustedCAs []config.RegistryCA) (*Client, error) {
+	reg, err := oci.NewRegistry(host, trustedCAs)
+	if err != nil {
+		return nil, fmt.Errorf("invalid registry host %q: %w", host, err)
+	}
+	return &Client{host: host, reg: reg}, nil
+}
+
+// Client returns a registry API client for the local registry, using its
+// CA and credentials when it is secured
+func (m *Manager) Client() (*Client, error) {
+	if !m.Secured() {
+		return NewClient(m.GetRegistryHost(), nil)
+	}
+	a, err := m.Assets()
+	if err != nil {
+		return nil, err
+	}
+	c, err := NewClient(m.GetRegistryHost(), m.TrustedCAs(a))
+	if err != nil {
+		return nil, err
+	}
+	// The generated credentials win over any stored by a runtime login
+	if client, ok := c.reg.Client.(*auth.Client); ok && m.cfg.Auth {
+		client.Credential = auth.StaticCredential(m.GetRegistryHost(), auth.Credential{
+			Username: a.Username,
+			Password: a.Password,
+		})
+	}
+	return c, nil
+}
+
+// Host returns the registry host the client talks to
+func (c *Client) Host() string {
+	return c.host
+}
+
+// Repositories returns the names of all repositories in the registry, sorted
+func (c *Client) Repositories(ctx context.Context) ([]string, error) {
+	var names []string
+	err := c.reg.Repositories(ctx, "", func(repos []string) error {
+		names = append(names, repos...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list repositories on %s: %w", c.host, err)
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// Repository returns a repository's tags with their digests and sizes
+func (c *Client) Repository(ctx context.Context, name string) (*RepositoryInfo, error) {
+	repo, err := c.reg.Repository(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	err = repo.Tags(ctx, "", func(page []string) error {
+		tags = append(tags, page...)
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to list tags of %s: %w", name, err)
+	}
+	sort.Strings(tags)
+
+	info := &RepositoryInfo{Name: name, Tags: []TagInfo{}}
+	for _, tag := range tags {
+		desc, err := repo.Resolve(ctx, tag)
+		if err != nil {
+			return nil, fmt.Errorf("failed to resolve %s:%s: %w", name, tag, err)
+		}
+		size, err := manifestSize(ctx, repo, desc, map[digest.Digest]bool{})
+		if err != nil {
+			return nil, fmt.Errorf("failed to read %s:%s: %w", name, tag, err)
+		}
+		info.Tags = append(info.Tags, TagInfo{
+			Tag:       tag,
+			Digest:    desc.Digest.String(),
+			MediaType: desc.MediaType,
+			Size:      size,
+		})
+	}
+	return info, nil
+}
+
+// List returns every repository in the registry with its tags. When filter
+// is set, only repositories whose name contains it are listed.
+func (c *Client) List(ctx context.Context, filter string) ([]RepositoryInfo, error) {
+	names, err := c.Repositories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	repos := []RepositoryInfo{}
+	for _, name := range names {
+		if filter != "" && !strings.Contains(name, filter) {
+			continue
+		}
+		info, err := c.Repository(ctx, name)
+		if err != nil {
+			return nil, err
+		}
+		repos = append(repos, *info)
+	}
+	return repos, nil
+}
+
+// Delete deletes the manifest a "repository:tag" or "repository@digest"
+// reference points to and returns its digest. The registry deletes the
+// manifest, so every tag pointing to it goes too; blobs are only freed by
+// garbage collection.
+func (c *Client) Delete(ctx context.Context, ref string) (string, error) {
+	name, reference, err := splitReference(ref)
+	if err != nil {
+		return "", err
+	}
+	repo, err := c.reg.Repository(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	desc, err := repo.Resolve(ctx, reference)
+	if err != nil {
+		return "", fmt.Errorf("failed to resolve %s: %w", ref, err)
+	}
+	if err := repo.Delete(ctx, desc); err != nil {
+		var errResp *errcode.ErrorResponse
+		if errors.As(err, &errResp) && errResp.StatusCode == http.StatusMethodNotAllowed {
+			return "", fmt.Errorf("%w: %s", ErrDeleteDisabled, ref)
+		}
+		return "", fmt.Errorf("failed to delete %s: %w", ref, err)
+	}
+	return desc.Digest.String(), nil
+}
+
+// splitReference splits "repository:tag" or "repository@digest" within the
+// registry; a missing tag means latest
+func splitReference(ref string) (name, reference string, err error) {
+	if name, dgst, ok := strings.Cut(ref, "@"); ok {
+		return name, dgst, nil
+	}
+	name, reference = ref, "latest"
+	if i := strings.LastIndex(ref, ":"); i > strings.LastIndex(ref, "/") {
+		name, reference = ref[:i], ref[i+1:]
+	}
+	if name == "" || reference == "" {
+		return "", "", fmt.Errorf("invalid reference %q, expected repository:tag", ref)
+	}
+	return name, reference, nil
+}
+
+// manifestSize adds up the size of a manifest and everything it references,
+// counting each blob once
+func manifestSize(ctx context.Context, repo orasregistry.Repository, desc ocispec.Descriptor, seen map[digest.Digest]bool) (int64, error) {
+	if seen[desc.Digest] {
+		return 0, nil
+	}
+	seen[desc.Digest] = true
+	size := desc.Size
+
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageIndex, mediaTypeDockerManifestList:
+		data, err := content.FetchAll(ctx, repo, desc)
+		if err != nil {
+			return 0, err
+		}
+		var index ocispec.Index
+		if err := json.Unmarshal(data, &index); err != nil {
+			return 0, err
+		}
+		for _, m := range index.Manifests {
+			n, err := manifestSize(ctx, repo, m, seen)
+			if err != nil {
+				return 0, err
+			}
+			size += n
+		}
+	case ocispec.MediaTypeImageManifest, mediaTypeDockerManifest:
+		data, err := content.FetchAll(ctx, repo, desc)
+		if err != nil {
+			return 0, err
+		}
+		var manifest ocispec.Manifest
+		if err := json.Unmarshal(data, &manifest); err != nil {
+			return 0, err
+		}
+		for _, blob := range append([]ocispec.Descriptor{manifest.Config}, manifest.Layers...) {
+			if !seen[blob.Digest] {
+				seen[blob.Digest] = true
+				size += blob.Size
+			}
+		}
+	}
+	return size, nil
+}
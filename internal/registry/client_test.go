@@ -0,0 +1,226 @@
+package registry
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	specs "github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+)
+
+// fakeRegistry serves the parts of the registry HTTP API v2 the client uses
+type fakeRegistry struct {
+	manifests     map[digest.Digest][]byte
+	mediaTypes    map[digest.Digest]string
+	tags          map[string]map[string]digest.Digest // repository -> tag -> digest
+	deleteAllowed bool
+}
+
+func newFakeRegistry() *fakeRegistry {
+	return &fakeRegistry{
+		manifests:     map[digest.Digest][]byte{},
+		mediaTypes:    map[digest.Digest]string{},
+		tags:          map[string]map[string]digest.Digest{},
+		deleteAllowed: true,
+	}
+}
+
+// add stores a manifest and tags it, returning its descriptor
+func (f *fakeRegistry) add(t *testing.T, repo, tag, mediaType string, manifest any) ocispec.Descriptor {
+	t.Helper()
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := digest.FromBytes(data)
+	f.manifests[d] = data
+	f.mediaTypes[d] = mediaType
+	if f.tags[repo] == nil {
+		f.tags[repo] = map[string]digest.Digest{}
+	}
+	if tag != "" {
+		f.tags[repo][tag] = d
+	}
+	return ocispec.Descriptor{MediaType: mediaType, Digest: d, Size: int64(len(data))}
+}
+
+func (f *fakeRegistry) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	path := strings.TrimPrefix(r.URL.Path, "/v2/")
+	switch {
+	case path == "_catalog":
+		var repos []string
+		for repo := range f.tags {
+			repos = append(repos, repo)
+		}
+		_ = json.NewEncoder(w).Encode(map[string][]string{"repositories": repos})
+	case strings.HasSuffix(path, "/tags/list"):
+		repo := strings.TrimSuffix(path, "/tags/list")
+		var tags []string
+		for tag := range f.tags[repo] {
+			tags = append(tags, tag)
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": repo, "tags": tags})
+	case strings.Contains(path, "/manifests/"):
+		repo, ref, _ := strings.Cut(path, "/manifests/")
+		d, ok := f.tags[repo][ref]
+		if !ok {
+			d = digest.Digest(ref)
+		}
+		data, ok := f.manifests[d]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		if r.Method == http.MethodDelete {
+			if !f.deleteAllowed {
+				w.WriteHeader(http.StatusMethodNotAllowed)
+				return
+			}
+			for tag, td := range f.tags[repo] {
+				if td == d {
+					delete(f.tags[repo], tag)
+				}
+			}
+			w.WriteHeader(http.StatusAccepted)
+			return
+		}
+		w.Header().Set("Content-Type", f.mediaTypes[d])
+		w.Header().Set("Docker-Content-Digest", d.String())
+		w.Header().Set("Content-Length", strconv.Itoa(len(data)))
+		if r.Method == http.MethodGet {
+			_, _ = w.Write(data)
+		}
+	default:
+		w.WriteHeader(http.StatusOK)
+	}
+}
+
+func blob(content string) ocispec.Descriptor {
+	return ocispec.Descriptor{MediaType: ocispec.MediaTypeImageLayer, Digest: digest.FromString(content), Size: int64(len(content))}
+}
+
+func newTestClient(t *testing.T, f *fakeRegistry) *Client {
+	t.Helper()
+	srv := httptest.NewServer(f)
+	t.Cleanup(srv.Close)
+	c, err := NewClient(strings.TrimPrefix(srv.URL, "http://"), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// TestClientList tests listing repositories with tag sizes, counting blobs
+// shared between platforms once
+func TestClientList(t *testing.T) {
+	f := newFakeRegistry()
+	shared := blob("shared layer")
+	amd64 := f.add(t, "org/app", "", ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    blob("amd64 config"),
+		Layers:    []ocispec.Descriptor{shared, blob("amd64 layer")},
+	})
+	arm64 := f.add(t, "org/app", "", mediaTypeDockerManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: mediaTypeDockerManifest,
+		Config:    blob("arm64 config"),
+		Layers:    []ocispec.Descriptor{shared},
+	})
+	index := f.add(t, "org/app", "v1", ocispec.MediaTypeImageIndex, ocispec.Index{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageIndex,
+		Manifests: []ocispec.Descriptor{amd64, arm64},
+	})
+	single := f.add(t, "busybox", "1.36", ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    blob("busybox config"),
+		Layers:    []ocispec.Descriptor{blob("busybox layer")},
+	})
+
+	c := newTestClient(t, f)
+	repos, err := c.List(context.Background(), "")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(repos) != 2 || repos[0].Name != "busybox" || repos[1].Name != "org/app" {
+		t.Fatalf("List() = %+v, want busybox and org/app", repos)
+	}
+
+	wantIndex := index.Size + amd64.Size + arm64.Size +
+		blob("amd64 config").Size + shared.Size + blob("amd64 layer").Size + blob("arm64 config").Size
+	if got := repos[1].Tags[0]; got.Tag != "v1" || got.Digest != index.Digest.String() || got.Size != wantIndex {
+		t.Errorf("org/app tag = %+v, want v1 %s size %d", got, index.Digest, wantIndex)
+	}
+	wantSingle := single.Size + blob("busybox config").Size + blob("busybox layer").Size
+	if got := repos[0].Tags[0]; got.Size != wantSingle {
+		t.Errorf("busybox size = %d, want %d", got.Size, wantSingle)
+	}
+
+	filtered, err := c.List(context.Background(), "org/")
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if len(filtered) != 1 || filtered[0].Name != "org/app" {
+		t.Errorf("filtered List() = %+v, want org/app", filtered)
+	}
+}
+
+// TestClientDelete tests deleting a tag, and the error when deletes are disabled
+func TestClientDelete(t *testing.T) {
+	f := newFakeRegistry()
+	desc := f.add(t, "my-app", "dev", ocispec.MediaTypeImageManifest, ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		Config:    blob("config"),
+	})
+	c := newTestClient(t, f)
+	ctx := context.Background()
+
+	f.deleteAllowed = false
+	if _, err := c.Delete(ctx, "my-app:dev"); !errors.Is(err, ErrDeleteDisabled) {
+		t.Errorf("Delete() with deletes disabled error = %v, want ErrDeleteDisabled", err)
+	}
+
+	f.deleteAllowed = true
+	got, err := c.Delete(ctx, "my-app:dev")
+	if err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if got != desc.Digest.String() {
+		t.Errorf("Delete() digest = %s, want %s", got, desc.Digest)
+	}
+	if _, ok := f.tags["my-app"]["dev"]; ok {
+		t.Error("expected tag to be deleted")
+	}
+}
+
+func TestSplitReference(t *testing.T) {
+	tests := []struct {
+		ref, name, reference string
+		wantErr              bool
+	}{
+		{ref: "my-app:dev", name: "my-app", reference: "dev"},
+		{ref: "org/app", name: "org/app", reference: "latest"},
+		{ref: "org/app@sha256:abc", name: "org/app", reference: "sha256:abc"},
+		{ref: ":dev", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.ref, func(t *testing.T) {
+			name, reference, err := splitReference(tt.ref)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("splitReference() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if name != tt.name || reference != tt.reference {
+				t.Errorf("splitReference() = %s, %s, want %s, %s", name, reference, tt.name, tt.reference)
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+package registry
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/kind"
+)
+
+// pullThroughPrefix names pull-through cache containers and their volumes
+const pullThroughPrefix = "kindplane-cache-"
+
+// PullThroughCache is a registry container that proxies one upstream registry
+type PullThroughCache struct {
+	// Upstream is the registry host images are pulled from, e.g. "docker.io"
+	Upstream string
+	// Name is the name of the cache container and of its storage volume
+	Name string
+}
+
+// RemoteURL returns the URL of the upstream registry API
+func (c PullThroughCache) RemoteURL() string {
+	if c.Upstream == "docker.io" {
+		return "https://registry-1.docker.io"
+	}
+	return "https://" + c.Upstream
+}
+
+// InternalHost returns the address nodes reach the cache on within the Kind network
+func (c PullThroughCache) InternalHost() string {
+	return fmt.Sprintf("%s:%d", c.Name, RegistryInternalPort)
+}
+
+// hostsToml returns the containerd hosts.toml that sends pulls for the
+// upstream through the cache, falling back to the upstream itself
+func (c PullThroughCache) hostsToml() string {
+	return fmt.Sprintf(`server = %q
+
+[host."http://%s"]
+  capabilities = ["pull", "resolve"]
+`, c.RemoteURL(), c.InternalHost())
+}
+
+// PullThroughManager runs pull-through cache registries and configures Kind
+// nodes to use them as mirrors. The caches are not tied to a cluster: every
+// kindplane cluster on the machine shares them, and their named volumes keep
+// cached images when the containers are removed.
+type PullThroughManager struct {
+	cfg *config.PullThroughConfig
+	rt  container.Runtime
+}
+
+// NewPullThroughManager creates a pull-through cache manager using the default container runtime
+func NewPullThroughManager(cfg *config.PullThroughConfig) *PullThroughManager {
+	return NewPullThroughManagerWithRuntime(cfg, container.Default())
+}
+
+// NewPullThroughManagerWithRuntime creates a pull-through cache manager backed by the given runtime
+func NewPullThroughManagerWithRuntime(cfg *config.PullThroughConfig, rt container.Runtime) *PullThroughManager {
+	return &PullThroughManager{cfg: cfg, rt: rt}
+}
+
+// Caches returns a cache for each configured upstream registry
+func (m *PullThroughManager) Caches() []PullThroughCache {
+	var caches []PullThroughCache
+	for _, upstream := range m.cfg.GetRegistries() {
+		caches = append(caches, PullThroughCache{
+			Upstream: upstream,
+			Name:     pullThroughPrefix + strings.NewReplacer(".", "-", ":", "-").Replace(upstream),
+		})
+	}
+	return caches
+}
+
+// Create creates and starts the cache containers that aren't running
+func (m *PullThroughManager) Create(ctx context.Context) error {
+	for _, cache := range m.Caches() {
+		err := ensureContainer(ctx, m.rt, container.RunOptions{
+			Name:    cache.Name,
+			Image:   DefaultRegistryImage,
+			Restart: "always",
+			Network: defaultNetwork(m.rt),
+			Env:     []string{"REGISTRY_PROXY_REMOTEURL=" + cache.RemoteURL()},
+			Volumes: []string{cache.Name + ":/var/lib/registry"},
+		})
+		if err != nil {
+			return fmt.Errorf("failed to create pull-through cache for %s: %w", cache.Upstream, err)
+		}
+	}
+	return nil
+}
+
+// ConnectToNetwork connects the cache containers to a container network
+func (m *PullThroughManager) ConnectToNetwork(ctx context.Context, network string) error {
+	for _, cache := range m.Caches() {
+		if err := connectNetwork(ctx, m.rt, cache.Name, network); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ConfigureNodes configures Kind nodes to pull through the caches
+func (m *PullThroughManager) ConfigureNodes(ctx context.Context, clusterName string) error {
+	nodes, err := listNodes(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := m.configureNode(ctx, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// configureNode writes the containerd hosts.toml of every cache on one node
+func (m *PullThroughManager) configureNode(ctx context.Context, nodeName string) error {
+	for _, cache := range m.Caches() {
+		if err := kind.WriteHostsToml(ctx, m.rt, nodeName, cache.Upstream, cache.hostsToml()); err != nil {
+			return fmt.Errorf("failed to configure pull-through cache for %s on node %s: %w", cache.Upstream, nodeName, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package registry
+
+import (
+	"context"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+// TestPullThroughCaches tests cache naming and upstream URLs
+func TestPullThroughCaches(t *testing.T) {
+	m := NewPullThroughManagerWithRuntime(&config.PullThroughConfig{Enabled: true}, container.NewFake())
+
+	caches := m.Caches()
+	want := []struct{ upstream, name, remote string }{
+		{"docker.io", "kindplane-cache-docker-io", "https://registry-1.docker.io"},
+		{"ghcr.io", "kindplane-cache-ghcr-io", "https://ghcr.io"},
+		{"xpkg.upbound.io", "kindplane-cache-xpkg-upbound-io", "https://xpkg.upbound.io"},
+		{"quay.io", "kindplane-cache-quay-io", "https://quay.io"},
+	}
+	if len(caches) != len(want) {
+		t.Fatalf("Caches() = %v, want %d caches", caches, len(want))
+	}
+	for i, w := range want {
+		c := caches[i]
+		if c.Upstream != w.upstream || c.Name != w.name || c.RemoteURL() != w.remote {
+			t.Errorf("cache %d = %+v (remote %s), want %+v", i, c, c.RemoteURL(), w)
+		}
+	}
+
+	custom := NewPullThroughManagerWithRuntime(&config.PullThroughConfig{Registries: []string{"registry.example.com:8443"}}, container.NewFake())
+	if got := custom.Caches()[0].Name; got != "kindplane-cache-registry-example-com-8443" {
+		t.Errorf("custom cache name = %s", got)
+	}
+}
+
+// TestPullThroughCreate tests that caches run in proxy mode on a named volume
+func TestPullThroughCreate(t *testing.T) {
+	rt := container.NewFake()
+	rt.Containers["kindplane-cache-ghcr-io"] = true
+	m := NewPullThroughManagerWithRuntime(&config.PullThroughConfig{Enabled: true, Registries: []string{"docker.io", "ghcr.io"}}, rt)
+
+	if err := m.Create(context.Background()); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if !rt.Called("RunContainer run -d --name kindplane-cache-docker-io --restart=always --network bridge -e REGISTRY_PROXY_REMOTEURL=https://registry-1.docker.io -v kindplane-cache-docker-io:/var/lib/registry registry:2") {
+		t.Errorf("expected docker.io cache to be run, calls: %v", rt.Calls)
+	}
+	if rt.Called("RunContainer run -d --name kindplane-cache-ghcr-io") {
+		t.Error("expected running ghcr.io cache to be reused")
+	}
+}
+
+// TestPullThroughConfigureNode tests the hosts.toml written for each upstream
+func TestPullThroughConfigureNode(t *testing.T) {
+	rt := container.NewFake()
+	written := map[string]string{}
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		if len(stdin) > 0 {
+			written[cmd[len(cmd)-1]] = string(stdin)
+		}
+		return nil, nil
+	}
+	m := NewPullThroughManagerWithRuntime(&config.PullThroughConfig{Enabled: true, Registries: []string{"docker.io", "quay.io"}}, rt)
+
+	if err := m.configureNode(context.Background(), "test-control-plane"); err != nil {
+		t.Fatalf("configureNode() error = %v", err)
+	}
+
+	want := `server = "https://registry-1.docker.io"
+
+[host."http://kindplane-cache-docker-io:5000"]
+  capabilities = ["pull", "resolve"]
+`
+	if got := written["cat > /etc/containerd/certs.d/docker.io/hosts.toml"]; got != want {
+		t.Errorf("docker.io hosts.toml = %q, want %q", got, want)
+	}
+	if _, ok := written["cat > /etc/containerd/certs.d/quay.io/hosts.toml"]; !ok {
+		t.Errorf("expected quay.io hosts.toml, wrote %v", written)
+	}
+}
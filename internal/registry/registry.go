@@ -2,8 +2,12 @@ package registry
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
 	"fmt"
-	"os/exec"
+	"os"
+	"slices"
 	"strings"
 	"time"
 
@@ -12,6 +16,7 @@ import (
 	"k8s.io/apimachinery/pkg/util/wait"
 
 	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
 	"github.com/kanzi/kindplane/internal/kind"
 )
 
@@ -24,105 +29,307 @@ const (
 	DefaultRegistryName = "kind-registry"
 	// RegistryInternalPort is the port the registry listens on inside the container
 	RegistryInternalPort = 5000
+	// registryConfigFile is the configuration file of the registry:2 image
+	registryConfigFile = "/etc/docker/registry/config.yml"
+	// configLabel holds a hash of the settings the registry container was
+	// created with; a container with other settings is recreated
+	configLabel = "kindplane.io/registry-config"
+	// registryDataDir is where the registry:2 image stores images; it is
+	// mounted from a volume named after the registry, so images outlive the
+	// container
+	registryDataDir = "/var/lib/registry"
+)
+
+// ContainerState compares an existing registry container with the
+// configured settings
+type ContainerState int
+
+const (
+	// ContainerMissing means there is no registry container
+	ContainerMissing ContainerState = iota
+	// ContainerCurrent means the container has the configured settings
+	ContainerCurrent
+	// ContainerUnlabelled means the container was created before kindplane
+	// labelled registry containers with their settings, without a volume
+	ContainerUnlabelled
+	// ContainerOutdated means the container was created with other settings
+	ContainerOutdated
 )
 
 // Manager handles local container registry operations
 type Manager struct {
-	cfg *config.RegistryConfig
+	cfg      *config.RegistryConfig
+	rt       container.Runtime
+	recreate bool
 }
 
-// NewManager creates a new registry manager
+// NewManager creates a new registry manager using the default container runtime
 func NewManager(cfg *config.RegistryConfig) *Manager {
-	return &Manager{cfg: cfg}
+	return NewManagerWithRuntime(cfg, container.Default())
+}
+
+// NewManagerWithRuntime creates a registry manager backed by the given runtime
+func NewManagerWithRuntime(cfg *config.RegistryConfig, rt container.Runtime) *Manager {
+	return &Manager{cfg: cfg, rt: rt}
 }
 
 // IsRunning checks if the registry container is running
 func (m *Manager) IsRunning(ctx context.Context) (bool, error) {
-	name := m.cfg.GetName()
-	cmd := exec.CommandContext(ctx, "docker", "inspect", "-f", "{{.State.Running}}", name)
-	output, err := cmd.Output()
-	if err != nil {
-		// Container doesn't exist
-		return false, nil
-	}
-	return strings.TrimSpace(string(output)) == "true", nil
+	return m.rt.ContainerRunning(ctx, m.cfg.GetName())
 }
 
 // Exists checks if the registry container exists (running or stopped)
 func (m *Manager) Exists(ctx context.Context) (bool, error) {
-	name := m.cfg.GetName()
-	cmd := exec.CommandContext(ctx, "docker", "inspect", name)
-	err := cmd.Run()
-	return err == nil, nil
+	return m.rt.ContainerExists(ctx, m.cfg.GetName())
+}
+
+// ResolvePort settles the host port the registry is reachable on and stores it
+// in the registry config. With the fixed strategy (auto unset) that is the
+// configured port. With auto, a running container keeps the port it is
+// published on; otherwise the configured port is used unless it is taken, in
+// which case a free port is chosen. A stopped container has no published
+// port, so it is not asked for one.
+func (m *Manager) ResolvePort(ctx context.Context, auto bool) (int, error) {
+	if !auto {
+		return m.cfg.GetPort(), nil
+	}
+
+	running, err := m.IsRunning(ctx)
+	if err != nil {
+		return 0, fmt.Errorf("failed to check registry status: %w", err)
+	}
+	if running {
+		port, err := m.rt.PublishedPort(ctx, m.cfg.GetName(), RegistryInternalPort)
+		if err != nil {
+			return 0, err
+		}
+		m.cfg.Port = port
+		return port, nil
+	}
+
+	if !kind.HostPortFree(m.cfg.GetPort(), "TCP") {
+		port, err := kind.FreeHostPort("TCP")
+		if err != nil {
+			return 0, fmt.Errorf("failed to find a free registry port: %w", err)
+		}
+		m.cfg.Port = port
+	}
+	return m.cfg.GetPort(), nil
 }
 
-// Create creates and starts the registry container
+// AllowRecreate lets Create recreate a persistent registry whose container
+// is outdated or unlabelled. Its images are kept in its volume.
+func (m *Manager) AllowRecreate() {
+	m.recreate = true
+}
+
+// Volume returns the name of the volume the registry stores images in
+func (m *Manager) Volume() string {
+	return m.cfg.GetName()
+}
+
+// Create creates and starts the registry container. An outdated container
+// is recreated, keeping the images in its volume, and an unlabelled one has
+// its images moved into the volume first. A persistent registry is only
+// recreated after AllowRecreate: an outdated one is an error, and an
+// unlabelled one is started as it is.
 func (m *Manager) Create(ctx context.Context) error {
+	opts, hash, err := m.runOptions()
+	if err != nil {
+		return err
+	}
+	state, err := m.state(ctx, hash)
+	if err != nil {
+		return err
+	}
+
+	keep := m.cfg.Persistent && !m.recreate
+	switch {
+	case state == ContainerOutdated && keep:
+		return fmt.Errorf("persistent registry container %s was created with other settings; run 'kindplane up --recreate-registry' to recreate it, keeping its images", opts.Name)
+	case state == ContainerOutdated:
+		if err := m.removeContainer(ctx); err != nil {
+			return err
+		}
+	case state == ContainerUnlabelled && !keep:
+		if err := m.adopt(ctx); err != nil {
+			return err
+		}
+	}
+
+	if err := ensureContainer(ctx, m.rt, opts); err != nil {
+		return fmt.Errorf("failed to create registry container: %w", err)
+	}
+	return nil
+}
+
+// State compares the registry container, if there is one, with the
+// configured settings
+func (m *Manager) State(ctx context.Context) (ContainerState, error) {
+	_, hash, err := m.runOptions()
+	if err != nil {
+		return ContainerMissing, err
+	}
+	return m.state(ctx, hash)
+}
+
+// state implements State for the settings hash of the configured container
+func (m *Manager) state(ctx context.Context, hash string) (ContainerState, error) {
+	exists, err := m.Exists(ctx)
+	if err != nil {
+		return ContainerMissing, fmt.Errorf("failed to check registry existence: %w", err)
+	}
+	if !exists {
+		return ContainerMissing, nil
+	}
+	current, err := m.rt.ContainerLabel(ctx, m.cfg.GetName(), configLabel)
+	if err != nil {
+		return ContainerMissing, err
+	}
+	switch current {
+	case "":
+		return ContainerUnlabelled, nil
+	case hash:
+		return ContainerCurrent, nil
+	default:
+		return ContainerOutdated, nil
+	}
+}
+
+// runOptions returns the options the registry container is run with,
+// labelled with the hash of its settings, and the hash
+func (m *Manager) runOptions() (container.RunOptions, string, error) {
+	opts := container.RunOptions{
+		Name:    m.cfg.GetName(),
+		Image:   DefaultRegistryImage,
+		Restart: "always",
+		Ports:   []string{fmt.Sprintf("127.0.0.1:%d:%d", m.cfg.GetPort(), RegistryInternalPort)},
+		Network: defaultNetwork(m.rt),
+		Volumes: []string{m.Volume() + ":" + registryDataDir},
+		// Allow 'kindplane registry rm' to delete manifests
+		Env: []string{"REGISTRY_STORAGE_DELETE_ENABLED=true"},
+	}
+	certFile := ""
+	if m.Secured() {
+		assets, err := m.EnsureAssets()
+		if err != nil {
+			return container.RunOptions{}, "", err
+		}
+		m.secureRunOptions(&opts, assets)
+		if m.cfg.TLS {
+			certFile = assets.CertFile
+		}
+	}
+
+	hash, err := configHash(opts, certFile)
+	if err != nil {
+		return container.RunOptions{}, "", fmt.Errorf("failed to hash registry settings: %w", err)
+	}
+	opts.Labels = append(opts.Labels, configLabel+"="+hash)
+	return opts, hash, nil
+}
+
+// configHash hashes the options a registry container is run with and the
+// server certificate it serves, so TLS, auth, port and certificate changes
+// all change the hash
+func configHash(opts container.RunOptions, certFile string) (string, error) {
+	h := sha256.New()
+	if err := json.NewEncoder(h).Encode(opts); err != nil {
+		return "", err
+	}
+	if certFile != "" {
+		cert, err := os.ReadFile(certFile)
+		if err != nil {
+			return "", err
+		}
+		h.Write(cert)
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16], nil
+}
+
+// adopt moves the images of an unlabelled registry container, which keeps
+// them in an anonymous volume, into the registry's volume and removes the
+// container, so it can be created again with its settings labelled
+func (m *Manager) adopt(ctx context.Context) error {
 	name := m.cfg.GetName()
-	port := m.cfg.GetPort()
+	_ = m.rt.StopContainer(ctx, name) // Ignore error if already stopped
+	err := m.rt.Command(ctx, nil, nil, "run", "--rm",
+		"--volumes-from", name,
+		"-v", m.Volume()+":/adopt",
+		"--entrypoint", "cp",
+		DefaultRegistryImage, "-a", registryDataDir+"/.", "/adopt/")
+	if err != nil {
+		return fmt.Errorf("failed to move the images of registry %s to volume %s: %w", name, m.Volume(), err)
+	}
+	return m.removeContainer(ctx)
+}
+
+// removeContainer removes the registry container, keeping its volume
+func (m *Manager) removeContainer(ctx context.Context) error {
+	name := m.cfg.GetName()
+	_ = m.rt.StopContainer(ctx, name) // Ignore error if already stopped
+	if err := m.rt.RemoveContainer(ctx, name); err != nil {
+		return fmt.Errorf("failed to remove registry container: %w", err)
+	}
+	return nil
+}
 
+// ensureContainer makes sure a registry container is running, starting it if
+// it is stopped and running it from opts if it doesn't exist
+func ensureContainer(ctx context.Context, rt container.Runtime, opts container.RunOptions) error {
 	// Check if already running
-	running, err := m.IsRunning(ctx)
+	running, err := rt.ContainerRunning(ctx, opts.Name)
 	if err != nil {
-		return fmt.Errorf("failed to check registry status: %w", err)
+		return fmt.Errorf("failed to check status of %s: %w", opts.Name, err)
 	}
 	if running {
 		return nil // Already running
 	}
 
 	// Check if container exists but is stopped
-	exists, err := m.Exists(ctx)
+	exists, err := rt.ContainerExists(ctx, opts.Name)
 	if err != nil {
-		return fmt.Errorf("failed to check registry existence: %w", err)
+		return fmt.Errorf("failed to check existence of %s: %w", opts.Name, err)
 	}
 	if exists {
 		// Start existing container
-		cmd := exec.CommandContext(ctx, "docker", "start", name)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to start existing registry container: %w", err)
+		if err := rt.StartContainer(ctx, opts.Name); err != nil {
+			return fmt.Errorf("failed to start existing container %s: %w", opts.Name, err)
 		}
 		return nil
 	}
 
-	// Create new registry container
-	cmd := exec.CommandContext(ctx, "docker", "run",
-		"-d",
-		"--restart=always",
-		"-p", fmt.Sprintf("127.0.0.1:%d:%d", port, RegistryInternalPort),
-		"--network", "bridge",
-		"--name", name,
-		DefaultRegistryImage,
-	)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to create registry container: %w", err)
-	}
+	// Create new container
+	return rt.RunContainer(ctx, opts)
+}
 
-	return nil
+// defaultNetwork returns the runtime's default bridge network name
+func defaultNetwork(rt container.Runtime) string {
+	if rt.Name() == container.RuntimePodman {
+		return "podman"
+	}
+	return "bridge"
 }
 
-// ConnectToNetwork connects the registry container to a Docker network
+// ConnectToNetwork connects the registry container to a container network
 func (m *Manager) ConnectToNetwork(ctx context.Context, network string) error {
-	name := m.cfg.GetName()
+	return connectNetwork(ctx, m.rt, m.cfg.GetName(), network)
+}
 
+// connectNetwork connects a container to a network unless it already is
+func connectNetwork(ctx context.Context, rt container.Runtime, name, network string) error {
 	// Check if already connected
-	cmd := exec.CommandContext(ctx, "docker", "inspect",
-		"-f", fmt.Sprintf("{{json .NetworkSettings.Networks.%s}}", network),
-		name,
-	)
-	output, err := cmd.Output()
+	networks, err := rt.ContainerNetworks(ctx, name)
 	if err != nil {
-		return fmt.Errorf("failed to inspect registry container: %w", err)
+		return fmt.Errorf("failed to inspect container %s: %w", name, err)
 	}
-
-	// If not null, already connected
-	if strings.TrimSpace(string(output)) != "null" {
+	if slices.Contains(networks, network) {
 		return nil
 	}
 
 	// Connect to network
-	cmd = exec.CommandContext(ctx, "docker", "network", "connect", network, name)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to connect registry to network %s: %w", network, err)
+	if err := rt.ConnectNetwork(ctx, network, name); err != nil {
+		return fmt.Errorf("failed to connect %s to network %s: %w", name, network, err)
 	}
 
 	return nil
@@ -130,13 +337,25 @@ func (m *Manager) ConnectToNetwork(ctx context.Context, network string) error {
 
 // ConfigureNodes configures Kind nodes to use the local registry
 func (m *Manager) ConfigureNodes(ctx context.Context, clusterName string) error {
-	name := m.cfg.GetName()
-	port := m.cfg.GetPort()
+	nodes, err := listNodes(ctx, clusterName)
+	if err != nil {
+		return err
+	}
+	for _, node := range nodes {
+		if err := m.configureNode(ctx, node); err != nil {
+			return err
+		}
+	}
+	return nil
+}
 
+// listNodes returns the names of a cluster's nodes, retrying while the API
+// server is still bootstrapping
+func listNodes(ctx context.Context, clusterName string) ([]string, error) {
 	// Get Kubernetes client to list nodes
 	kubeClient, err := kind.GetKubeClient(clusterName)
 	if err != nil {
-		return fmt.Errorf("failed to get kubernetes client: %w", err)
+		return nil, fmt.Errorf("failed to get kubernetes client: %w", err)
 	}
 
 	// List nodes using Kubernetes API with retry/backoff
@@ -168,46 +387,40 @@ func (m *Manager) ConfigureNodes(ctx context.Context, clusterName string) error
 
 	if err != nil {
 		if lastErr != nil {
-			return fmt.Errorf("node discovery timed out for cluster %s: %w", clusterName, lastErr)
+			return nil, fmt.Errorf("node discovery timed out for cluster %s: %w", clusterName, lastErr)
 		}
-		return fmt.Errorf("node discovery timed out for cluster %s: no nodes found after retries", clusterName)
+		return nil, fmt.Errorf("node discovery timed out for cluster %s: no nodes found after retries", clusterName)
 	}
 
-	registryDir := fmt.Sprintf("/etc/containerd/certs.d/localhost:%d", port)
-
-	// Configure each node
+	var nodes []string
 	for _, node := range nodesList.Items {
-		nodeName := node.Name
-		if nodeName == "" {
-			continue
+		if node.Name != "" {
+			nodes = append(nodes, node.Name)
 		}
+	}
+	return nodes, nil
+}
 
-		// Create registry config directory
-		cmd := exec.CommandContext(ctx, "docker", "exec", nodeName, "mkdir", "-p", registryDir)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to create registry config dir on node %s: %w", nodeName, err)
-		}
-
-		// Create hosts.toml configuration
-		hostsToml := fmt.Sprintf(`[host."http://%s:%d"]
-`, name, RegistryInternalPort)
-
-		// Write hosts.toml to node
-		cmd = exec.CommandContext(ctx, "docker", "exec", "-i", nodeName,
-			"sh", "-c", fmt.Sprintf("cat > %s/hosts.toml", registryDir))
-		cmd.Stdin = strings.NewReader(hostsToml)
-		if err := cmd.Run(); err != nil {
-			return fmt.Errorf("failed to configure registry on node %s: %w", nodeName, err)
-		}
+// configureNode writes the containerd hosts.toml for the registry on one node
+func (m *Manager) configureNode(ctx context.Context, nodeName string) error {
+	// Create hosts.toml configuration
+	hostsToml := fmt.Sprintf(`[host."http://%s"]
+`, m.GetInternalHost())
+	if m.cfg.TLS {
+		// The CA is mounted for the internal host like other trusted registry CAs
+		hostsToml = fmt.Sprintf(`[host."https://%s"]
+  ca = "/etc/containerd/certs.d/%s/ca.crt"
+`, m.GetInternalHost(), m.GetInternalHost())
 	}
 
+	if err := kind.WriteHostsToml(ctx, m.rt, nodeName, fmt.Sprintf("localhost:%d", m.cfg.GetPort()), hostsToml); err != nil {
+		return fmt.Errorf("failed to configure registry on node %s: %w", nodeName, err)
+	}
 	return nil
 }
 
-// Remove removes the registry container
+// Remove removes the registry container and the volume of its images
 func (m *Manager) Remove(ctx context.Context) error {
-	name := m.cfg.GetName()
-
 	// Check if container exists
 	exists, err := m.Exists(ctx)
 	if err != nil {
@@ -217,19 +430,48 @@ func (m *Manager) Remove(ctx context.Context) error {
 		return nil // Nothing to remove
 	}
 
-	// Stop container
-	cmd := exec.CommandContext(ctx, "docker", "stop", name)
-	_ = cmd.Run() // Ignore error if already stopped
-
-	// Remove container
-	cmd = exec.CommandContext(ctx, "docker", "rm", name)
-	if err := cmd.Run(); err != nil {
-		return fmt.Errorf("failed to remove registry container: %w", err)
+	if err := m.removeContainer(ctx); err != nil {
+		return err
 	}
 
+	// Remove the images too; a container created before registries had a
+	// volume has none
+	if err := m.rt.Command(ctx, nil, nil, "volume", "inspect", m.Volume()); err != nil {
+		return nil
+	}
+	if err := m.rt.Command(ctx, nil, nil, "volume", "rm", m.Volume()); err != nil {
+		return fmt.Errorf("failed to remove registry volume %s: %w", m.Volume(), err)
+	}
 	return nil
 }
 
+// GCOptions controls GarbageCollect
+type GCOptions struct {
+	// DryRun reports what would be removed without removing it
+	DryRun bool
+	// DeleteUntagged removes manifests no tag points to. The registry:2
+	// collector counts the per-platform manifests of an image index as
+	// untagged, so this breaks multi-platform images.
+	DeleteUntagged bool
+}
+
+// GarbageCollect runs the registry's garbage collector inside its container,
+// removing blobs no manifest references, and returns its output
+func (m *Manager) GarbageCollect(ctx context.Context, opts GCOptions) (string, error) {
+	args := []string{"registry", "garbage-collect", registryConfigFile}
+	if opts.DeleteUntagged {
+		args = append(args, "--delete-untagged")
+	}
+	if opts.DryRun {
+		args = append(args, "--dry-run")
+	}
+	out, err := m.rt.Exec(ctx, m.cfg.GetName(), nil, args...)
+	if err != nil {
+		return string(out), fmt.Errorf("garbage collection failed: %w\nOutput: %s", err, strings.TrimSpace(string(out)))
+	}
+	return string(out), nil
+}
+
 // GetRegistryHost returns the registry host for use in pod specs
 // This is the address that pods should use to pull images
 func (m *Manager) GetRegistryHost() string {
@@ -239,5 +481,5 @@ func (m *Manager) GetRegistryHost() string {
 // GetInternalHost returns the internal registry host for use within the Kind network
 // This is the address that containerd uses to pull images
 func (m *Manager) GetInternalHost() string {
-	return fmt.Sprintf("%s:%d", m.cfg.GetName(), RegistryInternalPort)
+	return m.cfg.GetInternalHost()
 }
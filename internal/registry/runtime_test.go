@@ -0,0 +1,337 @@
+package registry
+
+import (
+	"context"
+	"net"
+	"strings"
+	"testing"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+func newTestManager(rt container.Runtime) *Manager {
+	return NewManagerWithRuntime(&config.RegistryConfig{Enabled: true, Port: 5050, Name: "test-registry"}, rt)
+}
+
+// TestCreate_RunsContainer tests that a missing registry container is created
+func TestCreate_RunsContainer(t *testing.T) {
+	rt := container.NewFake()
+	m := newTestManager(rt)
+
+	if err := m.Create(context.Background()); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	if !rt.Called("RunContainer run -d --name test-registry --restart=always -p 127.0.0.1:5050:5000 --network bridge -e REGISTRY_STORAGE_DELETE_ENABLED=true -v test-registry:/var/lib/registry --label kindplane.io/registry-config=") {
+		t.Errorf("expected registry container to be run, calls: %v", rt.Calls)
+	}
+}
+
+// TestCreate_PodmanNetwork tests that Podman registries use the podman network
+func TestCreate_PodmanNetwork(t *testing.T) {
+	rt := container.NewFake()
+	rt.RuntimeName = container.RuntimePodman
+	m := newTestManager(rt)
+
+	if err := m.Create(context.Background()); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if got := rt.Networks["test-registry"]; len(got) != 1 || got[0] != "podman" {
+		t.Errorf("networks = %v, want [podman]", got)
+	}
+}
+
+// TestCreate_StartsStoppedContainer tests that an existing stopped registry is started
+func TestCreate_StartsStoppedContainer(t *testing.T) {
+	rt := container.NewFake()
+	m := newTestManager(rt)
+	ctx := context.Background()
+	if err := m.Create(ctx); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	rt.Containers["test-registry"] = false
+	rt.Calls = nil
+
+	if err := m.Create(ctx); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if rt.Called("RunContainer") || rt.Called("RemoveContainer") {
+		t.Errorf("expected existing container to be reused, calls: %v", rt.Calls)
+	}
+	if !rt.Containers["test-registry"] {
+		t.Error("expected registry container to be running")
+	}
+}
+
+// TestCreate_RecreatesChangedContainer tests that a registry created with
+// other settings is replaced rather than started as it was, keeping the
+// volume of its images
+func TestCreate_RecreatesChangedContainer(t *testing.T) {
+	rt := container.NewFake()
+	rt.Containers["test-registry"] = false
+	rt.Labels["test-registry"] = []string{"kindplane.io/registry-config=0123456789abcdef"}
+	m := newTestManager(rt)
+
+	if err := m.Create(context.Background()); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !rt.Called("RemoveContainer test-registry") || !rt.Called("RunContainer") {
+		t.Errorf("expected the container to be recreated, calls: %v", rt.Calls)
+	}
+	if rt.Called("StartContainer") || rt.Called("Command volume rm") {
+		t.Errorf("expected the outdated container to be replaced and its volume kept, calls: %v", rt.Calls)
+	}
+}
+
+// TestCreate_AdoptsUnlabelledContainer tests that a registry created before
+// containers were labelled has its images moved into the volume before it
+// is recreated with a label, and that a persistent one is kept as it is
+func TestCreate_AdoptsUnlabelledContainer(t *testing.T) {
+	tests := []struct {
+		name       string
+		persistent bool
+		recreate   bool
+		adopted    bool
+	}{
+		{name: "ephemeral", adopted: true},
+		{name: "persistent", persistent: true},
+		{name: "persistent with recreate", persistent: true, recreate: true, adopted: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := container.NewFake()
+			rt.Containers["test-registry"] = false
+			m := NewManagerWithRuntime(&config.RegistryConfig{Enabled: true, Port: 5050, Name: "test-registry", Persistent: tt.persistent}, rt)
+			if tt.recreate {
+				m.AllowRecreate()
+			}
+
+			if state, err := m.State(context.Background()); err != nil || state != ContainerUnlabelled {
+				t.Fatalf("State() = %v, %v, want ContainerUnlabelled", state, err)
+			}
+			if err := m.Create(context.Background()); err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			copied := rt.Called("Command run --rm --volumes-from test-registry -v test-registry:/adopt --entrypoint cp registry:2 -a /var/lib/registry/. /adopt/")
+			if copied != tt.adopted || rt.Called("RemoveContainer test-registry") != tt.adopted || rt.Called("RunContainer") != tt.adopted {
+				t.Errorf("adopted = %v, want %v, calls: %v", copied, tt.adopted, rt.Calls)
+			}
+			if !rt.Containers["test-registry"] {
+				t.Error("expected registry container to be running")
+			}
+			if labelled := len(rt.Labels["test-registry"]) > 0; labelled != tt.adopted {
+				t.Errorf("labelled = %v, want %v", labelled, tt.adopted)
+			}
+		})
+	}
+}
+
+// TestCreate_PersistentOutdated tests that a persistent registry with other
+// settings is only recreated after AllowRecreate
+func TestCreate_PersistentOutdated(t *testing.T) {
+	rt := container.NewFake()
+	rt.Containers["test-registry"] = true
+	rt.Labels["test-registry"] = []string{"kindplane.io/registry-config=0123456789abcdef"}
+	m := NewManagerWithRuntime(&config.RegistryConfig{Enabled: true, Port: 5050, Name: "test-registry", Persistent: true}, rt)
+	ctx := context.Background()
+
+	err := m.Create(ctx)
+	if err == nil || !strings.Contains(err.Error(), "--recreate-registry") {
+		t.Errorf("Create() error = %v, want one naming --recreate-registry", err)
+	}
+	if rt.Called("RemoveContainer") {
+		t.Errorf("expected the persistent registry to be kept, calls: %v", rt.Calls)
+	}
+
+	m.AllowRecreate()
+	if err := m.Create(ctx); err != nil {
+		t.Fatalf("Create() after AllowRecreate() error = %v", err)
+	}
+	if !rt.Called("RemoveContainer test-registry") || !rt.Called("RunContainer") {
+		t.Errorf("expected the container to be recreated, calls: %v", rt.Calls)
+	}
+}
+
+// TestConnectToNetwork tests network connection is idempotent
+func TestConnectToNetwork(t *testing.T) {
+	rt := container.NewFake()
+	rt.Containers["test-registry"] = true
+	rt.Networks["test-registry"] = []string{"bridge"}
+	m := newTestManager(rt)
+
+	ctx := context.Background()
+	if err := m.ConnectToNetwork(ctx, "kind"); err != nil {
+		t.Fatalf("ConnectToNetwork() error = %v", err)
+	}
+	if err := m.ConnectToNetwork(ctx, "kind"); err != nil {
+		t.Fatalf("ConnectToNetwork() second call error = %v", err)
+	}
+
+	connects := 0
+	for _, c := range rt.Calls {
+		if strings.HasPrefix(c, "ConnectNetwork") {
+			connects++
+		}
+	}
+	if connects != 1 {
+		t.Errorf("expected 1 network connect, got %d", connects)
+	}
+}
+
+// TestConfigureNode_WritesHostsToml tests the hosts.toml written to a node
+func TestConfigureNode_WritesHostsToml(t *testing.T) {
+	rt := container.NewFake()
+	written := map[string]string{}
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		if len(stdin) > 0 {
+			written[node] = string(stdin)
+		}
+		return nil, nil
+	}
+	m := newTestManager(rt)
+
+	if err := m.configureNode(context.Background(), "test-control-plane"); err != nil {
+		t.Fatalf("configureNode() error = %v", err)
+	}
+
+	if !rt.Called("Exec test-control-plane mkdir -p /etc/containerd/certs.d/localhost:5050") {
+		t.Errorf("expected registry dir to be created, calls: %v", rt.Calls)
+	}
+	want := "[host.\"http://test-registry:5000\"]\n"
+	if written["test-control-plane"] != want {
+		t.Errorf("hosts.toml = %q, want %q", written["test-control-plane"], want)
+	}
+}
+
+// TestRemove tests registry removal, including when it does not exist
+func TestRemove(t *testing.T) {
+	rt := container.NewFake()
+	m := newTestManager(rt)
+	ctx := context.Background()
+
+	if err := m.Remove(ctx); err != nil {
+		t.Fatalf("Remove() on missing registry error = %v", err)
+	}
+	if rt.Called("RemoveContainer") {
+		t.Error("expected no removal for missing registry")
+	}
+
+	rt.Containers["test-registry"] = true
+	if err := m.Remove(ctx); err != nil {
+		t.Fatalf("Remove() error = %v", err)
+	}
+	if _, ok := rt.Containers["test-registry"]; ok {
+		t.Error("expected registry container to be removed")
+	}
+	if !rt.Called("Command volume rm test-registry") {
+		t.Errorf("expected the registry volume to be removed, calls: %v", rt.Calls)
+	}
+}
+
+// TestResolvePort_ExistingContainer tests that a running registry keeps its published port
+func TestResolvePort_ExistingContainer(t *testing.T) {
+	rt := container.NewFake()
+	rt.Containers["test-registry"] = true
+	rt.Ports["test-registry"] = map[int]int{RegistryInternalPort: 5099}
+	m := newTestManager(rt)
+
+	port, err := m.ResolvePort(context.Background(), true)
+	if err != nil {
+		t.Fatalf("ResolvePort() error = %v", err)
+	}
+	if port != 5099 || m.cfg.GetPort() != 5099 {
+		t.Errorf("port = %d (config %d), want 5099", port, m.cfg.GetPort())
+	}
+}
+
+// TestResolvePort_StoppedContainer tests that a stopped registry, which has no
+// published port, falls back to the configured port
+func TestResolvePort_StoppedContainer(t *testing.T) {
+	rt := container.NewFake()
+	rt.Containers["test-registry"] = false
+	m := newTestManager(rt)
+
+	port, err := m.ResolvePort(context.Background(), true)
+	if err != nil {
+		t.Fatalf("ResolvePort() error = %v", err)
+	}
+	if port != 5050 {
+		t.Errorf("port = %d, want the configured 5050", port)
+	}
+	if rt.Called("PublishedPort") {
+		t.Error("expected no published port lookup for a stopped registry")
+	}
+}
+
+// TestResolvePort_Fixed tests that the fixed strategy uses the configured port
+// without asking the runtime
+func TestResolvePort_Fixed(t *testing.T) {
+	rt := container.NewFake()
+	rt.Containers["test-registry"] = true
+	rt.Ports["test-registry"] = map[int]int{RegistryInternalPort: 5099}
+	m := newTestManager(rt)
+
+	port, err := m.ResolvePort(context.Background(), false)
+	if err != nil {
+		t.Fatalf("ResolvePort() error = %v", err)
+	}
+	if port != 5050 {
+		t.Errorf("port = %d, want the configured 5050", port)
+	}
+	if len(rt.Calls) != 0 {
+		t.Errorf("expected no runtime calls, got %v", rt.Calls)
+	}
+}
+
+// TestResolvePort_Auto tests that a busy port is replaced only with the auto strategy
+func TestResolvePort_Auto(t *testing.T) {
+	l, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer func() { _ = l.Close() }()
+	busy := l.Addr().(*net.TCPAddr).Port
+
+	ctx := context.Background()
+	fixed := NewManagerWithRuntime(&config.RegistryConfig{Enabled: true, Port: busy}, container.NewFake())
+	if port, _ := fixed.ResolvePort(ctx, false); port != busy {
+		t.Errorf("fixed strategy port = %d, want %d", port, busy)
+	}
+
+	auto := NewManagerWithRuntime(&config.RegistryConfig{Enabled: true, Port: busy}, container.NewFake())
+	port, err := auto.ResolvePort(ctx, true)
+	if err != nil {
+		t.Fatalf("ResolvePort() error = %v", err)
+	}
+	if port == busy {
+		t.Errorf("auto strategy kept busy port %d", busy)
+	}
+}
+
+// TestGarbageCollect tests the garbage-collect command run in the registry
+func TestGarbageCollect(t *testing.T) {
+	tests := []struct {
+		name string
+		opts GCOptions
+		want string
+	}{
+		{"default", GCOptions{}, "Exec test-registry registry garbage-collect /etc/docker/registry/config.yml"},
+		{"dry run", GCOptions{DryRun: true}, "Exec test-registry registry garbage-collect /etc/docker/registry/config.yml --dry-run"},
+		{"delete untagged", GCOptions{DeleteUntagged: true, DryRun: true}, "Exec test-registry registry garbage-collect /etc/docker/registry/config.yml --delete-untagged --dry-run"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rt := container.NewFake()
+			m := newTestManager(rt)
+
+			if _, err := m.GarbageCollect(context.Background(), tt.opts); err != nil {
+				t.Fatalf("GarbageCollect() error = %v", err)
+			}
+			if len(rt.Calls) != 1 || rt.Calls[0] != tt.want {
+				t.Errorf("calls = %v, want [%s]", rt.Calls, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,366 @@
+package registry
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/state"
+)
+
+const (
+	// PullSecretName is the image pull secret created for an authenticated registry
+	PullSecretName = "kindplane-registry"
+	// RegistryUsername is the user an authenticated registry accepts
+	RegistryUsername = "kindplane"
+
+	// assetsMountPath is where the registry container sees its assets
+	assetsMountPath = "/kindplane"
+	// caValidity and certValidity are how long the generated CA and server
+	// certificates are valid for
+	caValidity   = 10 * 365 * 24 * time.Hour
+	certValidity = 365 * 24 * time.Hour
+	// certRenewAhead is how long before expiry a server certificate is renewed
+	certRenewAhead = 30 * 24 * time.Hour
+)
+
+// Assets are the files a TLS or authenticated registry is started with.
+// They are generated once per registry name and kept in the kindplane state
+// directory, so the CA and password stay the same across clusters.
+type Assets struct {
+	Dir          string `json:"-"`
+	CAFile       string `json:"caFile"`
+	CertFile     string `json:"-"`
+	KeyFile      string `json:"-"`
+	HtpasswdFile string `json:"-"`
+	Username     string `json:"username,omitempty"`
+	Password     string `json:"password,omitempty"`
+}
+
+// Secured reports whether the registry uses TLS or authentication
+func (m *Manager) Secured() bool {
+	return m.cfg.TLS || m.cfg.Auth
+}
+
+// assetsDir returns the directory holding the registry's generated assets
+func (m *Manager) assetsDir() (string, error) {
+	dir, err := state.Dir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "registry", m.cfg.GetName()), nil
+}
+
+// EnsureAssets generates the CA, server certificate and htpasswd file the
+// registry config asks for, reusing ones generated before. The server
+// certificate is renewed when it is about to expire or doesn't name the
+// registry container.
+func (m *Manager) EnsureAssets() (*Assets, error) {
+	dir, err := m.assetsDir()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return nil, fmt.Errorf("failed to create registry assets dir: %w", err)
+	}
+	a := newAssets(dir)
+
+	if m.cfg.TLS {
+		ca, caKey, err := loadOrCreateCA(a.CAFile, filepath.Join(dir, "ca.key"))
+		if err != nil {
+			return nil, err
+		}
+		hosts := []string{"localhost", "127.0.0.1", "::1", m.cfg.GetName()}
+		if !certValid(a.CertFile, ca, hosts) {
+			if err := createServerCert(a.CertFile, a.KeyFile, ca, caKey, hosts); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	if m.cfg.Auth {
+		password, err := os.ReadFile(filepath.Join(dir, "password"))
+		if errors.Is(err, os.ErrNotExist) {
+			password, err = createPassword(filepath.Join(dir, "password"), a.HtpasswdFile)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to set up registry credentials: %w", err)
+		}
+		a.Username, a.Password = RegistryUsername, strings.TrimSpace(string(password))
+	}
+	return a, nil
+}
+
+// Assets returns the assets generated for the registry by EnsureAssets
+func (m *Manager) Assets() (*Assets, error) {
+	dir, err := m.assetsDir()
+	if err != nil {
+		return nil, err
+	}
+	a := newAssets(dir)
+	if m.cfg.TLS {
+		if _, err := os.Stat(a.CAFile); err != nil {
+			return nil, fmt.Errorf("registry CA not found (run 'kindplane up' first): %w", err)
+		}
+	}
+	if m.cfg.Auth {
+		password, err := os.ReadFile(filepath.Join(dir, "password"))
+		if err != nil {
+			return nil, fmt.Errorf("registry credentials not found (run 'kindplane up' first): %w", err)
+		}
+		a.Username, a.Password = RegistryUsername, strings.TrimSpace(string(password))
+	}
+	return a, nil
+}
+
+func newAssets(dir string) *Assets {
+	return &Assets{
+		Dir:          dir,
+		CAFile:       filepath.Join(dir, "ca.crt"),
+		CertFile:     filepath.Join(dir, "tls.crt"),
+		KeyFile:      filepath.Join(dir, "tls.key"),
+		HtpasswdFile: filepath.Join(dir, "htpasswd"),
+	}
+}
+
+// secureRunOptions adds the TLS and htpasswd settings to the registry container
+func (m *Manager) secureRunOptions(opts *container.RunOptions, a *Assets) {
+	opts.Volumes = append(opts.Volumes, a.Dir+":"+assetsMountPath+":ro")
+	if m.cfg.TLS {
+		opts.Env = append(opts.Env,
+			"REGISTRY_HTTP_TLS_CERTIFICATE="+assetsMountPath+"/tls.crt",
+			"REGISTRY_HTTP_TLS_KEY="+assetsMountPath+"/tls.key",
+		)
+	}
+	if m.cfg.Auth {
+		opts.Env = append(opts.Env,
+			"REGISTRY_AUTH=htpasswd",
+			"REGISTRY_AUTH_HTPASSWD_REALM=kindplane",
+			"REGISTRY_AUTH_HTPASSWD_PATH="+assetsMountPath+"/htpasswd",
+		)
+	}
+}
+
+// TrustedCAs returns the registry CA for the registry's host and internal
+// host, to be added to cluster.trustedCAs.registries: nodes then trust it
+// through the usual CA mounts and containerd config, and kindplane's own
+// registry clients reach the registry over HTTPS
+func (m *Manager) TrustedCAs(a *Assets) []config.RegistryCA {
+	if !m.cfg.TLS {
+		return nil
+	}
+	return []config.RegistryCA{
+		{Host: m.GetInternalHost(), CAFile: a.CAFile},
+		{Host: m.GetRegistryHost(), CAFile: a.CAFile},
+	}
+}
+
+// PullSecret returns a docker-registry secret with the registry credentials
+// for both the host and the internal host, or nil without authentication
+func (m *Manager) PullSecret(a *Assets, namespace string) (*corev1.Secret, error) {
+	if !m.cfg.Auth {
+		return nil, nil
+	}
+	token := base64.StdEncoding.EncodeToString([]byte(a.Username + ":" + a.Password))
+	entry := map[string]string{"username": a.Username, "password": a.Password, "auth": token}
+	dockerConfig, err := json.Marshal(map[string]any{
+		"auths": map[string]any{
+			m.GetRegistryHost(): entry,
+			m.GetInternalHost(): entry,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      PullSecretName,
+			Namespace: namespace,
+			Labels:    map[string]string{"app.kubernetes.io/managed-by": "kindplane"},
+		},
+		Type: corev1.SecretTypeDockerConfigJson,
+		Data: map[string][]byte{corev1.DockerConfigJsonKey: dockerConfig},
+	}, nil
+}
+
+// Login stores the registry credentials in the container runtime's config,
+// so image pushes to the registry are authenticated
+func (m *Manager) Login(ctx context.Context, a *Assets) error {
+	if !m.cfg.Auth {
+		return nil
+	}
+	args := []string{"login", "--username", a.Username, "--password-stdin"}
+	// Docker accepts any certificate from localhost registries; Podman needs telling
+	if m.rt.Name() == container.RuntimePodman {
+		args = append(args, "--tls-verify=false")
+	}
+	args = append(args, m.GetRegistryHost())
+	if err := m.rt.Command(ctx, strings.NewReader(a.Password), nil, args...); err != nil {
+		return fmt.Errorf("failed to log in to %s: %w", m.GetRegistryHost(), err)
+	}
+	return nil
+}
+
+// loadOrCreateCA loads the registry CA, creating it if it doesn't exist
+func loadOrCreateCA(certFile, keyFile string) (*x509.Certificate, *ecdsa.PrivateKey, error) {
+	cert, certErr := readCert(certFile)
+	key, keyErr := readKey(keyFile)
+	if certErr == nil && keyErr == nil {
+		return cert, key, nil
+	}
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+	template := &x509.Certificate{
+		SerialNumber:          newSerial(),
+		Subject:               pkix.Name{CommonName: "kindplane registry CA", Organization: []string{"kindplane"}},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(caValidity),
+		KeyUsage:              x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to create registry CA: %w", err)
+	}
+	if err := writeKeyPair(certFile, keyFile, der, key); err != nil {
+		return nil, nil, err
+	}
+	cert, err = x509.ParseCertificate(der)
+	return cert, key, err
+}
+
+// createServerCert issues a server certificate for hosts signed by the CA
+func createServerCert(certFile, keyFile string, ca *x509.Certificate, caKey *ecdsa.PrivateKey, hosts []string) error {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return err
+	}
+	template := &x509.Certificate{
+		SerialNumber: newSerial(),
+		Subject:      pkix.Name{CommonName: hosts[len(hosts)-1], Organization: []string{"kindplane"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(certValidity),
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			template.IPAddresses = append(template.IPAddresses, ip)
+		} else {
+			template.DNSNames = append(template.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	if err != nil {
+		return fmt.Errorf("failed to create registry certificate: %w", err)
+	}
+	return writeKeyPair(certFile, keyFile, der, key)
+}
+
+// certValid reports whether a server certificate exists, was signed by the
+// CA, names every host and isn't close to expiring
+func certValid(certFile string, ca *x509.Certificate, hosts []string) bool {
+	cert, err := readCert(certFile)
+	if err != nil || cert.CheckSignatureFrom(ca) != nil {
+		return false
+	}
+	if time.Until(cert.NotAfter) < certRenewAhead {
+		return false
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			if !slices.ContainsFunc(cert.IPAddresses, ip.Equal) {
+				return false
+			}
+		} else if !slices.Contains(cert.DNSNames, h) {
+			return false
+		}
+	}
+	return true
+}
+
+// createPassword generates a random password and writes it along with its
+// bcrypt htpasswd entry
+func createPassword(passwordFile, htpasswdFile string) ([]byte, error) {
+	buf := make([]byte, 18)
+	if _, err := rand.Read(buf); err != nil {
+		return nil, err
+	}
+	password := []byte(base64.RawURLEncoding.EncodeToString(buf))
+	hash, err := bcrypt.GenerateFromPassword(password, bcrypt.DefaultCost)
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(htpasswdFile, []byte(RegistryUsername+":"+string(hash)+"\n"), 0600); err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(passwordFile, password, 0600); err != nil {
+		return nil, err
+	}
+	return password, nil
+}
+
+func newSerial() *big.Int {
+	serial, _ := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 127))
+	return serial
+}
+
+func writeKeyPair(certFile, keyFile string, der []byte, key *ecdsa.PrivateKey) error {
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600); err != nil {
+		return err
+	}
+	return os.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+}
+
+func readCert(path string) (*x509.Certificate, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no certificate in %s", path)
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func readKey(path string) (*ecdsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("no key in %s", path)
+	}
+	return x509.ParseECPrivateKey(block.Bytes)
+}
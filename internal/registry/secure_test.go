@@ -0,0 +1,247 @@
+package registry
+
+import (
+	"context"
+	"crypto/x509"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang.org/x/crypto/bcrypt"
+	corev1 "k8s.io/api/core/v1"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+)
+
+func newSecureTestManager(t *testing.T, rt container.Runtime) *Manager {
+	t.Helper()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	return NewManagerWithRuntime(&config.RegistryConfig{Enabled: true, Port: 5050, Name: "test-registry", TLS: true, Auth: true}, rt)
+}
+
+// TestEnsureAssets tests the generated CA, server certificate and credentials
+func TestEnsureAssets(t *testing.T) {
+	m := newSecureTestManager(t, container.NewFake())
+
+	a, err := m.EnsureAssets()
+	if err != nil {
+		t.Fatalf("EnsureAssets() error = %v", err)
+	}
+
+	ca, err := readCert(a.CAFile)
+	if err != nil {
+		t.Fatalf("failed to read CA: %v", err)
+	}
+	cert, err := readCert(a.CertFile)
+	if err != nil {
+		t.Fatalf("failed to read server certificate: %v", err)
+	}
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	for _, host := range []string{"localhost", "127.0.0.1", "test-registry"} {
+		if _, err := cert.Verify(x509.VerifyOptions{DNSName: host, Roots: roots}); err != nil {
+			t.Errorf("certificate does not verify for %s: %v", host, err)
+		}
+	}
+
+	if a.Username != RegistryUsername || a.Password == "" {
+		t.Fatalf("credentials = %q/%q, want %s and a password", a.Username, a.Password, RegistryUsername)
+	}
+	htpasswd, err := os.ReadFile(a.HtpasswdFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	user, hash, _ := strings.Cut(strings.TrimSpace(string(htpasswd)), ":")
+	if user != RegistryUsername || bcrypt.CompareHashAndPassword([]byte(hash), []byte(a.Password)) != nil {
+		t.Errorf("htpasswd %q does not match the password", htpasswd)
+	}
+	if info, err := os.Stat(a.KeyFile); err != nil || info.Mode().Perm() != 0600 {
+		t.Errorf("expected a private key readable only by the owner, got %v, %v", info, err)
+	}
+
+	again, err := m.EnsureAssets()
+	if err != nil {
+		t.Fatalf("second EnsureAssets() error = %v", err)
+	}
+	if again.Password != a.Password {
+		t.Error("expected the password to be reused")
+	}
+	if ca2, _ := readCert(again.CAFile); ca2 == nil || !ca2.Equal(ca) {
+		t.Error("expected the CA to be reused")
+	}
+}
+
+// TestAssets_NotGenerated tests that reading assets before up fails clearly
+func TestAssets_NotGenerated(t *testing.T) {
+	m := newSecureTestManager(t, container.NewFake())
+
+	if _, err := m.Assets(); err == nil || !strings.Contains(err.Error(), "kindplane up") {
+		t.Errorf("Assets() error = %v, want a hint to run kindplane up", err)
+	}
+}
+
+// TestCreate_Secured tests that a secured registry is started with TLS and htpasswd
+func TestCreate_Secured(t *testing.T) {
+	rt := container.NewFake()
+	m := newSecureTestManager(t, rt)
+
+	if err := m.Create(context.Background()); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	a, err := m.Assets()
+	if err != nil {
+		t.Fatalf("Assets() error = %v", err)
+	}
+	for _, want := range []string{
+		"-v " + a.Dir + ":/kindplane:ro",
+		"-e REGISTRY_HTTP_TLS_CERTIFICATE=/kindplane/tls.crt",
+		"-e REGISTRY_HTTP_TLS_KEY=/kindplane/tls.key",
+		"-e REGISTRY_AUTH=htpasswd",
+		"-e REGISTRY_AUTH_HTPASSWD_PATH=/kindplane/htpasswd",
+	} {
+		found := false
+		for _, c := range rt.Calls {
+			if strings.HasPrefix(c, "RunContainer") && strings.Contains(c, want) {
+				found = true
+			}
+		}
+		if !found {
+			t.Errorf("expected %q in the registry container, calls: %v", want, rt.Calls)
+		}
+	}
+}
+
+// TestCreate_SecuredChanges tests that a registry is recreated when TLS is
+// turned on or its certificate is renewed, and reused otherwise
+func TestCreate_SecuredChanges(t *testing.T) {
+	rt := container.NewFake()
+	t.Setenv("XDG_STATE_HOME", t.TempDir())
+	cfg := &config.RegistryConfig{Enabled: true, Port: 5050, Name: "test-registry"}
+	m := NewManagerWithRuntime(cfg, rt)
+	ctx := context.Background()
+
+	recreated := func(step string) bool {
+		t.Helper()
+		rt.Calls = nil
+		if err := m.Create(ctx); err != nil {
+			t.Fatalf("%s: Create() error = %v", step, err)
+		}
+		return rt.Called("RemoveContainer test-registry")
+	}
+
+	if err := m.Create(ctx); err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	cfg.TLS, cfg.Auth = true, true
+	if !recreated("tls and auth turned on") {
+		t.Error("expected the plain HTTP registry to be recreated")
+	}
+	if recreated("unchanged") {
+		t.Error("expected an unchanged registry to be reused")
+	}
+
+	a, err := m.Assets()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(a.CertFile); err != nil {
+		t.Fatal(err)
+	}
+	if !recreated("certificate renewed") {
+		t.Error("expected the registry to be recreated with the new certificate")
+	}
+}
+
+// TestConfigureNode_TLS tests that nodes reach a TLS registry over HTTPS with its CA
+func TestConfigureNode_TLS(t *testing.T) {
+	rt := container.NewFake()
+	var written string
+	rt.ExecFunc = func(node string, stdin []byte, cmd ...string) ([]byte, error) {
+		if len(stdin) > 0 {
+			written = string(stdin)
+		}
+		return nil, nil
+	}
+	m := newSecureTestManager(t, rt)
+
+	if err := m.configureNode(context.Background(), "test-control-plane"); err != nil {
+		t.Fatalf("configureNode() error = %v", err)
+	}
+	want := "[host.\"https://test-registry:5000\"]\n  ca = \"/etc/containerd/certs.d/test-registry:5000/ca.crt\"\n"
+	if written != want {
+		t.Errorf("hosts.toml = %q, want %q", written, want)
+	}
+}
+
+// TestPullSecret tests the docker config of the registry pull secret
+func TestPullSecret(t *testing.T) {
+	m := newSecureTestManager(t, container.NewFake())
+	a := &Assets{Username: RegistryUsername, Password: "s3cret"}
+
+	secret, err := m.PullSecret(a, "crossplane-system")
+	if err != nil {
+		t.Fatalf("PullSecret() error = %v", err)
+	}
+	if secret.Name != PullSecretName || secret.Namespace != "crossplane-system" || secret.Type != corev1.SecretTypeDockerConfigJson {
+		t.Errorf("unexpected secret %s/%s of type %s", secret.Namespace, secret.Name, secret.Type)
+	}
+
+	var dockerConfig struct {
+		Auths map[string]struct {
+			Username string `json:"username"`
+			Password string `json:"password"`
+			Auth     string `json:"auth"`
+		} `json:"auths"`
+	}
+	if err := json.Unmarshal(secret.Data[corev1.DockerConfigJsonKey], &dockerConfig); err != nil {
+		t.Fatalf("invalid docker config: %v", err)
+	}
+	for _, host := range []string{"localhost:5050", "test-registry:5000"} {
+		entry, ok := dockerConfig.Auths[host]
+		if !ok {
+			t.Errorf("expected credentials for %s, got %v", host, dockerConfig.Auths)
+			continue
+		}
+		if entry.Password != "s3cret" || entry.Auth != "a2luZHBsYW5lOnMzY3JldA==" {
+			t.Errorf("credentials for %s = %+v", host, entry)
+		}
+	}
+
+	m.cfg.Auth = false
+	if secret, _ := m.PullSecret(a, "crossplane-system"); secret != nil {
+		t.Error("expected no pull secret without authentication")
+	}
+}
+
+// TestTrustedCAs tests the CA entries added for a TLS registry
+func TestTrustedCAs(t *testing.T) {
+	m := newSecureTestManager(t, container.NewFake())
+	a := newAssets(filepath.Join(t.TempDir(), "test-registry"))
+
+	cas := m.TrustedCAs(a)
+	if len(cas) != 2 || cas[0].Host != "test-registry:5000" || cas[1].Host != "localhost:5050" || cas[0].CAFile != a.CAFile {
+		t.Errorf("TrustedCAs() = %+v", cas)
+	}
+
+	m.cfg.TLS = false
+	if cas := m.TrustedCAs(a); cas != nil {
+		t.Errorf("TrustedCAs() without TLS = %+v, want nil", cas)
+	}
+}
+
+// TestLogin tests that the runtime logs in with the password on stdin
+func TestLogin(t *testing.T) {
+	rt := container.NewFake()
+	m := newSecureTestManager(t, rt)
+
+	if err := m.Login(context.Background(), &Assets{Username: RegistryUsername, Password: "s3cret"}); err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if !rt.Called("Command login --username kindplane --password-stdin localhost:5050") {
+		t.Errorf("expected a runtime login, calls: %v", rt.Calls)
+	}
+}
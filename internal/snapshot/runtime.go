@@ -0,0 +1,484 @@
+package snapshot
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"k8s.io/apimachinery/pkg/util/wait"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/container"
+	"github.com/kanzi/kindplane/internal/kind"
+)
+
+const (
+	// kindRoleLabel is the label Kind sets on node containers to record their role
+	kindRoleLabel = "io.x-k8s.kind.role"
+
+	// defaultNetwork is the container network Kind attaches nodes to
+	defaultNetwork = "kind"
+
+	// apiReadyTimeout bounds how long Restore waits for the API server
+	apiReadyTimeout = 3 * time.Minute
+)
+
+// inspectResult is the subset of `docker inspect` output needed to recreate a node
+type inspectResult struct {
+	Name   string `json:"Name"`
+	Config struct {
+		Hostname string            `json:"Hostname"`
+		Labels   map[string]string `json:"Labels"`
+	} `json:"Config"`
+	HostConfig struct {
+		Privileged    bool              `json:"Privileged"`
+		SecurityOpt   []string          `json:"SecurityOpt"`
+		Tmpfs         map[string]string `json:"Tmpfs"`
+		Binds         []string          `json:"Binds"`
+		CgroupnsMode  string            `json:"CgroupnsMode"`
+		RestartPolicy struct {
+			Name              string `json:"Name"`
+			MaximumRetryCount int    `json:"MaximumRetryCount"`
+		} `json:"RestartPolicy"`
+		Devices []struct {
+			PathOnHost        string `json:"PathOnHost"`
+			PathInContainer   string `json:"PathInContainer"`
+			CgroupPermissions string `json:"CgroupPermissions"`
+		} `json:"Devices"`
+		PortBindings map[string][]struct {
+			HostIP   string `json:"HostIp"`
+			HostPort string `json:"HostPort"`
+		} `json:"PortBindings"`
+	} `json:"HostConfig"`
+	NetworkSettings struct {
+		Networks map[string]struct{} `json:"Networks"`
+	} `json:"NetworkSettings"`
+}
+
+// Save captures the state of every node of the configured cluster. The nodes
+// are stopped while their root filesystem is committed to a local image and
+// their /var volume (etcd data and the containerd image store) is archived,
+// then started again.
+func Save(ctx context.Context, store *Store, cfg *config.Config, name string, logFn func(string)) (*Metadata, error) {
+	if logFn == nil {
+		logFn = func(string) {}
+	}
+	if err := ValidateName(name); err != nil {
+		return nil, err
+	}
+
+	clusterName := cfg.Cluster.Name
+	if _, err := store.Get(clusterName, name); err == nil {
+		return nil, fmt.Errorf("snapshot %q already exists for cluster %s", name, clusterName)
+	}
+
+	hash, err := ConfigHash(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	containers, err := kind.GetNodeContainers(clusterName)
+	if err != nil {
+		return nil, err
+	}
+	if len(containers) == 0 {
+		return nil, fmt.Errorf("no running nodes found for cluster %s", clusterName)
+	}
+
+	meta := &Metadata{
+		Name:              name,
+		Cluster:           clusterName,
+		ConfigHash:        hash,
+		KubernetesVersion: cfg.Cluster.KubernetesVersion,
+		CreatedAt:         time.Now(),
+	}
+	for _, container := range containers {
+		node, err := inspectNode(ctx, container)
+		if err != nil {
+			return nil, err
+		}
+		node.Image = ImageRef(container, name)
+		node.VarArchive = container + ".var.tar.gz"
+		meta.Nodes = append(meta.Nodes, node)
+	}
+	sortNodes(meta.Nodes)
+
+	dir := store.Dir(clusterName, name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	// Stop workers before control planes so etcd is the last thing to go down
+	logFn("Stopping cluster nodes...")
+	stopOrder := make([]string, 0, len(meta.Nodes))
+	for i := len(meta.Nodes) - 1; i >= 0; i-- {
+		stopOrder = append(stopOrder, meta.Nodes[i].Container)
+	}
+	if err := engine(ctx, nil, nil, append([]string{"stop"}, stopOrder...)...); err != nil {
+		return nil, fmt.Errorf("failed to stop cluster nodes: %w", err)
+	}
+
+	saveErr := func() error {
+		for _, node := range meta.Nodes {
+			logFn(fmt.Sprintf("Committing %s...", node.Container))
+			if err := engine(ctx, nil, nil, "commit", node.Container, node.Image); err != nil {
+				return fmt.Errorf("failed to commit node %s: %w", node.Container, err)
+			}
+
+			logFn(fmt.Sprintf("Archiving /var of %s...", node.Container))
+			if err := exportVar(ctx, node.Container, filepath.Join(dir, node.VarArchive)); err != nil {
+				return fmt.Errorf("failed to archive node %s: %w", node.Container, err)
+			}
+		}
+		return store.Save(meta)
+	}()
+
+	logFn("Starting cluster nodes...")
+	startErr := startNodes(ctx, meta.Nodes)
+
+	if saveErr != nil {
+		_ = os.RemoveAll(dir)
+		removeImages(ctx, meta)
+		return nil, saveErr
+	}
+	if startErr != nil {
+		return meta, fmt.Errorf("snapshot saved but failed to restart nodes: %w", startErr)
+	}
+	return meta, nil
+}
+
+// Restore recreates the node containers of a snapshot and waits for the API
+// server to become ready. The cluster must not exist. When restoring fails,
+// the containers it created are removed again, so the cluster can be
+// restored again or created from scratch. Exporting the kubeconfig is left
+// to the caller.
+func Restore(ctx context.Context, store *Store, meta *Metadata, logFn func(string)) (err error) {
+	if logFn == nil {
+		logFn = func(string) {}
+	}
+
+	exists, err := kind.ClusterExists(meta.Cluster)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return fmt.Errorf("cluster %s already exists; delete it before restoring", meta.Cluster)
+	}
+
+	for _, node := range meta.Nodes {
+		if err := engine(ctx, nil, nil, "image", "inspect", node.Image); err != nil {
+			return fmt.Errorf("snapshot image %s is missing", node.Image)
+		}
+	}
+
+	if err := ensureNetwork(ctx, meta.Nodes); err != nil {
+		return err
+	}
+
+	var created []string
+	defer func() {
+		if err != nil && len(created) > 0 {
+			removeNodes(context.WithoutCancel(ctx), created)
+		}
+	}()
+
+	dir := store.Dir(meta.Cluster, meta.Name)
+	for _, node := range meta.Nodes {
+		logFn(fmt.Sprintf("Creating %s...", node.Container))
+		if err := engine(ctx, nil, nil, createArgs(node)...); err != nil {
+			return fmt.Errorf("failed to create node %s: %w", node.Container, err)
+		}
+		created = append(created, node.Container)
+
+		logFn(fmt.Sprintf("Restoring /var of %s...", node.Container))
+		if err := importVar(ctx, node.Container, filepath.Join(dir, node.VarArchive)); err != nil {
+			return fmt.Errorf("failed to restore node %s: %w", node.Container, err)
+		}
+	}
+
+	logFn("Starting cluster nodes...")
+	if err := startNodes(ctx, meta.Nodes); err != nil {
+		return err
+	}
+
+	logFn("Waiting for API server...")
+	return waitForAPIServer(ctx, meta.Cluster)
+}
+
+// Remove deletes a snapshot's images and files
+func Remove(ctx context.Context, store *Store, meta *Metadata) error {
+	removeImages(ctx, meta)
+	return store.Remove(meta.Cluster, meta.Name)
+}
+
+// inspectNode reads the settings of a node container
+func inspectNode(ctx context.Context, container string) (NodeSnapshot, error) {
+	var out bytes.Buffer
+	if err := engine(ctx, nil, &out, "inspect", container); err != nil {
+		return NodeSnapshot{}, fmt.Errorf("failed to inspect node %s: %w", container, err)
+	}
+	return parseInspect(out.Bytes())
+}
+
+// parseInspect converts `docker inspect` output for a single container
+func parseInspect(data []byte) (NodeSnapshot, error) {
+	var results []inspectResult
+	if err := json.Unmarshal(data, &results); err != nil {
+		return NodeSnapshot{}, fmt.Errorf("failed to parse inspect output: %w", err)
+	}
+	if len(results) != 1 {
+		return NodeSnapshot{}, fmt.Errorf("expected 1 inspect result, got %d", len(results))
+	}
+	r := results[0]
+
+	node := NodeSnapshot{
+		Container:    strings.TrimPrefix(r.Name, "/"),
+		Role:         r.Config.Labels[kindRoleLabel],
+		Hostname:     r.Config.Hostname,
+		Labels:       r.Config.Labels,
+		Privileged:   r.HostConfig.Privileged,
+		SecurityOpt:  r.HostConfig.SecurityOpt,
+		Tmpfs:        r.HostConfig.Tmpfs,
+		Binds:        r.HostConfig.Binds,
+		CgroupnsMode: r.HostConfig.CgroupnsMode,
+	}
+
+	if policy := r.HostConfig.RestartPolicy; policy.Name != "" && policy.Name != "no" {
+		node.Restart = policy.Name
+		if policy.MaximumRetryCount > 0 {
+			node.Restart = fmt.Sprintf("%s:%d", policy.Name, policy.MaximumRetryCount)
+		}
+	}
+
+	for _, d := range r.HostConfig.Devices {
+		device := d.PathOnHost
+		if d.PathInContainer != "" {
+			device += ":" + d.PathInContainer
+		}
+		if d.CgroupPermissions != "" {
+			device += ":" + d.CgroupPermissions
+		}
+		node.Devices = append(node.Devices, device)
+	}
+
+	for port, bindings := range r.HostConfig.PortBindings {
+		containerPort, protocol, _ := strings.Cut(port, "/")
+		if protocol == "" {
+			protocol = "tcp"
+		}
+		for _, b := range bindings {
+			node.Ports = append(node.Ports, PortBinding{
+				ContainerPort: containerPort,
+				Protocol:      protocol,
+				HostIP:        b.HostIP,
+				HostPort:      b.HostPort,
+			})
+		}
+	}
+	sort.Slice(node.Ports, func(i, j int) bool {
+		if node.Ports[i].ContainerPort != node.Ports[j].ContainerPort {
+			return node.Ports[i].ContainerPort < node.Ports[j].ContainerPort
+		}
+		return node.Ports[i].Protocol < node.Ports[j].Protocol
+	})
+
+	networks := make([]string, 0, len(r.NetworkSettings.Networks))
+	for n := range r.NetworkSettings.Networks {
+		networks = append(networks, n)
+	}
+	sort.Strings(networks)
+	if _, ok := r.NetworkSettings.Networks[defaultNetwork]; ok {
+		node.Network = defaultNetwork
+	} else if len(networks) > 0 {
+		node.Network = networks[0]
+	}
+
+	return node, nil
+}
+
+// createArgs builds the `docker create` arguments that recreate a node from
+// its committed image with the same runtime settings Kind used originally
+func createArgs(node NodeSnapshot) []string {
+	args := []string{"create", "--name", node.Container, "--hostname", node.Hostname}
+
+	labelKeys := make([]string, 0, len(node.Labels))
+	for k := range node.Labels {
+		labelKeys = append(labelKeys, k)
+	}
+	sort.Strings(labelKeys)
+	for _, k := range labelKeys {
+		args = append(args, "--label", fmt.Sprintf("%s=%s", k, node.Labels[k]))
+	}
+
+	if node.Privileged {
+		args = append(args, "--privileged")
+	}
+	for _, opt := range node.SecurityOpt {
+		args = append(args, "--security-opt", opt)
+	}
+
+	tmpfsPaths := make([]string, 0, len(node.Tmpfs))
+	for p := range node.Tmpfs {
+		tmpfsPaths = append(tmpfsPaths, p)
+	}
+	sort.Strings(tmpfsPaths)
+	for _, p := range tmpfsPaths {
+		if opts := node.Tmpfs[p]; opts != "" {
+			args = append(args, "--tmpfs", p+":"+opts)
+		} else {
+			args = append(args, "--tmpfs", p)
+		}
+	}
+
+	// /var is an anonymous volume on Kind nodes; its content comes from the archive
+	args = append(args, "--volume", "/var")
+	for _, bind := range node.Binds {
+		args = append(args, "--volume", bind)
+	}
+	for _, device := range node.Devices {
+		args = append(args, "--device", device)
+	}
+	if node.CgroupnsMode != "" {
+		args = append(args, "--cgroupns", node.CgroupnsMode)
+	}
+	if node.Restart != "" {
+		args = append(args, "--restart", node.Restart)
+	}
+	if node.Network != "" {
+		args = append(args, "--network", node.Network)
+	}
+	for _, p := range node.Ports {
+		publish := fmt.Sprintf("%s:%s/%s", p.HostPort, p.ContainerPort, p.Protocol)
+		if p.HostIP != "" {
+			publish = p.HostIP + ":" + publish
+		}
+		args = append(args, "--publish", publish)
+	}
+
+	return append(args, node.Image)
+}
+
+// sortNodes orders control plane nodes before workers, then by name
+func sortNodes(nodes []NodeSnapshot) {
+	sort.SliceStable(nodes, func(i, j int) bool {
+		if nodes[i].IsControlPlane() != nodes[j].IsControlPlane() {
+			return nodes[i].IsControlPlane()
+		}
+		return nodes[i].Container < nodes[j].Container
+	})
+}
+
+// startNodes starts nodes in order, control planes first
+func startNodes(ctx context.Context, nodes []NodeSnapshot) error {
+	names := make([]string, 0, len(nodes))
+	for _, n := range nodes {
+		names = append(names, n.Container)
+	}
+	if err := engine(ctx, nil, nil, append([]string{"start"}, names...)...); err != nil {
+		return fmt.Errorf("failed to start cluster nodes: %w", err)
+	}
+	return nil
+}
+
+// removeNodes force-removes node containers and their /var volumes, ignoring
+// errors
+func removeNodes(ctx context.Context, containers []string) {
+	_ = engine(ctx, nil, nil, append([]string{"rm", "-f", "-v"}, containers...)...)
+}
+
+// exportVar streams the /var volume of a stopped container into a gzipped tarball
+func exportVar(ctx context.Context, container, path string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zw, err := gzip.NewWriterLevel(f, gzip.BestSpeed)
+	if err != nil {
+		return err
+	}
+	if err := engine(ctx, nil, zw, "cp", container+":/var", "-"); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// importVar extracts a /var archive produced by exportVar into a created container
+func importVar(ctx context.Context, container, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	zr, err := gzip.NewReader(f)
+	if err != nil {
+		return fmt.Errorf("failed to read archive %s: %w", path, err)
+	}
+	defer zr.Close()
+
+	return engine(ctx, zr, nil, "cp", "-", container+":/")
+}
+
+// ensureNetwork creates the nodes' network if it was removed since the snapshot
+func ensureNetwork(ctx context.Context, nodes []NodeSnapshot) error {
+	seen := map[string]bool{}
+	for _, node := range nodes {
+		if node.Network == "" || seen[node.Network] {
+			continue
+		}
+		seen[node.Network] = true
+		if err := engine(ctx, nil, nil, "network", "inspect", node.Network); err == nil {
+			continue
+		}
+		args := []string{"network", "create", "--driver", "bridge"}
+		// Podman rejects Docker's bridge driver options
+		if container.Default().Name() == container.RuntimeDocker {
+			args = append(args, "--opt", "com.docker.network.bridge.enable_ip_masquerade=true")
+		}
+		if err := engine(ctx, nil, nil, append(args, node.Network)...); err != nil {
+			return fmt.Errorf("failed to create network %s: %w", node.Network, err)
+		}
+	}
+	return nil
+}
+
+// removeImages deletes the committed node images, ignoring missing ones
+func removeImages(ctx context.Context, meta *Metadata) {
+	for _, node := range meta.Nodes {
+		_ = engine(ctx, nil, nil, "rmi", node.Image)
+	}
+}
+
+// waitForAPIServer polls the restored cluster until the API server reports ready
+func waitForAPIServer(ctx context.Context, clusterName string) error {
+	return wait.PollUntilContextTimeout(ctx, 2*time.Second, apiReadyTimeout, true, func(ctx context.Context) (bool, error) {
+		client, err := kind.GetKubeClient(clusterName)
+		if err != nil {
+			return false, nil
+		}
+		body, err := client.Discovery().RESTClient().Get().AbsPath("/readyz").DoRaw(ctx)
+		if err != nil {
+			return false, nil
+		}
+		return string(body) == "ok", nil
+	})
+}
+
+// engine runs a container runtime command, optionally wiring stdin and
+// stdout. Stderr is included in the returned error.
+func engine(ctx context.Context, stdin io.Reader, stdout io.Writer, args ...string) error {
+	return container.Default().Command(ctx, stdin, stdout, args...)
+}
@@ -0,0 +1,231 @@
+package snapshot
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/kanzi/kindplane/internal/config"
+	"github.com/kanzi/kindplane/internal/state"
+)
+
+const (
+	// MetadataFileName is the name of the metadata file stored with each snapshot
+	MetadataFileName = "metadata.json"
+
+	// ImageRepository is the local image repository prefix used for committed node images
+	ImageRepository = "kindplane-snapshot"
+)
+
+// ErrNotFound is returned when a snapshot does not exist
+var ErrNotFound = errors.New("snapshot not found")
+
+// validName matches names that are usable both as directory names and image tags
+var validName = regexp.MustCompile(`^[a-zA-Z0-9_][a-zA-Z0-9_.-]{0,127}$`)
+
+// Metadata describes a saved cluster snapshot
+type Metadata struct {
+	Name              string         `json:"name"`
+	Cluster           string         `json:"cluster"`
+	ConfigHash        string         `json:"configHash"`
+	KubernetesVersion string         `json:"kubernetesVersion,omitempty"`
+	CreatedAt         time.Time      `json:"createdAt"`
+	Nodes             []NodeSnapshot `json:"nodes"`
+}
+
+// NodeSnapshot holds everything needed to recreate a single node container
+type NodeSnapshot struct {
+	Container    string            `json:"container"`
+	Role         string            `json:"role"`
+	Image        string            `json:"image"`
+	VarArchive   string            `json:"varArchive"`
+	Hostname     string            `json:"hostname"`
+	Network      string            `json:"network"`
+	Labels       map[string]string `json:"labels,omitempty"`
+	Privileged   bool              `json:"privileged"`
+	SecurityOpt  []string          `json:"securityOpt,omitempty"`
+	Tmpfs        map[string]string `json:"tmpfs,omitempty"`
+	Binds        []string          `json:"binds,omitempty"`
+	Devices      []string          `json:"devices,omitempty"`
+	CgroupnsMode string            `json:"cgroupnsMode,omitempty"`
+	Restart      string            `json:"restart,omitempty"`
+	Ports        []PortBinding     `json:"ports,omitempty"`
+}
+
+// PortBinding is a published container port
+type PortBinding struct {
+	ContainerPort string `json:"containerPort"`
+	Protocol      string `json:"protocol"`
+	HostIP        string `json:"hostIP,omitempty"`
+	HostPort      string `json:"hostPort"`
+}
+
+// IsControlPlane reports whether the node is a control plane node
+func (n NodeSnapshot) IsControlPlane() bool {
+	return n.Role == "control-plane"
+}
+
+// ValidateName checks that a snapshot name can be used as an image tag
+func ValidateName(name string) error {
+	if !validName.MatchString(name) {
+		return fmt.Errorf("invalid snapshot name %q: use letters, digits, '_', '.' or '-' (max 128 characters)", name)
+	}
+	return nil
+}
+
+// ConfigHash returns a stable hash of the configuration. The configuration is
+// re-serialised first, so comments and formatting in kindplane.yaml do not
+// affect the result.
+func ConfigHash(cfg *config.Config) (string, error) {
+	data, err := yaml.Marshal(cfg)
+	if err != nil {
+		return "", fmt.Errorf("failed to serialise config: %w", err)
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// ImageRef returns the image reference used for a node's committed filesystem
+func ImageRef(container, snapshotName string) string {
+	return fmt.Sprintf("%s/%s:%s", ImageRepository, container, snapshotName)
+}
+
+// Store persists snapshot metadata and archives on disk.
+// Snapshots are laid out as <root>/<cluster>/<name>/.
+type Store struct {
+	root string
+}
+
+// NewStore creates a store rooted in the kindplane state directory
+func NewStore() (*Store, error) {
+	dir, err := state.Dir()
+	if err != nil {
+		return nil, fmt.Errorf("failed to determine state directory: %w", err)
+	}
+	return NewStoreAt(filepath.Join(dir, "snapshots")), nil
+}
+
+// NewStoreAt creates a store rooted at the given directory
+func NewStoreAt(root string) *Store {
+	return &Store{root: root}
+}
+
+// Dir returns the directory holding a snapshot's files
+func (s *Store) Dir(cluster, name string) string {
+	return filepath.Join(s.root, cluster, name)
+}
+
+// Save writes the snapshot metadata
+func (s *Store) Save(meta *Metadata) error {
+	dir := s.Dir(meta.Cluster, meta.Name)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshot metadata: %w", err)
+	}
+
+	return os.WriteFile(filepath.Join(dir, MetadataFileName), data, 0644)
+}
+
+// Get loads the metadata of a single snapshot
+func (s *Store) Get(cluster, name string) (*Metadata, error) {
+	data, err := os.ReadFile(filepath.Join(s.Dir(cluster, name), MetadataFileName))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, fmt.Errorf("%w: %s/%s", ErrNotFound, cluster, name)
+		}
+		return nil, fmt.Errorf("failed to read snapshot metadata: %w", err)
+	}
+
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil {
+		return nil, fmt.Errorf("failed to parse snapshot metadata: %w", err)
+	}
+	return &meta, nil
+}
+
+// List returns all snapshots, optionally restricted to a single cluster,
+// newest first. Directories without readable metadata are ignored.
+func (s *Store) List(cluster string) ([]*Metadata, error) {
+	clusters := []string{cluster}
+	if cluster == "" {
+		entries, err := os.ReadDir(s.root)
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil, nil
+			}
+			return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+		}
+		clusters = clusters[:0]
+		for _, e := range entries {
+			if e.IsDir() {
+				clusters = append(clusters, e.Name())
+			}
+		}
+	}
+
+	var snapshots []*Metadata
+	for _, c := range clusters {
+		entries, err := os.ReadDir(filepath.Join(s.root, c))
+		if err != nil {
+			if os.IsNotExist(err) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to read snapshot directory: %w", err)
+		}
+		for _, e := range entries {
+			if !e.IsDir() {
+				continue
+			}
+			meta, err := s.Get(c, e.Name())
+			if err != nil {
+				continue
+			}
+			snapshots = append(snapshots, meta)
+		}
+	}
+
+	sort.Slice(snapshots, func(i, j int) bool {
+		return snapshots[i].CreatedAt.After(snapshots[j].CreatedAt)
+	})
+	return snapshots, nil
+}
+
+// FindMatching returns the newest snapshot of the cluster whose config hash
+// matches, or nil when there is none.
+func (s *Store) FindMatching(cluster, configHash string) (*Metadata, error) {
+	snapshots, err := s.List(cluster)
+	if err != nil {
+		return nil, err
+	}
+	for _, meta := range snapshots {
+		if meta.ConfigHash == configHash {
+			return meta, nil
+		}
+	}
+	return nil, nil
+}
+
+// Remove deletes a snapshot's metadata and archives
+func (s *Store) Remove(cluster, name string) error {
+	dir := s.Dir(cluster, name)
+	if _, err := os.Stat(dir); err != nil {
+		if os.IsNotExist(err) {
+			return fmt.Errorf("%w: %s/%s", ErrNotFound, cluster, name)
+		}
+		return err
+	}
+	return os.RemoveAll(dir)
+}
@@ -0,0 +1,206 @@
+package snapshot
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/kanzi/kindplane/internal/config"
+)
+
+func TestValidateName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{name: "simple", input: "baseline", wantErr: false},
+		{name: "with dots and dashes", input: "v1.2-after_providers", wantErr: false},
+		{name: "empty", input: "", wantErr: true},
+		{name: "leading dash", input: "-bad", wantErr: true},
+		{name: "slash", input: "a/b", wantErr: true},
+		{name: "too long", input: strings.Repeat("a", 129), wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateName(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Errorf("ValidateName(%q) error = %v, wantErr %v", tt.input, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestConfigHash(t *testing.T) {
+	cfgA := &config.Config{Cluster: config.ClusterConfig{Name: "dev", KubernetesVersion: "1.31.0"}}
+	cfgB := &config.Config{Cluster: config.ClusterConfig{Name: "dev", KubernetesVersion: "1.31.0"}}
+	cfgC := &config.Config{Cluster: config.ClusterConfig{Name: "dev", KubernetesVersion: "1.32.0"}}
+
+	hashA, err := ConfigHash(cfgA)
+	if err != nil {
+		t.Fatalf("ConfigHash failed: %v", err)
+	}
+	hashB, _ := ConfigHash(cfgB)
+	hashC, _ := ConfigHash(cfgC)
+
+	if hashA != hashB {
+		t.Errorf("expected equal configs to hash equally, got %s and %s", hashA, hashB)
+	}
+	if hashA == hashC {
+		t.Error("expected different configs to hash differently")
+	}
+	if len(hashA) != 64 {
+		t.Errorf("expected sha256 hex digest, got %q", hashA)
+	}
+}
+
+func TestStore(t *testing.T) {
+	store := NewStoreAt(t.TempDir())
+
+	older := &Metadata{Name: "older", Cluster: "dev", ConfigHash: "aaa", CreatedAt: time.Now().Add(-time.Hour)}
+	newer := &Metadata{Name: "newer", Cluster: "dev", ConfigHash: "aaa", CreatedAt: time.Now()}
+	other := &Metadata{Name: "other", Cluster: "prod", ConfigHash: "bbb", CreatedAt: time.Now()}
+	for _, m := range []*Metadata{older, newer, other} {
+		if err := store.Save(m); err != nil {
+			t.Fatalf("Save failed: %v", err)
+		}
+	}
+
+	all, err := store.List("")
+	if err != nil {
+		t.Fatalf("List failed: %v", err)
+	}
+	if len(all) != 3 {
+		t.Fatalf("expected 3 snapshots, got %d", len(all))
+	}
+
+	dev, _ := store.List("dev")
+	if len(dev) != 2 || dev[0].Name != "newer" {
+		t.Errorf("expected dev snapshots newest first, got %+v", dev)
+	}
+
+	match, err := store.FindMatching("dev", "aaa")
+	if err != nil || match == nil || match.Name != "newer" {
+		t.Errorf("expected newest matching snapshot, got %+v (err %v)", match, err)
+	}
+	if match, _ := store.FindMatching("dev", "bbb"); match != nil {
+		t.Errorf("expected no match, got %+v", match)
+	}
+
+	if err := store.Remove("dev", "newer"); err != nil {
+		t.Fatalf("Remove failed: %v", err)
+	}
+	if _, err := store.Get("dev", "newer"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound after removal, got %v", err)
+	}
+	if err := store.Remove("dev", "newer"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("expected ErrNotFound removing twice, got %v", err)
+	}
+}
+
+func TestStore_ListMissingRoot(t *testing.T) {
+	store := NewStoreAt(t.TempDir() + "/does-not-exist")
+	snapshots, err := store.List("")
+	if err != nil {
+		t.Fatalf("expected no error for missing root, got %v", err)
+	}
+	if len(snapshots) != 0 {
+		t.Errorf("expected no snapshots, got %d", len(snapshots))
+	}
+}
+
+const inspectFixture = `[{
+  "Name": "/dev-control-plane",
+  "Config": {
+    "Hostname": "dev-control-plane",
+    "Labels": {"io.x-k8s.kind.cluster": "dev", "io.x-k8s.kind.role": "control-plane"}
+  },
+  "HostConfig": {
+    "Privileged": true,
+    "SecurityOpt": ["seccomp=unconfined", "apparmor=unconfined"],
+    "Tmpfs": {"/run": "", "/tmp": ""},
+    "Binds": ["/lib/modules:/lib/modules:ro"],
+    "CgroupnsMode": "private",
+    "RestartPolicy": {"Name": "on-failure", "MaximumRetryCount": 1},
+    "Devices": [{"PathOnHost": "/dev/fuse", "PathInContainer": "/dev/fuse", "CgroupPermissions": "rwm"}],
+    "PortBindings": {
+      "6443/tcp": [{"HostIp": "127.0.0.1", "HostPort": "40123"}],
+      "80/tcp": [{"HostIp": "", "HostPort": "8080"}]
+    }
+  },
+  "NetworkSettings": {"Networks": {"kind": {"IPAddress": "172.18.0.2"}}}
+}]`
+
+func TestParseInspect(t *testing.T) {
+	node, err := parseInspect([]byte(inspectFixture))
+	if err != nil {
+		t.Fatalf("parseInspect failed: %v", err)
+	}
+
+	if node.Container != "dev-control-plane" {
+		t.Errorf("expected container name without slash, got %q", node.Container)
+	}
+	if !node.IsControlPlane() {
+		t.Errorf("expected control-plane role, got %q", node.Role)
+	}
+	if node.Restart != "on-failure:1" {
+		t.Errorf("expected restart policy on-failure:1, got %q", node.Restart)
+	}
+	if len(node.Devices) != 1 || node.Devices[0] != "/dev/fuse:/dev/fuse:rwm" {
+		t.Errorf("unexpected devices: %v", node.Devices)
+	}
+	if node.Network != "kind" {
+		t.Errorf("unexpected network %q", node.Network)
+	}
+	if len(node.Ports) != 2 || node.Ports[0].ContainerPort != "6443" || node.Ports[1].HostPort != "8080" {
+		t.Errorf("unexpected ports: %+v", node.Ports)
+	}
+}
+
+func TestCreateArgs(t *testing.T) {
+	node, err := parseInspect([]byte(inspectFixture))
+	if err != nil {
+		t.Fatalf("parseInspect failed: %v", err)
+	}
+	node.Image = ImageRef(node.Container, "baseline")
+
+	args := strings.Join(createArgs(node), " ")
+	expected := []string{
+		"create --name dev-control-plane --hostname dev-control-plane",
+		"--label io.x-k8s.kind.cluster=dev --label io.x-k8s.kind.role=control-plane",
+		"--privileged",
+		"--tmpfs /run --tmpfs /tmp",
+		"--volume /var --volume /lib/modules:/lib/modules:ro",
+		"--cgroupns private",
+		"--restart on-failure:1",
+		"--network kind",
+		"--publish 127.0.0.1:40123:6443/tcp",
+		"--publish 8080:80/tcp",
+	}
+	for _, want := range expected {
+		if !strings.Contains(args, want) {
+			t.Errorf("expected args to contain %q, got:\n%s", want, args)
+		}
+	}
+	if !strings.HasSuffix(args, "kindplane-snapshot/dev-control-plane:baseline") {
+		t.Errorf("expected image as last argument, got:\n%s", args)
+	}
+}
+
+func TestSortNodes(t *testing.T) {
+	nodes := []NodeSnapshot{
+		{Container: "dev-worker2", Role: "worker"},
+		{Container: "dev-control-plane", Role: "control-plane"},
+		{Container: "dev-worker", Role: "worker"},
+	}
+	sortNodes(nodes)
+
+	want := []string{"dev-control-plane", "dev-worker", "dev-worker2"}
+	for i, n := range nodes {
+		if n.Container != want[i] {
+			t.Errorf("position %d: expected %s, got %s", i, want[i], n.Container)
+		}
+	}
+}
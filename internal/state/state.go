@@ -0,0 +1,52 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+)
+
+// appDirName is the directory created under the XDG base directories
+const appDirName = "kindplane"
+
+// Dir returns the directory kindplane uses for per-user state such as
+// snapshots and lock files. It honours XDG_STATE_HOME and falls back to
+// ~/.local/state/kindplane.
+func Dir() (string, error) {
+	base := os.Getenv("XDG_STATE_HOME")
+	if base == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(homeDir, ".local", "state")
+	}
+	return filepath.Join(base, appDirName), nil
+}
+
+// CacheDir returns the directory kindplane uses for re-creatable cached data.
+// It honours XDG_CACHE_HOME and falls back to ~/.cache/kindplane.
+func CacheDir() (string, error) {
+	base := os.Getenv("XDG_CACHE_HOME")
+	if base == "" {
+		homeDir, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		base = filepath.Join(homeDir, ".cache")
+	}
+	return filepath.Join(base, appDirName), nil
+}
+
+// Path joins elem onto the state directory and makes sure the parent
+// directory of the result exists.
+func Path(elem ...string) (string, error) {
+	dir, err := Dir()
+	if err != nil {
+		return "", err
+	}
+	p := filepath.Join(append([]string{dir}, elem...)...)
+	if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+		return "", err
+	}
+	return p, nil
+}
@@ -311,6 +311,20 @@ func TruncateWithEllipsis(text string, maxLen int) string {
 	return text[:maxLen-3] + "..."
 }
 
+// FormatBytes formats a byte count with a binary unit, e.g. "1.5 MiB"
+func FormatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
+
 // WrapText wraps text to a specified width
 func WrapText(text string, width int) string {
 	if width <= 0 {
@@ -117,6 +117,18 @@ type PodStatusUpdateMsg struct {
 
 func (PodStatusUpdateMsg) isDashboardMsg() {}
 
+// TaskProgressMsg updates the progress of one of several concurrent tasks
+// within the current operation, such as an image being loaded
+type TaskProgressMsg struct {
+	Name     string
+	Status   string
+	Progress float64 // 0.0 to 1.0
+	Done     bool
+	Failed   bool
+}
+
+func (TaskProgressMsg) isDashboardMsg() {}
+
 // tickMsg is sent periodically to update elapsed time and timeout
 type tickMsg time.Time
 
@@ -136,8 +148,9 @@ type DashboardModel struct {
 	extendAmount time.Duration
 
 	// Current operation state
-	currentStep     string  // Current sub-step being executed
-	currentProgress float64 // -1 for spinner, 0-1 for progress bar
+	currentStep     string            // Current sub-step being executed
+	currentProgress float64           // -1 for spinner, 0-1 for progress bar
+	tasks           []TaskProgressMsg // Concurrent tasks, in the order they were first reported
 
 	// UI components
 	spinner  spinner.Model
@@ -429,6 +442,7 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		m.tracker.MarkPhaseRunning(msg.PhaseName)
 		m.currentStep = ""
 		m.currentProgress = -1
+		m.tasks = nil
 		m.addLogLine(fmt.Sprintf("Starting: %s", msg.PhaseName))
 		cmds = append(cmds, m.spinner.Tick)
 
@@ -442,6 +456,7 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 		m.currentStep = ""
 		m.currentProgress = -1
+		m.tasks = nil
 
 	case PhaseSkippedMsg:
 		m.tracker.MarkPhaseSkipped(msg.PhaseName, msg.Reason)
@@ -469,6 +484,9 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			m.addLogLine(fmt.Sprintf("  %s", msg.Step))
 		}
 
+	case TaskProgressMsg:
+		m.updateTask(msg)
+
 	case LogLineMsg:
 		m.addLogLine(msg.Line)
 
@@ -497,6 +515,17 @@ func (m DashboardModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	return m, tea.Batch(cmds...)
 }
 
+// updateTask records a task's progress, adding the task if it is new
+func (m *DashboardModel) updateTask(msg TaskProgressMsg) {
+	for i := range m.tasks {
+		if m.tasks[i].Name == msg.Name {
+			m.tasks[i] = msg
+			return
+		}
+	}
+	m.tasks = append(m.tasks, msg)
+}
+
 func (m *DashboardModel) addLogLine(line string) {
 	m.logLines = append(m.logLines, line)
 	// Keep buffer size limited (larger to allow scroll history)
@@ -717,6 +746,10 @@ func (m DashboardModel) renderCurrentOperation(width int) string {
 		content += "\n\n" + m.progress.ViewAs(m.currentProgress)
 	}
 
+	if len(m.tasks) > 0 {
+		content += "\n\n" + m.renderTasks(width-6)
+	}
+
 	// Render box with consistent width
 	box := StyleDashboardOperationBox.Width(width - 2).Render(content)
 
@@ -726,6 +759,59 @@ func (m DashboardModel) renderCurrentOperation(width int) string {
 	return box
 }
 
+// renderTasks renders a small progress bar per concurrent task; finished
+// tasks are summarised rather than listed once there are many
+func (m DashboardModel) renderTasks(width int) string {
+	const maxShown = 8
+
+	done, failed := 0, 0
+	var active []TaskProgressMsg
+	for _, t := range m.tasks {
+		switch {
+		case t.Failed:
+			failed++
+			done++
+		case t.Done:
+			done++
+		default:
+			active = append(active, t)
+		}
+	}
+
+	bar := m.progress
+	bar.Width = 20
+	bar.ShowPercentage = false
+	nameWidth := max(width-bar.Width-24, 10)
+
+	var lines []string
+	shown := active
+	if len(m.tasks) <= maxShown {
+		shown = m.tasks
+	} else if len(shown) > maxShown {
+		shown = shown[:maxShown]
+	}
+	for _, t := range shown {
+		name := TruncateWithEllipsis(t.Name, nameWidth)
+		var line string
+		switch {
+		case t.Failed:
+			line = fmt.Sprintf("%s %-*s %s", StyleError.Render(IconError), nameWidth, name, StyleError.Render(t.Status))
+		case t.Done:
+			line = fmt.Sprintf("%s %-*s %s", StyleSuccess.Render(IconSuccess), nameWidth, name, StyleMuted.Render(t.Status))
+		default:
+			line = fmt.Sprintf("  %-*s %s %s", nameWidth, name, bar.ViewAs(t.Progress), StyleMuted.Render(t.Status))
+		}
+		lines = append(lines, line)
+	}
+
+	summary := fmt.Sprintf("%d/%d complete", done, len(m.tasks))
+	if failed > 0 {
+		summary += fmt.Sprintf(", %d failed", failed)
+	}
+	lines = append(lines, StyleMuted.Render(summary))
+	return strings.Join(lines, "\n")
+}
+
 func (m DashboardModel) renderLogPanel(width int) string {
 	// Use viewport for scrollable log view
 	content := m.viewport.View()
@@ -1096,6 +1182,13 @@ func (c *DashboardController) UpdateOperation(step string, progress float64) {
 	}
 }
 
+// UpdateTask updates the progress of a concurrent task in the current operation
+func (c *DashboardController) UpdateTask(name, status string, progress float64, done, failed bool) {
+	if c.program != nil {
+		c.program.Send(TaskProgressMsg{Name: name, Status: status, Progress: progress, Done: done, Failed: failed})
+	}
+}
+
 // Log adds a line to the log buffer
 func (c *DashboardController) Log(line string) {
 	if c.program != nil {
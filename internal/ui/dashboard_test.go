@@ -70,6 +70,7 @@ func TestDashboardController_NilSafe(t *testing.T) {
 	ctrl.SkipPhase("test", "reason")
 	ctrl.FailPhase("test", nil)
 	ctrl.UpdateOperation("step", 0.5)
+	ctrl.UpdateTask("image", "loading", 0.5, false, false)
 	ctrl.Log("log line")
 	ctrl.ExtendTimeout(time.Now().Add(5 * time.Minute))
 	ctrl.Complete(true, "done", nil)
@@ -138,6 +139,47 @@ func TestDashboardModel_ViewRendersCurrentOperation(t *testing.T) {
 	}
 }
 
+func TestDashboardModel_TaskProgress(t *testing.T) {
+	tracker := NewPhaseTracker("Bootstrap")
+	tracker.AddPhase("Load images")
+
+	model := NewDashboardModel(tracker, context.Background())
+	model.width = 100
+	model.height = 40
+	tracker.MarkPhaseRunning("Load images")
+
+	updates := []TaskProgressMsg{
+		{Name: "crossplane/crossplane:v2.1.0", Status: "saving", Progress: 0.1},
+		{Name: "upbound/provider-aws-s3:v1", Status: "loading 0/2 nodes", Progress: 0.3},
+		{Name: "crossplane/crossplane:v2.1.0", Status: "loaded", Progress: 1, Done: true},
+	}
+	for _, msg := range updates {
+		updated, _ := model.Update(msg)
+		model = updated.(DashboardModel)
+	}
+
+	if len(model.tasks) != 2 {
+		t.Fatalf("expected 2 tasks, got %d", len(model.tasks))
+	}
+	if !model.tasks[0].Done {
+		t.Error("expected the first task to be updated in place")
+	}
+
+	view := model.View()
+	for _, want := range []string{"provider-aws-s3", "loading 0/2 nodes", "1/2 complete"} {
+		if !containsString(view, want) {
+			t.Errorf("expected view to contain %q", want)
+		}
+	}
+
+	// Tasks belong to the phase that reported them
+	updated, _ := model.Update(PhaseCompletedMsg{PhaseName: "Load images"})
+	model = updated.(DashboardModel)
+	if len(model.tasks) != 0 {
+		t.Errorf("expected tasks to be cleared when the phase completes, got %d", len(model.tasks))
+	}
+}
+
 func TestDashboardModel_VerboseMode(t *testing.T) {
 	tracker := NewPhaseTracker("Bootstrap")
 	tracker.AddPhase("Phase 1")
@@ -0,0 +1,162 @@
+package xpkg
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/kanzi/kindplane/internal/cache"
+	"github.com/kanzi/kindplane/internal/state"
+)
+
+// maxDependencyDepth bounds how far Images follows package dependencies
+const maxDependencyDepth = 5
+
+// Resolver resolves package metadata, caching results on disk by digest.
+// A digest identifies immutable content, so cached entries never expire.
+type Resolver struct {
+	cacheDir  string
+	newTarget func(ref registry.Reference) (oras.ReadOnlyTarget, error)
+}
+
+// NewResolver returns a Resolver that reads from remote registries and
+// caches under the kindplane cache directory
+func NewResolver() (*Resolver, error) {
+	dir, err := state.CacheDir()
+	if err != nil {
+		return nil, err
+	}
+	return &Resolver{
+		cacheDir:  filepath.Join(dir, "xpkg"),
+		newTarget: remoteTarget,
+	}, nil
+}
+
+// Resolve returns the metadata of a package. Only the manifest digest is
+// looked up remotely when the metadata is already cached, and nothing at
+// all for references pinned by digest. Offline, only references pinned by
+// digest resolve.
+func (r *Resolver) Resolve(ctx context.Context, pkg string) (*Metadata, error) {
+	qualified := Qualify(pkg)
+	ref, err := registry.ParseReference(qualified)
+	if err != nil {
+		return nil, fmt.Errorf("invalid package reference %q: %w", pkg, err)
+	}
+	if ref.Reference == "" {
+		return nil, fmt.Errorf("package %q has no tag or digest", pkg)
+	}
+
+	if d, err := ref.Digest(); err == nil {
+		if meta := r.load(d.String()); meta != nil {
+			meta.Package = qualified
+			return meta, nil
+		}
+	}
+	if cache.Offline() {
+		return nil, fmt.Errorf("cannot resolve %s offline", qualified)
+	}
+
+	target, err := r.newTarget(ref)
+	if err != nil {
+		return nil, fmt.Errorf("failed to access %s: %w", ref.Registry, err)
+	}
+	desc, err := target.Resolve(ctx, ref.Reference)
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve %s: %w", qualified, err)
+	}
+
+	meta := r.load(desc.Digest.String())
+	if meta == nil {
+		if meta, err = readMetadata(ctx, target, desc); err != nil {
+			return nil, fmt.Errorf("failed to read metadata of %s: %w", qualified, err)
+		}
+		r.store(meta)
+	}
+	meta.Package = qualified
+	return meta, nil
+}
+
+// Images returns the images of a package and of the dependencies it pins
+// to an exact version, following dependencies transitively. Dependencies
+// with version constraints are left for Crossplane to resolve.
+func (r *Resolver) Images(ctx context.Context, pkg string) ([]string, error) {
+	seen := map[string]bool{}
+	var images []string
+	add := func(imgs ...string) {
+		for _, img := range imgs {
+			if !seen[img] {
+				seen[img] = true
+				images = append(images, img)
+			}
+		}
+	}
+
+	var walk func(pkg string, depth int, root bool) error
+	walk = func(pkg string, depth int, root bool) error {
+		if seen[Qualify(pkg)] || depth > maxDependencyDepth {
+			return nil
+		}
+
+		meta, err := r.Resolve(ctx, pkg)
+		if err != nil {
+			if root {
+				return err
+			}
+			// A dependency we cannot inspect is still worth preloading
+			add(Qualify(pkg))
+			return nil
+		}
+		add(meta.Images()...)
+
+		for _, dep := range meta.Dependencies {
+			if ref, ok := dep.Reference(); ok {
+				if err := walk(ref, depth+1, false); err != nil {
+					return err
+				}
+			}
+		}
+		return nil
+	}
+
+	if err := walk(pkg, 0, true); err != nil {
+		return nil, err
+	}
+	return images, nil
+}
+
+// cachePath returns the cache file for a digest
+func (r *Resolver) cachePath(digest string) string {
+	return filepath.Join(r.cacheDir, strings.ReplaceAll(digest, ":", "-")+".json")
+}
+
+// load returns cached metadata for a digest, or nil
+func (r *Resolver) load(digest string) *Metadata {
+	data, err := os.ReadFile(r.cachePath(digest))
+	if err != nil {
+		return nil
+	}
+	var meta Metadata
+	if err := json.Unmarshal(data, &meta); err != nil || meta.Digest != digest {
+		return nil
+	}
+	return &meta
+}
+
+// store caches metadata by digest. Failures are ignored; the cache only
+// saves a registry round trip.
+func (r *Resolver) store(meta *Metadata) {
+	data, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return
+	}
+	if err := os.MkdirAll(r.cacheDir, 0755); err != nil {
+		return
+	}
+	_ = os.WriteFile(r.cachePath(meta.Digest), data, 0644)
+}
@@ -0,0 +1,260 @@
+// Package xpkg reads Crossplane package metadata straight from an OCI
+// registry. It fetches a package's manifest and its package.yaml layer to
+// learn the controller image and dependencies the package declares, rather
+// than guessing them from the package name.
+package xpkg
+
+import (
+	"archive/tar"
+	"bufio"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"runtime"
+	"strings"
+
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"gopkg.in/yaml.v3"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content"
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/kanzi/kindplane/internal/oci"
+)
+
+const (
+	// DefaultRegistry is the registry Crossplane assumes for packages
+	// without a registry host
+	DefaultRegistry = "xpkg.upbound.io"
+
+	// layerAnnotation marks the layer holding package.yaml in an xpkg
+	layerAnnotation = "io.crossplane.xpkg"
+	// baseLayer is the value of layerAnnotation on the package.yaml layer
+	baseLayer = "base"
+	// streamFile is the name of the package metadata file inside the layer
+	streamFile = "package.yaml"
+
+	mediaTypeDockerManifestList = "application/vnd.docker.distribution.manifest.list.v2+json"
+)
+
+// Metadata is what a package declares about itself
+type Metadata struct {
+	// Package is the fully qualified package reference
+	Package string `json:"package"`
+	// Digest is the digest the package reference resolved to
+	Digest string `json:"digest"`
+	// Kind is Provider, Configuration or Function
+	Kind string `json:"kind"`
+	// ControllerImage is spec.controller.image; empty when the package
+	// image itself runs as the controller
+	ControllerImage string `json:"controllerImage,omitempty"`
+	// Dependencies lists spec.dependsOn
+	Dependencies []Dependency `json:"dependencies,omitempty"`
+}
+
+// Dependency is one entry of a package's spec.dependsOn
+type Dependency struct {
+	Package string `json:"package"`
+	Kind    string `json:"kind,omitempty"`
+	Version string `json:"version,omitempty"`
+}
+
+// Images returns the images needed to run the package: the package image
+// and, when it declares a separate one, the controller image
+func (m *Metadata) Images() []string {
+	images := []string{m.Package}
+	if m.ControllerImage != "" && m.ControllerImage != m.Package {
+		images = append(images, m.ControllerImage)
+	}
+	return images
+}
+
+// Reference returns the dependency as a pullable reference, or false when
+// its version is a constraint rather than an exact tag or digest
+func (d Dependency) Reference() (string, bool) {
+	v := strings.TrimSpace(d.Version)
+	if v == "" || strings.ContainsAny(v, "<>=~^*|, ") {
+		return "", false
+	}
+	if strings.HasPrefix(v, "sha256:") {
+		return d.Package + "@" + v, true
+	}
+	return d.Package + ":" + v, true
+}
+
+// Qualify adds DefaultRegistry to package references without a registry host
+func Qualify(pkg string) string {
+	first, _, ok := strings.Cut(pkg, "/")
+	if ok && (strings.ContainsAny(first, ".:") || first == "localhost") {
+		return pkg
+	}
+	return DefaultRegistry + "/" + pkg
+}
+
+// packageMeta is the subset of meta.pkg.crossplane.io objects we read
+type packageMeta struct {
+	Kind string `yaml:"kind"`
+	Spec struct {
+		Controller struct {
+			Image *string `yaml:"image"`
+		} `yaml:"controller"`
+		DependsOn []struct {
+			Provider      *string `yaml:"provider"`
+			Configuration *string `yaml:"configuration"`
+			Function      *string `yaml:"function"`
+			Kind          string  `yaml:"kind"`
+			Package       string  `yaml:"package"`
+			Version       string  `yaml:"version"`
+		} `yaml:"dependsOn"`
+	} `yaml:"spec"`
+}
+
+// readMetadata fetches the manifest desc points to and parses package.yaml
+// from its package layer
+func readMetadata(ctx context.Context, target oras.ReadOnlyTarget, desc ocispec.Descriptor) (*Metadata, error) {
+	manifest, err := fetchManifest(ctx, target, desc)
+	if err != nil {
+		return nil, err
+	}
+
+	// Prefer the annotated base layer; older packages have a single
+	// unannotated layer, so fall back to searching from the top layer down
+	var layers []ocispec.Descriptor
+	for _, l := range manifest.Layers {
+		if l.Annotations[layerAnnotation] == baseLayer {
+			layers = []ocispec.Descriptor{l}
+			break
+		}
+	}
+	if layers == nil {
+		for i := len(manifest.Layers) - 1; i >= 0; i-- {
+			layers = append(layers, manifest.Layers[i])
+		}
+	}
+
+	for _, layer := range layers {
+		meta, err := readLayer(ctx, target, layer)
+		if err != nil {
+			return nil, err
+		}
+		if meta != nil {
+			meta.Digest = desc.Digest.String()
+			return meta, nil
+		}
+	}
+	return nil, fmt.Errorf("no %s found in package layers", streamFile)
+}
+
+// fetchManifest returns the image manifest for desc, picking a platform
+// manifest when desc is an index. Package metadata is identical across
+// platforms, so any manifest will do.
+func fetchManifest(ctx context.Context, target oras.ReadOnlyTarget, desc ocispec.Descriptor) (*ocispec.Manifest, error) {
+	data, err := content.FetchAll(ctx, target, desc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch manifest: %w", err)
+	}
+
+	switch desc.MediaType {
+	case ocispec.MediaTypeImageIndex, mediaTypeDockerManifestList:
+		var index ocispec.Index
+		if err := json.Unmarshal(data, &index); err != nil {
+			return nil, fmt.Errorf("failed to parse image index: %w", err)
+		}
+		if len(index.Manifests) == 0 {
+			return nil, errors.New("image index has no manifests")
+		}
+		chosen := index.Manifests[0]
+		for _, m := range index.Manifests {
+			if m.Platform != nil && m.Platform.OS == "linux" && m.Platform.Architecture == runtime.GOARCH {
+				chosen = m
+				break
+			}
+		}
+		return fetchManifest(ctx, target, chosen)
+	}
+
+	var manifest ocispec.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse image manifest: %w", err)
+	}
+	return &manifest, nil
+}
+
+// readLayer streams a layer looking for package.yaml. It returns nil
+// without error when the layer does not contain one.
+func readLayer(ctx context.Context, target oras.ReadOnlyTarget, layer ocispec.Descriptor) (*Metadata, error) {
+	rc, err := target.Fetch(ctx, layer)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch package layer: %w", err)
+	}
+	defer func() { _ = rc.Close() }()
+
+	// Layers may or may not be compressed whatever their media type says
+	br := bufio.NewReader(rc)
+	var r io.Reader = br
+	if magic, _ := br.Peek(2); len(magic) == 2 && magic[0] == 0x1f && magic[1] == 0x8b {
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decompress package layer: %w", err)
+		}
+		defer func() { _ = gz.Close() }()
+		r = gz
+	}
+
+	tr := tar.NewReader(r)
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read package layer: %w", err)
+		}
+		if path.Clean("/"+hdr.Name) == "/"+streamFile {
+			return parseStream(tr)
+		}
+	}
+}
+
+// parseStream parses the package metadata object, the first document of
+// package.yaml; the remaining documents are CRDs and are not needed
+func parseStream(r io.Reader) (*Metadata, error) {
+	var pm packageMeta
+	if err := yaml.NewDecoder(r).Decode(&pm); err != nil {
+		return nil, fmt.Errorf("failed to parse %s: %w", streamFile, err)
+	}
+	if pm.Kind == "" {
+		return nil, fmt.Errorf("%s does not start with a package metadata object", streamFile)
+	}
+
+	meta := &Metadata{Kind: pm.Kind}
+	if pm.Spec.Controller.Image != nil {
+		meta.ControllerImage = *pm.Spec.Controller.Image
+	}
+	for _, d := range pm.Spec.DependsOn {
+		dep := Dependency{Kind: d.Kind, Package: d.Package, Version: d.Version}
+		switch {
+		case d.Provider != nil:
+			dep.Kind, dep.Package = "Provider", *d.Provider
+		case d.Configuration != nil:
+			dep.Kind, dep.Package = "Configuration", *d.Configuration
+		case d.Function != nil:
+			dep.Kind, dep.Package = "Function", *d.Function
+		}
+		if dep.Package != "" {
+			dep.Package = Qualify(dep.Package)
+			meta.Dependencies = append(meta.Dependencies, dep)
+		}
+	}
+	return meta, nil
+}
+
+// remoteTarget returns the registry repository for ref, authenticated with
+// the Docker credential store when one is configured
+func remoteTarget(ref registry.Reference) (oras.ReadOnlyTarget, error) {
+	return oci.NewRepository(ref, nil)
+}
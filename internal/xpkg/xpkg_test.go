@@ -0,0 +1,278 @@
+package xpkg
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/opencontainers/go-digest"
+	"github.com/opencontainers/image-spec/specs-go"
+	ocispec "github.com/opencontainers/image-spec/specs-go/v1"
+	"oras.land/oras-go/v2"
+	"oras.land/oras-go/v2/content/memory"
+	"oras.land/oras-go/v2/registry"
+
+	"github.com/kanzi/kindplane/internal/cache"
+)
+
+const providerYAML = `apiVersion: meta.pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-example
+spec:
+  controller:
+    image: ghcr.io/example/provider-example-controller:v1.0.0
+  dependsOn:
+    - provider: example/provider-family
+      version: v1.0.0
+    - function: xpkg.upbound.io/crossplane-contrib/function-patch-and-transform
+      version: ">=v0.1.0"
+---
+apiVersion: apiextensions.k8s.io/v1
+kind: CustomResourceDefinition
+metadata:
+  name: things.example.org
+`
+
+const familyYAML = `apiVersion: meta.pkg.crossplane.io/v1
+kind: Provider
+metadata:
+  name: provider-family
+`
+
+// layerTar builds a package layer containing package.yaml
+func layerTar(t *testing.T, stream string, compress bool) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	var w io.Writer = &buf
+	var gz *gzip.Writer
+	if compress {
+		gz = gzip.NewWriter(&buf)
+		w = gz
+	}
+	tw := tar.NewWriter(w)
+	if err := tw.WriteHeader(&tar.Header{Name: "package.yaml", Mode: 0644, Size: int64(len(stream))}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write([]byte(stream)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	if gz != nil {
+		if err := gz.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return buf.Bytes()
+}
+
+// pushPackage stores a single-layer package in store under tag
+func pushPackage(t *testing.T, store *memory.Store, tag, stream string, annotate, compress bool) ocispec.Descriptor {
+	t.Helper()
+	ctx := context.Background()
+
+	push := func(mediaType string, data []byte, annotations map[string]string) ocispec.Descriptor {
+		desc := ocispec.Descriptor{
+			MediaType:   mediaType,
+			Digest:      digest.FromBytes(data),
+			Size:        int64(len(data)),
+			Annotations: annotations,
+		}
+		if err := store.Push(ctx, desc, bytes.NewReader(data)); err != nil {
+			t.Fatal(err)
+		}
+		return desc
+	}
+
+	var annotations map[string]string
+	if annotate {
+		annotations = map[string]string{layerAnnotation: baseLayer}
+	}
+	layer := push(ocispec.MediaTypeImageLayerGzip, layerTar(t, stream, compress), annotations)
+	config := push(ocispec.MediaTypeImageConfig, []byte("{}"), nil)
+
+	manifest, err := json.Marshal(ocispec.Manifest{
+		Versioned: specs.Versioned{SchemaVersion: 2},
+		MediaType: ocispec.MediaTypeImageManifest,
+		Config:    config,
+		Layers:    []ocispec.Descriptor{layer},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	desc := push(ocispec.MediaTypeImageManifest, manifest, nil)
+	if err := store.Tag(ctx, desc, tag); err != nil {
+		t.Fatal(err)
+	}
+	return desc
+}
+
+// countingTarget counts fetches so tests can tell cache hits apart
+type countingTarget struct {
+	oras.ReadOnlyTarget
+	fetches int
+}
+
+func (c *countingTarget) Fetch(ctx context.Context, desc ocispec.Descriptor) (io.ReadCloser, error) {
+	c.fetches++
+	return c.ReadOnlyTarget.Fetch(ctx, desc)
+}
+
+func newTestResolver(t *testing.T, target oras.ReadOnlyTarget) *Resolver {
+	return &Resolver{
+		cacheDir:  t.TempDir(),
+		newTarget: func(registry.Reference) (oras.ReadOnlyTarget, error) { return target, nil },
+	}
+}
+
+func TestResolve(t *testing.T) {
+	store := memory.New()
+	desc := pushPackage(t, store, "v1.0.0", providerYAML, true, true)
+	target := &countingTarget{ReadOnlyTarget: store}
+	r := newTestResolver(t, target)
+
+	meta, err := r.Resolve(context.Background(), "localhost:5001/example/provider-example:v1.0.0")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+
+	want := &Metadata{
+		Package:         "localhost:5001/example/provider-example:v1.0.0",
+		Digest:          desc.Digest.String(),
+		Kind:            "Provider",
+		ControllerImage: "ghcr.io/example/provider-example-controller:v1.0.0",
+		Dependencies: []Dependency{
+			{Package: "xpkg.upbound.io/example/provider-family", Kind: "Provider", Version: "v1.0.0"},
+			{Package: "xpkg.upbound.io/crossplane-contrib/function-patch-and-transform", Kind: "Function", Version: ">=v0.1.0"},
+		},
+	}
+	if !reflect.DeepEqual(meta, want) {
+		t.Errorf("Resolve() = %+v, want %+v", meta, want)
+	}
+
+	// A second resolution of the same digest is served from the cache
+	fetches := target.fetches
+	if _, err := r.Resolve(context.Background(), "localhost:5001/example/provider-example:v1.0.0"); err != nil {
+		t.Fatalf("Resolve() cached error = %v", err)
+	}
+	if target.fetches != fetches {
+		t.Errorf("cached Resolve() fetched %d more blobs", target.fetches-fetches)
+	}
+
+	// A digest reference needs no registry at all once cached
+	offline := &Resolver{
+		cacheDir: r.cacheDir,
+		newTarget: func(registry.Reference) (oras.ReadOnlyTarget, error) {
+			return nil, errors.New("offline")
+		},
+	}
+	pinned, err := offline.Resolve(context.Background(), "localhost:5001/example/provider-example@"+desc.Digest.String())
+	if err != nil {
+		t.Fatalf("Resolve() by digest error = %v", err)
+	}
+	if pinned.ControllerImage != want.ControllerImage {
+		t.Errorf("Resolve() by digest controller = %q", pinned.ControllerImage)
+	}
+
+	// Offline, tags are not looked up even when the registry is reachable
+	cache.SetOffline(true)
+	t.Cleanup(func() { cache.SetOffline(false) })
+	fetches = target.fetches
+	if _, err := r.Resolve(context.Background(), "localhost:5001/example/provider-example:v1.0.0"); err == nil {
+		t.Error("offline Resolve() by tag succeeded")
+	}
+	if _, err := r.Resolve(context.Background(), "localhost:5001/example/provider-example@"+desc.Digest.String()); err != nil {
+		t.Errorf("offline Resolve() by digest error = %v", err)
+	}
+	if target.fetches != fetches {
+		t.Errorf("offline Resolve() fetched %d blobs", target.fetches-fetches)
+	}
+}
+
+func TestResolve_UnannotatedLayer(t *testing.T) {
+	store := memory.New()
+	pushPackage(t, store, "v0.1.0", familyYAML, false, false)
+	r := newTestResolver(t, store)
+
+	meta, err := r.Resolve(context.Background(), "example/provider-family:v0.1.0")
+	if err != nil {
+		t.Fatalf("Resolve() error = %v", err)
+	}
+	if meta.Kind != "Provider" || meta.ControllerImage != "" {
+		t.Errorf("Resolve() = %+v", meta)
+	}
+	if got := meta.Images(); !reflect.DeepEqual(got, []string{"xpkg.upbound.io/example/provider-family:v0.1.0"}) {
+		t.Errorf("Images() = %v", got)
+	}
+}
+
+func TestResolverImages(t *testing.T) {
+	store := memory.New()
+	pushPackage(t, store, "v1.0.0", providerYAML, true, true)
+	r := newTestResolver(t, store)
+
+	// The pinned dependency resolves to the same tag in the fake registry;
+	// the constrained function dependency is skipped
+	images, err := r.Images(context.Background(), "xpkg.upbound.io/example/provider-example:v1.0.0")
+	if err != nil {
+		t.Fatalf("Images() error = %v", err)
+	}
+	want := []string{
+		"xpkg.upbound.io/example/provider-example:v1.0.0",
+		"ghcr.io/example/provider-example-controller:v1.0.0",
+		"xpkg.upbound.io/example/provider-family:v1.0.0",
+	}
+	if !reflect.DeepEqual(images, want) {
+		t.Errorf("Images() = %v, want %v", images, want)
+	}
+
+	if _, err := r.Images(context.Background(), "xpkg.upbound.io/example/missing:v9"); err == nil {
+		t.Error("Images() of an unknown package should fail")
+	}
+}
+
+func TestDependencyReference(t *testing.T) {
+	tests := []struct {
+		version string
+		want    string
+		ok      bool
+	}{
+		{version: "v1.2.0", want: "xpkg.upbound.io/a/b:v1.2.0", ok: true},
+		{version: "sha256:abc", want: "xpkg.upbound.io/a/b@sha256:abc", ok: true},
+		{version: ">=v1.0.0"},
+		{version: ">=v1.0.0, <v2"},
+		{version: "~1.2"},
+		{version: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.version, func(t *testing.T) {
+			got, ok := Dependency{Package: "xpkg.upbound.io/a/b", Version: tt.version}.Reference()
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("Reference() = %q, %v; want %q, %v", got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
+
+func TestQualify(t *testing.T) {
+	tests := map[string]string{
+		"upbound/provider-aws:v1":                 "xpkg.upbound.io/upbound/provider-aws:v1",
+		"xpkg.upbound.io/upbound/provider-aws:v1": "xpkg.upbound.io/upbound/provider-aws:v1",
+		"localhost:5001/example/provider:v1":      "localhost:5001/example/provider:v1",
+		"localhost/example/provider:v1":           "localhost/example/provider:v1",
+		"ghcr.io/example/provider-example:v0.2.0": "ghcr.io/example/provider-example:v0.2.0",
+	}
+	for in, want := range tests {
+		if got := Qualify(in); got != want {
+			t.Errorf("Qualify(%q) = %q, want %q", in, got, want)
+		}
+	}
+}